@@ -0,0 +1,293 @@
+package ast
+
+import (
+	"github.com/verily-src/fhirpath-go/fhirpath/system"
+)
+
+// Kind identifies the kind of a node.
+type Kind int
+
+// Kinds of nodes.
+const (
+	KindField Kind = iota + 1
+	KindFunction
+	KindOperator
+	KindLiteral
+	KindTypeOperator
+	KindExternalConstant
+	KindResourceType
+	KindTypeSpecifier
+	KindVariable
+)
+
+var kindNames = map[Kind]string{
+	KindField:            "Field",
+	KindFunction:         "Function",
+	KindOperator:         "Operator",
+	KindLiteral:          "Literal",
+	KindTypeOperator:     "TypeOperator",
+	KindExternalConstant: "ExternalConstant",
+	KindResourceType:     "ResourceType",
+	KindTypeSpecifier:    "TypeSpecifier",
+	KindVariable:         "Variable",
+}
+
+// String returns the name of the kind, e.g. "Field".
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+	return "Invalid"
+}
+
+// Position is a location in the source of an expression.
+type Position struct {
+	// Offset is the zero-based offset of the character in the source.
+	Offset int
+	// Line is the one-based line number.
+	Line int
+	// Column is the one-based column number, in characters.
+	Column int
+}
+
+// Span is the range of the source that a node was parsed from. The end
+// position is exclusive.
+type Span struct {
+	Start Position
+	End   Position
+}
+
+// Node is a node of the syntax tree.
+type Node interface {
+	// Kind returns the kind of the node.
+	Kind() Kind
+	// Span returns the range of the source that the node was parsed from.
+	Span() Span
+	// Children returns the direct children of the node, in source order.
+	Children() []Node
+}
+
+// Field is the navigation to a field, such as 'name' in 'Patient.name'.
+type Field struct {
+	// Input is the expression whose items are navigated, or nil if the field
+	// is navigated from the input of the expression.
+	Input Node
+	// Name is the name of the field, without delimiting backticks.
+	Name string
+
+	Source Span
+}
+
+// Function is a function invocation, such as 'where(use = 'official')'.
+type Function struct {
+	// Input is the expression that the function is invoked on, or nil if it
+	// is invoked on the input of the expression.
+	Input Node
+	// Name is the name of the function.
+	Name string
+	// Args are the arguments of the function, in order. The argument of a type
+	// function, such as 'ofType', is a TypeSpecifier.
+	Args []Node
+
+	Source Span
+}
+
+// Operator is a binary or unary operator, such as '=' or 'and'. Indexing,
+// such as 'name[0]', is represented with the '[]' operator.
+type Operator struct {
+	// Op is the operator, e.g. "=", "and" or "[]".
+	Op string
+	// Left is the left operand, or nil for the unary '+' and '-' operators.
+	Left Node
+	// Right is the right operand.
+	Right Node
+
+	Source Span
+}
+
+// Literal is a literal value, such as 'official', 5 or @2020-01-01.
+type Literal struct {
+	// Value is the value of the literal, or nil for the empty collection '{}'.
+	Value system.Any
+	// Text is the literal as written in the source.
+	Text string
+
+	Source Span
+}
+
+// TypeOperator is an 'is' or 'as' expression, such as 'value as Quantity'.
+type TypeOperator struct {
+	// Op is the operator, either "is" or "as".
+	Op string
+	// Expr is the expression whose type is tested.
+	Expr Node
+	// Type is the type that is tested against.
+	Type *TypeSpecifier
+
+	Source Span
+}
+
+// ExternalConstant is a reference to an environment variable, such as
+// '%resource' or a variable defined with 'defineVariable'.
+type ExternalConstant struct {
+	// Name is the name of the constant, without the '%' prefix and without
+	// delimiting backticks or quotes.
+	Name string
+
+	Source Span
+}
+
+// ResourceType is the resource type at the root of an expression, such as
+// 'Patient' in 'Patient.name', which selects the input resources of that
+// type.
+type ResourceType struct {
+	// Input is the expression whose items are selected, or nil if the items
+	// are selected from the input of the expression.
+	Input Node
+	// Name is the name of the resource type.
+	Name string
+
+	Source Span
+}
+
+// TypeSpecifier is a type name, such as 'Quantity' or 'FHIR.Patient'.
+type TypeSpecifier struct {
+	// Name is the type name as written, optionally qualified by its
+	// namespace.
+	Name string
+
+	Source Span
+}
+
+// Variable is one of the special invocations '$this', '$index' or '$total'.
+type Variable struct {
+	// Input is the expression that the variable is invoked on, if any.
+	Input Node
+	// Name is the name of the variable, including the '$' prefix.
+	Name string
+
+	Source Span
+}
+
+func (*Field) Kind() Kind            { return KindField }
+func (*Function) Kind() Kind         { return KindFunction }
+func (*Operator) Kind() Kind         { return KindOperator }
+func (*Literal) Kind() Kind          { return KindLiteral }
+func (*TypeOperator) Kind() Kind     { return KindTypeOperator }
+func (*ExternalConstant) Kind() Kind { return KindExternalConstant }
+func (*ResourceType) Kind() Kind     { return KindResourceType }
+func (*TypeSpecifier) Kind() Kind    { return KindTypeSpecifier }
+func (*Variable) Kind() Kind         { return KindVariable }
+
+func (n *Field) Span() Span            { return n.Source }
+func (n *Function) Span() Span         { return n.Source }
+func (n *Operator) Span() Span         { return n.Source }
+func (n *Literal) Span() Span          { return n.Source }
+func (n *TypeOperator) Span() Span     { return n.Source }
+func (n *ExternalConstant) Span() Span { return n.Source }
+func (n *ResourceType) Span() Span     { return n.Source }
+func (n *TypeSpecifier) Span() Span    { return n.Source }
+func (n *Variable) Span() Span         { return n.Source }
+
+func (n *Field) Children() []Node {
+	return nonNil(n.Input)
+}
+
+func (n *Function) Children() []Node {
+	return append(nonNil(n.Input), n.Args...)
+}
+
+func (n *Operator) Children() []Node {
+	return nonNil(n.Left, n.Right)
+}
+
+func (n *TypeOperator) Children() []Node {
+	if n.Type == nil {
+		return nonNil(n.Expr)
+	}
+	return nonNil(n.Expr, n.Type)
+}
+
+func (n *ResourceType) Children() []Node {
+	return nonNil(n.Input)
+}
+
+func (n *Variable) Children() []Node {
+	return nonNil(n.Input)
+}
+
+func (*Literal) Children() []Node          { return nil }
+func (*ExternalConstant) Children() []Node { return nil }
+func (*TypeSpecifier) Children() []Node    { return nil }
+
+// Clone returns a deep copy of the tree rooted at node, so that the copy can be
+// modified without affecting the original. Returns nil if node is nil.
+func Clone(node Node) Node {
+	switch n := node.(type) {
+	case *Field:
+		c := *n
+		c.Input = Clone(n.Input)
+		return &c
+	case *Function:
+		c := *n
+		c.Input = Clone(n.Input)
+		c.Args = nil
+		for _, arg := range n.Args {
+			c.Args = append(c.Args, Clone(arg))
+		}
+		return &c
+	case *Operator:
+		c := *n
+		c.Left = Clone(n.Left)
+		c.Right = Clone(n.Right)
+		return &c
+	case *Literal:
+		c := *n
+		return &c
+	case *TypeOperator:
+		c := *n
+		c.Expr = Clone(n.Expr)
+		if n.Type != nil {
+			c.Type = Clone(n.Type).(*TypeSpecifier)
+		}
+		return &c
+	case *ExternalConstant:
+		c := *n
+		return &c
+	case *ResourceType:
+		c := *n
+		c.Input = Clone(n.Input)
+		return &c
+	case *TypeSpecifier:
+		c := *n
+		return &c
+	case *Variable:
+		c := *n
+		c.Input = Clone(n.Input)
+		return &c
+	}
+	return node
+}
+
+// nonNil returns the given nodes that aren't nil.
+func nonNil(nodes ...Node) []Node {
+	var result []Node
+	for _, node := range nodes {
+		if node != nil {
+			result = append(result, node)
+		}
+	}
+	return result
+}
+
+var (
+	_ Node = (*Field)(nil)
+	_ Node = (*Function)(nil)
+	_ Node = (*Operator)(nil)
+	_ Node = (*Literal)(nil)
+	_ Node = (*TypeOperator)(nil)
+	_ Node = (*ExternalConstant)(nil)
+	_ Node = (*ResourceType)(nil)
+	_ Node = (*TypeSpecifier)(nil)
+	_ Node = (*Variable)(nil)
+)
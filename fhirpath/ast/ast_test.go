@@ -0,0 +1,276 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/verily-src/fhirpath-go/fhirpath"
+	"github.com/verily-src/fhirpath-go/fhirpath/ast"
+	"github.com/verily-src/fhirpath-go/fhirpath/system"
+)
+
+// describe lists the nodes of the tree in depth-first order, as their kind and
+// name.
+func describe(node ast.Node) []string {
+	var got []string
+	ast.Inspect(node, func(node ast.Node) bool {
+		if node == nil {
+			return false
+		}
+		var name string
+		switch n := node.(type) {
+		case *ast.Field:
+			name = n.Name
+		case *ast.Function:
+			name = n.Name
+		case *ast.Operator:
+			name = n.Op
+		case *ast.Literal:
+			name = n.Text
+		case *ast.TypeOperator:
+			name = n.Op
+		case *ast.ExternalConstant:
+			name = n.Name
+		case *ast.ResourceType:
+			name = n.Name
+		case *ast.TypeSpecifier:
+			name = n.Name
+		case *ast.Variable:
+			name = n.Name
+		}
+		got = append(got, node.Kind().String()+" "+name)
+		return true
+	})
+	return got
+}
+
+func TestAST_DescribesExpression(t *testing.T) {
+	testCases := []struct {
+		name string
+		path string
+		want []string
+	}{
+		{
+			name: "field navigation",
+			path: "Patient.name.given",
+			want: []string{"Field given", "Field name", "ResourceType Patient"},
+		},
+		{
+			name: "function with criteria",
+			path: "name.where(use = 'official').exists()",
+			want: []string{
+				"Function exists", "Function where", "Field name",
+				"Operator =", "Field use", "Literal 'official'",
+			},
+		},
+		{
+			name: "type functions and operators",
+			path: "value.ofType(Quantity) | (value as FHIR.Quantity)",
+			want: []string{
+				"Operator |", "Function ofType", "Field value", "TypeSpecifier Quantity",
+				"TypeOperator as", "Field value", "TypeSpecifier FHIR.Quantity",
+			},
+		},
+		{
+			name: "resource type after a constant",
+			path: "%resource.Patient.`div`",
+			want: []string{"Field div", "ResourceType Patient", "ExternalConstant resource"},
+		},
+		{
+			name: "resource type in right operand",
+			path: "Patient.active and Patient.deceased",
+			want: []string{
+				"Operator and", "Field active", "ResourceType Patient",
+				"Field deceased", "ResourceType Patient",
+			},
+		},
+		{
+			name: "unary operator and indexer",
+			path: "-name[$index]",
+			want: []string{"Operator -", "Operator []", "Field name", "Variable $index"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			expression := fhirpath.MustCompile(tc.path)
+
+			got := describe(expression.AST())
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("AST of %q returned unexpected diff (-want, +got):\n%s", tc.path, diff)
+			}
+		})
+	}
+}
+
+func TestAST_ParsesLiterals(t *testing.T) {
+	testCases := []struct {
+		name string
+		path string
+		want system.Any
+	}{
+		{"string", "'a\\'b'", system.String("a'b")},
+		{"integer", "42", system.Integer(42)},
+		{"long", "42L", system.Long(42)},
+		{"boolean", "true", system.Boolean(true)},
+		{"empty", "{}", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			literal, ok := fhirpath.MustCompile(tc.path).AST().(*ast.Literal)
+			if !ok {
+				t.Fatalf("AST of %q is not a literal", tc.path)
+			}
+
+			if literal.Value != tc.want {
+				t.Errorf("AST of %q has value %v, want %v", tc.path, literal.Value, tc.want)
+			}
+			if literal.Text != tc.path {
+				t.Errorf("AST of %q has text %q, want %q", tc.path, literal.Text, tc.path)
+			}
+		})
+	}
+}
+
+func TestAST_Span(t *testing.T) {
+	path := "Patient.name\n  .where(use = 'official')"
+	expression := fhirpath.MustCompile(path)
+
+	where := expression.AST().(*ast.Function)
+	criteria := where.Args[0].(*ast.Operator)
+
+	wantWhere := ast.Span{
+		Start: ast.Position{Offset: 0, Line: 1, Column: 1},
+		End:   ast.Position{Offset: len(path), Line: 2, Column: 27},
+	}
+	if diff := cmp.Diff(wantWhere, where.Span()); diff != "" {
+		t.Errorf("Span() of where returned unexpected diff (-want, +got):\n%s", diff)
+	}
+	wantCriteria := ast.Span{
+		Start: ast.Position{Offset: 22, Line: 2, Column: 10},
+		End:   ast.Position{Offset: 38, Line: 2, Column: 26},
+	}
+	if diff := cmp.Diff(wantCriteria, criteria.Span()); diff != "" {
+		t.Errorf("Span() of criteria returned unexpected diff (-want, +got):\n%s", diff)
+	}
+	if got := path[criteria.Span().Start.Offset:criteria.Span().End.Offset]; got != "use = 'official'" {
+		t.Errorf("Span() of criteria covers %q, want %q", got, "use = 'official'")
+	}
+}
+
+func TestAST_Span_LongLiteral(t *testing.T) {
+	expression := fhirpath.MustCompile("5L + 10L")
+
+	sum := expression.AST().(*ast.Operator)
+
+	wantLeft := ast.Span{
+		Start: ast.Position{Offset: 0, Line: 1, Column: 1},
+		End:   ast.Position{Offset: 2, Line: 1, Column: 3},
+	}
+	if diff := cmp.Diff(wantLeft, sum.Left.Span()); diff != "" {
+		t.Errorf("Span() of 5L returned unexpected diff (-want, +got):\n%s", diff)
+	}
+	wantSum := ast.Span{
+		Start: ast.Position{Offset: 0, Line: 1, Column: 1},
+		End:   ast.Position{Offset: 8, Line: 1, Column: 9},
+	}
+	if diff := cmp.Diff(wantSum, sum.Span()); diff != "" {
+		t.Errorf("Span() of sum returned unexpected diff (-want, +got):\n%s", diff)
+	}
+}
+
+func TestAST_ReturnsCopy(t *testing.T) {
+	path := "Patient.name.where(given is string).exists(%a or $this)"
+	expression := fhirpath.MustCompile(path)
+	want := describe(expression.AST())
+
+	ast.Inspect(expression.AST(), func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.Field:
+			n.Name = "modified"
+		case *ast.Function:
+			n.Name = "modified"
+			n.Args[0] = &ast.Literal{Text: "modified"}
+		case *ast.Operator:
+			n.Op = "modified"
+		}
+		return node != nil
+	})
+
+	if diff := cmp.Diff(want, describe(expression.AST())); diff != "" {
+		t.Errorf("AST of %q was modified (-want, +got):\n%s", path, diff)
+	}
+}
+
+func TestClone(t *testing.T) {
+	node := fhirpath.MustCompile("Patient.name.where(given is string).exists(%a or $this | 1 | $index)").AST()
+
+	got := ast.Clone(node)
+
+	if diff := cmp.Diff(describe(node), describe(got)); diff != "" {
+		t.Errorf("Clone returned unexpected diff (-want, +got):\n%s", diff)
+	}
+	original := map[ast.Node]bool{}
+	ast.Inspect(node, func(node ast.Node) bool {
+		original[node] = true
+		return node != nil
+	})
+	ast.Inspect(got, func(node ast.Node) bool {
+		if node != nil && original[node] {
+			t.Errorf("Clone shares %v node with the original", node.Kind())
+		}
+		return node != nil
+	})
+}
+
+type recorder struct {
+	events *[]string
+}
+
+func (r recorder) Visit(node ast.Node) ast.Visitor {
+	if node == nil {
+		*r.events = append(*r.events, "end")
+		return nil
+	}
+	*r.events = append(*r.events, node.Kind().String())
+	if node.Kind() == ast.KindFunction {
+		// Skip the input and arguments of functions.
+		return nil
+	}
+	return r
+}
+
+func TestWalk_VisitsChildrenUntilNil(t *testing.T) {
+	expression := fhirpath.MustCompile("name.first() = %value")
+	var got []string
+
+	ast.Walk(recorder{&got}, expression.AST())
+
+	want := []string{"Operator", "Function", "ExternalConstant", "end", "end"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Walk returned unexpected diff (-want, +got):\n%s", diff)
+	}
+}
+
+func TestKind_String(t *testing.T) {
+	if got, want := ast.KindTypeOperator.String(), "TypeOperator"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if got, want := ast.Kind(0).String(), "Invalid"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestAST_OfCopiedExpression(t *testing.T) {
+	path := "Patient.name.exists()"
+	expression := fhirpath.MustCompile(path)
+	want := describe(expression.AST())
+
+	copied := *expression
+
+	if diff := cmp.Diff(want, describe(copied.AST())); diff != "" {
+		t.Errorf("AST of copied %q returned unexpected diff (-want, +got):\n%s", path, diff)
+	}
+}
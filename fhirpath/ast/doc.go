@@ -0,0 +1,26 @@
+/*
+Package ast provides a read-only syntax tree of compiled FHIRPath expressions,
+which can be used to inspect the fields, functions and operators that an
+expression uses without parsing it again.
+
+The tree of an expression is returned by fhirpath.Expression.AST, and can be
+traversed with Walk or Inspect:
+
+	expression := fhirpath.MustCompile("Patient.name.where(use = 'official').given")
+	ast.Inspect(expression.AST(), func(node ast.Node) bool {
+		if fn, ok := node.(*ast.Function); ok {
+			fmt.Println(fn.Name)
+		}
+		return true
+	})
+
+The tree describes the source of the expression; modifying it has no effect on
+evaluation.
+
+Trees can be formatted as canonical FHIRPath source with Format, or with a
+Printer to break long 'where' and 'select' chains across lines:
+
+	node, err := fhirpath.Parse("Patient.name.where(use='official').given")
+	fmt.Println(ast.Format(node)) // Patient.name.where(use = 'official').given
+*/
+package ast
@@ -0,0 +1,328 @@
+package ast
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/verily-src/fhirpath-go/fhirpath/system"
+)
+
+// defaultIndent is the indentation of broken lines, if none is configured.
+const defaultIndent = "    "
+
+// Printer formats syntax trees as canonical FHIRPath source, with consistent
+// spacing, quoting and parentheses. Formatting a tree and parsing the result
+// again produces an equivalent tree.
+//
+// Comments aren't part of the syntax tree, so they aren't preserved.
+type Printer struct {
+	// LineWidth is the width beyond which invocation chains that call 'where'
+	// or 'select' are broken across lines, with each function invocation on
+	// its own line. If it is zero, the output is always a single line.
+	LineWidth int
+
+	// Indent is the indentation of broken lines, per level of nesting. If it
+	// is empty, four spaces are used.
+	Indent string
+}
+
+// Format returns the canonical single-line source of the tree.
+func Format(node Node) string {
+	return (&Printer{}).Format(node)
+}
+
+// Format returns the canonical source of the tree.
+func (p *Printer) Format(node Node) string {
+	indent := p.Indent
+	if indent == "" {
+		indent = defaultIndent
+	}
+	return (&printer{width: p.LineWidth, indent: indent}).expression(node)
+}
+
+// printer formats nodes at a level of nesting.
+type printer struct {
+	width  int
+	indent string
+	depth  int
+}
+
+// nested returns a printer for the continuation lines of broken chains.
+func (p *printer) nested() *printer {
+	return &printer{width: p.width, indent: p.indent, depth: p.depth + 1}
+}
+
+// flat returns a printer that never breaks lines.
+func (p *printer) flat() *printer {
+	return &printer{indent: p.indent, depth: p.depth}
+}
+
+// Precedences of the operators, from loosest to tightest. Invocations and
+// terms bind tighter than any operator.
+const (
+	precedenceImplies = iota + 1
+	precedenceOr
+	precedenceAnd
+	precedenceMembership
+	precedenceEquality
+	precedenceInequality
+	precedenceUnion
+	precedenceType
+	precedenceAdditive
+	precedenceMultiplicative
+	precedencePolarity
+	precedenceInvocation
+)
+
+var binaryPrecedences = map[string]int{
+	"implies":  precedenceImplies,
+	"or":       precedenceOr,
+	"xor":      precedenceOr,
+	"and":      precedenceAnd,
+	"in":       precedenceMembership,
+	"contains": precedenceMembership,
+	"=":        precedenceEquality,
+	"~":        precedenceEquality,
+	"!=":       precedenceEquality,
+	"!~":       precedenceEquality,
+	"<=":       precedenceInequality,
+	"<":        precedenceInequality,
+	">":        precedenceInequality,
+	">=":       precedenceInequality,
+	"|":        precedenceUnion,
+	"+":        precedenceAdditive,
+	"-":        precedenceAdditive,
+	"&":        precedenceAdditive,
+	"*":        precedenceMultiplicative,
+	"/":        precedenceMultiplicative,
+	"div":      precedenceMultiplicative,
+	"mod":      precedenceMultiplicative,
+}
+
+// precedenceOf returns how tightly the node binds its operands.
+func precedenceOf(node Node) int {
+	switch n := node.(type) {
+	case *Operator:
+		if n.Op == "[]" {
+			return precedenceInvocation
+		}
+		if n.Left == nil {
+			return precedencePolarity
+		}
+		return binaryPrecedences[n.Op]
+	case *TypeOperator:
+		return precedenceType
+	}
+	return precedenceInvocation
+}
+
+// operand formats an operand of an operator, parenthesizing it if it binds
+// looser than the given precedence.
+func (p *printer) operand(node Node, precedence int) string {
+	if precedenceOf(node) < precedence {
+		return "(" + p.expression(node) + ")"
+	}
+	return p.expression(node)
+}
+
+func (p *printer) expression(node Node) string {
+	switch n := node.(type) {
+	case *Operator:
+		if n.Op == "[]" {
+			return p.chain(n)
+		}
+		if n.Left == nil {
+			return n.Op + p.operand(n.Right, precedencePolarity)
+		}
+		precedence := binaryPrecedences[n.Op]
+		// Binary operators are left-associative, so right operands of the
+		// same precedence must be parenthesized.
+		return p.operand(n.Left, precedence) + " " + n.Op + " " + p.operand(n.Right, precedence+1)
+	case *TypeOperator:
+		return p.operand(n.Expr, precedenceType) + " " + n.Op + " " + n.Type.Name
+	case *Literal:
+		return literal(n)
+	case *ExternalConstant:
+		return "%" + constant(n.Name)
+	case *TypeSpecifier:
+		return n.Name
+	case *Field, *Function, *Variable, *ResourceType:
+		return p.chain(n)
+	}
+	return ""
+}
+
+// chain formats an invocation chain, such as 'name.where(use = 'official')'.
+// Chains that call 'where' or 'select' and are too long are broken before
+// each function invocation.
+func (p *printer) chain(node Node) string {
+	var links []Node
+	head := node
+	for {
+		input := inputOf(head)
+		if input == nil {
+			break
+		}
+		links = append([]Node{head}, links...)
+		head = input
+	}
+	if p.width > 0 && breaksChain(links) {
+		if flat := p.flat().chain(node); utf8.RuneCountInString(flat) > p.width {
+			return p.brokenChain(head, links)
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(p.link(head, true))
+	for _, link := range links {
+		sb.WriteString(p.link(link, false))
+	}
+	return sb.String()
+}
+
+// brokenChain formats an invocation chain with each function invocation on
+// its own line.
+func (p *printer) brokenChain(head Node, links []Node) string {
+	nested := p.nested()
+	continuation := "\n" + strings.Repeat(p.indent, nested.depth)
+
+	var sb strings.Builder
+	sb.WriteString(p.link(head, true))
+	for _, link := range links {
+		if _, ok := link.(*Function); ok {
+			sb.WriteString(continuation)
+		}
+		sb.WriteString(nested.link(link, false))
+	}
+	return sb.String()
+}
+
+// link formats a node of an invocation chain, without its input. The head of
+// a chain isn't preceded by a '.'.
+func (p *printer) link(node Node, head bool) string {
+	dot := "."
+	if head {
+		dot = ""
+	}
+	switch n := node.(type) {
+	case *Field:
+		return dot + identifier(n.Name)
+	case *ResourceType:
+		return dot + identifier(n.Name)
+	case *Variable:
+		return dot + n.Name
+	case *Function:
+		args := make([]string, len(n.Args))
+		for i, arg := range n.Args {
+			args[i] = p.expression(arg)
+		}
+		return dot + n.Name + "(" + strings.Join(args, ", ") + ")"
+	case *Operator:
+		if n.Op == "[]" && !head {
+			return "[" + p.expression(n.Right) + "]"
+		}
+	}
+	return p.operand(node, precedenceInvocation)
+}
+
+// inputOf returns the input of a node of an invocation chain, or nil if the
+// node starts the chain.
+func inputOf(node Node) Node {
+	switch n := node.(type) {
+	case *Field:
+		return n.Input
+	case *Function:
+		return n.Input
+	case *Variable:
+		return n.Input
+	case *ResourceType:
+		return n.Input
+	case *Operator:
+		if n.Op == "[]" {
+			return n.Left
+		}
+	}
+	return nil
+}
+
+// breaksChain returns true if the chain calls 'where' or 'select', which
+// makes it a candidate for breaking across lines.
+func breaksChain(links []Node) bool {
+	for _, link := range links {
+		if fn, ok := link.(*Function); ok && (fn.Name == "where" || fn.Name == "select") {
+			return true
+		}
+	}
+	return false
+}
+
+// keywords are the words that are tokens of the grammar, which must be
+// delimited with backticks to be used as identifiers.
+var keywords = map[string]bool{
+	"as": true, "contains": true, "in": true, "is": true,
+	"and": true, "or": true, "xor": true, "implies": true,
+	"div": true, "mod": true, "true": true, "false": true,
+	"year": true, "month": true, "week": true, "day": true,
+	"hour": true, "minute": true, "second": true, "millisecond": true,
+	"years": true, "months": true, "weeks": true, "days": true,
+	"hours": true, "minutes": true, "seconds": true, "milliseconds": true,
+}
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// identifier formats an identifier, delimiting it with backticks only if it
+// isn't a plain identifier.
+func identifier(name string) string {
+	if identifierPattern.MatchString(name) && !keywords[name] {
+		return name
+	}
+	return "`" + name + "`"
+}
+
+// constant formats the name of an external constant. Names that can't be
+// delimited with backticks are written as strings.
+func constant(name string) string {
+	if strings.ContainsAny(name, "`\\") {
+		return quote(name)
+	}
+	return identifier(name)
+}
+
+var stringEscapes = strings.NewReplacer(
+	`\`, `\\`,
+	`'`, `\'`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\t", `\t`,
+	"\f", `\f`,
+)
+
+// quote formats a string literal, escaping the characters that must be.
+func quote(s string) string {
+	return "'" + stringEscapes.Replace(s) + "'"
+}
+
+var quantityPattern = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)\s*(.*)$`)
+
+// literal formats a literal. String literals and the units of quantities are
+// quoted consistently, while other literals are written as they were parsed,
+// to preserve their precision.
+func literal(n *Literal) string {
+	switch value := n.Value.(type) {
+	case system.String:
+		return quote(string(value))
+	case system.Quantity:
+		match := quantityPattern.FindStringSubmatch(n.Text)
+		if match == nil {
+			break
+		}
+		number, unit := match[1], match[2]
+		if strings.HasPrefix(unit, "'") {
+			value, _ := system.ParseString(unit)
+			unit = quote(string(value))
+		}
+		return number + " " + unit
+	}
+	return n.Text
+}
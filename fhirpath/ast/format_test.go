@@ -0,0 +1,199 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/verily-src/fhirpath-go/fhirpath"
+	"github.com/verily-src/fhirpath-go/fhirpath/ast"
+)
+
+func TestFormat(t *testing.T) {
+	testCases := []struct {
+		name string
+		path string
+		want string
+	}{
+		{
+			name: "normalizes spacing",
+			path: "Patient.name.where(use='official'and(given.count()>1 or family.exists()))",
+			want: "Patient.name.where(use = 'official' and (given.count() > 1 or family.exists()))",
+		},
+		{
+			name: "removes whitespace and comments",
+			path: "Patient . name /* names */\n  .given // given names",
+			want: "Patient.name.given",
+		},
+		{
+			name: "escapes strings",
+			path: `'it\'s' & 'a\\b\nc' & '\"quoted\"'`,
+			want: `'it\'s' & 'a\\b\nc' & '"quoted"'`,
+		},
+		{
+			name: "separates quantity units",
+			path: "@2020-01-01 + 4days - 5   'mg'",
+			want: "@2020-01-01 + 4 days - 5 'mg'",
+		},
+		{
+			name: "preserves decimal precision",
+			path: "1.50 'kg' = 1.500'kg'",
+			want: "1.50 'kg' = 1.500 'kg'",
+		},
+		{
+			name: "removes unneeded backticks",
+			path: "`Patient`.`name`.`given`",
+			want: "Patient.name.given",
+		},
+		{
+			name: "keeps backticks on keywords",
+			path: "text.`div`",
+			want: "text.`div`",
+		},
+		{
+			name: "quotes external constants consistently",
+			path: "%'vs-name' | %`vs-name` | %`resource`",
+			want: "%`vs-name` | %`vs-name` | %resource",
+		},
+		{
+			name: "removes unneeded parentheses",
+			path: "((1 + 2)) * 3 = (4 * 5) + (6)",
+			want: "(1 + 2) * 3 = 4 * 5 + 6",
+		},
+		{
+			name: "keeps parentheses of right operands",
+			path: "1 - (2 - 3)",
+			want: "1 - (2 - 3)",
+		},
+		{
+			name: "parenthesizes operands of invocations",
+			path: "(value as Quantity).value | (name | contact.name)[0]",
+			want: "(value as Quantity).value | (name | contact.name)[0]",
+		},
+		{
+			name: "parenthesizes type expressions followed by operators",
+			path: "value as Quantity + 1",
+			want: "(value as Quantity) + 1",
+		},
+		{
+			name: "formats polarity",
+			path: "- (1 + 2) + - 3",
+			want: "-(1 + 2) + -3",
+		},
+		{
+			name: "formats type functions",
+			path: "Bundle.entry.resource.ofType( FHIR.Patient )",
+			want: "Bundle.entry.resource.ofType(FHIR.Patient)",
+		},
+		{
+			name: "formats variables and empty collections",
+			path: "name.select($this.given[ $index ]) | { }",
+			want: "name.select($this.given[$index]) | {}",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			node, err := fhirpath.Parse(tc.path)
+			if err != nil {
+				t.Fatalf("Parse(%s) returned unexpected error: %v", tc.path, err)
+			}
+
+			if got := ast.Format(node); got != tc.want {
+				t.Errorf("Format(%s) = %s, want %s", tc.path, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPrinter_BreaksLongChains(t *testing.T) {
+	testCases := []struct {
+		name  string
+		path  string
+		width int
+		want  string
+	}{
+		{
+			name:  "chain with where and select",
+			path:  "Patient.name.where(use = 'official').select(given.first()).exists()",
+			width: 40,
+			want: "Patient.name\n" +
+				"    .where(use = 'official')\n" +
+				"    .select(given.first())\n" +
+				"    .exists()",
+		},
+		{
+			name:  "nested chain",
+			path:  "Patient.contact.where(name.where(use = 'official').given.exists()).telecom",
+			width: 40,
+			want: "Patient.contact\n" +
+				"    .where(name\n" +
+				"        .where(use = 'official').given\n" +
+				"        .exists()).telecom",
+		},
+		{
+			name:  "short chain",
+			path:  "Patient.name.where(use = 'official')",
+			width: 40,
+			want:  "Patient.name.where(use = 'official')",
+		},
+		{
+			name:  "long chain without where or select",
+			path:  "Patient.name.given.first().substring(0, 10).upper()",
+			width: 20,
+			want:  "Patient.name.given.first().substring(0, 10).upper()",
+		},
+		{
+			name:  "no line width",
+			path:  "Patient.name.where(use = 'official').select(given.first()).exists()",
+			width: 0,
+			want:  "Patient.name.where(use = 'official').select(given.first()).exists()",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			node, err := fhirpath.Parse(tc.path)
+			if err != nil {
+				t.Fatalf("Parse(%s) returned unexpected error: %v", tc.path, err)
+			}
+			printer := &ast.Printer{LineWidth: tc.width}
+
+			if got := printer.Format(node); got != tc.want {
+				t.Errorf("Format(%s) = \n%s\nwant\n%s", tc.path, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFormat_ParsesToSameTree(t *testing.T) {
+	paths := []string{
+		"Patient.name.where(use = 'official' and given.count() > 1).select(given.first() & ' ' & family)",
+		"(1 + 2) * -3 div 4 mod 5 - 6 & 'a' | {} is Integer",
+		"a implies (b or c xor (d and e)) in f contains g != h !~ i ~ j <= k",
+		"(value as Quantity).value > 5 'mg' and value is Quantity",
+		"%resource.Patient.name[0].given.defineVariable('g').select(%g)",
+		"-(-name.count())",
+	}
+
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			node, err := fhirpath.Parse(path)
+			if err != nil {
+				t.Fatalf("Parse(%s) returned unexpected error: %v", path, err)
+			}
+			printer := &ast.Printer{LineWidth: 20}
+			formatted := printer.Format(node)
+
+			got, err := fhirpath.Parse(formatted)
+			if err != nil {
+				t.Fatalf("Parse(%s) returned unexpected error: %v", formatted, err)
+			}
+			if diff := cmp.Diff(describe(node), describe(got)); diff != "" {
+				t.Errorf("Parse(Format(%s)) returned unexpected tree (-want, +got):\n%s", path, diff)
+			}
+			if again := printer.Format(got); again != formatted {
+				t.Errorf("Format is not idempotent: got %s, want %s", again, formatted)
+			}
+		})
+	}
+}
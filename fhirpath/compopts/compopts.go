@@ -8,14 +8,17 @@ package compopts
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/verily-src/fhirpath-go/fhirpath/internal/funcs"
 	"github.com/verily-src/fhirpath-go/fhirpath/internal/opts"
 	"github.com/verily-src/fhirpath-go/fhirpath/internal/parser"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/typecheck"
 )
 
 var (
 	ErrMultipleTransforms = errors.New("multiple transforms provided")
+	ErrUnknownInputType   = errors.New("unknown input type")
 )
 
 // AddFunction creates a CompileOption that will register a custom FHIRPath
@@ -65,3 +68,42 @@ func WithExperimentalFuncs() opts.CompileOption {
 		return nil
 	})
 }
+
+// WithR4Funcs is an option that enables the FHIR-specific functions defined
+// for R4, such as 'hasValue', 'htmlChecks' and 'conformsTo', which are used by
+// the invariants of the core FHIR StructureDefinitions.
+func WithR4Funcs() opts.CompileOption {
+	return opts.Transform(func(cfg *opts.CompileConfig) error {
+		cfg.Table = funcs.AddR4Funcs(cfg.Table)
+		return nil
+	})
+}
+
+// WithInputType is an option that type checks the expression against an input
+// of the named FHIR type, such as "Patient". Navigation to unknown fields, type
+// casts that can never succeed, and arguments of the wrong type are reported as
+// compilation errors. The inferred output type is available through
+// Expression.OutputType.
+//
+// If the name isn't a FHIR type, compilation will return an error.
+func WithInputType(name string) opts.CompileOption {
+	return opts.Transform(func(cfg *opts.CompileConfig) error {
+		if _, ok := typecheck.Of(name); !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownInputType, name)
+		}
+		cfg.InputType = name
+		return nil
+	})
+}
+
+// Optimize is an option that simplifies the compiled expression, so that
+// sub-expressions that don't depend on the input, such as '1 + 1' or
+// 'iif(true, x, y)', are evaluated once during compilation. Results and errors
+// are unchanged. Sub-expressions that always evaluate to an empty collection
+// are reported by Expression.Warnings.
+func Optimize() opts.CompileOption {
+	return opts.Transform(func(cfg *opts.CompileConfig) error {
+		cfg.Optimize = true
+		return nil
+	})
+}
@@ -0,0 +1,129 @@
+package fhirpath
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/verily-src/fhirpath-go/fhirpath/ast"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/diagnostic"
+	"github.com/verily-src/fhirpath-go/internal/slices"
+)
+
+// ErrorCode classifies a CompileError.
+type ErrorCode string
+
+const (
+	// CodeSyntax is the code of expressions that can't be parsed.
+	CodeSyntax ErrorCode = diagnostic.CodeSyntax
+
+	// CodeUnknownFunction is the code of calls to functions that don't exist.
+	CodeUnknownFunction ErrorCode = diagnostic.CodeUnknownFunction
+
+	// CodeWrongArity is the code of calls with the wrong number of arguments.
+	CodeWrongArity ErrorCode = diagnostic.CodeWrongArity
+
+	// CodeInvalidType is the code of type specifiers that don't name a type.
+	CodeInvalidType ErrorCode = diagnostic.CodeInvalidType
+
+	// CodeInvalidVariable is the code of invalid 'defineVariable' calls.
+	CodeInvalidVariable ErrorCode = diagnostic.CodeInvalidVariable
+
+	// CodeInvalidLiteral is the code of literals with invalid values.
+	CodeInvalidLiteral ErrorCode = diagnostic.CodeInvalidLiteral
+
+	// CodeUnknownField is the code of fields that don't exist on the input
+	// type given with compopts.WithInputType.
+	CodeUnknownField ErrorCode = diagnostic.CodeUnknownField
+
+	// CodeImpossibleType is the code of type casts that can never succeed.
+	CodeImpossibleType ErrorCode = diagnostic.CodeImpossibleType
+
+	// CodeInvalidArgument is the code of arguments of the wrong type.
+	CodeInvalidArgument ErrorCode = diagnostic.CodeInvalidArgument
+
+	// CodeInvalidExpression is the code of any other invalid expression.
+	CodeInvalidExpression ErrorCode = diagnostic.CodeInvalidExpression
+
+	// CodeAlwaysEmpty is the code of sub-expressions that always evaluate to
+	// an empty collection, which are reported by Expression.Warnings.
+	CodeAlwaysEmpty ErrorCode = diagnostic.CodeAlwaysEmpty
+)
+
+// CompileError is an error in the source of a FHIRPath expression, which is
+// located at the span of the source that caused it.
+type CompileError struct {
+	// Code classifies the error.
+	Code ErrorCode
+
+	// Span is the range of the source that caused the error.
+	Span ast.Span
+
+	// Token is the text of the offending token, e.g. the name of an unknown
+	// function.
+	Token string
+
+	// Suggestion is a likely replacement for the offending token, e.g. the
+	// closest function name to a misspelled one. It is empty if there is none.
+	Suggestion string
+
+	// Err is the underlying error.
+	Err error
+}
+
+// Error returns the message of the error, prefixed with the line and column
+// where it was found.
+func (e *CompileError) Error() string {
+	message := fmt.Sprintf("%d:%d: %v", e.Span.Start.Line, e.Span.Start.Column, e.Err)
+	if e.Suggestion != "" {
+		message += fmt.Sprintf(" (did you mean '%s'?)", e.Suggestion)
+	}
+	return message
+}
+
+func (e *CompileError) Unwrap() error {
+	return e.Err
+}
+
+// CompileErrors are all the errors found while compiling a FHIRPath
+// expression, in the order that they appear in the source.
+type CompileErrors []*CompileError
+
+// Error returns the messages of all errors, one per line.
+func (e CompileErrors) Error() string {
+	return strings.Join(slices.Map(e, (*CompileError).Error), "\n")
+}
+
+func (e CompileErrors) Unwrap() []error {
+	return slices.Map(e, func(err *CompileError) error { return err })
+}
+
+// compileErrors converts the located errors that make up the given error to
+// CompileErrors. Errors that aren't located are returned unchanged.
+func compileErrors(err error) error {
+	located := diagnostic.Flatten(err)
+	if len(located) == 0 {
+		return err
+	}
+	return toCompileErrors(located)
+}
+
+// toCompileErrors converts located errors to CompileErrors, sorted by their
+// position in the source.
+func toCompileErrors(located []*diagnostic.Error) CompileErrors {
+	if len(located) == 0 {
+		return nil
+	}
+	sort.SliceStable(located, func(i, j int) bool {
+		return located[i].Span.Start.Offset < located[j].Span.Start.Offset
+	})
+	return CompileErrors(slices.Map(located, func(err *diagnostic.Error) *CompileError {
+		return &CompileError{
+			Code:       ErrorCode(err.Code),
+			Span:       err.Span,
+			Token:      err.Token,
+			Suggestion: err.Suggestion,
+			Err:        err.Err,
+		}
+	}))
+}
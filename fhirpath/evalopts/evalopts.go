@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/verily-src/fhirpath-go/fhirpath/internal/opts"
+	"github.com/verily-src/fhirpath-go/fhirpath/profile"
 	"github.com/verily-src/fhirpath-go/fhirpath/resolver"
 	"github.com/verily-src/fhirpath-go/fhirpath/system"
 	"github.com/verily-src/fhirpath-go/fhirpath/terminology"
@@ -41,10 +42,12 @@ func OverrideTime(t time.Time) opts.EvaluateOption {
 //   - A FHIR Element or Resource type, or
 //   - A FHIRPath Collection, containing the above types.
 //
-// If an EnvVariable is specified that already exists in the expression, then
-// evaluation will yield an ErrExistingConstant error. If an EnvVariable is
-// contains a type that is not one of the above valid types, then evaluation
-// will yield an ErrUnsupportedType error.
+// If an EnvVariable is specified that already exists in the expression, such
+// as %context or %ucum, then evaluation will yield an ErrExistingConstant
+// error. The other environment variables defined by FHIR, such as %resource,
+// %sct and %vs-[name], have default values that an EnvVariable overrides.
+// If an EnvVariable contains a type that is not one of the above valid types,
+// then evaluation will yield an ErrUnsupportedType error.
 func EnvVariable(name string, value any) opts.EvaluateOption {
 	return opts.Transform(func(cfg *opts.EvaluateConfig) error {
 		if err := validateType(value); err != nil {
@@ -92,6 +95,25 @@ func WithTerminologyService(termService terminology.Service) opts.EvaluateOption
 	})
 }
 
+// WithStrictTerminology returns an EvaluateOption that causes the 'memberOf'
+// function to return errors from the Terminology Service, such as an unknown
+// value set. By default, such errors evaluate to false.
+func WithStrictTerminology() opts.EvaluateOption {
+	return opts.Transform(func(cfg *opts.EvaluateConfig) error {
+		cfg.Context.StrictTerminology = true
+		return nil
+	})
+}
+
+// WithProfileValidator returns an EvaluateOption that sets the validator used
+// by the 'conformsTo' function to check resources against profiles.
+func WithProfileValidator(validator profile.Validator) opts.EvaluateOption {
+	return opts.Transform(func(cfg *opts.EvaluateConfig) error {
+		cfg.Context.ProfileValidator = validator
+		return nil
+	})
+}
+
 // WithContext returns an EvaluateOption that sets the Golang context.Context in the expr.Context
 func WithContext(ctx context.Context) opts.EvaluateOption {
 	return opts.Transform(func(cfg *opts.EvaluateConfig) error {
@@ -99,3 +121,23 @@ func WithContext(ctx context.Context) opts.EvaluateOption {
 		return nil
 	})
 }
+
+// WithMaxRepeatDepth returns an EvaluateOption that sets the maximum number of
+// levels that the 'repeat' function will traverse before raising an error. If
+// unset, a default maximum of 1000 levels is used.
+func WithMaxRepeatDepth(depth int) opts.EvaluateOption {
+	return opts.Transform(func(cfg *opts.EvaluateConfig) error {
+		cfg.Context.MaxRepeatDepth = depth
+		return nil
+	})
+}
+
+// WithTraceSink returns an EvaluateOption that sets the callback receiving the
+// output of the 'trace' function. Each call receives the name passed to 'trace'
+// and the traced collection. If unset, traced output is discarded.
+func WithTraceSink(sink func(name string, c system.Collection)) opts.EvaluateOption {
+	return opts.Transform(func(cfg *opts.EvaluateConfig) error {
+		cfg.Context.TraceSink = sink
+		return nil
+	})
+}
@@ -2,13 +2,18 @@ package fhirpath
 
 import (
 	"errors"
+	"sync"
 
 	dtpb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/datatypes_go_proto"
+	"github.com/verily-src/fhirpath-go/fhirpath/ast"
 	"github.com/verily-src/fhirpath-go/fhirpath/evalopts"
 	"github.com/verily-src/fhirpath-go/fhirpath/internal/compile"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/diagnostic"
 	"github.com/verily-src/fhirpath-go/fhirpath/internal/expr"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/optimize"
 	"github.com/verily-src/fhirpath-go/fhirpath/internal/opts"
 	"github.com/verily-src/fhirpath-go/fhirpath/internal/parser"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/typecheck"
 	"github.com/verily-src/fhirpath-go/fhirpath/system"
 	"github.com/verily-src/fhirpath-go/internal/fhir"
 	"github.com/verily-src/fhirpath-go/internal/slices"
@@ -16,8 +21,14 @@ import (
 
 var (
 	ErrInvalidField     = expr.ErrInvalidField
+	ErrNotSingleton     = expr.ErrNotSingleton
+	ErrConstantNotFound = expr.ErrConstantNotFound
+	ErrExistingVariable = expr.ErrExistingVariable
 	ErrUnsupportedType  = evalopts.ErrUnsupportedType
 	ErrExistingConstant = evalopts.ErrExistingConstant
+	ErrImpossibleType   = typecheck.ErrImpossibleType
+	ErrInvalidArgument  = typecheck.ErrInvalidArgument
+	ErrAlwaysEmpty      = optimize.ErrAlwaysEmpty
 )
 
 // Resource is a FHIR resource. This is an alias for the
@@ -28,13 +39,41 @@ type Resource = fhir.Resource
 type Expression struct {
 	expression expr.Expression
 	path       string
+	syntax     *lazySyntax
+	outputType *TypeInfo
+	warnings   CompileErrors
+
+	// trackOwners is true if the expression uses %resource or %rootResource,
+	// which require the owner of each navigated node to be tracked.
+	trackOwners bool
+}
+
+// lazySyntax holds the syntax tree of an expression, which is only built when
+// it is first requested. It is shared by copies of the Expression.
+type lazySyntax struct {
+	once sync.Once
+	node ast.Node
+}
+
+// TypeInfo is the type of the collection that an expression evaluates to, as
+// inferred during compilation.
+type TypeInfo struct {
+	// Type is the qualified name of the type of the items, e.g. "FHIR.HumanName"
+	// or "System.Boolean". It is empty if the type couldn't be inferred, such as
+	// for choice elements or custom functions.
+	Type string
+
+	// Singleton is true if the expression evaluates to at most one item.
+	Singleton bool
 }
 
 // Compile parses and compiles the FHIRPath expression down to a single
 // Expression object.
 //
 // If there are any syntax or semantic errors, this will return an
-// error indicating the compilation failure reason.
+// error indicating the compilation failure reason. Errors in the expression
+// are returned as CompileErrors, which locate each error in the source; all
+// errors are reported, rather than just the first.
 func Compile(expr string, options ...CompileOption) (*Expression, error) {
 	config, err := compile.PopulateConfig(options...)
 	if err != nil {
@@ -43,33 +82,97 @@ func Compile(expr string, options ...CompileOption) (*Expression, error) {
 
 	tree, err := compile.Tree(expr)
 	if err != nil {
-		return nil, err
+		return nil, compileErrors(err)
 	}
 
 	visitor := &parser.FHIRPathVisitor{
 		Functions:  config.Table,
 		Permissive: config.Permissive,
 	}
+	if config.Optimize {
+		visitor.Sources = parser.Sources{}
+	}
 	vr, ok := visitor.Visit(tree).(*parser.VisitResult)
 	if !ok {
 		return nil, errors.New("input expression currently unsupported")
 	}
 
-	if vr.Error != nil {
-		return nil, vr.Error
+	var outputType *TypeInfo
+	var checkErr error
+	if input, ok := typecheck.Of(config.InputType); ok {
+		var output typecheck.Type
+		output, checkErr = typecheck.Check(tree, input, config.Permissive)
+		outputType = &TypeInfo{Type: output.Name(), Singleton: output.Singleton}
+	}
+	if err := errors.Join(vr.Error, checkErr); err != nil {
+		return nil, compileErrors(err)
+	}
+
+	result := vr.Result
+	var warnings CompileErrors
+	if config.Optimize {
+		var located []*diagnostic.Error
+		result, located = optimize.Optimize(result, visitor.Sources)
+		warnings = toCompileErrors(located)
 	}
 	return &Expression{
-		expression: vr.Result,
-		path:       expr,
+		expression:  result,
+		path:        expr,
+		syntax:      &lazySyntax{},
+		outputType:  outputType,
+		warnings:    warnings,
+		trackOwners: compile.UsesConstant(tree, "resource", "rootResource"),
 	}, nil
 }
 
+// Parse parses the FHIRPath expression, returning its syntax tree without
+// compiling it. Functions and types aren't resolved, so only syntax errors are
+// reported, as CompileErrors. The tree can be formatted with ast.Printer.
+func Parse(expr string) (ast.Node, error) {
+	tree, err := compile.Tree(expr)
+	if err != nil {
+		return nil, compileErrors(err)
+	}
+	return compile.AST(tree), nil
+}
+
+// OutputType returns the type of the collection that the expression evaluates
+// to, as inferred from the input type given with compopts.WithInputType. The
+// second return value is false if the expression wasn't compiled with an input
+// type.
+func (e *Expression) OutputType() (TypeInfo, bool) {
+	if e.outputType == nil {
+		return TypeInfo{}, false
+	}
+	return *e.outputType, true
+}
+
+// Warnings returns the problems found in the expression that don't prevent it
+// from being evaluated, such as sub-expressions that always evaluate to an
+// empty collection, with the code CodeAlwaysEmpty. These are only reported for
+// expressions compiled with compopts.Optimize.
+func (e *Expression) Warnings() CompileErrors {
+	return e.warnings
+}
+
 // String returns the string representation of this FHIRPath expression.
 // This is just the input that initially produced the FHIRPath value.
 func (e *Expression) String() string {
 	return e.path
 }
 
+// AST returns the syntax tree of this FHIRPath expression, which describes the
+// fields, functions and operators that it uses. The tree is built on the first
+// call, and each call returns a copy of it that the caller may modify.
+func (e *Expression) AST() ast.Node {
+	e.syntax.once.Do(func() {
+		// The path was already parsed by Compile, so can't fail to parse.
+		tree, _ := compile.Tree(e.path)
+		e.syntax.node = compile.AST(tree)
+	})
+	return ast.Clone(e.syntax.node)
+}
+
 // MustCompile compiles the FHIRpath expression input, and returns the
 // compiled expression. If any compilation error occurs, this function
 // will panic.
@@ -86,6 +189,9 @@ func (e *Expression) Evaluate(input []Resource, options ...EvaluateOption) (syst
 	config := &opts.EvaluateConfig{
 		Context: expr.InitializeContext(slices.MustConvert[any](input)),
 	}
+	if e.trackOwners {
+		config.Context.TrackOwners()
+	}
 	config, err := opts.ApplyOptions(config, options...)
 	if err != nil {
 		return nil, err
@@ -1,38 +1,50 @@
 package fhirpath_test
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	cpb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/codes_go_proto"
 	dtpb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/datatypes_go_proto"
+	bcrpb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/bundle_and_contained_resource_go_proto"
+	cspb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/code_system_go_proto"
 	drpb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/document_reference_go_proto"
 	epb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/encounter_go_proto"
 	lpb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/list_go_proto"
 	mrpb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/medication_request_go_proto"
 	opb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/observation_go_proto"
+	pgp "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/parameters_go_proto"
 	ppb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/patient_go_proto"
 	prpb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/practitioner_go_proto"
 	qrpb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/questionnaire_response_go_proto"
 	tpb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/task_go_proto"
+	vspb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/value_set_go_proto"
 
 	"github.com/verily-src/fhirpath-go/fhirpath"
+	"github.com/verily-src/fhirpath-go/fhirpath/ast"
 	"github.com/verily-src/fhirpath-go/fhirpath/compopts"
 	"github.com/verily-src/fhirpath-go/fhirpath/evalopts"
 	"github.com/verily-src/fhirpath-go/fhirpath/fhirjson"
 	"github.com/verily-src/fhirpath-go/fhirpath/internal/funcs/impl"
+	"github.com/verily-src/fhirpath-go/fhirpath/profile"
 	"github.com/verily-src/fhirpath-go/fhirpath/resolver/resolvertest"
 	"github.com/verily-src/fhirpath-go/fhirpath/system"
+	"github.com/verily-src/fhirpath-go/fhirpath/terminology"
+	"github.com/verily-src/fhirpath-go/internal/containedresource"
 	"github.com/verily-src/fhirpath-go/internal/element/extension"
 	"github.com/verily-src/fhirpath-go/internal/element/reference"
 	"github.com/verily-src/fhirpath-go/internal/fhir"
 	"github.com/verily-src/fhirpath-go/internal/fhirconv"
+	"github.com/verily-src/fhirpath-go/internal/slices"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/shopspring/decimal"
 	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/anypb"
 )
 
 type evaluateTestCase struct {
@@ -812,7 +824,7 @@ func TestEvaluateEquality_ReturnsBoolean(t *testing.T) {
 		},
 		{
 			name:            "comparing integer to quantity",
-			inputPath:       "2 = 2.0 'lbs'",
+			inputPath:       "2 = 2.0 '[lb_av]'",
 			inputCollection: []fhirpath.Resource{},
 			wantCollection:  system.Collection{system.Boolean(true)},
 		},
@@ -821,6 +833,1661 @@ func TestEvaluateEquality_ReturnsBoolean(t *testing.T) {
 	testEvaluate(t, testCases)
 }
 
+func TestEvaluateEquivalence_ReturnsBoolean(t *testing.T) {
+	testCases := []evaluateTestCase{
+		{
+			name:            "case-insensitive family name",
+			inputPath:       "Patient.name[0].family ~ 'chu'",
+			inputCollection: []fhirpath.Resource{patientChu},
+			wantCollection:  system.Collection{system.Boolean(true)},
+		},
+		{
+			name:            "inequivalent family name",
+			inputPath:       "Patient.name[0].family !~ 'chu'",
+			inputCollection: []fhirpath.Resource{patientChu},
+			wantCollection:  system.Collection{system.Boolean(false)},
+		},
+		{
+			name:            "empty collections are equivalent",
+			inputPath:       "Patient.maritalStatus ~ {}",
+			inputCollection: []fhirpath.Resource{patientChu},
+			wantCollection:  system.Collection{system.Boolean(true)},
+		},
+		{
+			name:            "empty and non-empty collections are not equivalent",
+			inputPath:       "Patient.maritalStatus ~ false",
+			inputCollection: []fhirpath.Resource{patientChu},
+			wantCollection:  system.Collection{system.Boolean(false)},
+		},
+		{
+			name:            "decimals compared with least precision",
+			inputPath:       "1.2 ~ 1.24",
+			inputCollection: []fhirpath.Resource{},
+			wantCollection:  system.Collection{system.Boolean(true)},
+		},
+		{
+			name:            "mismatched date precision is not equivalent",
+			inputPath:       "@2000-01 ~ @2000-01-03",
+			inputCollection: []fhirpath.Resource{},
+			wantCollection:  system.Collection{system.Boolean(false)},
+		},
+		{
+			name:            "unordered collections",
+			inputPath:       "Patient.name.given ~ ('kang' | 'senpai')",
+			inputCollection: []fhirpath.Resource{patientChu},
+			wantCollection:  system.Collection{system.Boolean(true)},
+		},
+		{
+			name:            "complex types",
+			inputPath:       "Patient.name[0].given ~ Patient.contact.name.given",
+			inputCollection: []fhirpath.Resource{patientChu},
+			wantCollection:  system.Collection{system.Boolean(true)},
+		},
+	}
+
+	testEvaluate(t, testCases)
+}
+
+func TestEvaluateIterationVariables(t *testing.T) {
+	testCases := []evaluateTestCase{
+		{
+			name:            "selects index of each item",
+			inputPath:       "Patient.name.select($index)",
+			inputCollection: []fhirpath.Resource{patientChu},
+			wantCollection:  system.Collection{system.Integer(0), system.Integer(1)},
+		},
+		{
+			name:            "filters by index",
+			inputPath:       "Patient.name.where($index = 1).given",
+			inputCollection: []fhirpath.Resource{patientChu},
+			wantCollection:  system.Collection{fhir.String("Kang")},
+		},
+		{
+			name:            "aggregates sum with initial value",
+			inputPath:       "(1 | 2 | 3).aggregate($this + $total, 0)",
+			inputCollection: []fhirpath.Resource{},
+			wantCollection:  system.Collection{system.Integer(6)},
+		},
+		{
+			name:            "aggregates min without initial value",
+			inputPath:       "(3 | 1 | 2).aggregate(iif($total.empty(), $this, iif($this < $total, $this, $total)))",
+			inputCollection: []fhirpath.Resource{},
+			wantCollection:  system.Collection{system.Integer(1)},
+		},
+		{
+			name:            "aggregates over indices",
+			inputPath:       "Patient.name.aggregate($total + $index, 10)",
+			inputCollection: []fhirpath.Resource{patientChu},
+			wantCollection:  system.Collection{system.Integer(11)},
+		},
+		{
+			name:            "index is empty outside of iteration",
+			inputPath:       "$index",
+			inputCollection: []fhirpath.Resource{patientChu},
+			wantCollection:  system.Collection{},
+		},
+	}
+
+	testEvaluate(t, testCases)
+}
+
+func TestEvaluateRepeat(t *testing.T) {
+	response := &qrpb.QuestionnaireResponse{
+		Item: []*qrpb.QuestionnaireResponse_Item{
+			{
+				LinkId: fhir.String("1"),
+				Item: []*qrpb.QuestionnaireResponse_Item{
+					{
+						LinkId: fhir.String("1.1"),
+						Item: []*qrpb.QuestionnaireResponse_Item{
+							{LinkId: fhir.String("1.1.1")},
+						},
+					},
+				},
+			},
+			{LinkId: fhir.String("2")},
+		},
+	}
+	testCases := []evaluateTestCase{
+		{
+			name:            "traverses nested items",
+			inputPath:       "QuestionnaireResponse.repeat(item).linkId",
+			inputCollection: []fhirpath.Resource{response},
+			wantCollection: system.Collection{
+				fhir.String("1"),
+				fhir.String("2"),
+				fhir.String("1.1"),
+				fhir.String("1.1.1"),
+			},
+		},
+		{
+			name:            "terminates when projection yields existing items",
+			inputPath:       "(1 | 2).repeat($this)",
+			inputCollection: []fhirpath.Resource{},
+			wantCollection:  system.Collection{system.Integer(1), system.Integer(2)},
+		},
+		{
+			name:            "raises error when exceeding maximum depth",
+			inputPath:       "1.repeat($this + 1)",
+			inputCollection: []fhirpath.Resource{},
+			wantErr:         impl.ErrMaxDepthExceeded,
+			evaluateOptions: []fhirpath.EvaluateOption{evalopts.WithMaxRepeatDepth(5)},
+		},
+	}
+
+	testEvaluate(t, testCases)
+}
+
+func TestEvaluateSingleUnionSupersetOf(t *testing.T) {
+	testCases := []evaluateTestCase{
+		{
+			name:            "single returns singleton item",
+			inputPath:       "Patient.name[0].given.single()",
+			inputCollection: []fhirpath.Resource{patientChu},
+			wantCollection:  system.Collection{fhir.String("Senpai")},
+		},
+		{
+			name:            "single returns empty for empty input",
+			inputPath:       "Patient.maritalStatus.single()",
+			inputCollection: []fhirpath.Resource{patientChu},
+			wantCollection:  system.Collection{},
+		},
+		{
+			name:            "single raises error for multiple items",
+			inputPath:       "Patient.name.given.single()",
+			inputCollection: []fhirpath.Resource{patientChu},
+			wantErr:         fhirpath.ErrNotSingleton,
+		},
+		{
+			name:            "union removes duplicates",
+			inputPath:       "Patient.name.given.union(%context.contact.name.given)",
+			inputCollection: []fhirpath.Resource{patientChu},
+			wantCollection:  system.Collection{fhir.String("Senpai"), fhir.String("Kang")},
+		},
+		{
+			name:            "union matches union operator",
+			inputPath:       "(1 | 2).union(2 | 3) = (1 | 2 | 3)",
+			inputCollection: []fhirpath.Resource{},
+			wantCollection:  system.Collection{system.Boolean(true)},
+		},
+		{
+			name:            "supersetOf returns true for superset",
+			inputPath:       "Patient.name.given.supersetOf(%context.contact.name.given)",
+			inputCollection: []fhirpath.Resource{patientChu},
+			wantCollection:  system.Collection{system.Boolean(true)},
+		},
+		{
+			name:            "supersetOf returns false for subset",
+			inputPath:       "Patient.contact.name.given.supersetOf(%context.name.given)",
+			inputCollection: []fhirpath.Resource{patientChu},
+			wantCollection:  system.Collection{system.Boolean(false)},
+		},
+	}
+
+	testEvaluate(t, testCases)
+}
+
+func TestEvaluateTrace_SendsToSink(t *testing.T) {
+	var gotNames []string
+	var gotTraced []system.Collection
+	sink := func(name string, c system.Collection) {
+		gotNames = append(gotNames, name)
+		gotTraced = append(gotTraced, c)
+	}
+	expression := fhirpath.MustCompile("Patient.name.trace('names', given).family")
+
+	got, err := expression.Evaluate([]fhirpath.Resource{patientChu}, evalopts.WithTraceSink(sink))
+	if err != nil {
+		t.Fatalf("Evaluate() returned unexpected error: %v", err)
+	}
+
+	wantCollection := system.Collection{fhir.String("Chu"), fhir.String("Chu")}
+	if diff := cmp.Diff(wantCollection, got, protocmp.Transform()); diff != "" {
+		t.Errorf("Evaluate() returned unexpected diff (-want, +got)\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"names"}, gotNames); diff != "" {
+		t.Errorf("trace sink received unexpected names (-want, +got)\n%s", diff)
+	}
+	wantTraced := []system.Collection{{fhir.String("Senpai"), fhir.String("Kang")}}
+	if diff := cmp.Diff(wantTraced, gotTraced, protocmp.Transform()); diff != "" {
+		t.Errorf("trace sink received unexpected collections (-want, +got)\n%s", diff)
+	}
+}
+
+func TestEvaluateTrace_EvaluatesProjectionForEachItem(t *testing.T) {
+	var gotTraced []system.Collection
+	sink := func(_ string, c system.Collection) {
+		gotTraced = append(gotTraced, c)
+	}
+	expression := fhirpath.MustCompile("(1 | 2 | 3).trace('next', $this + 1).trace('first', first())")
+
+	if _, err := expression.Evaluate([]fhirpath.Resource{}, evalopts.WithTraceSink(sink)); err != nil {
+		t.Fatalf("Evaluate() returned unexpected error: %v", err)
+	}
+
+	wantTraced := []system.Collection{
+		{system.Integer(2), system.Integer(3), system.Integer(4)},
+		{system.Integer(1), system.Integer(2), system.Integer(3)},
+	}
+	if diff := cmp.Diff(wantTraced, gotTraced); diff != "" {
+		t.Errorf("trace sink received unexpected collections (-want, +got)\n%s", diff)
+	}
+}
+
+func TestEvaluateTrace_WithoutSink_ReturnsInput(t *testing.T) {
+	testCases := []evaluateTestCase{
+		{
+			name:            "returns input unchanged",
+			inputPath:       "Patient.name.trace('names').given",
+			inputCollection: []fhirpath.Resource{patientChu},
+			wantCollection:  system.Collection{fhir.String("Senpai"), fhir.String("Kang")},
+		},
+	}
+
+	testEvaluate(t, testCases)
+}
+
+func TestEvaluateDefineVariable(t *testing.T) {
+	testCases := []evaluateTestCase{
+		{
+			name:            "returns input unchanged",
+			inputPath:       "Patient.name.defineVariable('n').family",
+			inputCollection: []fhirpath.Resource{patientChu},
+			wantCollection:  system.Collection{fhir.String("Chu"), fhir.String("Chu")},
+		},
+		{
+			name:            "defines variable with input value",
+			inputPath:       "Patient.name.given.defineVariable('g').select(%g)",
+			inputCollection: []fhirpath.Resource{patientChu},
+			wantCollection: system.Collection{
+				fhir.String("Senpai"), fhir.String("Kang"),
+				fhir.String("Senpai"), fhir.String("Kang"),
+			},
+		},
+		{
+			name:            "defines variable with expression value",
+			inputPath:       "Patient.defineVariable('official', name.where(use = 'official')).select(contact.name.given = %official.given)",
+			inputCollection: []fhirpath.Resource{patientChu},
+			wantCollection:  system.Collection{system.Boolean(false)},
+		},
+		{
+			name:            "variable is visible in nested expressions",
+			inputPath:       "Patient.defineVariable('g', contact.name.given).name.where(given = %g).use",
+			inputCollection: []fhirpath.Resource{patientChu},
+			wantCollection:  system.Collection{&dtpb.HumanName_UseCode{Value: cpb.NameUseCode_NICKNAME}},
+		},
+		{
+			name:            "variables defined in nested scopes",
+			inputPath:       "Patient.defineVariable('outer', id).name.select(defineVariable('inner', family).select(%inner & %outer))",
+			inputCollection: []fhirpath.Resource{patientChu},
+			wantCollection:  system.Collection{system.String("Chu123"), system.String("Chu123")},
+		},
+		{
+			name:            "same name defined in sibling scopes",
+			inputPath:       "1.defineVariable('n', 10).select(%n) | 2.defineVariable('n', 20).select(%n)",
+			inputCollection: []fhirpath.Resource{},
+			wantCollection:  system.Collection{system.Integer(10), system.Integer(20)},
+		},
+		{
+			name:            "variable is redefined on each iteration",
+			inputPath:       "(1 | 2 | 3).select(defineVariable('n', $this * 2).select(%n))",
+			inputCollection: []fhirpath.Resource{},
+			wantCollection:  system.Collection{system.Integer(2), system.Integer(4), system.Integer(6)},
+		},
+	}
+
+	testEvaluate(t, testCases)
+}
+
+func TestEvaluateDefineVariable_OutOfScope_ReturnsError(t *testing.T) {
+	testCases := []evaluateTestCase{
+		{
+			name:            "variable is not visible outside of function argument",
+			inputPath:       "Patient.select(defineVariable('n', name)).select(%n)",
+			inputCollection: []fhirpath.Resource{patientChu},
+			wantErr:         fhirpath.ErrConstantNotFound,
+		},
+		{
+			name:            "variable is not visible in sibling operand",
+			inputPath:       "Patient.defineVariable('n', name).name = %n",
+			inputCollection: []fhirpath.Resource{},
+			wantErr:         fhirpath.ErrConstantNotFound,
+		},
+	}
+
+	testEvaluate(t, testCases)
+}
+
+func TestEvaluateEnvironmentVariables(t *testing.T) {
+	testCases := []evaluateTestCase{
+		{
+			name:            "%sct returns SNOMED CT URL",
+			inputPath:       "%sct",
+			inputCollection: []fhirpath.Resource{},
+			wantCollection:  system.Collection{system.String("http://snomed.info/sct")},
+		},
+		{
+			name:            "%loinc returns LOINC URL",
+			inputPath:       "%loinc",
+			inputCollection: []fhirpath.Resource{},
+			wantCollection:  system.Collection{system.String("http://loinc.org")},
+		},
+		{
+			name:            "%vs-[name] returns ValueSet URL",
+			inputPath:       "%`vs-administrative-gender`",
+			inputCollection: []fhirpath.Resource{},
+			wantCollection:  system.Collection{system.String("http://hl7.org/fhir/ValueSet/administrative-gender")},
+		},
+		{
+			name:            "%ext-[name] returns extension URL",
+			inputPath:       "%'ext-patient-birthPlace'",
+			inputCollection: []fhirpath.Resource{},
+			wantCollection:  system.Collection{system.String("http://hl7.org/fhir/StructureDefinition/patient-birthPlace")},
+		},
+		{
+			name:            "%resource returns input resource",
+			inputPath:       "%resource.id",
+			inputCollection: []fhirpath.Resource{patientChu},
+			wantCollection:  system.Collection{fhir.ID("123")},
+		},
+		{
+			name:            "%resource returns resource containing element",
+			inputPath:       "Patient.name.given.select(%resource.id)",
+			inputCollection: []fhirpath.Resource{patientChu},
+			wantCollection:  system.Collection{fhir.ID("123"), fhir.ID("123")},
+		},
+		{
+			name:            "%rootResource returns input resource",
+			inputPath:       "Patient.name.select(%rootResource.id)",
+			inputCollection: []fhirpath.Resource{patientChu},
+			wantCollection:  system.Collection{fhir.ID("123"), fhir.ID("123")},
+		},
+	}
+
+	testEvaluate(t, testCases)
+}
+
+func TestEvaluateEnvironmentVariables_NestedResources(t *testing.T) {
+	practitioner := &prpb.Practitioner{
+		Id:   fhir.ID("practitioner"),
+		Name: []*dtpb.HumanName{{Family: fhir.String("Kim")}},
+	}
+	contained, err := anypb.New(containedresource.Wrap(practitioner))
+	if err != nil {
+		t.Fatalf("wrapping contained resource: %v", err)
+	}
+	patient := &ppb.Patient{
+		Id:        fhir.ID("patient"),
+		Name:      []*dtpb.HumanName{{Family: fhir.String("Chu"), Given: []*dtpb.String{fhir.String("Senpai")}}},
+		Contained: []*anypb.Any{contained},
+	}
+	bundle := &bcrpb.Bundle{
+		Id: fhir.ID("bundle"),
+		Entry: []*bcrpb.Bundle_Entry{
+			{Resource: containedresource.Wrap(patient)},
+			{Resource: containedresource.Wrap(patientVoldemort)},
+		},
+	}
+	testCases := []evaluateTestCase{
+		{
+			name:            "%resource of contained resource is its container",
+			inputPath:       "Patient.contained.select(%resource.id)",
+			inputCollection: []fhirpath.Resource{patient},
+			wantCollection:  system.Collection{fhir.ID("patient")},
+		},
+		{
+			name:            "%resource inside contained resource is the contained resource",
+			inputPath:       "Patient.contained.name.select(%resource.id)",
+			inputCollection: []fhirpath.Resource{patient},
+			wantCollection:  system.Collection{fhir.ID("practitioner")},
+		},
+		{
+			name:            "%rootResource inside contained resource is the container",
+			inputPath:       "Patient.contained.name.select(%rootResource.id)",
+			inputCollection: []fhirpath.Resource{patient},
+			wantCollection:  system.Collection{fhir.ID("patient")},
+		},
+		{
+			name:            "%resource inside bundle entry is the entry resource",
+			inputPath:       "Bundle.entry.resource.name.family.select(%resource.id)",
+			inputCollection: []fhirpath.Resource{bundle},
+			wantCollection:  system.Collection{fhir.ID("patient"), patientVoldemort.GetId()},
+		},
+		{
+			name:            "%rootResource inside bundle entry is the bundle",
+			inputPath:       "Bundle.entry.resource.name.family.select(%rootResource.id)",
+			inputCollection: []fhirpath.Resource{bundle},
+			wantCollection:  system.Collection{fhir.ID("bundle"), fhir.ID("bundle")},
+		},
+		{
+			name:            "%resource of multiple elements returns each distinct resource",
+			inputPath:       "Bundle.entry.resource.name.given.iif(true, %resource.id)",
+			inputCollection: []fhirpath.Resource{bundle},
+			wantCollection:  system.Collection{fhir.ID("patient"), patientVoldemort.GetId()},
+		},
+		{
+			name:            "delimited %resource inside contained resource is the contained resource",
+			inputPath:       "Patient.contained.name.select(%`resource`.id)",
+			inputCollection: []fhirpath.Resource{patient},
+			wantCollection:  system.Collection{fhir.ID("practitioner")},
+		},
+		{
+			name:            "string %rootResource inside bundle entry is the bundle",
+			inputPath:       "Bundle.entry.resource.id.select(%'rootResource'.id)",
+			inputCollection: []fhirpath.Resource{bundle},
+			wantCollection:  system.Collection{fhir.ID("bundle"), fhir.ID("bundle")},
+		},
+	}
+
+	testEvaluate(t, testCases)
+}
+
+func TestEvaluateEnvironmentVariables_Redefinition_ReturnsError(t *testing.T) {
+	testCases := []struct {
+		name      string
+		inputPath string
+	}{
+		{
+			name:      "defining %resource",
+			inputPath: "defineVariable('resource')",
+		},
+		{
+			name:      "defining %vs-[name]",
+			inputPath: "defineVariable('vs-name')",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := fhirpath.Compile(tc.inputPath); err == nil {
+				t.Errorf("Compiling \"%s\" doesn't raise error when expected to", tc.inputPath)
+			}
+		})
+	}
+}
+
+func TestEvaluateStringFunctions_Experimental(t *testing.T) {
+	experimental := []fhirpath.CompileOption{compopts.WithExperimentalFuncs()}
+	testCases := []evaluateTestCase{
+		{
+			name:           "trim removes surrounding whitespace",
+			inputPath:      "'  Lee Jieun  '.trim()",
+			wantCollection: system.Collection{system.String("Lee Jieun")},
+			compileOptions: experimental,
+		},
+		{
+			name:           "encode and decode round trip",
+			inputPath:      "'Senpai'.encode('base64').decode('base64')",
+			wantCollection: system.Collection{system.String("Senpai")},
+			compileOptions: experimental,
+		},
+		{
+			name:            "encodes field value as hex",
+			inputPath:       "Patient.name[0].given.encode('hex')",
+			inputCollection: []fhirpath.Resource{patientChu},
+			wantCollection:  system.Collection{system.String("53656e706169")},
+			compileOptions:  experimental,
+		},
+		{
+			name:           "escape and unescape html",
+			inputPath:      "'<div>'.escape('html') = '&lt;div&gt;' and '&lt;div&gt;'.unescape('html') = '<div>'",
+			wantCollection: system.Collection{system.Boolean(true)},
+			compileOptions: experimental,
+		},
+		{
+			name:           "lastIndexOf finds last occurrence",
+			inputPath:      "'abcabc'.lastIndexOf('a')",
+			wantCollection: system.Collection{system.Integer(3)},
+			compileOptions: experimental,
+		},
+		{
+			name:            "matchesFull requires the whole string to match",
+			inputPath:       "Patient.name[0].family.matchesFull('C.') | Patient.name[0].family.matchesFull('C.+')",
+			inputCollection: []fhirpath.Resource{patientChu},
+			wantCollection:  system.Collection{system.Boolean(false), system.Boolean(true)},
+			compileOptions:  experimental,
+		},
+		{
+			name:           "decode raises error for unsupported format",
+			inputPath:      "'abc'.decode('rot13')",
+			wantErr:        impl.ErrInvalidFormat,
+			compileOptions: experimental,
+		},
+	}
+
+	testEvaluate(t, testCases)
+}
+
+func TestEvaluatePrecisionAndBoundaries(t *testing.T) {
+	experimental := []fhirpath.CompileOption{compopts.WithExperimentalFuncs()}
+	testCases := []evaluateTestCase{
+		{
+			name:           "precision of decimal",
+			inputPath:      "1.58700.precision()",
+			wantCollection: system.Collection{system.Integer(5)},
+			compileOptions: experimental,
+		},
+		{
+			name:           "precision of partial date",
+			inputPath:      "@2014-01.precision()",
+			wantCollection: system.Collection{system.Integer(6)},
+			compileOptions: experimental,
+		},
+		{
+			name:           "decimal boundaries",
+			inputPath:      "1.587.lowBoundary() = 1.5865 and 1.587.highBoundary(2) = 1.59",
+			wantCollection: system.Collection{system.Boolean(true)},
+			compileOptions: experimental,
+		},
+		{
+			name:           "partial date boundaries",
+			inputPath:      "@2020-03.lowBoundary() = @2020-03-01 and @2020-03.highBoundary() = @2020-03-31",
+			wantCollection: system.Collection{system.Boolean(true)},
+			compileOptions: experimental,
+		},
+		{
+			name:           "partial date comparison with boundaries",
+			inputPath:      "@2020-03.lowBoundary() >= @2020-03-15 or @2020-03.highBoundary() >= @2020-03-15",
+			wantCollection: system.Collection{system.Boolean(true)},
+			compileOptions: experimental,
+		},
+		{
+			name:           "datetime boundaries",
+			inputPath:      "@2014-01-01T08.highBoundary()",
+			wantCollection: system.Collection{system.MustParseDateTime("2014-01-01T08:59:59.999-12:00")},
+			compileOptions: experimental,
+		},
+		{
+			name:           "time boundaries",
+			inputPath:      "@T10:30.lowBoundary()",
+			wantCollection: system.Collection{system.MustParseTime("10:30:00.000")},
+			compileOptions: experimental,
+		},
+		{
+			name:           "invalid precision returns empty",
+			inputPath:      "@2020.lowBoundary(5)",
+			wantCollection: system.Collection{},
+			compileOptions: experimental,
+		},
+	}
+
+	testEvaluate(t, testCases)
+}
+
+func TestEvaluateDateTimeComponents(t *testing.T) {
+	experimental := []fhirpath.CompileOption{compopts.WithExperimentalFuncs()}
+	testCases := []evaluateTestCase{
+		{
+			name:            "yearOf birth date",
+			inputPath:       "Patient.birthDate.yearOf()",
+			inputCollection: []fhirpath.Resource{patientChu},
+			wantCollection:  system.Collection{system.Integer(2000)},
+			compileOptions:  experimental,
+		},
+		{
+			name:           "components of datetime",
+			inputPath:      "@2014-01-05T10:30:15.250+02:00.select(monthOf() | dayOf() | hourOf() | minuteOf() | secondOf() | millisecondOf())",
+			wantCollection: system.Collection{system.Integer(1), system.Integer(5), system.Integer(10), system.Integer(30), system.Integer(15), system.Integer(250)},
+			compileOptions: experimental,
+		},
+		{
+			name:           "timezoneOffsetOf datetime",
+			inputPath:      "@2014-01-05T10:30-05:00.timezoneOffsetOf()",
+			wantCollection: system.Collection{system.MustParseDecimal("-5")},
+			compileOptions: experimental,
+		},
+		{
+			name:           "dateOf and timeOf datetime",
+			inputPath:      "@2014-01-05T10:30.dateOf() = @2014-01-05 and @2014-01-05T10:30.timeOf() = @T10:30",
+			wantCollection: system.Collection{system.Boolean(true)},
+			compileOptions: experimental,
+		},
+		{
+			name:            "age in whole years",
+			inputPath:       "Patient.birthDate.duration(today(), 'years')",
+			inputCollection: []fhirpath.Resource{patientChu},
+			wantCollection:  system.Collection{system.MustParseQuantity("17", "years")},
+			compileOptions:  experimental,
+			evaluateOptions: []fhirpath.EvaluateOption{evalopts.OverrideTime(time.Date(2018, 3, 21, 0, 0, 0, 0, time.UTC))},
+		},
+		{
+			name:            "age based eligibility",
+			inputPath:       "Patient.birthDate.duration(today(), 'years') >= 18 years",
+			inputCollection: []fhirpath.Resource{patientChu},
+			wantCollection:  system.Collection{system.Boolean(true)},
+			compileOptions:  experimental,
+			evaluateOptions: []fhirpath.EvaluateOption{evalopts.OverrideTime(time.Date(2018, 3, 22, 0, 0, 0, 0, time.UTC))},
+		},
+		{
+			name:           "difference in days",
+			inputPath:      "@2020-12-31T23:00.difference(@2021-01-01T01:00, 'days')",
+			wantCollection: system.Collection{system.MustParseQuantity("1", "days")},
+			compileOptions: experimental,
+		},
+		{
+			name:           "difference in days in the time zone of the input",
+			inputPath:      "@2020-01-01T01:00:00+05:00.difference(@2020-01-01T23:00:00+05:00, 'days')",
+			wantCollection: system.Collection{system.MustParseQuantity("0", "days")},
+			compileOptions: experimental,
+		},
+		{
+			name:           "duration with insufficient precision returns empty",
+			inputPath:      "@2020.duration(@2021-01-01, 'days')",
+			wantCollection: system.Collection{},
+			compileOptions: experimental,
+		},
+		{
+			name:           "duration with non-calendar precision raises error",
+			inputPath:      "@2020.duration(@2021, 'a')",
+			wantErr:        impl.ErrInvalidInput,
+			compileOptions: experimental,
+		},
+	}
+
+	testEvaluate(t, testCases)
+}
+
+func TestEvaluateQuantities_CommensurableUnits(t *testing.T) {
+	testCases := []evaluateTestCase{
+		{
+			name:           "compares quantities of different units",
+			inputPath:      "1 'kg' > 500 'g'",
+			wantCollection: system.Collection{system.Boolean(true)},
+		},
+		{
+			name:           "equates quantities of different units",
+			inputPath:      "1 'kg' = 1000 'g'",
+			wantCollection: system.Collection{system.Boolean(true)},
+		},
+		{
+			name:           "equates quantities of compound units",
+			inputPath:      "5 'mg/dL' = 50 'mg/L'",
+			wantCollection: system.Collection{system.Boolean(true)},
+		},
+		{
+			name:           "returns empty for incommensurable units",
+			inputPath:      "1 'kg' = 1 'L'",
+			wantCollection: system.Collection{},
+		},
+		{
+			name:           "returns empty comparing incommensurable units",
+			inputPath:      "1 'kg' > 1 'L'",
+			wantCollection: system.Collection{},
+		},
+		{
+			name:           "adds quantities in the unit of the left operand",
+			inputPath:      "(1 'kg' + 500 'g') = 1.5 'kg'",
+			wantCollection: system.Collection{system.Boolean(true)},
+		},
+		{
+			name:           "converts quantity to a unit",
+			inputPath:      "5 'mg/dL'.toQuantity('mg/L')",
+			wantCollection: system.Collection{system.MustParseQuantity("50", "mg/L")},
+		},
+		{
+			name:           "returns empty converting to an incommensurable unit",
+			inputPath:      "5 'mg/dL'.toQuantity('g')",
+			wantCollection: system.Collection{},
+		},
+		{
+			name:           "converts quantity string to a unit",
+			inputPath:      "'1 mg'.toQuantity('g')",
+			wantCollection: system.Collection{system.MustParseQuantity("0.001", "g")},
+		},
+		{
+			name:           "converts number string to a quantity in a unit",
+			inputPath:      "'1'.toQuantity('mg')",
+			wantCollection: system.Collection{system.MustParseQuantity("1", "mg")},
+		},
+	}
+
+	testEvaluate(t, testCases)
+}
+
+func TestEvaluateQuantities_MultiplicationAndDivision(t *testing.T) {
+	weight := &opb.Observation{
+		Value: &opb.Observation_ValueX{
+			Choice: &opb.Observation_ValueX_Quantity{
+				Quantity: fhir.UCUMQuantity(72, "kg"),
+			},
+		},
+	}
+	tablets := &opb.Observation{
+		Value: &opb.Observation_ValueX{
+			Choice: &opb.Observation_ValueX_Quantity{
+				Quantity: fhir.Quantity(2, "tablets"),
+			},
+		},
+	}
+	testCases := []evaluateTestCase{
+		{
+			name:            "multiplies observation value by a number",
+			inputPath:       "Observation.value * 2",
+			inputCollection: []fhirpath.Resource{weight},
+			wantCollection:  system.Collection{system.MustParseQuantity("144", "kg")},
+		},
+		{
+			name:            "computes body mass index",
+			inputPath:       "Observation.value / (1.8 'm' * 1.8 'm')",
+			inputCollection: []fhirpath.Resource{weight},
+			wantCollection:  system.Collection{system.MustParseQuantity("22.2222222222222222", "kg/m2")},
+		},
+		{
+			name:            "accepts a unit from data that isn't a UCUM unit",
+			inputPath:       "Observation.value * 2",
+			inputCollection: []fhirpath.Resource{tablets},
+			wantCollection:  system.Collection{system.MustParseQuantity("4", "tablets")},
+		},
+		{
+			name:            "computes body mass index from commensurable units",
+			inputPath:       "Observation.value / (180 'cm' * 1.8 'm') > 22 'kg/m2'",
+			inputCollection: []fhirpath.Resource{weight},
+			wantCollection:  system.Collection{system.Boolean(true)},
+		},
+		{
+			name:           "divides quantities to a dimensionless quantity",
+			inputPath:      "1 'kg' / 500 'g'",
+			wantCollection: system.Collection{system.MustParseQuantity("2", "1")},
+		},
+		{
+			name:           "returns empty on division by zero quantity",
+			inputPath:      "1 'kg' / 0 'L'",
+			wantCollection: system.Collection{},
+		},
+		{
+			name:           "returns empty on multiplication of special units",
+			inputPath:      "1 'Cel' * 2 'Cel'",
+			wantCollection: system.Collection{},
+		},
+		{
+			name:           "returns empty on division of special units",
+			inputPath:      "10 'Cel' / 2",
+			wantCollection: system.Collection{},
+		},
+	}
+
+	testEvaluate(t, testCases)
+}
+
+func TestEvaluateQuantities_CalendarAndDefiniteDurations(t *testing.T) {
+	testCases := []evaluateTestCase{
+		{
+			name:           "calendar and UCUM years are not comparable",
+			inputPath:      "1 year = 1 'a'",
+			wantCollection: system.Collection{},
+		},
+		{
+			name:           "calendar and UCUM years are equivalent",
+			inputPath:      "1 year ~ 1 'a'",
+			wantCollection: system.Collection{system.Boolean(true)},
+		},
+		{
+			name:           "calendar and UCUM seconds are equal",
+			inputPath:      "1 second = 1 's'",
+			wantCollection: system.Collection{system.Boolean(true)},
+		},
+		{
+			name:           "singular and plural calendar keywords are equal",
+			inputPath:      "2 year = 2 years",
+			wantCollection: system.Collection{system.Boolean(true)},
+		},
+		{
+			name:           "adds calendar month",
+			inputPath:      "@2021-01-31 + 1 month",
+			wantCollection: system.Collection{system.MustParseDate("2021-02-28")},
+		},
+		{
+			name:           "adds UCUM month as a definite duration",
+			inputPath:      "@2021-01-31 + 1 'mo'",
+			wantCollection: system.Collection{system.MustParseDate("2021-03-02")},
+		},
+		{
+			name:           "adds UCUM year as a definite duration",
+			inputPath:      "@2021-01-01T00:00:00Z + 1 'a'",
+			wantCollection: system.Collection{system.MustParseDateTime("2022-01-01T06:00:00Z")},
+		},
+		{
+			name:           "adds UCUM hours to time",
+			inputPath:      "@T08:00 + 2 'h'",
+			wantCollection: system.Collection{system.MustParseTime("10:00")},
+		},
+		{
+			name:           "converts calendar duration to UCUM unit",
+			inputPath:      "2 weeks.toQuantity('d')",
+			wantCollection: system.Collection{system.MustParseQuantity("14", "d")},
+		},
+	}
+
+	testEvaluate(t, testCases)
+}
+
+func TestEvaluateLong(t *testing.T) {
+	experimental := []fhirpath.CompileOption{compopts.WithExperimentalFuncs()}
+	testCases := []evaluateTestCase{
+		{
+			name:           "parses long literal",
+			inputPath:      "1700000000000L",
+			wantCollection: system.Collection{system.Long(1700000000000)},
+		},
+		{
+			name:           "adds longs beyond the integer range",
+			inputPath:      "2147483647L + 1L",
+			wantCollection: system.Collection{system.Long(2147483648)},
+		},
+		{
+			name:           "promotes integer to long",
+			inputPath:      "(1L + 2) is Long",
+			wantCollection: system.Collection{system.Boolean(true)},
+		},
+		{
+			name:           "compares long to integer",
+			inputPath:      "1L = 1",
+			wantCollection: system.Collection{system.Boolean(true)},
+		},
+		{
+			name:           "promotes long to decimal",
+			inputPath:      "3L + 0.5",
+			wantCollection: system.Collection{system.MustParseDecimal("3.5")},
+		},
+		{
+			name:           "negates long literal",
+			inputPath:      "-3L < 0",
+			wantCollection: system.Collection{system.Boolean(true)},
+		},
+		{
+			name:           "returns empty on long overflow",
+			inputPath:      "9223372036854775807L + 1L",
+			wantCollection: system.Collection{},
+		},
+		{
+			name:           "returns empty on long division by zero",
+			inputPath:      "5L / 0L",
+			wantCollection: system.Collection{},
+		},
+		{
+			name:           "returns empty on long floor division by zero",
+			inputPath:      "5L div 0L",
+			wantCollection: system.Collection{},
+		},
+		{
+			name:           "returns empty on long modulus by zero",
+			inputPath:      "5L mod 0L",
+			wantCollection: system.Collection{},
+		},
+		{
+			name:           "returns empty on optimized long division by zero",
+			inputPath:      "5L div 0L",
+			wantCollection: system.Collection{},
+			compileOptions: []fhirpath.CompileOption{compopts.Optimize()},
+		},
+		{
+			name:           "takes the absolute value of a long",
+			inputPath:      "(-5L).abs()",
+			wantCollection: system.Collection{system.Long(5)},
+		},
+		{
+			name:           "indexes a collection with a long",
+			inputPath:      "(1 | 2 | 3)[1L]",
+			wantCollection: system.Collection{system.Integer(2)},
+		},
+		{
+			name:           "converts string to long",
+			inputPath:      "'4294967296'.toLong()",
+			wantCollection: system.Collection{system.Long(4294967296)},
+			compileOptions: experimental,
+		},
+		{
+			name:           "checks conversion to long",
+			inputPath:      "'4294967296'.convertsToLong() and 4294967296L.convertsToInteger().not()",
+			wantCollection: system.Collection{system.Boolean(true)},
+			compileOptions: experimental,
+		},
+	}
+
+	testEvaluate(t, testCases)
+}
+
+// namedPatientValidator is a profile.Validator for a single profile, which
+// requires patients to have a name.
+type namedPatientValidator struct{}
+
+func (namedPatientValidator) ConformsTo(ctx context.Context, input fhir.Base, url string) (bool, error) {
+	if url != "http://example.com/StructureDefinition/named-patient" {
+		return false, profile.ErrUnknownProfile
+	}
+	patient, ok := input.(*ppb.Patient)
+	return ok && len(patient.GetName()) > 0, nil
+}
+
+func TestEvaluateR4Functions(t *testing.T) {
+	r4 := []fhirpath.CompileOption{compopts.WithR4Funcs()}
+	patient := &ppb.Patient{
+		Text: &dtpb.Narrative{
+			Status: &dtpb.Narrative_StatusCode{Value: cpb.NarrativeStatusCode_GENERATED},
+			Div:    &dtpb.Xhtml{Value: `<div xmlns="http://www.w3.org/1999/xhtml"><p>Jane Doe</p></div>`},
+		},
+		Name: []*dtpb.HumanName{
+			{Family: fhir.String("Doe")},
+		},
+		BirthDate: &dtpb.Date{
+			Extension: []*dtpb.Extension{
+				extension.New("https://g.co/fhir/StructureDefinition/primitiveHasNoValue", fhir.Boolean(true)),
+				extension.New("http://hl7.org/fhir/StructureDefinition/data-absent-reason", fhir.Code("masked")),
+			},
+		},
+	}
+	unsafePatient := &ppb.Patient{
+		Text: &dtpb.Narrative{
+			Div: &dtpb.Xhtml{Value: `<div xmlns="http://www.w3.org/1999/xhtml"><p onclick="steal()">Jane</p></div>`},
+		},
+	}
+	testCases := []evaluateTestCase{
+		{
+			name:            "txt-1 holds for basic formatting",
+			inputPath:       "Patient.text.`div`.htmlChecks()",
+			inputCollection: []fhirpath.Resource{patient},
+			wantCollection:  system.Collection{system.Boolean(true)},
+			compileOptions:  r4,
+		},
+		{
+			name:            "txt-1 fails for event handlers",
+			inputPath:       "Patient.text.`div`.htmlChecks()",
+			inputCollection: []fhirpath.Resource{unsafePatient},
+			wantCollection:  system.Collection{system.Boolean(false)},
+			compileOptions:  r4,
+		},
+		{
+			name:            "ele-1 holds for element with children",
+			inputPath:       "Patient.name.all(hasValue() or (children().count() > id.count()))",
+			inputCollection: []fhirpath.Resource{patient},
+			wantCollection:  system.Collection{system.Boolean(true)},
+			compileOptions:  r4,
+		},
+		{
+			name:            "ele-1 holds for primitive with only extensions",
+			inputPath:       "Patient.birthDate.all(hasValue() or (children().count() > id.count()))",
+			inputCollection: []fhirpath.Resource{patient},
+			wantCollection:  system.Collection{system.Boolean(true)},
+			compileOptions:  r4,
+		},
+		{
+			name:            "primitive with only extensions has no value",
+			inputPath:       "Patient.birthDate.hasValue()",
+			inputCollection: []fhirpath.Resource{patient},
+			wantCollection:  system.Collection{system.Boolean(false)},
+			compileOptions:  r4,
+		},
+		{
+			name:            "gets value of primitive",
+			inputPath:       "Patient.name.family.getValue()",
+			inputCollection: []fhirpath.Resource{patient},
+			wantCollection:  system.Collection{system.String("Doe")},
+			compileOptions:  r4,
+		},
+		{
+			name:            "conforms to base resource definition",
+			inputPath:       "Patient.conformsTo('http://hl7.org/fhir/StructureDefinition/Patient')",
+			inputCollection: []fhirpath.Resource{patient},
+			wantCollection:  system.Collection{system.Boolean(true)},
+			compileOptions:  r4,
+		},
+		{
+			name:            "conforms to profile with validator",
+			inputPath:       "Patient.conformsTo('http://example.com/StructureDefinition/named-patient')",
+			inputCollection: []fhirpath.Resource{patient},
+			wantCollection:  system.Collection{system.Boolean(true)},
+			compileOptions:  r4,
+			evaluateOptions: []fhirpath.EvaluateOption{evalopts.WithProfileValidator(namedPatientValidator{})},
+		},
+		{
+			name:           "compares commensurable quantities",
+			inputPath:      "1 'kg'.comparable(1 '[lb_av]') and 1 'kg'.comparable(1 'm').not()",
+			wantCollection: system.Collection{system.Boolean(true)},
+			compileOptions: r4,
+		},
+	}
+
+	testEvaluate(t, testCases)
+}
+
+func TestEvaluateType(t *testing.T) {
+	observation := &opb.Observation{
+		Status: &opb.Observation_StatusCode{Value: cpb.ObservationStatusCode_FINAL},
+		Value: &opb.Observation_ValueX{
+			Choice: &opb.Observation_ValueX_Quantity{
+				Quantity: &dtpb.Quantity{Value: fhir.Decimal(1.5), Unit: fhir.String("mg")},
+			},
+		},
+	}
+	testCases := []evaluateTestCase{
+		{
+			name:            "returns name of choice type",
+			inputPath:       "Observation.value.type().name",
+			inputCollection: []fhirpath.Resource{observation},
+			wantCollection:  system.Collection{system.String("Quantity")},
+		},
+		{
+			name:            "returns namespace and base type of FHIR type",
+			inputPath:       "Observation.value.type().namespace + '|' + Observation.value.type().baseType",
+			inputCollection: []fhirpath.Resource{observation},
+			wantCollection:  system.Collection{system.String("FHIR|FHIR.Element")},
+		},
+		{
+			name:            "returns type of FHIR primitive",
+			inputPath:       "Observation.status.type().name",
+			inputCollection: []fhirpath.Resource{observation},
+			wantCollection:  system.Collection{system.String("code")},
+		},
+		{
+			name:            "returns base type of resource",
+			inputPath:       "Observation.type().baseType",
+			inputCollection: []fhirpath.Resource{observation},
+			wantCollection:  system.Collection{system.String("FHIR.DomainResource")},
+		},
+		{
+			name:            "navigates class elements",
+			inputPath:       "Observation.value.type().element.where(name = 'unit').type",
+			inputCollection: []fhirpath.Resource{observation},
+			wantCollection:  system.Collection{system.String("FHIR.string")},
+		},
+		{
+			name:           "returns type of system value",
+			inputPath:      "1.type().namespace + '.' + 1.type().name",
+			wantCollection: system.Collection{system.String("System.Integer")},
+		},
+		{
+			name:           "compares type information",
+			inputPath:      "1.type() = 2.type() and 1.type() != 'a'.type()",
+			wantCollection: system.Collection{system.Boolean(true)},
+		},
+		{
+			name:            "compares class information for equivalence",
+			inputPath:       "Observation.type() ~ Observation.type() and (Observation.type() in Observation.value.type()).not()",
+			inputCollection: []fhirpath.Resource{observation},
+			wantCollection:  system.Collection{system.Boolean(true)},
+		},
+		{
+			name:           "compares type information for equivalence",
+			inputPath:      "1.type() ~ 2.type() and 1.type() !~ 'a'.type()",
+			wantCollection: system.Collection{system.Boolean(true)},
+		},
+		{
+			name:           "checks membership of type information",
+			inputPath:      "1.type() in ('a'.type() | 2.type()) and (('a'.type() | 2.type()) contains true.type()).not()",
+			wantCollection: system.Collection{system.Boolean(true)},
+		},
+
+		{
+			name:            "branches on type of choice element",
+			inputPath:       "Observation.select(iif(value.type().name = 'Quantity', value.unit, 'n/a'))",
+			inputCollection: []fhirpath.Resource{observation},
+			wantCollection:  system.Collection{fhir.String("mg")},
+		},
+		{
+			name:            "raises error for unknown property",
+			inputPath:       "Observation.value.type().unknown",
+			inputCollection: []fhirpath.Resource{observation},
+			wantErr:         fhirpath.ErrInvalidField,
+		},
+	}
+
+	testEvaluate(t, testCases)
+}
+
+func TestEvaluateTypeHierarchy(t *testing.T) {
+	patient := &ppb.Patient{
+		Id:      fhir.ID("123"),
+		Contact: []*ppb.Patient_Contact{{Name: &dtpb.HumanName{Family: fhir.String("Doe")}}},
+	}
+	bundle := &bcrpb.Bundle{
+		Entry: []*bcrpb.Bundle_Entry{
+			{Resource: &bcrpb.ContainedResource{OneofResource: &bcrpb.ContainedResource_Patient{Patient: patient}}},
+			{Resource: &bcrpb.ContainedResource{OneofResource: &bcrpb.ContainedResource_Observation{Observation: &opb.Observation{}}}},
+			{Resource: &bcrpb.ContainedResource{OneofResource: &bcrpb.ContainedResource_Bundle{Bundle: &bcrpb.Bundle{}}}},
+		},
+	}
+	age := &dtpb.Age{Value: fhir.Decimal(42), Unit: fhir.String("a")}
+	testCases := []evaluateTestCase{
+		{
+			name:            "filters bundle resources by DomainResource",
+			inputPath:       "Bundle.entry.resource.ofType(DomainResource).count()",
+			inputCollection: []fhirpath.Resource{bundle},
+			wantCollection:  system.Collection{system.Integer(2)},
+		},
+		{
+			name:            "filters bundle resources by Resource",
+			inputPath:       "Bundle.entry.resource.ofType(FHIR.Resource).count()",
+			inputCollection: []fhirpath.Resource{bundle},
+			wantCollection:  system.Collection{system.Integer(3)},
+		},
+		{
+			name:            "bundle entry is a backbone element",
+			inputPath:       "Bundle.entry.first() is BackboneElement and Bundle.entry.first() is Element",
+			inputCollection: []fhirpath.Resource{bundle},
+			wantCollection:  system.Collection{system.Boolean(true)},
+		},
+		{
+			name:            "bundle entry is not a resource",
+			inputPath:       "Bundle.entry.first() is Resource",
+			inputCollection: []fhirpath.Resource{bundle},
+			wantCollection:  system.Collection{system.Boolean(false)},
+		},
+		{
+			name:            "filters backbone elements",
+			inputPath:       "Bundle.descendants().ofType(BackboneElement).count()",
+			inputCollection: []fhirpath.Resource{bundle},
+			wantCollection:  system.Collection{system.Integer(4)},
+		},
+		{
+			name:            "id is string",
+			inputPath:       "Patient.id is FHIR.string",
+			inputCollection: []fhirpath.Resource{patient},
+			wantCollection:  system.Collection{system.Boolean(true)},
+		},
+		{
+			name:            "age is quantity",
+			inputPath:       "%age is FHIR.Quantity and (%age as FHIR.Quantity).value = 42",
+			evaluateOptions: []fhirpath.EvaluateOption{evalopts.EnvVariable("age", age)},
+			wantCollection:  system.Collection{system.Boolean(true)},
+		},
+	}
+
+	testEvaluate(t, testCases)
+}
+
+// snomedService is a terminology.Service which knows that type 2 diabetes
+// mellitus is a kind of diabetes mellitus.
+type snomedService struct{}
+
+func (snomedService) ValueSetValidateCode(ctx context.Context, opts *terminology.ValueSetValidateCodeOptions) (*pgp.Parameters, error) {
+	return outcomeParameters("result", &pgp.Parameters_Parameter_ValueX{
+		Choice: &pgp.Parameters_Parameter_ValueX_Boolean{Boolean: fhir.Boolean(opts.Code == "44054006")},
+	}), nil
+}
+
+func (snomedService) Subsumes(ctx context.Context, opts *terminology.SubsumesOptions) (*pgp.Parameters, error) {
+	outcome := "not-subsumed"
+	switch {
+	case opts.CodeA == opts.CodeB:
+		outcome = "equivalent"
+	case opts.CodeA == "73211009" && opts.CodeB == "44054006":
+		outcome = "subsumes"
+	case opts.CodeA == "44054006" && opts.CodeB == "73211009":
+		outcome = "subsumed-by"
+	}
+	return outcomeParameters("outcome", &pgp.Parameters_Parameter_ValueX{
+		Choice: &pgp.Parameters_Parameter_ValueX_Code{Code: fhir.Code(outcome)},
+	}), nil
+}
+
+func outcomeParameters(name string, value *pgp.Parameters_Parameter_ValueX) *pgp.Parameters {
+	return &pgp.Parameters{
+		Parameter: []*pgp.Parameters_Parameter{{Name: fhir.String(name), Value: value}},
+	}
+}
+
+func TestEvaluateTerminologies(t *testing.T) {
+	experimental := []fhirpath.CompileOption{compopts.WithExperimentalFuncs()}
+	diabetes := &dtpb.Coding{System: fhir.URI("http://snomed.info/sct"), Code: fhir.Code("73211009")}
+	observation := &opb.Observation{
+		Code: &dtpb.CodeableConcept{
+			Coding: []*dtpb.Coding{
+				{System: fhir.URI("http://snomed.info/sct"), Code: fhir.Code("44054006")},
+			},
+		},
+	}
+	evaluateOptions := []fhirpath.EvaluateOption{
+		evalopts.WithTerminologyService(snomedService{}),
+		evalopts.EnvVariable("diabetes", diabetes),
+	}
+	testCases := []evaluateTestCase{
+		{
+			name:            "code is subsumed by its ancestor",
+			inputPath:       "Observation.code.subsumedBy(%diabetes)",
+			inputCollection: []fhirpath.Resource{observation},
+			wantCollection:  system.Collection{system.Boolean(true)},
+			compileOptions:  experimental,
+			evaluateOptions: evaluateOptions,
+		},
+		{
+			name:            "code does not subsume its ancestor",
+			inputPath:       "Observation.code.subsumes(%diabetes)",
+			inputCollection: []fhirpath.Resource{observation},
+			wantCollection:  system.Collection{system.Boolean(false)},
+			compileOptions:  experimental,
+			evaluateOptions: evaluateOptions,
+		},
+		{
+			name:            "terminologies returns subsumption outcome",
+			inputPath:       "%terminologies.subsumes(%sct, %diabetes, %resource.code.coding.first())",
+			inputCollection: []fhirpath.Resource{observation},
+			wantCollection:  system.Collection{system.String("subsumes")},
+			compileOptions:  experimental,
+			evaluateOptions: evaluateOptions,
+		},
+		{
+			name:            "terminologies validates code against value set",
+			inputPath:       "%terminologies.validateVS('http://example.com/ValueSet/diabetes', %resource.code).parameter.where(name = 'result').value",
+			inputCollection: []fhirpath.Resource{observation},
+			wantCollection:  system.Collection{fhir.Boolean(true)},
+			compileOptions:  experimental,
+			evaluateOptions: evaluateOptions,
+		},
+		{
+			name:            "memberOf uses local terminology service",
+			inputPath:       "Observation.code.memberOf('diabetes') and Observation.code.subsumedBy(%diabetes)",
+			inputCollection: []fhirpath.Resource{observation},
+			wantCollection:  system.Collection{system.Boolean(true)},
+			compileOptions:  experimental,
+			evaluateOptions: []fhirpath.EvaluateOption{
+				evalopts.WithTerminologyService(terminology.NewLocalService([]fhir.Resource{
+					&cspb.CodeSystem{
+						Url: fhir.URI("http://snomed.info/sct"),
+						Concept: []*cspb.CodeSystem_ConceptDefinition{
+							{
+								Code:    fhir.Code("73211009"),
+								Concept: []*cspb.CodeSystem_ConceptDefinition{{Code: fhir.Code("44054006")}},
+							},
+						},
+					},
+					&vspb.ValueSet{
+						Id: fhir.ID("diabetes"),
+						Compose: &vspb.ValueSet_Compose{
+							Include: []*vspb.ValueSet_Compose_ConceptSet{
+								{
+									System: fhir.URI("http://snomed.info/sct"),
+									Filter: []*vspb.ValueSet_Compose_ConceptSet_Filter{
+										{
+											Property: fhir.Code("concept"),
+											Op:       &vspb.ValueSet_Compose_ConceptSet_Filter_OpCode{Value: cpb.FilterOperatorCode_IS_A},
+											Value:    fhir.String("73211009"),
+										},
+									},
+								},
+							},
+						},
+					},
+				})),
+				evalopts.EnvVariable("diabetes", diabetes),
+			},
+		},
+	}
+
+	testEvaluate(t, testCases)
+}
+
+func TestEvaluateMemberOf(t *testing.T) {
+	experimental := []fhirpath.CompileOption{compopts.WithExperimentalFuncs()}
+	genders := &vspb.ValueSet{
+		Url:     fhir.URI("http://hl7.org/fhir/ValueSet/administrative-gender"),
+		Version: fhir.String("4.0.1"),
+		Expansion: &vspb.ValueSet_Expansion{
+			Contains: []*vspb.ValueSet_Expansion_Contains{
+				{System: fhir.URI("http://hl7.org/fhir/administrative-gender"), Code: fhir.Code("female")},
+				{System: fhir.URI("http://hl7.org/fhir/administrative-gender"), Code: fhir.Code("male")},
+			},
+		},
+	}
+	service := evalopts.WithTerminologyService(terminology.NewLocalService([]fhir.Resource{genders}))
+	patient := &ppb.Patient{
+		Gender: &ppb.Patient_GenderCode{Value: cpb.AdministrativeGenderCode_FEMALE},
+	}
+	testCases := []evaluateTestCase{
+		{
+			name:            "code is member of bound value set",
+			inputPath:       "Patient.gender.memberOf('http://hl7.org/fhir/ValueSet/administrative-gender')",
+			inputCollection: []fhirpath.Resource{patient},
+			wantCollection:  system.Collection{system.Boolean(true)},
+			compileOptions:  experimental,
+			evaluateOptions: []fhirpath.EvaluateOption{service},
+		},
+		{
+			name:            "code is member of versioned value set",
+			inputPath:       "Patient.gender.memberOf('http://hl7.org/fhir/ValueSet/administrative-gender|4.0.1')",
+			inputCollection: []fhirpath.Resource{patient},
+			wantCollection:  system.Collection{system.Boolean(true)},
+			compileOptions:  experimental,
+			evaluateOptions: []fhirpath.EvaluateOption{service},
+		},
+		{
+			name:            "unknown value set is false",
+			inputPath:       "Patient.gender.memberOf('http://example.com/ValueSet/unknown')",
+			inputCollection: []fhirpath.Resource{patient},
+			wantCollection:  system.Collection{system.Boolean(false)},
+			compileOptions:  experimental,
+			evaluateOptions: []fhirpath.EvaluateOption{service},
+		},
+		{
+			name:            "unknown value set raises error in strict mode",
+			inputPath:       "Patient.gender.memberOf('http://example.com/ValueSet/unknown')",
+			inputCollection: []fhirpath.Resource{patient},
+			wantErr:         terminology.ErrUnknownValueSet,
+			compileOptions:  experimental,
+			evaluateOptions: []fhirpath.EvaluateOption{service, evalopts.WithStrictTerminology()},
+		},
+	}
+
+	testEvaluate(t, testCases)
+}
+
+func TestCompile_WithInputType_InfersOutputType(t *testing.T) {
+	testCases := []struct {
+		name      string
+		inputPath string
+		inputType string
+		want      fhirpath.TypeInfo
+	}{
+		{
+			name:      "repeated field",
+			inputPath: "Patient.name.given",
+			inputType: "Patient",
+			want:      fhirpath.TypeInfo{Type: "FHIR.string"},
+		},
+		{
+			name:      "singleton field",
+			inputPath: "Patient.birthDate",
+			inputType: "Patient",
+			want:      fhirpath.TypeInfo{Type: "FHIR.date", Singleton: true},
+		},
+		{
+			name:      "choice cast",
+			inputPath: "Observation.value.ofType(Quantity)",
+			inputType: "Observation",
+			want:      fhirpath.TypeInfo{Type: "FHIR.Quantity", Singleton: true},
+		},
+		{
+			name:      "boolean expression",
+			inputPath: "Patient.name.where(use = 'official').exists()",
+			inputType: "Patient",
+			want:      fhirpath.TypeInfo{Type: "System.Boolean", Singleton: true},
+		},
+		{
+			name:      "choice field",
+			inputPath: "Observation.value",
+			inputType: "Observation",
+			want:      fhirpath.TypeInfo{Singleton: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			expression, err := fhirpath.Compile(tc.inputPath, compopts.WithInputType(tc.inputType))
+			if err != nil {
+				t.Fatalf("Compiling %q returned unexpected error: %v", tc.inputPath, err)
+			}
+
+			got, ok := expression.OutputType()
+			if !ok {
+				t.Fatalf("OutputType() of %q returned false, want true", tc.inputPath)
+			}
+			if got != tc.want {
+				t.Errorf("OutputType() of %q = %+v, want %+v", tc.inputPath, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCompile_WithInputType_AcceptsSameCallsAsEvaluation(t *testing.T) {
+	testCases := []struct {
+		inputPath string
+		want      system.Collection
+	}{
+		{"1.5.round()", system.Collection{system.MustParseDecimal("2")}},
+		{"3.14159.round(3)", system.Collection{system.MustParseDecimal("3.142")}},
+		{"8.log(2)", system.Collection{system.MustParseDecimal("3")}},
+		{"2.power(3)", system.Collection{system.Integer(8)}},
+		{"2.5.power(2)", system.Collection{system.MustParseDecimal("6.25")}},
+		{"2L.power(40)", system.Collection{system.Long(1099511627776)}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.inputPath, func(t *testing.T) {
+			for _, opts := range [][]fhirpath.CompileOption{nil, {compopts.WithInputType("Patient")}} {
+				expression, err := fhirpath.Compile(tc.inputPath, opts...)
+				if err != nil {
+					t.Fatalf("Compiling %q returned unexpected error: %v", tc.inputPath, err)
+				}
+
+				got, err := expression.Evaluate([]fhirpath.Resource{})
+				if err != nil {
+					t.Fatalf("Evaluating %q returned unexpected error: %v", tc.inputPath, err)
+				}
+				if diff := cmp.Diff(tc.want, got); diff != "" {
+					t.Errorf("Evaluating %q returned unexpected diff (-want, +got)\n%s", tc.inputPath, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestCompile_WithoutInputType_HasNoOutputType(t *testing.T) {
+	expression := fhirpath.MustCompile("Patient.nmae")
+
+	if _, ok := expression.OutputType(); ok {
+		t.Errorf("OutputType() returned true, want false")
+	}
+}
+
+func TestCompile_WithInputType_ReportsAllErrors(t *testing.T) {
+	_, err := fhirpath.Compile("Patient.nmae | Patient.name.ofType(Quantity)", compopts.WithInputType("Patient"))
+
+	if !errors.Is(err, fhirpath.ErrInvalidField) {
+		t.Errorf("Compile returned error %v, want %v", err, fhirpath.ErrInvalidField)
+	}
+	if !errors.Is(err, fhirpath.ErrImpossibleType) {
+		t.Errorf("Compile returned error %v, want %v", err, fhirpath.ErrImpossibleType)
+	}
+}
+
+func TestCompile_ReturnsLocatedError(t *testing.T) {
+	testCases := []struct {
+		name      string
+		inputPath string
+		want      *fhirpath.CompileError
+	}{
+		{
+			name:      "misspelled function",
+			inputPath: "Patient.name.wehre(use = 'official')",
+			want: &fhirpath.CompileError{
+				Code: fhirpath.CodeUnknownFunction,
+				Span: ast.Span{
+					Start: ast.Position{Offset: 13, Line: 1, Column: 14},
+					End:   ast.Position{Offset: 18, Line: 1, Column: 19},
+				},
+				Token:      "wehre",
+				Suggestion: "where",
+			},
+		},
+		{
+			name:      "wrong arity on second line",
+			inputPath: "Patient.name\n  .given.substring()",
+			want: &fhirpath.CompileError{
+				Code: fhirpath.CodeWrongArity,
+				Span: ast.Span{
+					Start: ast.Position{Offset: 22, Line: 2, Column: 10},
+					End:   ast.Position{Offset: 33, Line: 2, Column: 21},
+				},
+				Token: "substring",
+			},
+		},
+		{
+			name:      "unexpected end of input",
+			inputPath: "Patient.name.",
+			want: &fhirpath.CompileError{
+				Code: fhirpath.CodeSyntax,
+				Span: ast.Span{
+					Start: ast.Position{Offset: 13, Line: 1, Column: 14},
+					End:   ast.Position{Offset: 13, Line: 1, Column: 14},
+				},
+				Token: "<EOF>",
+			},
+		},
+		{
+			name:      "lexer error",
+			inputPath: "Patient^",
+			want: &fhirpath.CompileError{
+				Code: fhirpath.CodeSyntax,
+				Span: ast.Span{
+					Start: ast.Position{Offset: 7, Line: 1, Column: 8},
+					End:   ast.Position{Offset: 8, Line: 1, Column: 9},
+				},
+				Token: "^",
+			},
+		},
+		{
+			name:      "invalid type specifier",
+			inputPath: "Patient.name.ofType(Foo)",
+			want: &fhirpath.CompileError{
+				Code: fhirpath.CodeInvalidType,
+				Span: ast.Span{
+					Start: ast.Position{Offset: 20, Line: 1, Column: 21},
+					End:   ast.Position{Offset: 23, Line: 1, Column: 24},
+				},
+				Token: "Foo",
+			},
+		},
+		{
+			name:      "invalid quantity unit",
+			inputPath: "1 'kg' > 1 'foo'",
+			want: &fhirpath.CompileError{
+				Code: fhirpath.CodeInvalidLiteral,
+				Span: ast.Span{
+					Start: ast.Position{Offset: 9, Line: 1, Column: 10},
+					End:   ast.Position{Offset: 16, Line: 1, Column: 17},
+				},
+				Token: "1",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := fhirpath.Compile(tc.inputPath)
+
+			var got *fhirpath.CompileError
+			if !errors.As(err, &got) {
+				t.Fatalf("Compile(%s) returned error %v, want CompileError", tc.inputPath, err)
+			}
+			if diff := cmp.Diff(tc.want, got, cmpopts.IgnoreFields(fhirpath.CompileError{}, "Err")); diff != "" {
+				t.Errorf("Compile(%s) returned unexpected error (-want, +got):\n%s", tc.inputPath, diff)
+			}
+		})
+	}
+}
+
+func TestCompile_ReportsAllErrors(t *testing.T) {
+	_, err := fhirpath.Compile("Patient.name.given.frist() + name.lenght() + name.ofType(Foo)")
+
+	var got fhirpath.CompileErrors
+	if !errors.As(err, &got) {
+		t.Fatalf("Compile returned error %v, want CompileErrors", err)
+	}
+	want := []string{"frist", "lenght", "Foo"}
+	if diff := cmp.Diff(want, slices.Map(got, func(err *fhirpath.CompileError) string { return err.Token })); diff != "" {
+		t.Errorf("Compile returned unexpected errors (-want, +got):\n%s", diff)
+	}
+}
+
+func TestCompile_WithInputType_SuggestsField(t *testing.T) {
+	_, err := fhirpath.Compile("Patient.nmae.given", compopts.WithInputType("Patient"))
+
+	var got *fhirpath.CompileError
+	if !errors.As(err, &got) {
+		t.Fatalf("Compile returned error %v, want CompileError", err)
+	}
+	if got.Code != fhirpath.CodeUnknownField || got.Suggestion != "name" {
+		t.Errorf("Compile returned error %v, want unknown field with suggestion 'name'", got)
+	}
+	if !errors.Is(err, fhirpath.ErrInvalidField) {
+		t.Errorf("Compile returned error %v, want %v", err, fhirpath.ErrInvalidField)
+	}
+}
+
+func TestParse_DoesNotResolveFunctions(t *testing.T) {
+	node, err := fhirpath.Parse("Patient.name.custom('a')")
+	if err != nil {
+		t.Fatalf("Parse returned unexpected error: %v", err)
+	}
+
+	if got, want := ast.Format(node), "Patient.name.custom('a')"; got != want {
+		t.Errorf("Parse returned tree %s, want %s", got, want)
+	}
+}
+
+func TestParse_ReturnsSyntaxError(t *testing.T) {
+	_, err := fhirpath.Parse("Patient.name.")
+
+	var got *fhirpath.CompileError
+	if !errors.As(err, &got) || got.Code != fhirpath.CodeSyntax {
+		t.Errorf("Parse returned error %v, want syntax error", err)
+	}
+}
+
+func TestCompile_Optimize_EvaluatesToSameResult(t *testing.T) {
+	paths := []string{
+		"@2020-01-01 + 1 year",
+		"'a' & 'b' & name.family.first()",
+		"iif(true, name.given, id)",
+		"iif({}, name.given)",
+		"iif(1 < 2, defineVariable('n', name).select(%n.given), {})",
+		"$this.name.$this.given",
+		"name.where(true).given.where(false)",
+		"'abc'.substring(1).upper() + name.given.first()",
+		"false and name.exists()",
+		"name.exists() or true",
+		"name.given and false",
+		"true or name.given",
+		"false implies name.given",
+		"name.given.single() or true",
+		"(1 > 2) and true",
+		"'2020-01-01T10:00:00'.convertToDateTime()",
+		"(1 | 2).count() = name.count()",
+		"name.given[1 + 1]",
+	}
+
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			want, wantErr := fhirpath.MustCompile(path).Evaluate([]fhirpath.Resource{patientChu})
+			optimized, err := fhirpath.Compile(path, compopts.Optimize())
+			if err != nil {
+				t.Fatalf("Compile(%q, compopts.Optimize()) returned unexpected error: %v", path, err)
+			}
+
+			got, gotErr := optimized.Evaluate([]fhirpath.Resource{patientChu})
+			if fmt.Sprint(gotErr) != fmt.Sprint(wantErr) {
+				t.Errorf("Evaluating optimized %q returned error %v, want %v", path, gotErr, wantErr)
+			}
+			if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+				t.Errorf("Evaluating optimized %q returned unexpected diff (-want, +got)\n%s", path, diff)
+			}
+		})
+	}
+}
+
+func TestCompile_Optimize_ReportsAlwaysEmpty(t *testing.T) {
+	path := "Patient.name.given | name.where(false).given"
+
+	expression, err := fhirpath.Compile(path, compopts.Optimize())
+	if err != nil {
+		t.Fatalf("Compile(%q) returned unexpected error: %v", path, err)
+	}
+
+	warnings := expression.Warnings()
+	if len(warnings) != 1 {
+		t.Fatalf("Warnings() returned %v, want exactly one warning", warnings)
+	}
+	got := warnings[0]
+	if got.Code != fhirpath.CodeAlwaysEmpty || got.Span.Start.Column != 27 || got.Span.End.Column != 39 || got.Token != "where" {
+		t.Errorf("Warnings() returned %v (span %v), want always-empty warning at 'where(false)'", got, got.Span)
+	}
+	if !errors.Is(got, fhirpath.ErrAlwaysEmpty) {
+		t.Errorf("Warnings() returned %v, want %v", got, fhirpath.ErrAlwaysEmpty)
+	}
+	if warnings := fhirpath.MustCompile(path).Warnings(); warnings != nil {
+		t.Errorf("Warnings() without compopts.Optimize returned %v, want none", warnings)
+	}
+}
+
 func TestParenthesizedExpression_MaintainsPrecedence(t *testing.T) {
 	patient := &ppb.Patient{
 		Name: []*dtpb.HumanName{
@@ -1355,10 +3022,10 @@ func TestComparisonExpression_ReturnsBool(t *testing.T) {
 			wantCollection:  system.Collection{system.Boolean(false)},
 		},
 		{
-			name:            "returns empty for quantities of different precision",
+			name:            "compares quantities of commensurable units",
 			inputPath:       "99.9 'cm' < 1 'm'",
 			inputCollection: []fhirpath.Resource{},
-			wantCollection:  system.Collection{},
+			wantCollection:  system.Collection{system.Boolean(true)},
 		},
 		{
 			name:            "compares dates correctly",
@@ -1433,6 +3100,42 @@ func TestArithmetic_ReturnsResult(t *testing.T) {
 			inputCollection: []fhirpath.Resource{},
 			wantCollection:  system.Collection{system.Integer(1)},
 		},
+		{
+			name:            "returns empty on decimal division by zero",
+			inputPath:       "1.0 / 0",
+			inputCollection: []fhirpath.Resource{},
+			wantCollection:  system.Collection{},
+		},
+		{
+			name:            "returns empty on integer division by zero",
+			inputPath:       "5 / 0",
+			inputCollection: []fhirpath.Resource{},
+			wantCollection:  system.Collection{},
+		},
+		{
+			name:            "returns empty on integer floor division by zero",
+			inputPath:       "5 div 0",
+			inputCollection: []fhirpath.Resource{},
+			wantCollection:  system.Collection{},
+		},
+		{
+			name:            "returns empty on decimal floor division by zero",
+			inputPath:       "1.5 div 0.0",
+			inputCollection: []fhirpath.Resource{},
+			wantCollection:  system.Collection{},
+		},
+		{
+			name:            "returns empty on integer modulo by zero",
+			inputPath:       "5 mod 0",
+			inputCollection: []fhirpath.Resource{},
+			wantCollection:  system.Collection{},
+		},
+		{
+			name:            "returns empty on decimal modulo by zero",
+			inputPath:       "1.5 mod 0",
+			inputCollection: []fhirpath.Resource{},
+			wantCollection:  system.Collection{},
+		},
 	}
 
 	testEvaluate(t, testCases)
@@ -1464,10 +3167,45 @@ func TestCompile_ReturnsError(t *testing.T) {
 			name:      "invalid character (lexer error)",
 			inputPath: "Patient^",
 		},
+		{
+			name:      "R4 function without option",
+			inputPath: "Patient.text.`div`.htmlChecks()",
+		},
+		{
+			name:      "long suffix separated from number",
+			inputPath: "1 L",
+		},
+		{
+			name:      "long suffix on decimal",
+			inputPath: "1.5L",
+		},
+		{
+			name:      "invalid quantity unit",
+			inputPath: "1 'foo'",
+		},
+		{
+			name:      "malformed quantity unit",
+			inputPath: "1 '[[[' = 1 '[[['",
+		},
 		{
 			name:      "non-existent function",
 			inputPath: "Patient.notAFunc()",
 		},
+		{
+			name:           "terminology function invoked on resource",
+			inputPath:      "Patient.expand('http://hl7.org/fhir/ValueSet/administrative-gender')",
+			compileOptions: []fhirpath.CompileOption{compopts.WithExperimentalFuncs()},
+		},
+		{
+			name:           "terminology function invoked without receiver",
+			inputPath:      "validateVS('http://example.com/ValueSet/diabetes', code)",
+			compileOptions: []fhirpath.CompileOption{compopts.WithExperimentalFuncs()},
+		},
+		{
+			name:           "terminology function invoked on other variable",
+			inputPath:      "%resource.lookup(%resource.code.coding.first())",
+			compileOptions: []fhirpath.CompileOption{compopts.WithExperimentalFuncs()},
+		},
 		{
 			name:           "expanding function table with bad function",
 			inputPath:      "Patient.badFn()",
@@ -1490,6 +3228,46 @@ func TestCompile_ReturnsError(t *testing.T) {
 			name:      "resolving invalid type specifier",
 			inputPath: "1 is System.Patient",
 		},
+		{
+			name:      "redefining variable in the same scope",
+			inputPath: "Patient.defineVariable('n', name).defineVariable('n', gender)",
+		},
+		{
+			name:      "redefining variable in a nested scope",
+			inputPath: "Patient.defineVariable('n', name).select(defineVariable('n'))",
+		},
+		{
+			name:      "redefining system variable",
+			inputPath: "Patient.defineVariable('context')",
+		},
+		{
+			name:      "defining variable with non-literal name",
+			inputPath: "Patient.defineVariable(id)",
+		},
+		{
+			name:      "defining variable with too many arguments",
+			inputPath: "Patient.defineVariable('n', name, gender)",
+		},
+		{
+			name:           "unknown input type",
+			inputPath:      "Patient.name",
+			compileOptions: []fhirpath.CompileOption{compopts.WithInputType("Patinet")},
+		},
+		{
+			name:           "unknown field of input type",
+			inputPath:      "Patient.nmae.given",
+			compileOptions: []fhirpath.CompileOption{compopts.WithInputType("Patient")},
+		},
+		{
+			name:           "impossible ofType target",
+			inputPath:      "Observation.value.ofType(HumanName)",
+			compileOptions: []fhirpath.CompileOption{compopts.WithInputType("Observation")},
+		},
+		{
+			name:           "argument of wrong type",
+			inputPath:      "Patient.name.family.substring('1')",
+			compileOptions: []fhirpath.CompileOption{compopts.WithInputType("Patient")},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -1551,6 +3329,22 @@ func TestEvaluate_ReturnsError(t *testing.T) {
 				evalopts.EnvVariable("context", system.String("context")),
 			},
 		},
+		{
+			name:            "overriding %ucum constant",
+			inputPath:       "'valid fhirpath'",
+			inputCollection: []fhirpath.Resource{},
+			evaluateOptions: []fhirpath.EvaluateOption{
+				evalopts.EnvVariable("ucum", system.String("ucum")),
+			},
+		},
+		{
+			name:            "defining variable with existing environment variable name",
+			inputPath:       "defineVariable('var', 1)",
+			inputCollection: []fhirpath.Resource{},
+			evaluateOptions: []fhirpath.EvaluateOption{
+				evalopts.EnvVariable("var", system.String("value")),
+			},
+		},
 		{
 			name:            "adding unsupported type as constant",
 			inputPath:       "%var",
@@ -1628,6 +3422,43 @@ func TestExternalConstantExpression_ReturnsConstant(t *testing.T) {
 			inputCollection: []fhirpath.Resource{},
 			wantCollection:  system.Collection{system.String("http://unitsofmeasure.org")},
 		},
+		{
+			name:            "overrides %resource with environment variable",
+			inputPath:       "%resource",
+			inputCollection: []fhirpath.Resource{patientChu},
+			evaluateOptions: []fhirpath.EvaluateOption{
+				evalopts.EnvVariable("resource", patientVoldemort),
+			},
+			wantCollection: system.Collection{patientVoldemort},
+		},
+		{
+			name:            "overrides %rootResource with environment variable",
+			inputPath:       "%rootResource",
+			inputCollection: []fhirpath.Resource{patientChu},
+			evaluateOptions: []fhirpath.EvaluateOption{
+				evalopts.EnvVariable("rootResource", patientVoldemort),
+			},
+			wantCollection: system.Collection{patientVoldemort},
+		},
+		{
+			name:            "overrides %sct and %loinc with environment variables",
+			inputPath:       "%sct | %loinc",
+			inputCollection: []fhirpath.Resource{},
+			evaluateOptions: []fhirpath.EvaluateOption{
+				evalopts.EnvVariable("sct", system.String("http://snomed.info/sct/731000124108")),
+				evalopts.EnvVariable("loinc", system.String("http://loinc.org/2.76")),
+			},
+			wantCollection: system.Collection{system.String("http://snomed.info/sct/731000124108"), system.String("http://loinc.org/2.76")},
+		},
+		{
+			name:            "overrides %vs-[name] with environment variable",
+			inputPath:       "%`vs-mine`",
+			inputCollection: []fhirpath.Resource{},
+			evaluateOptions: []fhirpath.EvaluateOption{
+				evalopts.EnvVariable("vs-mine", system.String("http://example.com/ValueSet/mine")),
+			},
+			wantCollection: system.Collection{system.String("http://example.com/ValueSet/mine")},
+		},
 	}
 
 	testEvaluate(t, testCases)
@@ -0,0 +1,173 @@
+package compile
+
+import (
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/verily-src/fhirpath-go/fhirpath/ast"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/diagnostic"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/grammar"
+	"github.com/verily-src/fhirpath-go/fhirpath/system"
+	"github.com/verily-src/fhirpath-go/internal/resource"
+	"github.com/verily-src/fhirpath-go/internal/slices"
+)
+
+// AST builds the syntax tree of a parsed FHIRPath expression. Resource types
+// at the root of the expression are resolved the same way as they are by the
+// parser's visitor.
+func AST(tree grammar.IProgContext) ast.Node {
+	return (&astBuilder{}).expression(tree.Expression())
+}
+
+// astBuilder builds syntax trees, mirroring the scoping of the parser's
+// visitor.
+type astBuilder struct {
+	visitedRoot bool
+}
+
+// clone produces a builder for sub-expressions, which resets the root node.
+func (b *astBuilder) clone() *astBuilder {
+	return &astBuilder{}
+}
+
+// binaryExpression is implemented by the parse trees of all binary operators.
+type binaryExpression interface {
+	antlr.ParserRuleContext
+	Expression(i int) grammar.IExpressionContext
+}
+
+func (b *astBuilder) expression(tree grammar.IExpressionContext) ast.Node {
+	switch ctx := tree.(type) {
+	case *grammar.TermExpressionContext:
+		return b.term(ctx.Term())
+	case *grammar.InvocationExpressionContext:
+		input := b.expression(ctx.Expression())
+		return b.invocation(ctx.Invocation(), input, diagnostic.SpanOf(ctx))
+	case *grammar.IndexerExpressionContext:
+		left := b.expression(ctx.Expression(0))
+		right := b.clone().expression(ctx.Expression(1))
+		return &ast.Operator{Op: "[]", Left: left, Right: right, Source: diagnostic.SpanOf(ctx)}
+	case *grammar.PolarityExpressionContext:
+		operand := b.expression(ctx.Expression())
+		return &ast.Operator{Op: ctx.GetChild(0).(antlr.TerminalNode).GetText(), Right: operand, Source: diagnostic.SpanOf(ctx)}
+	case *grammar.TypeExpressionContext:
+		operand := b.expression(ctx.Expression())
+		return &ast.TypeOperator{
+			Op:     ctx.GetChild(1).(antlr.TerminalNode).GetText(),
+			Expr:   operand,
+			Type:   &ast.TypeSpecifier{Name: ctx.TypeSpecifier().GetText(), Source: diagnostic.SpanOf(ctx.TypeSpecifier())},
+			Source: diagnostic.SpanOf(ctx),
+		}
+	case binaryExpression:
+		left := b.expression(ctx.Expression(0))
+		right := b.clone().expression(ctx.Expression(1))
+		return &ast.Operator{
+			Op:     ctx.GetChild(1).(antlr.TerminalNode).GetText(),
+			Left:   left,
+			Right:  right,
+			Source: diagnostic.SpanOf(ctx),
+		}
+	}
+	return nil
+}
+
+func (b *astBuilder) term(tree grammar.ITermContext) ast.Node {
+	switch ctx := tree.(type) {
+	case *grammar.InvocationTermContext:
+		return b.invocation(ctx.Invocation(), nil, diagnostic.SpanOf(ctx))
+	case *grammar.LiteralTermContext:
+		return literal(ctx.Literal())
+	case *grammar.ExternalConstantTermContext:
+		return &ast.ExternalConstant{Name: constantName(ctx.ExternalConstant()), Source: diagnostic.SpanOf(ctx)}
+	case *grammar.ParenthesizedTermContext:
+		return b.expression(ctx.Expression())
+	}
+	return nil
+}
+
+func (b *astBuilder) invocation(tree grammar.IInvocationContext, input ast.Node, span ast.Span) ast.Node {
+	switch ctx := tree.(type) {
+	case *grammar.MemberInvocationContext:
+		name := unquoteIdentifier(ctx.GetText())
+		if resource.IsType(name) && !b.visitedRoot {
+			b.visitedRoot = true
+			return &ast.ResourceType{Input: input, Name: name, Source: span}
+		}
+		return &ast.Field{Input: input, Name: name, Source: span}
+	case *grammar.FunctionInvocationContext:
+		return b.function(ctx.Function(), input, span)
+	case *grammar.ThisInvocationContext, *grammar.IndexInvocationContext, *grammar.TotalInvocationContext:
+		return &ast.Variable{Input: input, Name: tree.GetText(), Source: span}
+	}
+	return nil
+}
+
+func (b *astBuilder) function(ctx grammar.IFunctionContext, input ast.Node, span ast.Span) ast.Node {
+	name := "ofType"
+	if ctx.Identifier() != nil {
+		name = ctx.Identifier().GetText()
+	}
+	var params []grammar.IExpressionContext
+	if paramList := ctx.ParamList(); paramList != nil {
+		params = paramList.AllExpression()
+	}
+	var args []ast.Node
+	if name == "ofType" {
+		args = slices.Map(params, func(param grammar.IExpressionContext) ast.Node {
+			return &ast.TypeSpecifier{Name: param.GetText(), Source: diagnostic.SpanOf(param)}
+		})
+	} else {
+		args = slices.Map(params, b.expression)
+	}
+	return &ast.Function{Input: input, Name: name, Args: args, Source: span}
+}
+
+func literal(tree grammar.ILiteralContext) ast.Node {
+	node := &ast.Literal{Text: tree.GetText(), Source: diagnostic.SpanOf(tree)}
+	var value system.Any
+	var err error
+	switch ctx := tree.(type) {
+	case *grammar.BooleanLiteralContext:
+		value, err = system.ParseBoolean(ctx.GetText())
+	case *grammar.StringLiteralContext:
+		value, err = system.ParseString(ctx.STRING().GetText())
+	case *grammar.NumberLiteralContext:
+		number := ctx.NUMBER().GetText()
+		switch {
+		case strings.HasSuffix(number, "L"):
+			value, err = system.ParseLong(number)
+		case strings.Contains(number, "."):
+			value, err = system.ParseDecimal(number)
+		default:
+			value, err = system.ParseInteger(number)
+		}
+	case *grammar.DateLiteralContext:
+		value, err = system.ParseDate(ctx.DATE().GetText())
+	case *grammar.DateTimeLiteralContext:
+		value, err = system.ParseDateTime(ctx.DATETIME().GetText())
+	case *grammar.TimeLiteralContext:
+		value, err = system.ParseTime(ctx.TIME().GetText())
+	case *grammar.QuantityLiteralContext:
+		unit := strings.Trim(ctx.Quantity().Unit().GetText(), "'")
+		value, err = system.ParseQuantity(ctx.Quantity().NUMBER().GetText(), unit)
+	}
+	if err == nil {
+		node.Value = value
+	}
+	return node
+}
+
+// constantName returns the name of an external constant, which may be an
+// identifier, a delimited identifier or a string.
+func constantName(ctx grammar.IExternalConstantContext) string {
+	if str := ctx.STRING(); str != nil {
+		value, _ := system.ParseString(str.GetText())
+		return string(value)
+	}
+	return unquoteIdentifier(ctx.Identifier().GetText())
+}
+
+// unquoteIdentifier removes the backticks around a delimited identifier.
+func unquoteIdentifier(identifier string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(identifier, "`"), "`")
+}
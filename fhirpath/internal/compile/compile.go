@@ -6,6 +6,7 @@ import (
 	"github.com/verily-src/fhirpath-go/fhirpath/internal/grammar"
 	"github.com/verily-src/fhirpath-go/fhirpath/internal/opts"
 	"github.com/verily-src/fhirpath-go/fhirpath/internal/parser"
+	"github.com/verily-src/fhirpath-go/internal/slices"
 )
 
 // PopulateConfig creates a CompileConfig and prepopulates it with
@@ -24,13 +25,13 @@ func PopulateConfig(options ...opts.CompileOption) (*opts.CompileConfig, error)
 // Tree creates an ANTLR parsing context from the provided FHIRPath string.
 func Tree(expr string) (grammar.IProgContext, error) {
 	inputStream := antlr.NewInputStream(expr)
-	errorListener := &parser.FHIRPathErrorListener{}
+	errorListener := &parser.FHIRPathErrorListener{Source: expr}
 
 	// Lex the input stream
 	lexer := grammar.NewfhirpathLexer(inputStream)
 	lexer.RemoveErrorListeners()
 	lexer.AddErrorListener(errorListener)
-	tokens := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+	tokens := antlr.NewCommonTokenStream(grammar.WithLongNumbers(lexer), antlr.TokenDefaultChannel)
 
 	// Parse the tokens
 	p := grammar.NewfhirpathParser(tokens)
@@ -44,3 +45,17 @@ func Tree(expr string) (grammar.IProgContext, error) {
 
 	return tree, nil
 }
+
+// UsesConstant reports whether the parsed expression refers to any of the
+// named external constants.
+func UsesConstant(tree antlr.Tree, names ...string) bool {
+	if ctx, ok := tree.(*grammar.ExternalConstantContext); ok {
+		return slices.Includes(names, constantName(ctx))
+	}
+	for _, child := range tree.GetChildren() {
+		if UsesConstant(child, names...) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package diagnostic
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/verily-src/fhirpath-go/fhirpath/ast"
+)
+
+// Codes classifying located errors.
+const (
+	CodeSyntax            = "syntax"
+	CodeUnknownFunction   = "unknown-function"
+	CodeWrongArity        = "wrong-arity"
+	CodeInvalidType       = "invalid-type"
+	CodeInvalidVariable   = "invalid-variable"
+	CodeInvalidLiteral    = "invalid-literal"
+	CodeUnknownField      = "unknown-field"
+	CodeImpossibleType    = "impossible-type"
+	CodeInvalidArgument   = "invalid-argument"
+	CodeInvalidExpression = "invalid-expression"
+	CodeAlwaysEmpty       = "always-empty"
+)
+
+// Error is an error located in the source of an expression.
+type Error struct {
+	// Code classifies the error.
+	Code string
+	// Span is the range of the source that caused the error.
+	Span ast.Span
+	// Token is the text of the offending token.
+	Token string
+	// Suggestion is a likely replacement for the offending token, if any.
+	Suggestion string
+	// Err is the underlying error.
+	Err error
+}
+
+// Error returns the message of the underlying error, prefixed with the line
+// and column where the error was found.
+func (e *Error) Error() string {
+	message := fmt.Sprintf("%d:%d: %v", e.Span.Start.Line, e.Span.Start.Column, e.Err)
+	if e.Suggestion != "" {
+		message += fmt.Sprintf(" (did you mean '%s'?)", e.Suggestion)
+	}
+	return message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// At creates an error located at the given parse tree, whose first token is
+// the offending token.
+func At(ctx antlr.ParserRuleContext, code string, err error) *Error {
+	return &Error{Code: code, Span: SpanOf(ctx), Token: ctx.GetStart().GetText(), Err: err}
+}
+
+// Locate returns the error located at the given parse tree, with the given
+// code, unless it is already located.
+func Locate(err error, ctx antlr.ParserRuleContext, code string) error {
+	if located := (*Error)(nil); err == nil || errors.As(err, &located) {
+		return err
+	}
+	return At(ctx, code, err)
+}
+
+// Flatten returns the located errors that make up the given error, in the
+// order that they were joined. Unlocated errors that are joined with located
+// ones, such as sentinel errors that prefix them, are dropped.
+func Flatten(err error) []*Error {
+	var result []*Error
+	flatten(err, &result)
+	return result
+}
+
+func flatten(err error, result *[]*Error) {
+	if located, ok := err.(*Error); ok {
+		*result = append(*result, located)
+		return
+	}
+	switch wrapped := err.(type) {
+	case interface{ Unwrap() []error }:
+		for _, err := range wrapped.Unwrap() {
+			flatten(err, result)
+		}
+	case interface{ Unwrap() error }:
+		flatten(wrapped.Unwrap(), result)
+	}
+}
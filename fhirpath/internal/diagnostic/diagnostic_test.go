@@ -0,0 +1,101 @@
+package diagnostic_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/verily-src/fhirpath-go/fhirpath/ast"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/diagnostic"
+)
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"where", "select", "exists", "first", "length", "lower"}
+	testCases := []struct {
+		name string
+		want string
+	}{
+		{"wehre", "where"},
+		{"slect", "select"},
+		{"Exists", "exists"},
+		{"frist", "first"},
+		{"lenght", "length"},
+		{"where", ""},
+		{"count", ""},
+		{"x", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := diagnostic.Suggest(tc.name, candidates); got != tc.want {
+				t.Errorf("Suggest(%s) = %q, want %q", tc.name, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	errSentinel := errors.New("sentinel")
+	first := &diagnostic.Error{Code: diagnostic.CodeSyntax, Err: errSentinel}
+	second := &diagnostic.Error{Code: diagnostic.CodeWrongArity, Err: errSentinel}
+	testCases := []struct {
+		name string
+		err  error
+		want []*diagnostic.Error
+	}{
+		{
+			name: "located error",
+			err:  first,
+			want: []*diagnostic.Error{first},
+		},
+		{
+			name: "joined errors",
+			err:  errors.Join(first, second),
+			want: []*diagnostic.Error{first, second},
+		},
+		{
+			name: "wrapped errors",
+			err:  fmt.Errorf("%w: %w", errSentinel, errors.Join(first, second)),
+			want: []*diagnostic.Error{first, second},
+		},
+		{
+			name: "unlocated error",
+			err:  errSentinel,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := diagnostic.Flatten(tc.err)
+
+			if diff := cmp.Diff(tc.want, got, cmp.Comparer(func(a, b *diagnostic.Error) bool { return a == b })); diff != "" {
+				t.Errorf("Flatten returned unexpected errors (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestError_Error(t *testing.T) {
+	err := &diagnostic.Error{
+		Span:       ast.Span{Start: ast.Position{Offset: 13, Line: 1, Column: 14}},
+		Suggestion: "where",
+		Err:        errors.New("unknown function"),
+	}
+
+	want := "1:14: unknown function (did you mean 'where'?)"
+	if got := err.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestPositionAt(t *testing.T) {
+	source := "Patient\n  .name"
+
+	got := diagnostic.PositionAt(source, 2, 3)
+
+	want := ast.Position{Offset: 11, Line: 2, Column: 4}
+	if got != want {
+		t.Errorf("PositionAt = %v, want %v", got, want)
+	}
+}
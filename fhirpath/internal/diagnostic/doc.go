@@ -0,0 +1,6 @@
+/*
+Package diagnostic provides errors that are located in the source of a
+FHIRPath expression, so that compilation errors can point to the exact span
+that caused them.
+*/
+package diagnostic
@@ -0,0 +1,69 @@
+package diagnostic
+
+import (
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/verily-src/fhirpath-go/fhirpath/ast"
+)
+
+// SpanOf returns the range of the source that the given parse tree was parsed
+// from.
+func SpanOf(ctx antlr.ParserRuleContext) ast.Span {
+	start, stop := ctx.GetStart(), ctx.GetStop()
+	span := ast.Span{Start: positionOf(start)}
+	if stop == nil || stop.GetStop() < start.GetStart() {
+		span.End = span.Start
+		return span
+	}
+	span.End = endOf(stop)
+	return span
+}
+
+// TokenSpan returns the range of the source that the given token was lexed
+// from. The span of the end of the input is empty.
+func TokenSpan(token antlr.Token) ast.Span {
+	span := ast.Span{Start: positionOf(token)}
+	if token.GetTokenType() == antlr.TokenEOF || token.GetStop() < token.GetStart() {
+		span.End = span.Start
+		return span
+	}
+	span.End = endOf(token)
+	return span
+}
+
+// positionOf returns the position of the first character of the token.
+func positionOf(token antlr.Token) ast.Position {
+	return ast.Position{Offset: token.GetStart(), Line: token.GetLine(), Column: token.GetColumn() + 1}
+}
+
+// endOf returns the position immediately after the last character of the
+// token.
+func endOf(token antlr.Token) ast.Position {
+	end := positionOf(token)
+	end.Offset = token.GetStop() + 1
+	for _, r := range token.GetText() {
+		end.Column++
+		if r == '\n' {
+			end.Line++
+			end.Column = 1
+		}
+	}
+	return end
+}
+
+// PositionAt returns the position of the character at the given line and
+// zero-based column of the source, as reported by ANTLR.
+func PositionAt(source string, line, column int) ast.Position {
+	position := ast.Position{Line: line, Column: column + 1}
+	currentLine := 1
+	for offset, r := range []rune(source) {
+		if currentLine == line {
+			position.Offset = offset + column
+			return position
+		}
+		if r == '\n' {
+			currentLine++
+		}
+	}
+	position.Offset = len([]rune(source))
+	return position
+}
@@ -18,11 +18,25 @@ func EvaluateAdd(lhs, rhs system.Any) (system.Any, error) {
 		if right, ok := rhs.(system.Integer); ok {
 			return left.Add(right)
 		}
+		if right, ok := rhs.(system.Quantity); ok {
+			return system.Normalize(left, right).(system.Quantity).Add(right)
+		}
+		return nil, typeMismatch(Add, lhs, rhs)
+	case system.Long:
+		if right, ok := rhs.(system.Long); ok {
+			return left.Add(right)
+		}
+		if right, ok := rhs.(system.Quantity); ok {
+			return system.Normalize(left, right).(system.Quantity).Add(right)
+		}
 		return nil, typeMismatch(Add, lhs, rhs)
 	case system.Decimal:
 		if right, ok := rhs.(system.Decimal); ok {
 			return left.Add(right), nil
 		}
+		if right, ok := rhs.(system.Quantity); ok {
+			return system.Normalize(left, right).(system.Quantity).Add(right)
+		}
 		return nil, typeMismatch(Add, lhs, rhs)
 	case system.Time:
 		if right, ok := rhs.(system.Quantity); ok {
@@ -40,7 +54,7 @@ func EvaluateAdd(lhs, rhs system.Any) (system.Any, error) {
 		}
 		return nil, typeMismatch(Add, lhs, rhs)
 	case system.Quantity:
-		if right, ok := rhs.(system.Quantity); ok {
+		if right, ok := system.Normalize(rhs, left).(system.Quantity); ok {
 			return left.Add(right)
 		}
 		return nil, typeMismatch(Add, lhs, rhs)
@@ -56,11 +70,25 @@ func EvaluateSub(lhs, rhs system.Any) (system.Any, error) {
 		if right, ok := rhs.(system.Integer); ok {
 			return left.Sub(right)
 		}
+		if right, ok := rhs.(system.Quantity); ok {
+			return system.Normalize(left, right).(system.Quantity).Sub(right)
+		}
+		return nil, typeMismatch(Sub, lhs, rhs)
+	case system.Long:
+		if right, ok := rhs.(system.Long); ok {
+			return left.Sub(right)
+		}
+		if right, ok := rhs.(system.Quantity); ok {
+			return system.Normalize(left, right).(system.Quantity).Sub(right)
+		}
 		return nil, typeMismatch(Sub, lhs, rhs)
 	case system.Decimal:
 		if right, ok := rhs.(system.Decimal); ok {
 			return left.Sub(right), nil
 		}
+		if right, ok := rhs.(system.Quantity); ok {
+			return system.Normalize(left, right).(system.Quantity).Sub(right)
+		}
 		return nil, typeMismatch(Sub, lhs, rhs)
 	case system.Time:
 		if right, ok := rhs.(system.Quantity); ok {
@@ -78,7 +106,7 @@ func EvaluateSub(lhs, rhs system.Any) (system.Any, error) {
 		}
 		return nil, typeMismatch(Sub, lhs, rhs)
 	case system.Quantity:
-		if right, ok := rhs.(system.Quantity); ok {
+		if right, ok := system.Normalize(rhs, left).(system.Quantity); ok {
 			return left.Sub(right)
 		}
 		return nil, typeMismatch(Sub, lhs, rhs)
@@ -94,23 +122,34 @@ func EvaluateMul(lhs, rhs system.Any) (system.Any, error) {
 		if right, ok := rhs.(system.Integer); ok {
 			return left.Mul(right)
 		}
-		if _, ok := rhs.(system.Quantity); ok {
-			// TODO: Implement multiplication with Quantity.
-			return nil, ErrToBeImplemented
+		if right, ok := rhs.(system.Quantity); ok {
+			return quantityOf(left).Mul(right)
+		}
+		return nil, typeMismatch(Mul, lhs, rhs)
+	case system.Long:
+		if right, ok := rhs.(system.Long); ok {
+			return left.Mul(right)
+		}
+		if right, ok := rhs.(system.Quantity); ok {
+			return quantityOf(left).Mul(right)
 		}
 		return nil, typeMismatch(Mul, lhs, rhs)
 	case system.Decimal:
 		if right, ok := rhs.(system.Decimal); ok {
 			return left.Mul(right), nil
 		}
-		if _, ok := rhs.(system.Quantity); ok {
-			// TODO: Implement multiplication with Quantity.
-			return nil, ErrToBeImplemented
+		if right, ok := rhs.(system.Quantity); ok {
+			return quantityOf(left).Mul(right)
 		}
 		return nil, typeMismatch(Mul, lhs, rhs)
 	case system.Quantity:
-		// TODO: Implement support for multiplication with Quantity.
-		return nil, ErrToBeImplemented
+		switch right := rhs.(type) {
+		case system.Quantity:
+			return left.Mul(right)
+		case system.Integer, system.Long, system.Decimal:
+			return left.Mul(quantityOf(right))
+		}
+		return nil, typeMismatch(Mul, lhs, rhs)
 	default:
 		return nil, typeMismatch(Mul, lhs, rhs)
 	}
@@ -121,25 +160,36 @@ func EvaluateDiv(lhs, rhs system.Any) (system.Any, error) {
 	switch left := lhs.(type) {
 	case system.Integer:
 		if right, ok := rhs.(system.Integer); ok {
-			return left.Div(right), nil
+			return left.Div(right)
 		}
-		if _, ok := rhs.(system.Quantity); ok {
-			// TODO: Implement division with Quantity.
-			return nil, ErrToBeImplemented
+		if right, ok := rhs.(system.Quantity); ok {
+			return quantityOf(left).Div(right)
+		}
+		return nil, typeMismatch(Div, lhs, rhs)
+	case system.Long:
+		if right, ok := rhs.(system.Long); ok {
+			return left.Div(right)
+		}
+		if right, ok := rhs.(system.Quantity); ok {
+			return quantityOf(left).Div(right)
 		}
 		return nil, typeMismatch(Div, lhs, rhs)
 	case system.Decimal:
 		if right, ok := rhs.(system.Decimal); ok {
-			return left.Div(right), nil
+			return left.Div(right)
 		}
-		if _, ok := rhs.(system.Quantity); ok {
-			// TODO: Implement division with Quantity.
-			return nil, ErrToBeImplemented
+		if right, ok := rhs.(system.Quantity); ok {
+			return quantityOf(left).Div(right)
 		}
 		return nil, typeMismatch(Div, lhs, rhs)
 	case system.Quantity:
-		// TODO: Implement support for division with Quantity.
-		return nil, ErrToBeImplemented
+		switch right := rhs.(type) {
+		case system.Quantity:
+			return left.Div(right)
+		case system.Integer, system.Long, system.Decimal:
+			return left.Div(quantityOf(right))
+		}
+		return nil, typeMismatch(Div, lhs, rhs)
 	default:
 		return nil, typeMismatch(Div, lhs, rhs)
 	}
@@ -150,13 +200,18 @@ func EvaluateFloorDiv(lhs, rhs system.Any) (system.Any, error) {
 	switch left := lhs.(type) {
 	case system.Integer:
 		if right, ok := rhs.(system.Integer); ok {
-			return left.FloorDiv(right), nil
+			return left.FloorDiv(right)
 		}
 		if _, ok := rhs.(system.Quantity); ok {
 			// TODO: Implement floor division with Quantity.
 			return nil, ErrToBeImplemented
 		}
 		return nil, typeMismatch(FloorDiv, lhs, rhs)
+	case system.Long:
+		if right, ok := rhs.(system.Long); ok {
+			return left.FloorDiv(right)
+		}
+		return nil, typeMismatch(FloorDiv, lhs, rhs)
 	case system.Decimal:
 		if right, ok := rhs.(system.Decimal); ok {
 			return left.FloorDiv(right)
@@ -179,16 +234,21 @@ func EvaluateMod(lhs, rhs system.Any) (system.Any, error) {
 	switch left := lhs.(type) {
 	case system.Integer:
 		if right, ok := rhs.(system.Integer); ok {
-			return left.Mod(right), nil
+			return left.Mod(right)
 		}
 		if _, ok := rhs.(system.Quantity); ok {
 			// TODO: Implement modulus with Quantity.
 			return nil, ErrToBeImplemented
 		}
 		return nil, typeMismatch(Mod, lhs, rhs)
+	case system.Long:
+		if right, ok := rhs.(system.Long); ok {
+			return left.Mod(right)
+		}
+		return nil, typeMismatch(Mod, lhs, rhs)
 	case system.Decimal:
 		if right, ok := rhs.(system.Decimal); ok {
-			return left.Mod(right), nil
+			return left.Mod(right)
 		}
 		if _, ok := rhs.(system.Quantity); ok {
 			// TODO: Implement modulus with Quantity.
@@ -203,6 +263,14 @@ func EvaluateMod(lhs, rhs system.Any) (system.Any, error) {
 	}
 }
 
+// quantityOf implicitly converts a number to a dimensionless Quantity, so
+// that it can scale another Quantity.
+func quantityOf(number system.Any) system.Quantity {
+	return system.Normalize(number, dimensionless).(system.Quantity)
+}
+
+var dimensionless = system.MustParseQuantity("1", "1")
+
 // typeMismatch generates an unsupported operation error.
 func typeMismatch(op Operator, lhs, rhs system.Any) error {
 	return fmt.Errorf("%w: %T %s %T", system.ErrTypeMismatch, lhs, op, rhs)
@@ -2,11 +2,16 @@ package expr
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/verily-src/fhirpath-go/fhirpath/profile"
 	"github.com/verily-src/fhirpath-go/fhirpath/resolver"
 	"github.com/verily-src/fhirpath-go/fhirpath/system"
 	"github.com/verily-src/fhirpath-go/fhirpath/terminology"
+	"github.com/verily-src/fhirpath-go/internal/fhir"
+	"google.golang.org/protobuf/proto"
 )
 
 // Context holds the global time and external constant
@@ -35,8 +40,52 @@ type Context struct {
 	// which can be used to validate code in valueSet
 	TermService terminology.Service
 
+	// StrictTerminology causes errors from the Terminology Service to be
+	// returned by the 'memberOf' function, rather than evaluating to false.
+	StrictTerminology bool
+
+	// ProfileValidator is an optional mechanism for validating resources
+	// against profiles, used in the 'conformsTo()' FHIRPath function.
+	ProfileValidator profile.Validator
+
 	// GoContext is a context from the calling main function
 	GoContext context.Context
+
+	// Index is the index of the item currently being evaluated by an iterating
+	// function such as 'where' or 'select', accessible through $index. It is
+	// empty outside of iteration.
+	Index system.Collection
+
+	// Total is the running total of the 'aggregate' function, accessible
+	// through $total. It is empty outside of aggregation.
+	Total system.Collection
+
+	// MaxRepeatDepth is the maximum number of levels that the 'repeat' function
+	// will traverse before raising an error. A non-positive value selects the
+	// default maximum.
+	MaxRepeatDepth int
+
+	// TraceSink is an optional callback that receives the collections traced by
+	// the 'trace' function. If nil, traced output is discarded.
+	TraceSink func(name string, collection system.Collection)
+
+	// variables holds the user-defined variables that are in scope, most
+	// recently defined first. It is never mutated in place, so that definitions
+	// made on a clone are not visible to the Context it was cloned from.
+	variables *variable
+
+	// owners maps each node navigated to during evaluation to the resource that
+	// contains it, and is used to resolve %resource and %rootResource. Contained
+	// resources and bundle entry resources are owned by their container.
+	owners map[proto.Message]fhir.Resource
+}
+
+// variable is a single user-defined variable, linked to the variables that
+// were defined before it.
+type variable struct {
+	name   string
+	value  system.Collection
+	parent *variable
 }
 
 // Deadline wraps the Deadline() method of context.Context. More information available at https://pkg.go.dev/context
@@ -67,8 +116,169 @@ func (c *Context) Clone() *Context {
 		LastResult:        c.LastResult,
 		Resolver:          c.Resolver,
 		TermService:       c.TermService,
+		StrictTerminology: c.StrictTerminology,
+		ProfileValidator:  c.ProfileValidator,
 		GoContext:         c.GoContext,
+		Index:             c.Index,
+		Total:             c.Total,
+		MaxRepeatDepth:    c.MaxRepeatDepth,
+		TraceSink:         c.TraceSink,
+		variables:         c.variables,
+		owners:            c.owners,
+	}
+}
+
+// DefineVariable defines a variable with the given name and value, which is
+// visible to all subsequent evaluations with this Context and its clones.
+// Returns ErrExistingVariable if the name is already used by an external
+// constant.
+func (c *Context) DefineVariable(name string, value system.Collection) error {
+	if _, ok := c.ExternalConstants[name]; ok {
+		return fmt.Errorf("%w: %s", ErrExistingVariable, name)
+	}
+	c.variables = &variable{name: name, value: value, parent: c.variables}
+	return nil
+}
+
+// lookupVariable returns the value of the most recently defined variable with
+// the given name.
+func (c *Context) lookupVariable(name string) (system.Collection, bool) {
+	for v := c.variables; v != nil; v = v.parent {
+		if v.name == name {
+			return v.value, true
+		}
+	}
+	return nil, false
+}
+
+// WithIndex clones this Context, setting the $index iteration variable to the
+// given index.
+func (c *Context) WithIndex(index int) *Context {
+	clone := c.Clone()
+	clone.Index = system.Collection{system.Integer(index)}
+	return clone
+}
+
+// TrackOwners enables recording the resource that contains each node navigated
+// to, which is needed to resolve %resource and %rootResource. It should only be
+// enabled for expressions that use these variables, since every navigated node
+// is retained until evaluation completes.
+func (c *Context) TrackOwners() {
+	c.owners = map[proto.Message]fhir.Resource{}
+}
+
+// trackOwner records the resource that contains the given node, which was
+// navigated to from the given parent node. Does nothing if ownership isn't
+// being tracked, or if the owner of the parent is unknown.
+func (c *Context) trackOwner(parent, node proto.Message) {
+	if c.owners == nil {
+		return
+	}
+	if resource, ok := parent.(fhir.Resource); ok {
+		c.owners[node] = resource
+	} else if owner, ok := c.owners[parent]; ok {
+		c.owners[node] = owner
+	}
+}
+
+// resourcesOf returns the resources that contain the items in the input
+// collection. If root is true, the container of each of these resources is
+// returned instead, if there is one. Returns false if the containing resource
+// of any item is unknown.
+func (c *Context) resourcesOf(input system.Collection, root bool) (system.Collection, bool) {
+	if c.owners == nil || len(input) == 0 {
+		return nil, false
+	}
+	result := system.Collection{}
+	for _, item := range input {
+		message, ok := item.(proto.Message)
+		if !ok {
+			return nil, false
+		}
+		resource, ok := c.owners[message]
+		if !ok {
+			if resource, ok = message.(fhir.Resource); !ok {
+				return nil, false
+			}
+		}
+		if container, ok := c.owners[resource]; ok && root {
+			resource = container
+		}
+		if !containsResource(result, resource) {
+			result = append(result, resource)
+		}
+	}
+	return result, true
+}
+
+func containsResource(collection system.Collection, resource fhir.Resource) bool {
+	for _, item := range collection {
+		if item == resource {
+			return true
+		}
+	}
+	return false
+}
+
+// Prefixes of the FHIR-defined environment variables that are shortcuts to
+// ValueSet and extension URLs, e.g. %vs-administrative-gender.
+const (
+	valueSetPrefix  = "vs-"
+	extensionPrefix = "ext-"
+)
+
+// Terminologies is the value of the %terminologies environment variable, on
+// which the functions of the FHIR terminology service API are invoked, e.g.
+// %terminologies.expand('http://hl7.org/fhir/ValueSet/administrative-gender').
+// See https://hl7.org/fhir/R4/fhirpath.html#txapi
+type Terminologies struct{}
+
+// IsSystemConstant returns true if the given name is one of the environment
+// variables defined by FHIRPath or FHIR, which cannot be redefined by an
+// expression. Apart from %context and %ucum, these have default values that
+// external constants of the same name take precedence over.
+func IsSystemConstant(name string) bool {
+	switch name {
+	case "context", "resource", "rootResource", "ucum", "sct", "loinc", "terminologies":
+		return true
+	}
+	return strings.HasPrefix(name, valueSetPrefix) || strings.HasPrefix(name, extensionPrefix)
+}
+
+// urlConstant returns the URL for the %vs-[name] and %ext-[name] environment
+// variables.
+func urlConstant(name string) (system.String, bool) {
+	if id, ok := strings.CutPrefix(name, valueSetPrefix); ok && id != "" {
+		return system.String("http://hl7.org/fhir/ValueSet/" + id), true
+	}
+	if id, ok := strings.CutPrefix(name, extensionPrefix); ok && id != "" {
+		return system.String("http://hl7.org/fhir/StructureDefinition/" + id), true
+	}
+	return "", false
+}
+
+// defaultConstant returns the default value of the FHIR-defined environment
+// variable with the given name, for the given input. These aren't set by
+// InitializeContext, so that external constants can override them.
+func (c *Context) defaultConstant(input system.Collection, name string) (any, bool) {
+	switch name {
+	case "resource", "rootResource":
+		if resources, ok := c.resourcesOf(input, name == "rootResource"); ok {
+			return resources, true
+		}
+		context, ok := c.ExternalConstants["context"]
+		return context, ok
+	case "sct":
+		return system.String("http://snomed.info/sct"), true
+	case "loinc":
+		return system.String("http://loinc.org"), true
+	case "terminologies":
+		return Terminologies{}, true
+	}
+	if url, ok := urlConstant(name); ok {
+		return url, true
 	}
+	return nil, false
 }
 
 // InitializeContext returns a base context, initialized with current time and initial
@@ -28,6 +28,7 @@ var (
 	ErrToBeImplemented  = errors.New("expression not yet implemented")
 	ErrInvalidField     = errors.New("invalid field")
 	ErrConstantNotFound = errors.New("external constant not found")
+	ErrExistingVariable = errors.New("variable already defined")
 )
 
 // Expression is the abstraction for all FHIRPath expressions,
@@ -87,6 +88,19 @@ func (e *FieldExpression) Evaluate(ctx *Context, input system.Collection) (syste
 	output := system.Collection{}
 
 	for _, item := range input {
+		// The type information returned by type() isn't a FHIR element, but
+		// its properties can still be navigated to.
+		if navigable, ok := item.(reflection.Navigable); ok {
+			values, ok := navigable.Field(e.FieldName)
+			if !ok {
+				if e.Permissive {
+					continue
+				}
+				return nil, e.errField(item)
+			}
+			output = append(output, values...)
+			continue
+		}
 		message, ok := item.(proto.Message)
 		if !ok {
 			if e.Permissive {
@@ -192,14 +206,15 @@ func (e *FieldExpression) Evaluate(ctx *Context, input system.Collection) (syste
 		}
 
 		if !field.IsList() {
-			message := reflect.Get(field).Message()
-			if !message.IsValid() {
+			value := reflect.Get(field).Message()
+			if !value.IsValid() {
 				continue
 			}
-			unwrapped, err := unwrap(message.Interface())
+			unwrapped, err := unwrap(value.Interface())
 			if err != nil {
 				return nil, err
 			}
+			ctx.trackOwner(message, unwrapped)
 			output = append(output, unwrapped)
 			continue
 		}
@@ -210,6 +225,7 @@ func (e *FieldExpression) Evaluate(ctx *Context, input system.Collection) (syste
 			if err != nil {
 				return nil, err
 			}
+			ctx.trackOwner(message, unwrapped)
 			output = append(output, unwrapped)
 		}
 	}
@@ -390,14 +406,19 @@ func (e *IndexExpression) Evaluate(ctx *Context, input system.Collection) (syste
 	if err != nil {
 		return nil, err
 	}
-	index, ok := value.(system.Integer)
-	if !ok {
-		return nil, fmt.Errorf("%w: want Integer but got %T", ErrInvalidType, index)
+	var index int64
+	switch value := value.(type) {
+	case system.Integer:
+		index = int64(value)
+	case system.Long:
+		index = int64(value)
+	default:
+		return nil, fmt.Errorf("%w: want Integer but got %T", ErrInvalidType, value)
 	}
-	if int(index) >= len(input) || int(index) < 0 {
+	if index >= int64(len(input)) || index < 0 {
 		return system.Collection{}, nil
 	}
-	return system.Collection{input[int(index)]}, nil
+	return system.Collection{input[index]}, nil
 }
 
 var _ Expression = (*IndexExpression)(nil)
@@ -439,11 +460,44 @@ func (e *EqualityExpression) Evaluate(ctx *Context, input system.Collection) (sy
 
 var _ Expression = (*EqualityExpression)(nil)
 
+// EquivalenceExpression allows checking equivalence of the two contained
+// subexpressions, using the '~' and '!~' operators.
+type EquivalenceExpression struct {
+	Left  Expression
+	Right Expression
+	Not   bool
+}
+
+// Evaluate evaluates the two subexpressions, and returns true if their
+// contents are equivalent, using the functionality of system.Collection.Equivalent.
+// Unlike equality, equivalence always returns a boolean; two empty collections
+// are equivalent.
+func (e *EquivalenceExpression) Evaluate(ctx *Context, input system.Collection) (system.Collection, error) {
+	leftResult, err := e.Left.Evaluate(ctx.Clone(), input)
+	if err != nil {
+		return nil, err
+	}
+	rightResult, err := e.Right.Evaluate(ctx.Clone(), input)
+	if err != nil {
+		return nil, err
+	}
+
+	result := leftResult.Equivalent(rightResult)
+	if e.Not {
+		result = !result
+	}
+	return system.Collection{system.Boolean(result)}, nil
+}
+
+var _ Expression = (*EquivalenceExpression)(nil)
+
 // FunctionExpression enables evaluation of Function Invocation expressions.
 // It holds the function and function arguments.
 type FunctionExpression struct {
 	Fn   func(*Context, system.Collection, ...Expression) (system.Collection, error)
 	Args []Expression
+	// Name is the name that the function was invoked with, if known.
+	Name string
 }
 
 // Evaluate evaluates the function with respect to its arguments. Returns the result
@@ -675,14 +729,20 @@ func (e *ArithmeticExpression) Evaluate(ctx *Context, input system.Collection) (
 		return nil, fmt.Errorf("%w: %w", ErrInvalidType, err)
 	}
 
-	// Implicitly convert types
-	leftPrimitive = system.Normalize(leftPrimitive, rightPrimitive)
-	rightPrimitive = system.Normalize(rightPrimitive, leftPrimitive)
+	// Implicitly convert types. Numbers are converted to Quantities by each
+	// operation, since their unit depends on the operation.
+	if !isQuantity(leftPrimitive) && !isQuantity(rightPrimitive) {
+		leftPrimitive = system.Normalize(leftPrimitive, rightPrimitive)
+		rightPrimitive = system.Normalize(rightPrimitive, leftPrimitive)
+	}
 
 	result, err := e.Op(leftPrimitive, rightPrimitive)
 	if errors.Is(err, system.ErrIntOverflow) {
 		return system.Collection{}, nil // "Operations that cause arithmetic overflow or underflow will result in empty ( { } )".
 	}
+	if errors.Is(err, system.ErrDivByZero) || errors.Is(err, system.ErrSpecialUnit) {
+		return system.Collection{}, nil
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -691,6 +751,11 @@ func (e *ArithmeticExpression) Evaluate(ctx *Context, input system.Collection) (
 
 var _ Expression = (*ArithmeticExpression)(nil)
 
+func isQuantity(value system.Any) bool {
+	_, ok := value.(system.Quantity)
+	return ok
+}
+
 // ConcatExpression enables the evaluation of a string concatenation expression.
 type ConcatExpression struct {
 	Left  Expression
@@ -752,12 +817,21 @@ type ExternalConstantExpression struct {
 	Identifier string
 }
 
-// Evaluate retrieves the constant from the map located in the Context. Returns an error if the
+// Evaluate retrieves the constant from the variables defined in the Context, or
+// from the map located in the Context. Constants defined by FHIR that aren't in
+// the map resolve to their default values: %resource and %rootResource resolve
+// to the resources containing the input, if they are known, and the %vs-[name]
+// and %ext-[name] constants resolve to their URLs. Returns an error if the
 // constant is not present.
 func (e *ExternalConstantExpression) Evaluate(ctx *Context, input system.Collection) (system.Collection, error) {
+	if value, ok := ctx.lookupVariable(e.Identifier); ok {
+		return value, nil
+	}
 	constant, ok := ctx.ExternalConstants[e.Identifier]
 	if !ok {
-		return nil, fmt.Errorf("%w: %s", ErrConstantNotFound, e.Identifier)
+		if constant, ok = ctx.defaultConstant(input, e.Identifier); !ok {
+			return nil, fmt.Errorf("%w: %s", ErrConstantNotFound, e.Identifier)
+		}
 	}
 	if collection, ok := constant.(system.Collection); ok {
 		return collection, nil
@@ -767,6 +841,57 @@ func (e *ExternalConstantExpression) Evaluate(ctx *Context, input system.Collect
 
 var _ Expression = (*ExternalConstantExpression)(nil)
 
+// DefineVariableExpression enables evaluation of the 'defineVariable' function,
+// which defines a variable that is visible to subsequent expressions in the
+// same invocation chain.
+type DefineVariableExpression struct {
+	Name string
+	// Value is the expression providing the variable's value. If nil, the
+	// variable takes the value of the input collection.
+	Value Expression
+}
+
+// Evaluate defines the variable in the given Context, and returns the input
+// collection unchanged. Unlike FunctionExpression, the Context is not cloned,
+// so that the definition is visible to subsequent expressions.
+func (e *DefineVariableExpression) Evaluate(ctx *Context, input system.Collection) (system.Collection, error) {
+	value := input
+	if e.Value != nil {
+		var err error
+		if value, err = e.Value.Evaluate(ctx.Clone(), input); err != nil {
+			return nil, err
+		}
+	}
+	if err := ctx.DefineVariable(e.Name, value); err != nil {
+		return nil, err
+	}
+	return input, nil
+}
+
+var _ Expression = (*DefineVariableExpression)(nil)
+
+// IndexInvocationExpression enables evaluation of the $index invocation.
+type IndexInvocationExpression struct{}
+
+// Evaluate returns the index of the item currently being iterated over, or an
+// empty collection if not evaluated within an iterating function.
+func (*IndexInvocationExpression) Evaluate(ctx *Context, input system.Collection) (system.Collection, error) {
+	return append(system.Collection{}, ctx.Index...), nil
+}
+
+var _ Expression = (*IndexInvocationExpression)(nil)
+
+// TotalInvocationExpression enables evaluation of the $total invocation.
+type TotalInvocationExpression struct{}
+
+// Evaluate returns the running total of the enclosing 'aggregate' function, or
+// an empty collection if not evaluated within an aggregation.
+func (*TotalInvocationExpression) Evaluate(ctx *Context, input system.Collection) (system.Collection, error) {
+	return append(system.Collection{}, ctx.Total...), nil
+}
+
+var _ Expression = (*TotalInvocationExpression)(nil)
+
 // MembershipExpression enables evaluation of the "in" and "contains" operators.
 type MembershipExpression struct {
 	Left     Expression
@@ -810,7 +935,7 @@ func (e *MembershipExpression) Evaluate(ctx *Context, input system.Collection) (
 
 var _ Expression = (*MembershipExpression)(nil)
 
-// NegationExpression enables negation of number values (Integer, Decimal, Quantity).
+// NegationExpression enables negation of number values (Integer, Long, Decimal, Quantity).
 type NegationExpression struct {
 	Expr Expression
 }
@@ -840,6 +965,8 @@ func (e *NegationExpression) Evaluate(ctx *Context, input system.Collection) (sy
 	switch v := primitive.(type) {
 	case system.Integer:
 		return system.Collection{system.Integer(-1) * v}, nil
+	case system.Long:
+		return system.Collection{system.Long(-1) * v}, nil
 	case system.Decimal:
 		negative := system.Decimal(decimal.NewFromInt(-1))
 		return system.Collection{v.Mul(negative)}, nil
@@ -682,6 +682,74 @@ func TestEqualityExpression_RaisesError(t *testing.T) {
 	}
 }
 
+func TestEquivalenceExpression_ReturnsResult(t *testing.T) {
+	testCases := []struct {
+		name            string
+		equivalenceExpr *expr.EquivalenceExpression
+		wantCollection  system.Collection
+	}{
+		{
+			name:            "both collections empty",
+			equivalenceExpr: &expr.EquivalenceExpression{exprtest.Return(), exprtest.Return(), false},
+			wantCollection:  system.Collection{system.Boolean(true)},
+		},
+		{
+			name:            "one empty collection",
+			equivalenceExpr: &expr.EquivalenceExpression{exprtest.Return(), exprtest.Return(system.String("one")), false},
+			wantCollection:  system.Collection{system.Boolean(false)},
+		},
+		{
+			name:            "case-insensitive strings",
+			equivalenceExpr: &expr.EquivalenceExpression{exprtest.Return(system.String("ABC")), exprtest.Return(system.String("abc")), false},
+			wantCollection:  system.Collection{system.Boolean(true)},
+		},
+		{
+			name:            "comparing with !~ operator",
+			equivalenceExpr: &expr.EquivalenceExpression{exprtest.Return(system.String("abc")), exprtest.Return(system.String("abcd")), true},
+			wantCollection:  system.Collection{system.Boolean(true)},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.equivalenceExpr.Evaluate(&expr.Context{}, system.Collection{})
+
+			if err != nil {
+				t.Fatalf("EquivalenceExpression.Evaluate raised unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.wantCollection, got); diff != "" {
+				t.Errorf("EquivalenceExpression.Evaluate returned unexpected diff: (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEquivalenceExpression_RaisesError(t *testing.T) {
+	testCases := []struct {
+		name            string
+		equivalenceExpr *expr.EquivalenceExpression
+	}{
+		{
+			name:            "subexpression one errors",
+			equivalenceExpr: &expr.EquivalenceExpression{exprtest.Error(errMock), exprtest.Return(system.Boolean(true)), false},
+		},
+		{
+			name:            "subexpression two errors",
+			equivalenceExpr: &expr.EquivalenceExpression{exprtest.Return(system.Boolean(true)), exprtest.Error(errMock), false},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.equivalenceExpr.Evaluate(&expr.Context{}, system.Collection{})
+
+			if err == nil {
+				t.Fatalf("EquivalenceExpression.Evaluate didn't propagate error when it should have")
+			}
+		})
+	}
+}
+
 func TestIsExpression_ReturnsResult(t *testing.T) {
 	testCases := []struct {
 		name           string
@@ -1247,6 +1315,60 @@ func TestArithmeticExpression_ReturnsResult(t *testing.T) {
 			},
 			want: system.Collection{system.Decimal(decimal.NewFromFloat(2.5))},
 		},
+		{
+			name: "multiplies quantity by a number",
+			expr: &expr.ArithmeticExpression{
+				Left:  exprtest.Return(system.MustParseQuantity("2.5", "mg")),
+				Right: exprtest.Return(system.Integer(2)),
+				Op:    expr.EvaluateMul,
+			},
+			want: system.Collection{system.MustParseQuantity("5", "mg")},
+		},
+		{
+			name: "multiplies quantities together",
+			expr: &expr.ArithmeticExpression{
+				Left:  exprtest.Return(system.MustParseQuantity("2", "m")),
+				Right: exprtest.Return(system.MustParseQuantity("50", "cm")),
+				Op:    expr.EvaluateMul,
+			},
+			want: system.Collection{system.MustParseQuantity("1", "m2")},
+		},
+		{
+			name: "divides quantities",
+			expr: &expr.ArithmeticExpression{
+				Left:  exprtest.Return(system.MustParseQuantity("10", "km")),
+				Right: exprtest.Return(system.MustParseQuantity("2", "h")),
+				Op:    expr.EvaluateDiv,
+			},
+			want: system.Collection{system.MustParseQuantity("5", "km/h")},
+		},
+		{
+			name: "divides number by quantity",
+			expr: &expr.ArithmeticExpression{
+				Left:  exprtest.Return(system.Integer(1)),
+				Right: exprtest.Return(system.MustParseQuantity("4", "s")),
+				Op:    expr.EvaluateDiv,
+			},
+			want: system.Collection{system.MustParseQuantity("0.25", "/s")},
+		},
+		{
+			name: "returns empty on quantity division by zero",
+			expr: &expr.ArithmeticExpression{
+				Left:  exprtest.Return(system.MustParseQuantity("1", "g")),
+				Right: exprtest.Return(system.MustParseQuantity("0", "L")),
+				Op:    expr.EvaluateDiv,
+			},
+			want: system.Collection{},
+		},
+		{
+			name: "adds a number to a quantity",
+			expr: &expr.ArithmeticExpression{
+				Left:  exprtest.Return(system.Integer(2)),
+				Right: exprtest.Return(system.MustParseQuantity("3", "kg")),
+				Op:    expr.EvaluateAdd,
+			},
+			want: system.Collection{system.MustParseQuantity("5", "kg")},
+		},
 		{
 			name: "performs floor division",
 			expr: &expr.ArithmeticExpression{
@@ -1377,6 +1499,24 @@ func TestExternalConstantExpression(t *testing.T) {
 			},
 			want: system.Collection{system.String("some string")},
 		},
+		{
+			name:    "returns ValueSet URL",
+			expr:    &expr.ExternalConstantExpression{Identifier: "vs-administrative-gender"},
+			context: &expr.Context{},
+			want:    system.Collection{system.String("http://hl7.org/fhir/ValueSet/administrative-gender")},
+		},
+		{
+			name:    "returns extension URL",
+			expr:    &expr.ExternalConstantExpression{Identifier: "ext-patient-birthPlace"},
+			context: &expr.Context{},
+			want:    system.Collection{system.String("http://hl7.org/fhir/StructureDefinition/patient-birthPlace")},
+		},
+		{
+			name:    "returns input resource if containing resource isn't known",
+			expr:    &expr.ExternalConstantExpression{Identifier: "resource"},
+			context: expr.InitializeContext(system.Collection{system.String("input")}),
+			want:    system.Collection{system.String("input")},
+		},
 		{
 			name: "returns error if constant doesn't exist",
 			expr: &expr.ExternalConstantExpression{Identifier: "value"},
@@ -1401,6 +1541,133 @@ func TestExternalConstantExpression(t *testing.T) {
 	}
 }
 
+func TestIterationInvocationExpressions(t *testing.T) {
+	testCases := []struct {
+		name    string
+		expr    expr.Expression
+		context *expr.Context
+		want    system.Collection
+	}{
+		{
+			name:    "returns index",
+			expr:    &expr.IndexInvocationExpression{},
+			context: (&expr.Context{}).WithIndex(2),
+			want:    system.Collection{system.Integer(2)},
+		},
+		{
+			name:    "returns empty index outside of iteration",
+			expr:    &expr.IndexInvocationExpression{},
+			context: &expr.Context{},
+			want:    system.Collection{},
+		},
+		{
+			name:    "returns total",
+			expr:    &expr.TotalInvocationExpression{},
+			context: &expr.Context{Total: system.Collection{system.Integer(3)}},
+			want:    system.Collection{system.Integer(3)},
+		},
+		{
+			name:    "returns empty total outside of aggregation",
+			expr:    &expr.TotalInvocationExpression{},
+			context: &expr.Context{},
+			want:    system.Collection{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.expr.Evaluate(tc.context, system.Collection{})
+
+			if err != nil {
+				t.Fatalf("Expression.Evaluate returned unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Expression.Evaluate returned unexpected diff: (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDefineVariableExpression(t *testing.T) {
+	testCases := []struct {
+		name    string
+		expr    expr.Expression
+		context *expr.Context
+		want    system.Collection
+		wantErr error
+	}{
+		{
+			name: "defines variable with input value",
+			expr: &expr.ExpressionSequence{Expressions: []expr.Expression{
+				&expr.DefineVariableExpression{Name: "value"},
+				&expr.ExternalConstantExpression{Identifier: "value"},
+			}},
+			context: &expr.Context{},
+			want:    system.Collection{system.Integer(1)},
+		},
+		{
+			name: "defines variable with expression value",
+			expr: &expr.ExpressionSequence{Expressions: []expr.Expression{
+				&expr.DefineVariableExpression{Name: "value", Value: exprtest.Return(system.String("some string"))},
+				&expr.ExternalConstantExpression{Identifier: "value"},
+			}},
+			context: &expr.Context{},
+			want:    system.Collection{system.String("some string")},
+		},
+		{
+			name: "returns input unchanged",
+			expr: &expr.DefineVariableExpression{
+				Name:  "value",
+				Value: exprtest.Return(system.String("some string")),
+			},
+			context: &expr.Context{},
+			want:    system.Collection{system.Integer(1)},
+		},
+		{
+			name: "variable defined on a clone is not visible",
+			expr: &expr.ExpressionSequence{Expressions: []expr.Expression{
+				&expr.FunctionExpression{
+					Fn: func(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+						return args[0].Evaluate(ctx, input)
+					},
+					Args: []expr.Expression{&expr.DefineVariableExpression{Name: "value"}},
+				},
+				&expr.ExternalConstantExpression{Identifier: "value"},
+			}},
+			context: &expr.Context{},
+			wantErr: expr.ErrConstantNotFound,
+		},
+		{
+			name:    "returns error if name is an existing constant",
+			expr:    &expr.DefineVariableExpression{Name: "value"},
+			context: &expr.Context{ExternalConstants: map[string]any{"value": system.String("some string")}},
+			wantErr: expr.ErrExistingVariable,
+		},
+		{
+			name: "returns error if value raises error",
+			expr: &expr.DefineVariableExpression{
+				Name:  "value",
+				Value: exprtest.Error(errMock),
+			},
+			context: &expr.Context{},
+			wantErr: errMock,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.expr.Evaluate(tc.context, system.Collection{system.Integer(1)})
+
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("DefineVariableExpression.Evaluate returned unexpected error: got %v, want %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("DefineVariableExpression.Evaluate returned unexpected diff: (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestNegationExpression(t *testing.T) {
 	testCases := []struct {
 		name    string
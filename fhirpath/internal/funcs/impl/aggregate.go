@@ -0,0 +1,39 @@
+package impl
+
+import (
+	"fmt"
+
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/expr"
+	"github.com/verily-src/fhirpath-go/fhirpath/system"
+)
+
+// Aggregate evaluates the aggregator expression args[0] for each input item,
+// with $this set to the item, $index set to its index, and $total set to the
+// result of the previous iteration. The optional args[1] provides the initial
+// value of $total, which is otherwise empty. Returns the result of the final
+// iteration.
+// FHIRPath docs here: https://hl7.org/fhirpath/N1/#aggregateaggregator-expression-init-value-value
+func Aggregate(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, fmt.Errorf("%w: received %v arguments, expected 1 or 2", ErrWrongArity, len(args))
+	}
+	total := system.Collection{}
+	if len(args) == 2 {
+		init, err := args[1].Evaluate(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		total = init
+	}
+	for i, item := range input {
+		iterCtx := ctx.WithIndex(i)
+		iterCtx.Total = total
+
+		output, err := args[0].Evaluate(iterCtx, system.Collection{item})
+		if err != nil {
+			return nil, fmt.Errorf("evaluating aggregator expression resulted in an error: %w", err)
+		}
+		total = output
+	}
+	return total, nil
+}
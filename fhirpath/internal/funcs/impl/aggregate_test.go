@@ -0,0 +1,103 @@
+package impl_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/expr"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/expr/exprtest"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/funcs/impl"
+	"github.com/verily-src/fhirpath-go/fhirpath/system"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestAggregate_Evaluates(t *testing.T) {
+	sum := &expr.ArithmeticExpression{
+		Left:  &expr.TotalInvocationExpression{},
+		Right: &expr.IdentityExpression{},
+		Op:    expr.EvaluateAdd,
+	}
+	numbers := system.Collection{system.Integer(1), system.Integer(2), system.Integer(3)}
+
+	testCases := []struct {
+		name            string
+		inputCollection system.Collection
+		inputArgs       []expr.Expression
+		wantCollection  system.Collection
+	}{
+		{
+			name:            "sums items with initial value",
+			inputCollection: numbers,
+			inputArgs:       []expr.Expression{sum, exprtest.Return(system.Integer(10))},
+			wantCollection:  system.Collection{system.Integer(16)},
+		},
+		{
+			name:            "total is empty without initial value",
+			inputCollection: numbers,
+			inputArgs:       []expr.Expression{sum},
+			wantCollection:  system.Collection{},
+		},
+		{
+			name:            "returns initial value for empty input",
+			inputCollection: system.Collection{},
+			inputArgs:       []expr.Expression{sum, exprtest.Return(system.Integer(0))},
+			wantCollection:  system.Collection{system.Integer(0)},
+		},
+		{
+			name:            "returns last index",
+			inputCollection: numbers,
+			inputArgs:       []expr.Expression{&expr.IndexInvocationExpression{}},
+			wantCollection:  system.Collection{system.Integer(2)},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := impl.Aggregate(&expr.Context{}, tc.inputCollection, tc.inputArgs...)
+			if err != nil {
+				t.Fatalf("Aggregate function returned unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.wantCollection, got, protocmp.Transform()); diff != "" {
+				t.Errorf("Aggregate function returned unexpected diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAggregate_RaisesError(t *testing.T) {
+	testCases := []struct {
+		name            string
+		inputCollection system.Collection
+		inputArgs       []expr.Expression
+	}{
+		{
+			name:            "no arguments",
+			inputCollection: system.Collection{system.Integer(1)},
+			inputArgs:       []expr.Expression{},
+		},
+		{
+			name:            "too many arguments",
+			inputCollection: system.Collection{system.Integer(1)},
+			inputArgs:       []expr.Expression{exprtest.Return(), exprtest.Return(), exprtest.Return()},
+		},
+		{
+			name:            "aggregator raises error",
+			inputCollection: system.Collection{system.Integer(1)},
+			inputArgs:       []expr.Expression{exprtest.Error(errors.New("some error"))},
+		},
+		{
+			name:            "initial value raises error",
+			inputCollection: system.Collection{system.Integer(1)},
+			inputArgs:       []expr.Expression{exprtest.Return(), exprtest.Error(errors.New("some error"))},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := impl.Aggregate(&expr.Context{}, tc.inputCollection, tc.inputArgs...); err == nil {
+				t.Fatalf("evaluating Aggregate function didn't return error when expected")
+			}
+		})
+	}
+}
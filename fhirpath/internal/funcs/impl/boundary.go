@@ -0,0 +1,155 @@
+package impl
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/expr"
+	"github.com/verily-src/fhirpath-go/fhirpath/system"
+)
+
+// Default precisions used by lowBoundary and highBoundary when no precision
+// argument is provided.
+const (
+	defaultDecimalPrecision  = 8
+	defaultDatePrecision     = 8
+	defaultDateTimePrecision = 17
+	defaultTimePrecision     = 9
+)
+
+// Precision returns the number of digits of precision of the input. For
+// Decimals this is the number of digits after the decimal point, and for
+// Dates, DateTimes and Times it is the number of digits in the value.
+// FHIRPath docs here: https://build.fhir.org/ig/HL7/FHIRPath/#precision--integer
+func Precision(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("%w: received %v arguments, expected 0", ErrWrongArity, len(args))
+	}
+	if input.IsEmpty() {
+		return system.Collection{}, nil
+	}
+	if !input.IsSingleton() {
+		return nil, fmt.Errorf("%w: input has %d elements", ErrNotSingleton, len(input))
+	}
+	value, err := system.From(input[0])
+	if err != nil {
+		return nil, err
+	}
+
+	switch value := value.(type) {
+	case system.Integer:
+		return system.Collection{system.Integer(0)}, nil
+	case system.Decimal:
+		return system.Collection{system.Integer(value.Precision())}, nil
+	case system.Date:
+		return system.Collection{system.Integer(value.Precision())}, nil
+	case system.DateTime:
+		return system.Collection{system.Integer(value.Precision())}, nil
+	case system.Time:
+		return system.Collection{system.Integer(value.Precision())}, nil
+	}
+	return nil, fmt.Errorf("%w: precision is not defined for type %T", ErrInvalidInput, value)
+}
+
+// LowBoundary returns the least possible value of the input to the optional
+// precision argument, given the precision of the input. Returns empty if the
+// precision is not valid for the input type.
+// FHIRPath docs here: https://build.fhir.org/ig/HL7/FHIRPath/#lowboundaryprecision-integer-decimal--date--datetime--time
+func LowBoundary(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	return boundary(ctx, input, false, args...)
+}
+
+// HighBoundary returns the greatest possible value of the input to the
+// optional precision argument, given the precision of the input. Returns empty
+// if the precision is not valid for the input type.
+// FHIRPath docs here: https://build.fhir.org/ig/HL7/FHIRPath/#highboundaryprecision-integer-decimal--date--datetime--time
+func HighBoundary(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	return boundary(ctx, input, true, args...)
+}
+
+// boundary computes the low or high boundary of the input, as determined by
+// the high flag.
+func boundary(ctx *expr.Context, input system.Collection, high bool, args ...expr.Expression) (system.Collection, error) {
+	if len(args) > 1 {
+		return nil, fmt.Errorf("%w: received %v arguments, expected 0 or 1", ErrWrongArity, len(args))
+	}
+	if input.IsEmpty() {
+		return system.Collection{}, nil
+	}
+	if !input.IsSingleton() {
+		return nil, fmt.Errorf("%w: input has %d elements", ErrNotSingleton, len(input))
+	}
+	value, err := system.From(input[0])
+	if err != nil {
+		return nil, err
+	}
+	if integer, ok := value.(system.Integer); ok {
+		value = system.Decimal(decimal.NewFromInt32(int32(integer)))
+	}
+
+	precision := -1
+	if len(args) == 1 {
+		output, err := args[0].Evaluate(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		if output.IsEmpty() {
+			return system.Collection{}, nil
+		}
+		if _, ok := output[0].(system.Integer); !ok || !output.IsSingleton() {
+			return nil, fmt.Errorf("%w: precision must be a single integer", ErrInvalidInput)
+		}
+		p, err := output.ToInt32()
+		if err != nil {
+			return nil, err
+		}
+		precision = int(p)
+	}
+
+	var result system.Any
+	var ok bool
+	switch value := value.(type) {
+	case system.Decimal:
+		if len(args) == 0 {
+			precision = defaultDecimalPrecision
+		}
+		if high {
+			result, ok = value.HighBoundary(precision)
+		} else {
+			result, ok = value.LowBoundary(precision)
+		}
+	case system.Date:
+		if len(args) == 0 {
+			precision = defaultDatePrecision
+		}
+		if high {
+			result, ok = value.HighBoundary(precision)
+		} else {
+			result, ok = value.LowBoundary(precision)
+		}
+	case system.DateTime:
+		if len(args) == 0 {
+			precision = defaultDateTimePrecision
+		}
+		if high {
+			result, ok = value.HighBoundary(precision)
+		} else {
+			result, ok = value.LowBoundary(precision)
+		}
+	case system.Time:
+		if len(args) == 0 {
+			precision = defaultTimePrecision
+		}
+		if high {
+			result, ok = value.HighBoundary(precision)
+		} else {
+			result, ok = value.LowBoundary(precision)
+		}
+	default:
+		return nil, fmt.Errorf("%w: boundaries are not defined for type %T", ErrInvalidInput, value)
+	}
+	if !ok {
+		return system.Collection{}, nil
+	}
+	return system.Collection{result}, nil
+}
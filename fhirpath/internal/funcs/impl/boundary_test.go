@@ -0,0 +1,214 @@
+package impl_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/expr"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/expr/exprtest"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/funcs/impl"
+	"github.com/verily-src/fhirpath-go/fhirpath/system"
+	"github.com/verily-src/fhirpath-go/internal/fhir"
+)
+
+func TestPrecision(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   system.Collection
+		args    []expr.Expression
+		want    system.Collection
+		wantErr error
+	}{
+		{
+			name:  "returns empty for empty input",
+			input: system.Collection{},
+			want:  system.Collection{},
+		},
+		{
+			name:  "returns decimal places of decimal",
+			input: system.Collection{system.MustParseDecimal("1.58700")},
+			want:  system.Collection{system.Integer(5)},
+		},
+		{
+			name:  "returns digits of date",
+			input: system.Collection{system.MustParseDate("2014-01")},
+			want:  system.Collection{system.Integer(6)},
+		},
+		{
+			name:  "returns digits of datetime",
+			input: system.Collection{system.MustParseDateTime("2014-01-05T10:30:00.000")},
+			want:  system.Collection{system.Integer(17)},
+		},
+		{
+			name:  "returns digits of time",
+			input: system.Collection{system.MustParseTime("10:30")},
+			want:  system.Collection{system.Integer(4)},
+		},
+		{
+			name:  "converts proto input",
+			input: system.Collection{fhir.Date(time.Date(2014, 1, 5, 0, 0, 0, 0, time.UTC))},
+			want:  system.Collection{system.Integer(8)},
+		},
+		{
+			name:    "errors on unsupported type",
+			input:   system.Collection{system.String("1.5")},
+			wantErr: impl.ErrInvalidInput,
+		},
+		{
+			name:    "errors on non-singleton input",
+			input:   system.Collection{system.Integer(1), system.Integer(2)},
+			wantErr: impl.ErrNotSingleton,
+		},
+		{
+			name:    "errors on arguments",
+			input:   system.Collection{system.Integer(1)},
+			args:    []expr.Expression{exprtest.Return(system.Integer(1))},
+			wantErr: impl.ErrWrongArity,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := impl.Precision(&expr.Context{}, tc.input, tc.args...)
+
+			if !cmp.Equal(err, tc.wantErr, cmpopts.EquateErrors()) {
+				t.Fatalf("Precision got unexpected error: got %v, want %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got, protocmp.Transform()); diff != "" {
+				t.Errorf("Precision returned unexpected result (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBoundaries(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    system.Collection
+		args     []expr.Expression
+		wantLow  system.Collection
+		wantHigh system.Collection
+		wantErr  error
+	}{
+		{
+			name:     "returns empty for empty input",
+			input:    system.Collection{},
+			wantLow:  system.Collection{},
+			wantHigh: system.Collection{},
+		},
+		{
+			name:     "computes decimal boundaries at default precision",
+			input:    system.Collection{system.MustParseDecimal("1.587")},
+			wantLow:  system.Collection{system.MustParseDecimal("1.5865")},
+			wantHigh: system.Collection{system.MustParseDecimal("1.5875")},
+		},
+		{
+			name:     "computes decimal boundaries at given precision",
+			input:    system.Collection{system.MustParseDecimal("1.587")},
+			args:     []expr.Expression{exprtest.Return(system.Integer(2))},
+			wantLow:  system.Collection{system.MustParseDecimal("1.58")},
+			wantHigh: system.Collection{system.MustParseDecimal("1.59")},
+		},
+		{
+			name:     "treats integers as decimals",
+			input:    system.Collection{system.Integer(1)},
+			wantLow:  system.Collection{system.MustParseDecimal("0.5")},
+			wantHigh: system.Collection{system.MustParseDecimal("1.5")},
+		},
+		{
+			name:     "computes date boundaries at default precision",
+			input:    system.Collection{system.MustParseDate("2020-03")},
+			wantLow:  system.Collection{system.MustParseDate("2020-03-01")},
+			wantHigh: system.Collection{system.MustParseDate("2020-03-31")},
+		},
+		{
+			name:     "computes datetime boundaries at default precision",
+			input:    system.Collection{system.MustParseDateTime("2020-03T")},
+			wantLow:  system.Collection{system.MustParseDateTime("2020-03-01T00:00:00.000+14:00")},
+			wantHigh: system.Collection{system.MustParseDateTime("2020-03-31T23:59:59.999-12:00")},
+		},
+		{
+			name:     "computes time boundaries at given precision",
+			input:    system.Collection{system.MustParseTime("10")},
+			args:     []expr.Expression{exprtest.Return(system.Integer(4))},
+			wantLow:  system.Collection{system.MustParseTime("10:00")},
+			wantHigh: system.Collection{system.MustParseTime("10:59")},
+		},
+		{
+			name:     "converts proto input",
+			input:    system.Collection{fhir.Decimal(1.5)},
+			args:     []expr.Expression{exprtest.Return(system.Integer(2))},
+			wantLow:  system.Collection{system.MustParseDecimal("1.45")},
+			wantHigh: system.Collection{system.MustParseDecimal("1.55")},
+		},
+		{
+			name:     "returns empty for invalid precision",
+			input:    system.Collection{system.MustParseDate("2020")},
+			args:     []expr.Expression{exprtest.Return(system.Integer(5))},
+			wantLow:  system.Collection{},
+			wantHigh: system.Collection{},
+		},
+		{
+			name:     "returns empty for empty precision",
+			input:    system.Collection{system.MustParseDate("2020")},
+			args:     []expr.Expression{exprtest.Return()},
+			wantLow:  system.Collection{},
+			wantHigh: system.Collection{},
+		},
+		{
+			name:    "errors on non-integer precision",
+			input:   system.Collection{system.MustParseDate("2020")},
+			args:    []expr.Expression{exprtest.Return(system.String("8"))},
+			wantErr: impl.ErrInvalidInput,
+		},
+		{
+			name:    "errors on unsupported type",
+			input:   system.Collection{system.String("2020")},
+			wantErr: impl.ErrInvalidInput,
+		},
+		{
+			name:    "errors on non-singleton input",
+			input:   system.Collection{system.Integer(1), system.Integer(2)},
+			wantErr: impl.ErrNotSingleton,
+		},
+		{
+			name:  "errors on too many arguments",
+			input: system.Collection{system.Integer(1)},
+			args: []expr.Expression{
+				exprtest.Return(system.Integer(1)),
+				exprtest.Return(system.Integer(1)),
+			},
+			wantErr: impl.ErrWrongArity,
+		},
+		{
+			name:    "propagates argument error",
+			input:   system.Collection{system.Integer(1)},
+			args:    []expr.Expression{exprtest.Error(impl.ErrInvalidInput)},
+			wantErr: impl.ErrInvalidInput,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gotLow, err := impl.LowBoundary(&expr.Context{}, tc.input, tc.args...)
+			if !cmp.Equal(err, tc.wantErr, cmpopts.EquateErrors()) {
+				t.Fatalf("LowBoundary got unexpected error: got %v, want %v", err, tc.wantErr)
+			}
+			gotHigh, err := impl.HighBoundary(&expr.Context{}, tc.input, tc.args...)
+			if !cmp.Equal(err, tc.wantErr, cmpopts.EquateErrors()) {
+				t.Fatalf("HighBoundary got unexpected error: got %v, want %v", err, tc.wantErr)
+			}
+
+			if diff := cmp.Diff(tc.wantLow, gotLow, protocmp.Transform()); diff != "" {
+				t.Errorf("LowBoundary returned unexpected result (-want, +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantHigh, gotHigh, protocmp.Transform()); diff != "" {
+				t.Errorf("HighBoundary returned unexpected result (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -2,10 +2,35 @@
 package impl
 
 import (
+	"fmt"
+
 	"github.com/verily-src/fhirpath-go/fhirpath/internal/expr"
 	"github.com/verily-src/fhirpath-go/fhirpath/system"
 )
 
+// Union merges the input and other collections into a single collection,
+// eliminating any duplicate values as determined by the equals (=) operation.
+// There is no expectation of order in the resulting collection.
+// FHIRPath docs here: https://hl7.org/fhirpath/N1/#unionother-collection
+func Union(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("%w: received %v arguments, expected 1", ErrWrongArity, len(args))
+	}
+	other, err := args[0].Evaluate(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	result := system.Collection{}
+	for _, collection := range []system.Collection{input, other} {
+		for _, item := range collection {
+			if !result.Contains(item) {
+				result = append(result, item)
+			}
+		}
+	}
+	return result, nil
+}
+
 // Combine merges input and other collections into a single collection without eliminating duplicate values.
 // Combining an empty collection with a non-empty collection will return the non-empty collection.
 // There is no expectation of order in the resulting collection.
@@ -1,6 +1,7 @@
 package impl
 
 import (
+	"errors"
 	"math"
 	"testing"
 
@@ -91,3 +92,83 @@ func TestCombine(t *testing.T) {
 		})
 	}
 }
+
+func TestUnion(t *testing.T) {
+	ctx := &expr.Context{}
+
+	testCases := []struct {
+		name     string
+		input    system.Collection
+		args     []expr.Expression
+		expected system.Collection
+	}{
+		{
+			name:     "Union two disjoint collections",
+			input:    system.Collection{system.Integer(1), system.Integer(2)},
+			args:     []expr.Expression{exprtest.Return(system.Integer(3))},
+			expected: system.Collection{system.Integer(1), system.Integer(2), system.Integer(3)},
+		},
+		{
+			name:     "Union removes duplicates across collections",
+			input:    system.Collection{system.Integer(1), system.Integer(2)},
+			args:     []expr.Expression{exprtest.Return(system.Integer(2), system.Integer(3))},
+			expected: system.Collection{system.Integer(1), system.Integer(2), system.Integer(3)},
+		},
+		{
+			name:     "Union removes duplicates within input",
+			input:    system.Collection{system.Integer(1), system.Integer(1)},
+			args:     []expr.Expression{exprtest.Return()},
+			expected: system.Collection{system.Integer(1)},
+		},
+		{
+			name:     "Union of empty collections",
+			input:    system.Collection{},
+			args:     []expr.Expression{exprtest.Return()},
+			expected: system.Collection{},
+		},
+		{
+			name:     "Union FHIR elements with system types",
+			input:    system.Collection{fhir.String("a"), fhir.ID("123")},
+			args:     []expr.Expression{exprtest.Return(system.String("a"), system.String("b"))},
+			expected: system.Collection{fhir.String("a"), fhir.ID("123"), system.String("b")},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Union(ctx, tc.input, tc.args...)
+			if err != nil {
+				t.Fatalf("Union() returned an error: %v", err)
+			}
+			if !cmp.Equal(result, tc.expected, protocmp.Transform()) {
+				t.Errorf("Union() result diff (-want, +got):\n%s", cmp.Diff(tc.expected, result, protocmp.Transform()))
+			}
+		})
+	}
+}
+
+func TestUnion_Errors(t *testing.T) {
+	ctx := &expr.Context{}
+
+	testCases := []struct {
+		name string
+		args []expr.Expression
+	}{
+		{
+			name: "Union with no arguments",
+			args: []expr.Expression{},
+		},
+		{
+			name: "Union with argument error",
+			args: []expr.Expression{exprtest.Error(errors.New("some error"))},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := Union(ctx, system.Collection{system.Integer(1)}, tc.args...); err == nil {
+				t.Errorf("Union() didn't return an error when expected")
+			}
+		})
+	}
+}
@@ -3,11 +3,8 @@ package impl
 import (
 	"errors"
 	"fmt"
-	"math"
 	"regexp"
 	"strconv"
-	"strings"
-	"time"
 
 	"github.com/verily-src/fhirpath-go/fhirpath/internal/expr"
 	"github.com/verily-src/fhirpath-go/fhirpath/system"
@@ -135,6 +132,28 @@ func ConvertsToInteger(ctx *expr.Context, input system.Collection, args ...expr.
 	return system.Collection{system.Boolean(true)}, nil
 }
 
+// ConvertsToLong checks if the input can be converted to a Long
+// FHIRPath docs here: https://build.fhir.org/ig/HL7/FHIRPath/#convertstolong-boolean
+func ConvertsToLong(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	// Input validation
+	if input.IsEmpty() {
+		return system.Collection{}, nil
+	}
+	if !input.IsSingleton() {
+		return nil, errors.New("invalid input, is not a singleton")
+	}
+	// Argument validation
+	if len(args) != 0 {
+		return nil, fmt.Errorf("%w: received %v arguments, expected 0", ErrWrongArity, len(args))
+	}
+	// Conversion validation
+	result, err := ToLong(ctx, input, args...)
+	if result.IsEmpty() || err != nil {
+		return system.Collection{system.Boolean(false)}, nil
+	}
+	return system.Collection{system.Boolean(true)}, nil
+}
+
 // ConvertsToQuantity checks if the input can be converted to a Quantity
 // FHIRPath docs here: https://hl7.org/fhirpath/N1/#convertstoquantityunit-string-boolean
 func ConvertsToQuantity(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
@@ -231,7 +250,7 @@ func ToBoolean(ctx *expr.Context, input system.Collection, args ...expr.Expressi
 			return system.Collection{}, nil
 		}
 		return system.Collection{result}, nil
-	case system.Integer, system.String:
+	case system.Integer, system.Long, system.String:
 		str := fmt.Sprintf("%v", value)
 		result, err := system.ParseBoolean(str)
 		if err != nil {
@@ -339,7 +358,7 @@ func ToDecimal(ctx *expr.Context, input system.Collection, args ...expr.Expressi
 	switch value.(type) {
 	case system.Decimal:
 		return system.Collection{value}, nil
-	case system.Integer:
+	case system.Integer, system.Long:
 		str := fmt.Sprintf("%v", value)
 		result, err := system.ParseDecimal(str)
 		if err != nil {
@@ -385,6 +404,12 @@ func ToInteger(ctx *expr.Context, input system.Collection, args ...expr.Expressi
 	switch value.(type) {
 	case system.Integer:
 		return system.Collection{value}, nil
+	case system.Long:
+		result, ok := value.(system.Long).ToInteger()
+		if !ok {
+			return system.Collection{}, nil
+		}
+		return system.Collection{result}, nil
 	case system.String:
 		str := fmt.Sprintf("%s", value)
 		result, err := system.ParseInteger(str)
@@ -401,6 +426,46 @@ func ToInteger(ctx *expr.Context, input system.Collection, args ...expr.Expressi
 	return system.Collection{}, nil
 }
 
+// ToLong converts the input to a Long
+// FHIRPath docs here: https://build.fhir.org/ig/HL7/FHIRPath/#tolong-long
+func ToLong(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	// Input validation
+	if input.IsEmpty() {
+		return system.Collection{}, nil
+	}
+	if !input.IsSingleton() {
+		return nil, errors.New("invalid input, is not a singleton")
+	}
+	// Argument validation
+	if len(args) != 0 {
+		return nil, fmt.Errorf("%w: received %v arguments, expected 0", ErrWrongArity, len(args))
+	}
+	// Input reading
+	value, err := system.From(input[0])
+	if err != nil {
+		return nil, err
+	}
+	// Input conversion
+	switch value := value.(type) {
+	case system.Long:
+		return system.Collection{value}, nil
+	case system.Integer:
+		return system.Collection{system.Long(value)}, nil
+	case system.String:
+		result, err := strconv.ParseInt(string(value), 10, 64)
+		if err != nil {
+			return system.Collection{}, nil
+		}
+		return system.Collection{system.Long(result)}, nil
+	case system.Boolean:
+		if value {
+			return system.Collection{system.Long(1)}, nil
+		}
+		return system.Collection{system.Long(0)}, nil
+	}
+	return system.Collection{}, nil
+}
+
 // ToQuantity converts the input to a Quantity
 // FHIRPath docs here: https://hl7.org/fhirpath/N1/#toquantityunit-string-quantity
 func ToQuantity(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
@@ -425,72 +490,73 @@ func ToQuantity(ctx *expr.Context, input system.Collection, args ...expr.Express
 		if err != nil {
 			return nil, err
 		}
+		if err := system.ValidateUnit(argStr); err != nil {
+			return nil, err
+		}
 	}
 	// Input reading
 	value, err := system.From(input[0])
 	if err != nil {
 		return nil, err
 	}
-	// Input conversion
+	// Input conversion. Inputs without a unit take the unit argument, or the
+	// default unit; quantities are converted to the unit argument.
+	unit := DefaultQuantityUnit
+	if argStr != "" {
+		unit = argStr
+	}
+	var quantity system.Quantity
 	switch value := value.(type) {
-	case system.Integer:
-		matches := regex.FindStringSubmatch(fmt.Sprintf("%v %v", value, argStr))
-		if matches == nil {
-			return system.Collection{}, nil
-		}
-		if argStr != "" {
-			unit := regex.SubexpIndex("unit")
-			t := regex.SubexpIndex("time")
-			if matches[unit] != "" {
-				result := system.MustParseQuantity(fmt.Sprintf("%v", value), matches[unit])
-				return system.Collection{result}, nil
-			}
-			if matches[t] != "" {
-				result := system.MustParseQuantity(fmt.Sprintf("%v", value), matches[t])
-				return system.Collection{result}, nil
-			}
-		}
-		result := system.MustParseQuantity(fmt.Sprintf("%v", value), DefaultQuantityUnit)
-		return system.Collection{result}, nil
+	case system.Integer, system.Long:
+		quantity, err = system.ParseQuantity(fmt.Sprintf("%v", value), unit)
 	case system.Decimal:
-		str := value.String()
-		result, err := system.ParseQuantity(string(str), DefaultQuantityUnit)
-		if err != nil {
-			return nil, err
-		}
-		return system.Collection{result}, nil
+		quantity, err = system.ParseQuantity(value.String(), unit)
 	case system.Quantity:
-		return system.Collection{value}, nil
+		quantity = value
 	case system.String:
-		matches := regex.FindStringSubmatch(string(value))
-		if matches == nil {
+		number, stringUnit, ok := parseQuantityString(string(value))
+		if !ok {
 			return system.Collection{}, nil
 		}
-		if argStr != "" {
-			if !isValidUnitConversion(argStr) {
-				return nil, fmt.Errorf("invalid unit of time: %v", input)
-			}
-			conversion, err := convertDuration(string(value), argStr)
-			if err != nil {
-				return nil, err
-			}
-			res := strings.SplitN(conversion, " ", 2)
-			result := system.MustParseQuantity(res[0], res[1])
-			return system.Collection{result}, nil
+		if stringUnit != "" {
+			unit = stringUnit
 		}
-		res := strings.SplitN(string(value), " ", 2)
-		unit := strings.Trim(res[1], "'")
-		result := system.MustParseQuantity(res[0], unit)
-		return system.Collection{result}, nil
+		quantity, err = system.ParseQuantity(number, unit)
 	case system.Boolean:
 		if value {
-			result := system.MustParseQuantity("1.0", DefaultQuantityUnit)
-			return system.Collection{result}, nil
+			quantity, err = system.ParseQuantity("1.0", unit)
+		} else {
+			quantity, err = system.ParseQuantity("0.0", unit)
 		}
-		result := system.MustParseQuantity("0.0", DefaultQuantityUnit)
-		return system.Collection{result}, nil
+	default:
+		return system.Collection{}, nil
 	}
-	return system.Collection{}, nil
+	if err != nil {
+		return nil, err
+	}
+	if argStr == "" {
+		return system.Collection{quantity}, nil
+	}
+	result, err := quantity.ToUnit(argStr)
+	if err != nil {
+		return system.Collection{}, nil
+	}
+	return system.Collection{result}, nil
+}
+
+// parseQuantityString returns the number and unit of a string in the FHIRPath
+// quantity format, such as "4 'mg'" or "2 days". The unit is empty if the
+// string holds only a number. Returns false if the string isn't a quantity.
+func parseQuantityString(input string) (string, string, bool) {
+	matches := regex.FindStringSubmatch(input)
+	if matches == nil {
+		return "", "", false
+	}
+	unit := matches[regex.SubexpIndex("unit")]
+	if unit == "" {
+		unit = matches[regex.SubexpIndex("time")]
+	}
+	return matches[regex.SubexpIndex("value")], unit, true
 }
 
 // ToString converts the input to a String
@@ -516,7 +582,7 @@ func ToString(ctx *expr.Context, input system.Collection, args ...expr.Expressio
 	switch value := value.(type) {
 	case system.String:
 		return system.Collection{value}, nil
-	case system.Integer:
+	case system.Integer, system.Long:
 		return system.Collection{system.String(fmt.Sprintf("%v", value))}, nil
 	case system.Decimal:
 		return system.Collection{system.String(value.String())}, nil
@@ -607,78 +673,3 @@ func Iif(ctx *expr.Context, input system.Collection, args ...expr.Expression) (s
 	// Return the true-result collection
 	return args[1].Evaluate(ctx, input)
 }
-
-func isValidUnitConversion(outputFormat string) bool {
-	validFormats := map[string]bool{
-		"years":   true,
-		"months":  true,
-		"days":    true,
-		"hours":   true,
-		"minutes": true,
-		"seconds": true,
-	}
-
-	return validFormats[outputFormat]
-}
-
-func convertDuration(input string, outputFormat string) (string, error) {
-	duration, err := parseHumanDuration(input)
-	if err != nil {
-		return "", err
-	}
-
-	var convertedValue float64
-	switch outputFormat {
-	case "years":
-		convertedValue = duration.Hours() / (24 * 365)
-	case "months":
-		convertedValue = duration.Hours() / (24 * 30)
-	case "days":
-		convertedValue = duration.Hours() / 24
-	case "hours":
-		convertedValue = duration.Hours()
-	case "minutes":
-		convertedValue = duration.Minutes()
-	case "seconds":
-		convertedValue = duration.Seconds()
-	}
-
-	if outputFormat == "years" {
-		convertedValue = math.Ceil(convertedValue / 12.0)
-	}
-
-	return fmt.Sprintf("%.0f %s", convertedValue, outputFormat), nil
-}
-
-func parseHumanDuration(input string) (time.Duration, error) {
-	re := regexp.MustCompile(`(\d+)\s*(\w+)`)
-	matches := re.FindAllStringSubmatch(input, -1)
-	totalSeconds := int64(0)
-
-	for _, match := range matches {
-		value, err := strconv.ParseInt(match[1], 10, 64)
-		if err != nil {
-			return 0, err
-		}
-
-		unit := strings.ToLower(match[2])
-		switch unit {
-		case "second", "seconds":
-			totalSeconds += value
-		case "minute", "minutes":
-			totalSeconds += value * 60
-		case "hour", "hours":
-			totalSeconds += value * 3600
-		case "day", "days":
-			totalSeconds += value * 86400
-		case "month", "months":
-			totalSeconds += value * 30 * 86400 // Assuming one month is 30 days
-		case "year", "years":
-			totalSeconds += value * 365 * 86400 // Assuming one year is 365 days
-		default:
-			return 0, fmt.Errorf("invalid unit: %s", unit)
-		}
-	}
-
-	return time.Duration(totalSeconds) * time.Second, nil
-}
@@ -660,6 +660,95 @@ func TestConvertsToInteger(t *testing.T) {
 	}
 }
 
+func TestConvertsToLong(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   system.Collection
+		args    []expr.Expression
+		want    system.Collection
+		wantErr bool
+	}{
+		{
+			name: "errors if input length is more than 1",
+			input: system.Collection{
+				system.String("101"),
+				system.String("102")},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:  "errors if args length is more than 0",
+			input: system.Collection{system.String("100")},
+			args: []expr.Expression{
+				exprtest.Return(system.String("200")),
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "returns an empty collection if input is empty",
+			input:   system.Collection{},
+			want:    system.Collection{},
+			wantErr: false,
+		},
+		{
+			name:    "input is system.Long '1700000000000'",
+			input:   system.Collection{system.Long(1700000000000)},
+			want:    system.Collection{system.Boolean(true)},
+			wantErr: false,
+		},
+		{
+			name:    "input is system.Integer '13'",
+			input:   system.Collection{system.Integer(13)},
+			want:    system.Collection{system.Boolean(true)},
+			wantErr: false,
+		},
+		{
+			name:    "input is system.String '9223372036854775807'",
+			input:   system.Collection{system.String("9223372036854775807")},
+			want:    system.Collection{system.Boolean(true)},
+			wantErr: false,
+		},
+		{
+			name:    "input is system.String '9223372036854775808'",
+			input:   system.Collection{system.String("9223372036854775808")},
+			want:    system.Collection{system.Boolean(false)},
+			wantErr: false,
+		},
+		{
+			name:    "input is system.String '12L'",
+			input:   system.Collection{system.String("12L")},
+			want:    system.Collection{system.Boolean(false)},
+			wantErr: false,
+		},
+		{
+			name:    "input is system.Decimal '1.5'",
+			input:   system.Collection{system.MustParseDecimal("1.5")},
+			want:    system.Collection{system.Boolean(false)},
+			wantErr: false,
+		},
+		{
+			name:    "input is fhir.Boolean 'true'",
+			input:   system.Collection{fhir.Boolean(true)},
+			want:    system.Collection{system.Boolean(true)},
+			wantErr: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := impl.ConvertsToLong(&expr.Context{}, tc.input, tc.args...)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("ConvertsToLong() error = %v, wantErr %v", err, tc.wantErr)
+				return
+			}
+			if diff := cmp.Diff(tc.want, got, protocmp.Transform()); diff != "" {
+				t.Errorf("ConvertsToLong() returned unexpected diff (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestConvertsToQuantity(t *testing.T) {
 	testCases := []struct {
 		name    string
@@ -785,7 +874,7 @@ func TestConvertsToQuantity(t *testing.T) {
 			args: []expr.Expression{
 				exprtest.Return(system.String("days")),
 			},
-			want:    system.Collection{system.Boolean(true)},
+			want:    system.Collection{system.Boolean(false)},
 			wantErr: false,
 		},
 		{
@@ -827,6 +916,15 @@ func TestConvertsToQuantity(t *testing.T) {
 			args: []expr.Expression{
 				exprtest.Return(system.String("'km'")),
 			},
+			want:    system.Collection{system.Boolean(false)},
+			wantErr: false,
+		},
+		{
+			name:  "input is system.Integer '100' with arg 'km'",
+			input: system.Collection{system.Integer(100)},
+			args: []expr.Expression{
+				exprtest.Return(system.String("km")),
+			},
 			want:    system.Collection{system.Boolean(true)},
 			wantErr: false,
 		},
@@ -1658,6 +1756,18 @@ func TestToInteger(t *testing.T) {
 			want:    system.Collection{system.Integer(12)},
 			wantErr: false,
 		},
+		{
+			name:    "input is system.Long '12'",
+			input:   system.Collection{system.Long(12)},
+			want:    system.Collection{system.Integer(12)},
+			wantErr: false,
+		},
+		{
+			name:    "returns an empty collection if system.Long is out of range",
+			input:   system.Collection{system.Long(4294967296)},
+			want:    system.Collection{},
+			wantErr: false,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -1674,6 +1784,95 @@ func TestToInteger(t *testing.T) {
 	}
 }
 
+func TestToLong(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   system.Collection
+		args    []expr.Expression
+		want    system.Collection
+		wantErr bool
+	}{
+		{
+			name: "errors if input length is more than 1",
+			input: system.Collection{
+				system.String("101"),
+				system.String("102")},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:  "errors if args length is more than 0",
+			input: system.Collection{system.String("100")},
+			args: []expr.Expression{
+				exprtest.Return(system.String("200")),
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "returns an empty collection if input is empty",
+			input:   system.Collection{},
+			want:    system.Collection{},
+			wantErr: false,
+		},
+		{
+			name:    "returns an empty collection if input is not convertible",
+			input:   system.Collection{system.String("404 Kg")},
+			want:    system.Collection{},
+			wantErr: false,
+		},
+		{
+			name:    "input is system.Long '1700000000000'",
+			input:   system.Collection{system.Long(1700000000000)},
+			want:    system.Collection{system.Long(1700000000000)},
+			wantErr: false,
+		},
+		{
+			name:    "input is system.Integer '13'",
+			input:   system.Collection{system.Integer(13)},
+			want:    system.Collection{system.Long(13)},
+			wantErr: false,
+		},
+		{
+			name:    "input is system.String '-4294967296'",
+			input:   system.Collection{system.String("-4294967296")},
+			want:    system.Collection{system.Long(-4294967296)},
+			wantErr: false,
+		},
+		{
+			name:    "input is system.Boolean 'true'",
+			input:   system.Collection{system.Boolean(true)},
+			want:    system.Collection{system.Long(1)},
+			wantErr: false,
+		},
+		{
+			name:    "input is system.Boolean 'false'",
+			input:   system.Collection{system.Boolean(false)},
+			want:    system.Collection{system.Long(0)},
+			wantErr: false,
+		},
+		{
+			name:    "input is fhir.UnsignedInt '12'",
+			input:   system.Collection{fhir.UnsignedInt(12)},
+			want:    system.Collection{system.Long(12)},
+			wantErr: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := impl.ToLong(&expr.Context{}, tc.input, tc.args...)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("ToLong() error = %v, wantErr %v", err, tc.wantErr)
+				return
+			}
+			if diff := cmp.Diff(tc.want, got, protocmp.Transform()); diff != "" {
+				t.Errorf("ToLong() returned unexpected diff (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestToQuantity(t *testing.T) {
 	testCases := []struct {
 		name    string
@@ -1739,6 +1938,33 @@ func TestToQuantity(t *testing.T) {
 			want:    system.Collection{system.MustParseQuantity("13.5", "lbs")},
 			wantErr: false,
 		},
+		{
+			name:  "input is system.Quantity '1.5 kg' with arg 'g'",
+			input: system.Collection{system.MustParseQuantity("1.5", "kg")},
+			args: []expr.Expression{
+				exprtest.Return(system.String("g")),
+			},
+			want:    system.Collection{system.MustParseQuantity("1500", "g")},
+			wantErr: false,
+		},
+		{
+			name:  "input is system.Quantity '5 mg/dL' with arg 'mg/L'",
+			input: system.Collection{system.MustParseQuantity("5", "mg/dL")},
+			args: []expr.Expression{
+				exprtest.Return(system.String("mg/L")),
+			},
+			want:    system.Collection{system.MustParseQuantity("50", "mg/L")},
+			wantErr: false,
+		},
+		{
+			name:  "input is system.Quantity '1 kg' with incommensurable arg 'L'",
+			input: system.Collection{system.MustParseQuantity("1", "kg")},
+			args: []expr.Expression{
+				exprtest.Return(system.String("L")),
+			},
+			want:    system.Collection{},
+			wantErr: false,
+		},
 		{
 			name:    "input is system.String '100 days'",
 			input:   system.Collection{system.String("100 days")},
@@ -1778,7 +2004,7 @@ func TestToQuantity(t *testing.T) {
 		{
 			name:    "input is system.String '100           km'",
 			input:   system.Collection{system.String("100           km")},
-			want:    system.Collection{system.MustParseQuantity("100", "          km")},
+			want:    system.Collection{system.MustParseQuantity("100", "km")},
 			wantErr: false,
 		},
 		{
@@ -1808,7 +2034,43 @@ func TestToQuantity(t *testing.T) {
 			args: []expr.Expression{
 				exprtest.Return(system.String("days")),
 			},
-			want:    system.Collection{system.MustParseQuantity("60", "days")},
+			want:    system.Collection{},
+			wantErr: false,
+		},
+		{
+			name:  "input is system.String '1 mg' with arg 'g'",
+			input: system.Collection{system.String("1 mg")},
+			args: []expr.Expression{
+				exprtest.Return(system.String("g")),
+			},
+			want:    system.Collection{system.MustParseQuantity("0.001", "g")},
+			wantErr: false,
+		},
+		{
+			name:  "input is system.String '5 'mg/dL'' with arg 'mg/L'",
+			input: system.Collection{system.String("5 'mg/dL'")},
+			args: []expr.Expression{
+				exprtest.Return(system.String("mg/L")),
+			},
+			want:    system.Collection{system.MustParseQuantity("50", "mg/L")},
+			wantErr: false,
+		},
+		{
+			name:  "input is system.String '1' with arg 'mg'",
+			input: system.Collection{system.String("1")},
+			args: []expr.Expression{
+				exprtest.Return(system.String("mg")),
+			},
+			want:    system.Collection{system.MustParseQuantity("1", "mg")},
+			wantErr: false,
+		},
+		{
+			name:  "input is system.String '1 kg' with incommensurable arg 'L'",
+			input: system.Collection{system.String("1 kg")},
+			args: []expr.Expression{
+				exprtest.Return(system.String("L")),
+			},
+			want:    system.Collection{},
 			wantErr: false,
 		},
 		{
@@ -1850,9 +2112,27 @@ func TestToQuantity(t *testing.T) {
 			args: []expr.Expression{
 				exprtest.Return(system.String("'km'")),
 			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:  "input is system.Integer '100' with arg 'km'",
+			input: system.Collection{system.Integer(100)},
+			args: []expr.Expression{
+				exprtest.Return(system.String("km")),
+			},
 			want:    system.Collection{system.MustParseQuantity("100", "km")},
 			wantErr: false,
 		},
+		{
+			name:  "errors if arg is not a valid unit",
+			input: system.Collection{system.MustParseQuantity("1", "kg")},
+			args: []expr.Expression{
+				exprtest.Return(system.String("foo")),
+			},
+			want:    nil,
+			wantErr: true,
+		},
 		{
 			name:  "input is system.Integer '100' with arg 'days''",
 			input: system.Collection{system.Integer(100)},
@@ -0,0 +1,250 @@
+package impl
+
+import (
+	"fmt"
+
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/expr"
+	"github.com/verily-src/fhirpath-go/fhirpath/system"
+)
+
+// YearOf returns the year component of the input Date or DateTime.
+// FHIRPath docs here: https://build.fhir.org/ig/HL7/FHIRPath/#yearof--integer
+func YearOf(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	return componentOf(input, system.YearComponent, args...)
+}
+
+// MonthOf returns the month component of the input Date or DateTime.
+// FHIRPath docs here: https://build.fhir.org/ig/HL7/FHIRPath/#monthof--integer
+func MonthOf(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	return componentOf(input, system.MonthComponent, args...)
+}
+
+// DayOf returns the day component of the input Date or DateTime.
+// FHIRPath docs here: https://build.fhir.org/ig/HL7/FHIRPath/#dayof--integer
+func DayOf(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	return componentOf(input, system.DayComponent, args...)
+}
+
+// HourOf returns the hour component of the input DateTime or Time.
+// FHIRPath docs here: https://build.fhir.org/ig/HL7/FHIRPath/#hourof--integer
+func HourOf(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	return componentOf(input, system.HourComponent, args...)
+}
+
+// MinuteOf returns the minute component of the input DateTime or Time.
+// FHIRPath docs here: https://build.fhir.org/ig/HL7/FHIRPath/#minuteof--integer
+func MinuteOf(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	return componentOf(input, system.MinuteComponent, args...)
+}
+
+// SecondOf returns the second component of the input DateTime or Time.
+// FHIRPath docs here: https://build.fhir.org/ig/HL7/FHIRPath/#secondof--integer
+func SecondOf(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	return componentOf(input, system.SecondComponent, args...)
+}
+
+// MillisecondOf returns the millisecond component of the input DateTime or Time.
+// FHIRPath docs here: https://build.fhir.org/ig/HL7/FHIRPath/#millisecondof--integer
+func MillisecondOf(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	return componentOf(input, system.MillisecondComponent, args...)
+}
+
+// TimezoneOffsetOf returns the time zone offset of the input DateTime, in
+// hours. Returns empty if the input doesn't have a time zone.
+// FHIRPath docs here: https://build.fhir.org/ig/HL7/FHIRPath/#timezoneoffsetof--decimal
+func TimezoneOffsetOf(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	value, err := temporalInput(input, args...)
+	if err != nil {
+		return nil, err
+	} else if value == nil {
+		return system.Collection{}, nil
+	}
+	switch value := value.(type) {
+	case system.DateTime:
+		if offset, ok := value.TimezoneOffset(); ok {
+			return system.Collection{offset}, nil
+		}
+	case system.Date:
+	default:
+		return nil, fmt.Errorf("%w: timezoneOffsetOf is not defined for type %T", ErrInvalidInput, value)
+	}
+	return system.Collection{}, nil
+}
+
+// DateOf returns the date component of the input Date or DateTime.
+// FHIRPath docs here: https://build.fhir.org/ig/HL7/FHIRPath/#dateof--date
+func DateOf(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	value, err := temporalInput(input, args...)
+	if err != nil {
+		return nil, err
+	} else if value == nil {
+		return system.Collection{}, nil
+	}
+	switch value := value.(type) {
+	case system.DateTime:
+		return system.Collection{value.Date()}, nil
+	case system.Date:
+		return system.Collection{value}, nil
+	}
+	return nil, fmt.Errorf("%w: dateOf is not defined for type %T", ErrInvalidInput, value)
+}
+
+// TimeOf returns the time component of the input DateTime. Returns empty if
+// the input doesn't have a time component.
+// FHIRPath docs here: https://build.fhir.org/ig/HL7/FHIRPath/#timeof--time
+func TimeOf(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	value, err := temporalInput(input, args...)
+	if err != nil {
+		return nil, err
+	} else if value == nil {
+		return system.Collection{}, nil
+	}
+	switch value := value.(type) {
+	case system.DateTime:
+		if t, ok := value.Time(); ok {
+			return system.Collection{t}, nil
+		}
+		return system.Collection{}, nil
+	case system.Time:
+		return system.Collection{value}, nil
+	}
+	return nil, fmt.Errorf("%w: timeOf is not defined for type %T", ErrInvalidInput, value)
+}
+
+// Duration returns the number of whole calendar periods of the given
+// precision between the input and the given value, as a Quantity with a
+// calendar duration unit. The result is negative if the value is before the
+// input. Returns empty if either value isn't precise enough.
+// FHIRPath docs here: https://build.fhir.org/ig/HL7/FHIRPath/#durationvalue-t-precision-string--integer
+func Duration(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	return calendarDuration(ctx, input, true, args...)
+}
+
+// Difference returns the number of calendar period boundaries of the given
+// precision crossed between the input and the given value, as a Quantity with
+// a calendar duration unit. The result is negative if the value is before the
+// input. Returns empty if either value isn't precise enough.
+// FHIRPath docs here: https://build.fhir.org/ig/HL7/FHIRPath/#differencevalue-t-precision-string--integer
+func Difference(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	return calendarDuration(ctx, input, false, args...)
+}
+
+// componentOf returns the given component of the singleton temporal input.
+// Returns empty if the input doesn't have the component.
+func componentOf(input system.Collection, c system.Component, args ...expr.Expression) (system.Collection, error) {
+	value, err := temporalInput(input, args...)
+	if err != nil {
+		return nil, err
+	} else if value == nil {
+		return system.Collection{}, nil
+	}
+
+	var result system.Integer
+	var ok bool
+	switch value := value.(type) {
+	case system.Date:
+		result, ok = value.Component(c)
+	case system.DateTime:
+		result, ok = value.Component(c)
+	case system.Time:
+		result, ok = value.Component(c)
+	default:
+		return nil, fmt.Errorf("%w: cannot get component of type %T", ErrInvalidInput, value)
+	}
+	if !ok {
+		return system.Collection{}, nil
+	}
+	return system.Collection{result}, nil
+}
+
+// temporalInput validates that no arguments were provided, and returns the
+// system value of the singleton input. Returns nil if the input is empty.
+func temporalInput(input system.Collection, args ...expr.Expression) (system.Any, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("%w: received %v arguments, expected 0", ErrWrongArity, len(args))
+	}
+	if input.IsEmpty() {
+		return nil, nil
+	}
+	if !input.IsSingleton() {
+		return nil, fmt.Errorf("%w: input has %d elements", ErrNotSingleton, len(input))
+	}
+	return system.From(input[0])
+}
+
+// calendarDuration computes the duration or difference between the input and
+// the value argument, as determined by the whole flag.
+func calendarDuration(ctx *expr.Context, input system.Collection, whole bool, args ...expr.Expression) (system.Collection, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("%w: received %v arguments, expected 2", ErrWrongArity, len(args))
+	}
+	from, err := temporalInput(input)
+	if err != nil {
+		return nil, err
+	} else if from == nil {
+		return system.Collection{}, nil
+	}
+	values, err := args[0].Evaluate(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	to, err := temporalInput(values)
+	if err != nil {
+		return nil, err
+	} else if to == nil {
+		return system.Collection{}, nil
+	}
+	precision, err := args[1].Evaluate(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if precision.IsEmpty() {
+		return system.Collection{}, nil
+	}
+	unit, err := precision.ToString()
+	if err != nil {
+		return nil, err
+	}
+	if !system.IsCalendarDuration(unit) {
+		return nil, fmt.Errorf("%w: '%s' is not a calendar duration", ErrInvalidInput, unit)
+	}
+
+	// Dates are compared as DateTimes of the same precision.
+	if date, ok := from.(system.Date); ok {
+		from = date.ToDateTime()
+	}
+	if date, ok := to.(system.Date); ok {
+		to = date.ToDateTime()
+	}
+
+	var result system.Quantity
+	var ok bool
+	switch from := from.(type) {
+	case system.DateTime:
+		other, isDateTime := to.(system.DateTime)
+		if !isDateTime {
+			return nil, fmt.Errorf("%w: %T, %T", system.ErrTypeMismatch, from, to)
+		}
+		if whole {
+			result, ok = from.Duration(other, unit)
+		} else {
+			result, ok = from.Difference(other, unit)
+		}
+	case system.Time:
+		other, isTime := to.(system.Time)
+		if !isTime {
+			return nil, fmt.Errorf("%w: %T, %T", system.ErrTypeMismatch, from, to)
+		}
+		if whole {
+			result, ok = from.Duration(other, unit)
+		} else {
+			result, ok = from.Difference(other, unit)
+		}
+	default:
+		return nil, fmt.Errorf("%w: cannot compute duration of type %T", ErrInvalidInput, from)
+	}
+	if !ok {
+		return system.Collection{}, nil
+	}
+	return system.Collection{result}, nil
+}
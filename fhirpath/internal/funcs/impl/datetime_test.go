@@ -0,0 +1,259 @@
+package impl_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/expr"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/expr/exprtest"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/funcs/impl"
+	"github.com/verily-src/fhirpath-go/fhirpath/system"
+	"github.com/verily-src/fhirpath-go/internal/fhir"
+)
+
+func TestComponentFunctions(t *testing.T) {
+	dateTime := system.MustParseDateTime("2014-01-05T10:30:15.250+02:00")
+
+	testCases := []struct {
+		name    string
+		fn      func(*expr.Context, system.Collection, ...expr.Expression) (system.Collection, error)
+		input   system.Collection
+		args    []expr.Expression
+		want    system.Collection
+		wantErr error
+	}{
+		{
+			name:  "yearOf returns empty for empty input",
+			fn:    impl.YearOf,
+			input: system.Collection{},
+			want:  system.Collection{},
+		},
+		{
+			name:  "yearOf returns year of date",
+			fn:    impl.YearOf,
+			input: system.Collection{system.MustParseDate("2014-01")},
+			want:  system.Collection{system.Integer(2014)},
+		},
+		{
+			name:  "yearOf converts proto date",
+			fn:    impl.YearOf,
+			input: system.Collection{fhir.MustParseDate("1990-05-10")},
+			want:  system.Collection{system.Integer(1990)},
+		},
+		{
+			name:  "monthOf returns month of datetime",
+			fn:    impl.MonthOf,
+			input: system.Collection{dateTime},
+			want:  system.Collection{system.Integer(1)},
+		},
+		{
+			name:  "dayOf returns empty for month precision",
+			fn:    impl.DayOf,
+			input: system.Collection{system.MustParseDate("2014-01")},
+			want:  system.Collection{},
+		},
+		{
+			name:  "hourOf returns hour of datetime",
+			fn:    impl.HourOf,
+			input: system.Collection{dateTime},
+			want:  system.Collection{system.Integer(10)},
+		},
+		{
+			name:  "minuteOf returns minute of time",
+			fn:    impl.MinuteOf,
+			input: system.Collection{system.MustParseTime("08:45")},
+			want:  system.Collection{system.Integer(45)},
+		},
+		{
+			name:  "secondOf returns second of datetime",
+			fn:    impl.SecondOf,
+			input: system.Collection{dateTime},
+			want:  system.Collection{system.Integer(15)},
+		},
+		{
+			name:  "millisecondOf returns millisecond of datetime",
+			fn:    impl.MillisecondOf,
+			input: system.Collection{dateTime},
+			want:  system.Collection{system.Integer(250)},
+		},
+		{
+			name:  "hourOf returns empty for date",
+			fn:    impl.HourOf,
+			input: system.Collection{system.MustParseDate("2014-01-05")},
+			want:  system.Collection{},
+		},
+		{
+			name:  "timezoneOffsetOf returns offset in hours",
+			fn:    impl.TimezoneOffsetOf,
+			input: system.Collection{dateTime},
+			want:  system.Collection{system.MustParseDecimal("2")},
+		},
+		{
+			name:  "timezoneOffsetOf returns empty without time zone",
+			fn:    impl.TimezoneOffsetOf,
+			input: system.Collection{system.MustParseDateTime("2014-01-05T10:30")},
+			want:  system.Collection{},
+		},
+		{
+			name:  "dateOf returns date of datetime",
+			fn:    impl.DateOf,
+			input: system.Collection{dateTime},
+			want:  system.Collection{system.MustParseDate("2014-01-05")},
+		},
+		{
+			name:  "timeOf returns time of datetime",
+			fn:    impl.TimeOf,
+			input: system.Collection{dateTime},
+			want:  system.Collection{system.MustParseTime("10:30:15.250")},
+		},
+		{
+			name:  "timeOf returns empty for date precision",
+			fn:    impl.TimeOf,
+			input: system.Collection{system.MustParseDateTime("2014-01-05T")},
+			want:  system.Collection{},
+		},
+		{
+			name:    "errors on unsupported type",
+			fn:      impl.YearOf,
+			input:   system.Collection{system.String("2014")},
+			wantErr: impl.ErrInvalidInput,
+		},
+		{
+			name:    "errors on non-singleton input",
+			fn:      impl.DateOf,
+			input:   system.Collection{dateTime, dateTime},
+			wantErr: impl.ErrNotSingleton,
+		},
+		{
+			name:    "errors on arguments",
+			fn:      impl.TimeOf,
+			input:   system.Collection{dateTime},
+			args:    []expr.Expression{exprtest.Return(system.Integer(1))},
+			wantErr: impl.ErrWrongArity,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.fn(&expr.Context{}, tc.input, tc.args...)
+
+			if !cmp.Equal(err, tc.wantErr, cmpopts.EquateErrors()) {
+				t.Fatalf("got unexpected error: got %v, want %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got, protocmp.Transform()); diff != "" {
+				t.Errorf("returned unexpected result (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDurationAndDifference(t *testing.T) {
+	testCases := []struct {
+		name           string
+		input          system.Collection
+		args           []expr.Expression
+		wantDuration   system.Collection
+		wantDifference system.Collection
+		wantErr        error
+	}{
+		{
+			name:  "computes years between dates",
+			input: system.Collection{system.MustParseDate("2000-06-15")},
+			args: []expr.Expression{
+				exprtest.Return(system.MustParseDate("2018-06-14")),
+				exprtest.Return(system.String("years")),
+			},
+			wantDuration:   system.Collection{system.MustParseQuantity("17", "years")},
+			wantDifference: system.Collection{system.MustParseQuantity("18", "years")},
+		},
+		{
+			name:  "compares date with datetime",
+			input: system.Collection{system.MustParseDate("2014-01-01")},
+			args: []expr.Expression{
+				exprtest.Return(system.MustParseDateTime("2014-01-03T12:00")),
+				exprtest.Return(system.String("day")),
+			},
+			wantDuration:   system.Collection{system.MustParseQuantity("2", "days")},
+			wantDifference: system.Collection{system.MustParseQuantity("2", "days")},
+		},
+		{
+			name:  "computes minutes between times",
+			input: system.Collection{system.MustParseTime("10:30")},
+			args: []expr.Expression{
+				exprtest.Return(system.MustParseTime("11:15")),
+				exprtest.Return(system.String("minutes")),
+			},
+			wantDuration:   system.Collection{system.MustParseQuantity("45", "minutes")},
+			wantDifference: system.Collection{system.MustParseQuantity("45", "minutes")},
+		},
+		{
+			name:  "returns empty for insufficient precision",
+			input: system.Collection{system.MustParseDate("2014")},
+			args: []expr.Expression{
+				exprtest.Return(system.MustParseDate("2014-05-01")),
+				exprtest.Return(system.String("days")),
+			},
+			wantDuration:   system.Collection{},
+			wantDifference: system.Collection{},
+		},
+		{
+			name:  "returns empty for empty value",
+			input: system.Collection{system.MustParseDate("2014")},
+			args: []expr.Expression{
+				exprtest.Return(),
+				exprtest.Return(system.String("years")),
+			},
+			wantDuration:   system.Collection{},
+			wantDifference: system.Collection{},
+		},
+		{
+			name:  "errors on non-calendar precision",
+			input: system.Collection{system.MustParseDate("2014")},
+			args: []expr.Expression{
+				exprtest.Return(system.MustParseDate("2015")),
+				exprtest.Return(system.String("a")),
+			},
+			wantErr: impl.ErrInvalidInput,
+		},
+		{
+			name:  "errors on mismatched types",
+			input: system.Collection{system.MustParseDate("2014")},
+			args: []expr.Expression{
+				exprtest.Return(system.MustParseTime("10:30")),
+				exprtest.Return(system.String("hours")),
+			},
+			wantErr: system.ErrTypeMismatch,
+		},
+		{
+			name:  "errors on wrong number of arguments",
+			input: system.Collection{system.MustParseDate("2014")},
+			args: []expr.Expression{
+				exprtest.Return(system.MustParseDate("2015")),
+			},
+			wantErr: impl.ErrWrongArity,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gotDuration, err := impl.Duration(&expr.Context{}, tc.input, tc.args...)
+			if !cmp.Equal(err, tc.wantErr, cmpopts.EquateErrors()) {
+				t.Fatalf("Duration got unexpected error: got %v, want %v", err, tc.wantErr)
+			}
+			gotDifference, err := impl.Difference(&expr.Context{}, tc.input, tc.args...)
+			if !cmp.Equal(err, tc.wantErr, cmpopts.EquateErrors()) {
+				t.Fatalf("Difference got unexpected error: got %v, want %v", err, tc.wantErr)
+			}
+
+			if diff := cmp.Diff(tc.wantDuration, gotDuration, protocmp.Transform()); diff != "" {
+				t.Errorf("Duration returned unexpected result (-want, +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantDifference, gotDifference, protocmp.Transform()); diff != "" {
+				t.Errorf("Difference returned unexpected result (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -1,10 +1,15 @@
 package impl
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/expr"
+)
 
 // Error constants
 var (
 	ErrWrongArity        = errors.New("incorrect function arity")
 	ErrInvalidReturnType = errors.New("invalid return type")
-	ErrNotSingleton      = errors.New("invalid cardinality: not a singleton")
+	ErrNotSingleton      = expr.ErrNotSingleton
+	ErrMaxDepthExceeded  = errors.New("maximum recursion depth exceeded")
 )
@@ -91,9 +91,9 @@ func All(ctx *expr.Context, input system.Collection, args ...expr.Expression) (s
 	}
 
 	// Evaluate the criteria expression for each element in the input collection
-	for _, element := range input {
+	for i, element := range input {
 		// Evaluate the criteria expression
-		output, err := args[0].Evaluate(ctx, system.Collection{element})
+		output, err := args[0].Evaluate(ctx.WithIndex(i), system.Collection{element})
 		if err != nil {
 			return nil, fmt.Errorf("evaluating criteria expression resulted in an error: %w", err)
 		}
@@ -17,8 +17,8 @@ func Where(ctx *expr.Context, input system.Collection, args ...expr.Expression)
 	}
 	e := args[0]
 	result := system.Collection{}
-	for _, item := range input {
-		output, err := e.Evaluate(ctx, system.Collection{item})
+	for i, item := range input {
+		output, err := e.Evaluate(ctx.WithIndex(i), system.Collection{item})
 		if err != nil {
 			return nil, err
 		}
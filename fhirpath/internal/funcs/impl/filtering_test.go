@@ -66,6 +66,17 @@ func TestWhere_Evaluates(t *testing.T) {
 			inputArgs:       []expr.Expression{exprtest.Return()},
 			wantCollection:  system.Collection{},
 		},
+		{
+			name:            "filters on index of each item",
+			inputCollection: slices.MustConvert[any](contact),
+			inputArgs: []expr.Expression{
+				&expr.EqualityExpression{
+					Left:  &expr.IndexInvocationExpression{},
+					Right: &expr.LiteralExpression{Literal: system.Integer(1)},
+				},
+			},
+			wantCollection: system.Collection{contact[1]},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -39,6 +39,19 @@ func Abs(ctx *expr.Context, input system.Collection, args ...expr.Expression) (s
 		// Absolution number
 		res := math.Abs(float64(number))
 		return system.Collection{system.Integer(res)}, nil
+	case system.Long:
+		if !input.IsSingleton() {
+			return nil, errors.New("invalid input, is not a singleton")
+		}
+		number := input[0].(system.Long)
+		// The absolute value of the smallest Long is not representable
+		if number == math.MinInt64 {
+			return system.Collection{}, nil
+		}
+		if number < 0 {
+			number = -number
+		}
+		return system.Collection{number}, nil
 	case system.Decimal:
 		// Input type conversion to float64
 		number, err := input.ToFloat64()
@@ -74,6 +87,10 @@ func Ceiling(ctx *expr.Context, input system.Collection, args ...expr.Expression
 	if len(args) != 0 {
 		return nil, fmt.Errorf("%w: received %v arguments, expected 0", ErrWrongArity, len(args))
 	}
+	// Long values are already whole numbers
+	if number, ok := input[0].(system.Long); ok && input.IsSingleton() {
+		return system.Collection{number}, nil
+	}
 	// Input type conversion to float64
 	number, err := input.ToFloat64()
 	if err != nil {
@@ -117,6 +134,10 @@ func Floor(ctx *expr.Context, input system.Collection, args ...expr.Expression)
 	if len(args) != 0 {
 		return nil, fmt.Errorf("%w: received %v arguments, expected 0", ErrWrongArity, len(args))
 	}
+	// Long values are already whole numbers
+	if number, ok := input[0].(system.Long); ok && input.IsSingleton() {
+		return system.Collection{number}, nil
+	}
 	// Input type conversion to float64
 	number, err := input.ToFloat64()
 	if err != nil {
@@ -203,6 +224,14 @@ func Power(ctx *expr.Context, input system.Collection, args ...expr.Expression)
 	if err != nil {
 		return nil, err
 	}
+	// Validating Long case
+	if number, exp, ok := longOperands(input, argValues); ok {
+		res, err := powLong(number, exp)
+		if err != nil {
+			return system.Collection{}, nil
+		}
+		return system.Collection{res}, nil
+	}
 	// Validating integers case
 	_, ok := input[0].(system.Integer)
 	_, ok2 := argValues[0].(system.Integer)
@@ -291,6 +320,10 @@ func Round(ctx *expr.Context, input system.Collection, args ...expr.Expression)
 		res := system.MustParseDecimal(fmt.Sprintf("%d", number))
 		result := res.Round(precision)
 		return system.Collection{result}, nil
+	case system.Long:
+		res := system.Decimal(decimal.NewFromInt(int64(input[0].(system.Long))))
+		result := res.Round(precision)
+		return system.Collection{result}, nil
 	}
 	return nil, errors.New("input is not a number")
 }
@@ -332,6 +365,10 @@ func Truncate(ctx *expr.Context, input system.Collection, args ...expr.Expressio
 	if len(args) != 0 {
 		return nil, fmt.Errorf("%w: received %v arguments, expected 0", ErrWrongArity, len(args))
 	}
+	// Long values are already whole numbers
+	if number, ok := input[0].(system.Long); ok && input.IsSingleton() {
+		return system.Collection{number}, nil
+	}
 	// Input type conversion to float64
 	number, err := input.ToFloat64()
 	if err != nil {
@@ -365,3 +402,43 @@ func powInt32(base, exp int32) int32 {
 	}
 	return result
 }
+
+// longOperands returns the input and argument of a Power call as Long values,
+// if both are singleton Integer or Long values and at least one is a Long.
+func longOperands(input, args system.Collection) (system.Long, system.Long, bool) {
+	if !input.IsSingleton() || !args.IsSingleton() {
+		return 0, 0, false
+	}
+	number, ok := toLong(input[0])
+	exp, ok2 := toLong(args[0])
+	_, isLong := input[0].(system.Long)
+	_, isLong2 := args[0].(system.Long)
+	return number, exp, ok && ok2 && (isLong || isLong2)
+}
+
+// toLong returns the value of a Long or Integer as a Long.
+func toLong(item any) (system.Long, bool) {
+	switch v := item.(type) {
+	case system.Long:
+		return v, true
+	case system.Integer:
+		return system.Long(v), true
+	}
+	return 0, false
+}
+
+// powLong returns the powering of a Long to a given exponential, or
+// system.ErrIntOverflow if the result does not fit in a Long.
+func powLong(base, exp system.Long) (system.Long, error) {
+	if exp < 0 {
+		return 0, nil
+	}
+	result := system.Long(1)
+	for i := system.Long(0); i < exp; i++ {
+		var err error
+		if result, err = result.Mul(base); err != nil {
+			return 0, err
+		}
+	}
+	return result, nil
+}
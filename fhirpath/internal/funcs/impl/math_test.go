@@ -51,6 +51,16 @@ func TestAbs(t *testing.T) {
 			want:    system.Collection{},
 			wantErr: false,
 		},
+		{
+			name:  "abs a negative Long number",
+			input: system.Collection{system.Long(-5)},
+			want:  system.Collection{system.Long(5)},
+		},
+		{
+			name:  "returns an empty collection if Long abs overflows",
+			input: system.Collection{system.Long(-9223372036854775808)},
+			want:  system.Collection{},
+		},
 		{
 			name:    "abs a positive Decimal number",
 			input:   system.Collection{system.MustParseDecimal("10.5")},
@@ -146,6 +156,11 @@ func TestCeiling(t *testing.T) {
 			want:    system.Collection{},
 			wantErr: false,
 		},
+		{
+			name:  "ceiling a Long number",
+			input: system.Collection{system.Long(5)},
+			want:  system.Collection{system.Long(5)},
+		},
 		{
 			name:    "ceiling a positive float number",
 			input:   system.Collection{system.MustParseDecimal("10.5")},
@@ -318,6 +333,11 @@ func TestFloor(t *testing.T) {
 			want:    system.Collection{},
 			wantErr: false,
 		},
+		{
+			name:  "floors a Long number",
+			input: system.Collection{system.Long(-5)},
+			want:  system.Collection{system.Long(-5)},
+		},
 		{
 			name:    "floors a positive float number",
 			input:   system.Collection{system.MustParseDecimal("10.5")},
@@ -588,6 +608,30 @@ func TestPower(t *testing.T) {
 			want:    system.Collection{},
 			wantErr: false,
 		},
+		{
+			name:  "powers a Long number to an Integer arg",
+			input: system.Collection{system.Long(2)},
+			args: []expr.Expression{
+				exprtest.Return(system.Integer(10)),
+			},
+			want: system.Collection{system.Long(1024)},
+		},
+		{
+			name:  "powers an Integer number to a Long arg",
+			input: system.Collection{system.Integer(3)},
+			args: []expr.Expression{
+				exprtest.Return(system.Long(2)),
+			},
+			want: system.Collection{system.Long(9)},
+		},
+		{
+			name:  "returns an empty collection if Long power overflows",
+			input: system.Collection{system.Long(10)},
+			args: []expr.Expression{
+				exprtest.Return(system.Integer(19)),
+			},
+			want: system.Collection{},
+		},
 		{
 			name:  "returns an empty collection if result is NaN",
 			input: system.Collection{system.MustParseDecimal("-1.0")},
@@ -777,6 +821,11 @@ func TestRound(t *testing.T) {
 			want:    system.Collection{},
 			wantErr: false,
 		},
+		{
+			name:  "rounds a Long number",
+			input: system.Collection{system.Long(5)},
+			want:  system.Collection{system.MustParseDecimal("5")},
+		},
 		{
 			name:  "rounds a positive Decimal",
 			input: system.Collection{system.MustParseDecimal("3.141592653589793")},
@@ -884,6 +933,11 @@ func TestSqrt(t *testing.T) {
 			want:    system.Collection{},
 			wantErr: false,
 		},
+		{
+			name:  "sqrt a Long number",
+			input: system.Collection{system.Long(16)},
+			want:  system.Collection{system.MustParseDecimal("4")},
+		},
 		{
 			name:    "errors if input is negative",
 			input:   system.Collection{system.MustParseDecimal("-16.0")},
@@ -988,6 +1042,11 @@ func TestTruncate(t *testing.T) {
 			want:    system.Collection{},
 			wantErr: false,
 		},
+		{
+			name:  "truncates a Long number",
+			input: system.Collection{system.Long(7)},
+			want:  system.Collection{system.Long(7)},
+		},
 		{
 			name:    "truncates a positive float number",
 			input:   system.Collection{system.MustParseDecimal("10.12345")},
@@ -8,6 +8,7 @@ import (
 	"github.com/verily-src/fhirpath-go/fhirpath/system"
 	"github.com/verily-src/fhirpath-go/internal/fhir"
 	"github.com/verily-src/fhirpath-go/internal/protofields"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 // Children returns a collection with all immediate child nodes of all items in the input collection
@@ -20,7 +21,7 @@ func Children(ctx *expr.Context, input system.Collection, args ...expr.Expressio
 
 	result := system.Collection{}
 	for _, item := range input {
-		if system.IsPrimitive(item) {
+		if _, ok := item.(system.Any); ok {
 			continue
 		}
 		base, ok := item.(fhir.Base)
@@ -28,15 +29,23 @@ func Children(ctx *expr.Context, input system.Collection, args ...expr.Expressio
 			return nil, fmt.Errorf("%w: unexpected input of type '%T'", ErrInvalidInput, item)
 		}
 		if oneOf := protofields.UnwrapOneofField(base, "choice"); oneOf != nil {
-			if system.IsPrimitive(oneOf) {
-				continue
-			}
 			base = oneOf
 		}
 
 		var fields []string
 		if _, ok := base.(*dtpb.Reference); ok {
 			fields = append(fields, "reference")
+		} else if isFHIRPrimitive(base) {
+			// The value of a primitive is not a child node, but its id and
+			// extensions are.
+			fd := base.ProtoReflect().Descriptor().Fields()
+			for _, name := range []protoreflect.Name{"id", "extension"} {
+				if fd.ByName(name) != nil {
+					fields = append(fields, string(name))
+				}
+			}
+		} else if system.IsPrimitive(base) {
+			continue
 		} else {
 			fd := base.ProtoReflect().Descriptor().Fields()
 			for i := 0; i < fd.Len(); i++ {
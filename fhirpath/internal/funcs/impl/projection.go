@@ -8,6 +8,10 @@ import (
 	"github.com/verily-src/fhirpath-go/fhirpath/system"
 )
 
+// defaultMaxRepeatDepth is the maximum depth that Repeat will traverse when
+// no maximum has been configured in the context.
+const defaultMaxRepeatDepth = 1000
+
 // Select evaluates the expression args[0] on each input item. The result of each evaluation is
 // added to the output collection.
 // FHIRPath docs here: https://hl7.org/fhirpath/N1/#selectprojection-expression-collection
@@ -18,8 +22,8 @@ func Select(ctx *expr.Context, input system.Collection, args ...expr.Expression)
 	e := args[0]
 	result := system.Collection{}
 	var fieldErrs []error
-	for _, item := range input {
-		output, err := e.Evaluate(ctx, system.Collection{item})
+	for i, item := range input {
+		output, err := e.Evaluate(ctx.WithIndex(i), system.Collection{item})
 		// If the error is ErrInvalidField, don't immediately raise it
 		if err != nil {
 			if errors.Is(err, expr.ErrInvalidField) {
@@ -36,3 +40,65 @@ func Select(ctx *expr.Context, input system.Collection, args ...expr.Expression)
 	}
 	return result, nil
 }
+
+// Repeat evaluates the expression args[0] on each input item, then repeatedly
+// evaluates it on each new item it produces, until no new items are found. The
+// input items themselves are only included in the output if the projection
+// returns them. Items that are already in the output, determined with the
+// equals (=) operation, are not projected again, so cyclic structures terminate.
+//
+// Returns ErrMaxDepthExceeded if traversal exceeds the configured maximum depth.
+// FHIRPath docs here: https://hl7.org/fhirpath/N1/#repeatprojection-expression-collection
+func Repeat(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("%w: received %v arguments, expected 1", ErrWrongArity, len(args))
+	}
+	maxDepth := ctx.MaxRepeatDepth
+	if maxDepth <= 0 {
+		maxDepth = defaultMaxRepeatDepth
+	}
+	e := args[0]
+	result := system.Collection{}
+	for depth := 0; len(input) > 0; depth++ {
+		if depth >= maxDepth {
+			return nil, fmt.Errorf("%w: repeat exceeded depth of %v", ErrMaxDepthExceeded, maxDepth)
+		}
+		var next system.Collection
+		var fieldErrs []error
+		for i, item := range input {
+			output, err := e.Evaluate(ctx.WithIndex(i), system.Collection{item})
+			// If the error is ErrInvalidField, don't immediately raise it
+			if err != nil {
+				if errors.Is(err, expr.ErrInvalidField) {
+					fieldErrs = append(fieldErrs, err)
+					continue
+				}
+				return nil, err
+			}
+			for _, value := range output {
+				if containsEqual(result, value) {
+					continue
+				}
+				result = append(result, value)
+				next = append(next, value)
+			}
+		}
+		// Raise field errors if one was raised for each input.
+		if len(fieldErrs) == len(input) {
+			return nil, errors.Join(fieldErrs...)
+		}
+		input = next
+	}
+	return result, nil
+}
+
+// containsEqual returns whether the collection contains an item that is equal
+// to the given value.
+func containsEqual(collection system.Collection, value any) bool {
+	for _, item := range collection {
+		if checkEquality(item, value) {
+			return true
+		}
+	}
+	return false
+}
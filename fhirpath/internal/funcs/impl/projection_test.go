@@ -74,6 +74,12 @@ func TestSelect_Evaluates(t *testing.T) {
 			inputArgs:       []expr.Expression{&expr.FieldExpression{FieldName: "state"}},
 			wantCollection:  system.Collection{address[0].GetState()},
 		},
+		{
+			name:            "project index of each item",
+			inputCollection: slices.MustConvert[any](address),
+			inputArgs:       []expr.Expression{&expr.IndexInvocationExpression{}},
+			wantCollection:  system.Collection{system.Integer(0), system.Integer(1)},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -120,3 +126,120 @@ func TestSelect_RaisesError(t *testing.T) {
 		})
 	}
 }
+
+func TestRepeat_Evaluates(t *testing.T) {
+	nested := &dtpb.Extension{
+		Url: fhir.URI("outer"),
+		Extension: []*dtpb.Extension{
+			{
+				Url: fhir.URI("middle"),
+				Extension: []*dtpb.Extension{
+					{Url: fhir.URI("inner")},
+				},
+			},
+			{Url: fhir.URI("sibling")},
+		},
+	}
+
+	testCases := []struct {
+		name            string
+		inputCollection system.Collection
+		inputArgs       []expr.Expression
+		wantCollection  system.Collection
+	}{
+		{
+			name:            "repeat on empty collection",
+			inputCollection: system.Collection{},
+			inputArgs:       []expr.Expression{&expr.FieldExpression{FieldName: "extension"}},
+			wantCollection:  system.Collection{},
+		},
+		{
+			name:            "traverses nested fields",
+			inputCollection: system.Collection{nested},
+			inputArgs:       []expr.Expression{&expr.FieldExpression{FieldName: "extension"}},
+			wantCollection: system.Collection{
+				nested.GetExtension()[0],
+				nested.GetExtension()[1],
+				nested.GetExtension()[0].GetExtension()[0],
+			},
+		},
+		{
+			name:            "terminates on cycles",
+			inputCollection: system.Collection{system.Integer(1), system.Integer(2)},
+			inputArgs:       []expr.Expression{&expr.IdentityExpression{}},
+			wantCollection:  system.Collection{system.Integer(1), system.Integer(2)},
+		},
+		{
+			name:            "does not include input unless projected",
+			inputCollection: system.Collection{system.Integer(1)},
+			inputArgs:       []expr.Expression{exprtest.Return(system.Integer(2))},
+			wantCollection:  system.Collection{system.Integer(2)},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := impl.Repeat(&expr.Context{}, tc.inputCollection, tc.inputArgs...)
+			if err != nil {
+				t.Fatalf("Repeat function returned unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.wantCollection, got, protocmp.Transform()); diff != "" {
+				t.Errorf("Repeat function returned unexpected diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRepeat_RaisesError(t *testing.T) {
+	increment := &expr.ArithmeticExpression{
+		Left:  &expr.IdentityExpression{},
+		Right: &expr.LiteralExpression{Literal: system.Integer(1)},
+		Op:    expr.EvaluateAdd,
+	}
+
+	testCases := []struct {
+		name            string
+		inputArgs       []expr.Expression
+		inputCollection system.Collection
+		context         *expr.Context
+		wantErr         error
+	}{
+		{
+			name:            "multiple arguments",
+			inputArgs:       []expr.Expression{exprtest.Return(1), exprtest.Return(1)},
+			inputCollection: slices.MustConvert[any](address),
+			context:         &expr.Context{},
+			wantErr:         impl.ErrWrongArity,
+		},
+		{
+			name:            "invalid field as argument expression",
+			inputArgs:       []expr.Expression{&expr.FieldExpression{FieldName: "invalid"}},
+			inputCollection: slices.MustConvert[any](address),
+			context:         &expr.Context{},
+			wantErr:         expr.ErrInvalidField,
+		},
+		{
+			name:            "exceeds configured maximum depth",
+			inputArgs:       []expr.Expression{increment},
+			inputCollection: system.Collection{system.Integer(1)},
+			context:         &expr.Context{MaxRepeatDepth: 10},
+			wantErr:         impl.ErrMaxDepthExceeded,
+		},
+		{
+			name:            "exceeds default maximum depth",
+			inputArgs:       []expr.Expression{increment},
+			inputCollection: system.Collection{system.Integer(1)},
+			context:         &expr.Context{},
+			wantErr:         impl.ErrMaxDepthExceeded,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := impl.Repeat(tc.context, tc.inputCollection, tc.inputArgs...)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Repeat function returned unexpected error: got %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
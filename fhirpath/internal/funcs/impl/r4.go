@@ -1,13 +1,28 @@
 package impl
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
+	dtpb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/datatypes_go_proto"
 	"github.com/verily-src/fhirpath-go/fhirpath/internal/expr"
+	"github.com/verily-src/fhirpath-go/fhirpath/profile"
 	"github.com/verily-src/fhirpath-go/fhirpath/system"
 	"github.com/verily-src/fhirpath-go/internal/fhir"
+	"github.com/verily-src/fhirpath-go/internal/narrative"
+	"github.com/verily-src/fhirpath-go/internal/protofields"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
+var (
+	ErrUnconfiguredValidator = errors.New("conformsTo() function requires a profile Validator to be configured in the evaluation context")
+)
+
+// baseDefinitionPrefix is the prefix of the canonical URLs of the
+// StructureDefinitions of the core FHIR resources and types.
+const baseDefinitionPrefix = "http://hl7.org/fhir/StructureDefinition/"
+
 // Extension is syntactic sugar over `extension.where(url = ...)`, and is
 // specific to the R4 extensions for FHIRPath (as oppose to being part of the
 // N1 normative spec).
@@ -40,3 +55,211 @@ func Extension(ctx *expr.Context, input system.Collection, args ...expr.Expressi
 	}
 	return result, nil
 }
+
+// primitiveHasNoValueURL is the URL of the extension that google/fhir uses to
+// mark primitives that only carry extensions, and have no value of their own.
+const primitiveHasNoValueURL = "https://g.co/fhir/StructureDefinition/primitiveHasNoValue"
+
+// isFHIRPrimitive returns true if the item is a FHIR primitive element.
+func isFHIRPrimitive(item any) bool {
+	switch item.(type) {
+	case *dtpb.Xhtml:
+		return true
+	case *dtpb.Quantity, system.Any:
+		return false
+	}
+	return system.IsPrimitive(item)
+}
+
+// primitiveValue returns the System value of the given FHIR primitive. Returns
+// false if the item is not a FHIR primitive, or if it has no value.
+func primitiveValue(item any) (system.Any, bool) {
+	message, ok := item.(fhir.Base)
+	if !ok || !isFHIRPrimitive(message) {
+		return nil, false
+	}
+	if extendable, ok := message.(fhir.Extendable); ok {
+		for _, ext := range extendable.GetExtension() {
+			if ext.GetUrl().GetValue() == primitiveHasNoValueURL {
+				return nil, false
+			}
+		}
+	}
+	reflect := message.ProtoReflect()
+	field := reflect.Descriptor().Fields().ByName("value")
+	if field == nil {
+		return nil, false
+	}
+	switch field.Kind() {
+	case protoreflect.StringKind, protoreflect.BytesKind, protoreflect.EnumKind:
+		if !reflect.Has(field) {
+			return nil, false
+		}
+	}
+	if xhtml, ok := message.(*dtpb.Xhtml); ok {
+		return system.String(xhtml.GetValue()), true
+	}
+	value, err := system.From(message)
+	if err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// HasValue returns true if the input collection contains a single value which
+// is a FHIR primitive, and it has a primitive value (as opposed to only having
+// extensions).
+//
+// For more details, see https://hl7.org/fhir/R4/fhirpath.html#functions
+func HasValue(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	if length := len(args); length != 0 {
+		return nil, fmt.Errorf("%w: received %v arguments, expected 0", ErrWrongArity, length)
+	}
+	if !input.IsSingleton() {
+		return system.Collection{system.Boolean(false)}, nil
+	}
+	_, ok := primitiveValue(input[0])
+	return system.Collection{system.Boolean(ok)}, nil
+}
+
+// GetValue returns the underlying System value of the FHIR primitive, if the
+// input collection contains a single value which is a FHIR primitive, and it
+// has a primitive value. Otherwise, returns empty.
+//
+// For more details, see https://hl7.org/fhir/R4/fhirpath.html#functions
+func GetValue(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	if length := len(args); length != 0 {
+		return nil, fmt.Errorf("%w: received %v arguments, expected 0", ErrWrongArity, length)
+	}
+	if !input.IsSingleton() {
+		return system.Collection{}, nil
+	}
+	value, ok := primitiveValue(input[0])
+	if !ok {
+		return system.Collection{}, nil
+	}
+	return system.Collection{value}, nil
+}
+
+// HTMLChecks returns true if the single xhtml input element follows the rules
+// for narrative XHTML: it is a well-formed 'div' element in the XHTML
+// namespace, that uses only the basic formatting elements and attributes, and
+// has some non-whitespace content. Returns empty for any other kind of input.
+//
+// For more details, see https://hl7.org/fhir/R4/narrative.html#rules
+func HTMLChecks(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	if length := len(args); length != 0 {
+		return nil, fmt.Errorf("%w: received %v arguments, expected 0", ErrWrongArity, length)
+	}
+	if !input.IsSingleton() {
+		return system.Collection{}, nil
+	}
+	xhtml, ok := input[0].(*dtpb.Xhtml)
+	if !ok {
+		return system.Collection{}, nil
+	}
+	return system.Collection{system.Boolean(narrative.Check(xhtml.GetValue()) == nil)}, nil
+}
+
+// ConformsTo returns true if the single input element conforms to the profile
+// with the given canonical URL, and false otherwise. Returns empty if the
+// profile can't be resolved.
+//
+// Conformance is checked by the Validator configured in the evaluation
+// context. Without one, only conformance to the base definitions of the FHIR
+// resources and types can be checked.
+//
+// For more details, see https://hl7.org/fhir/R4/fhirpath.html#functions
+func ConformsTo(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	if length := len(args); length != 1 {
+		return nil, fmt.Errorf("%w: received %v arguments, expected 1", ErrWrongArity, length)
+	}
+	if input.IsEmpty() {
+		return system.Collection{}, nil
+	}
+	if !input.IsSingleton() {
+		return nil, fmt.Errorf("%w: conformsTo input has %v items", ErrNotSingleton, len(input))
+	}
+	base, ok := input[0].(fhir.Base)
+	if !ok {
+		return nil, fmt.Errorf("%w: conformsTo is not defined for type %T", ErrInvalidInput, input[0])
+	}
+	arg, err := args[0].Evaluate(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	url, err := arg.ToString()
+	if err != nil {
+		return nil, err
+	}
+
+	validator := ctx.ProfileValidator
+	if validator == nil {
+		conforms, ok := conformsToBaseDefinition(base, url)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnconfiguredValidator, url)
+		}
+		return system.Collection{system.Boolean(conforms)}, nil
+	}
+	conforms, err := validator.ConformsTo(ctx, base, url)
+	if errors.Is(err, profile.ErrUnknownProfile) {
+		return system.Collection{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return system.Collection{system.Boolean(conforms)}, nil
+}
+
+// conformsToBaseDefinition returns true if the input is of the type defined by
+// the core FHIR StructureDefinition with the given URL. Returns false if the
+// URL is not that of a core FHIR resource or type.
+func conformsToBaseDefinition(input fhir.Base, url string) (bool, bool) {
+	name, ok := strings.CutPrefix(url, baseDefinitionPrefix)
+	if !ok || !(protofields.IsValidResourceType(name) || protofields.IsValidElementType(name)) {
+		return false, false
+	}
+	return protofields.DescriptorName(input) == name, true
+}
+
+// Comparable returns true if the single input Quantity can be compared to the
+// single Quantity argument, meaning that they have the same unit or
+// commensurable UCUM units.
+//
+// For more details, see https://hl7.org/fhir/R4/fhirpath.html#functions
+func Comparable(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	if length := len(args); length != 1 {
+		return nil, fmt.Errorf("%w: received %v arguments, expected 1", ErrWrongArity, length)
+	}
+	arg, err := args[0].Evaluate(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if input.IsEmpty() || arg.IsEmpty() {
+		return system.Collection{}, nil
+	}
+	left, err := singletonQuantity(input)
+	if err != nil {
+		return nil, err
+	}
+	right, err := singletonQuantity(arg)
+	if err != nil {
+		return nil, err
+	}
+	return system.Collection{system.Boolean(left.Comparable(right))}, nil
+}
+
+func singletonQuantity(collection system.Collection) (system.Quantity, error) {
+	if !collection.IsSingleton() {
+		return system.Quantity{}, fmt.Errorf("%w: expected a single Quantity, got %v items", ErrNotSingleton, len(collection))
+	}
+	value, err := system.From(collection[0])
+	if err != nil {
+		return system.Quantity{}, err
+	}
+	quantity, ok := value.(system.Quantity)
+	if !ok {
+		return system.Quantity{}, fmt.Errorf("%w: expected a Quantity, got %T", ErrInvalidInput, value)
+	}
+	return quantity, nil
+}
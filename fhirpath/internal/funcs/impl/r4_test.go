@@ -1,9 +1,11 @@
 package impl_test
 
 import (
+	"context"
 	"errors"
 	"testing"
 
+	cpb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/codes_go_proto"
 	dtpb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/datatypes_go_proto"
 	ppb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/patient_go_proto"
 	"github.com/google/go-cmp/cmp"
@@ -11,6 +13,7 @@ import (
 	"github.com/verily-src/fhirpath-go/fhirpath/internal/expr"
 	"github.com/verily-src/fhirpath-go/fhirpath/internal/expr/exprtest"
 	"github.com/verily-src/fhirpath-go/fhirpath/internal/funcs/impl"
+	"github.com/verily-src/fhirpath-go/fhirpath/profile"
 	"github.com/verily-src/fhirpath-go/fhirpath/system"
 	"github.com/verily-src/fhirpath-go/internal/element/extension"
 	"github.com/verily-src/fhirpath-go/internal/fhir"
@@ -118,3 +121,303 @@ func TestExtension_InvalidInput_RaisesError(t *testing.T) {
 		})
 	}
 }
+
+func TestHasValueAndGetValue(t *testing.T) {
+	noValue := &dtpb.String{
+		Extension: []*dtpb.Extension{
+			extension.New("https://g.co/fhir/StructureDefinition/primitiveHasNoValue", fhir.Boolean(true)),
+			extension.New("http://example.com/reason", fhir.String("masked")),
+		},
+	}
+	testCases := []struct {
+		name         string
+		input        system.Collection
+		wantHasValue bool
+		wantValue    system.Collection
+	}{
+		{
+			name:         "primitive with value",
+			input:        system.Collection{fhir.String("hello")},
+			wantHasValue: true,
+			wantValue:    system.Collection{system.String("hello")},
+		},
+		{
+			name:         "boolean primitive with false value",
+			input:        system.Collection{fhir.Boolean(false)},
+			wantHasValue: true,
+			wantValue:    system.Collection{system.Boolean(false)},
+		},
+		{
+			name:         "primitive with only extensions",
+			input:        system.Collection{noValue},
+			wantHasValue: false,
+			wantValue:    system.Collection{},
+		},
+		{
+			name:         "primitive with empty string",
+			input:        system.Collection{&dtpb.Uri{}},
+			wantHasValue: false,
+			wantValue:    system.Collection{},
+		},
+		{
+			name:         "code with enum value",
+			input:        system.Collection{&dtpb.Narrative_StatusCode{Value: cpb.NarrativeStatusCode_GENERATED}},
+			wantHasValue: true,
+			wantValue:    system.Collection{system.String("generated")},
+		},
+		{
+			name:         "code without enum value",
+			input:        system.Collection{&dtpb.Narrative_StatusCode{}},
+			wantHasValue: false,
+			wantValue:    system.Collection{},
+		},
+		{
+			name:         "xhtml with value",
+			input:        system.Collection{&dtpb.Xhtml{Value: "<div/>"}},
+			wantHasValue: true,
+			wantValue:    system.Collection{system.String("<div/>")},
+		},
+		{
+			name:         "complex type",
+			input:        system.Collection{&dtpb.HumanName{Family: fhir.String("Doe")}},
+			wantHasValue: false,
+			wantValue:    system.Collection{},
+		},
+		{
+			name:         "system type",
+			input:        system.Collection{system.String("hello")},
+			wantHasValue: false,
+			wantValue:    system.Collection{},
+		},
+		{
+			name:         "multiple primitives",
+			input:        system.Collection{fhir.String("a"), fhir.String("b")},
+			wantHasValue: false,
+			wantValue:    system.Collection{},
+		},
+		{
+			name:         "empty input",
+			input:        system.Collection{},
+			wantHasValue: false,
+			wantValue:    system.Collection{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hasValue, err := impl.HasValue(&expr.Context{}, tc.input)
+			if err != nil {
+				t.Fatalf("HasValue returned unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(system.Collection{system.Boolean(tc.wantHasValue)}, hasValue); diff != "" {
+				t.Errorf("HasValue returned unexpected diff (-want, +got):\n%s", diff)
+			}
+
+			value, err := impl.GetValue(&expr.Context{}, tc.input)
+			if err != nil {
+				t.Fatalf("GetValue returned unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.wantValue, value); diff != "" {
+				t.Errorf("GetValue returned unexpected diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHTMLChecks(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input system.Collection
+		want  system.Collection
+	}{
+		{
+			name:  "valid narrative",
+			input: system.Collection{&dtpb.Xhtml{Value: `<div xmlns="http://www.w3.org/1999/xhtml"><p>Hello</p></div>`}},
+			want:  system.Collection{system.Boolean(true)},
+		},
+		{
+			name:  "narrative with script",
+			input: system.Collection{&dtpb.Xhtml{Value: `<div xmlns="http://www.w3.org/1999/xhtml"><script>alert(1)</script></div>`}},
+			want:  system.Collection{system.Boolean(false)},
+		},
+		{
+			name:  "xhtml input is not a narrative",
+			input: system.Collection{fhir.String("<div/>")},
+			want:  system.Collection{},
+		},
+		{
+			name:  "empty input",
+			input: system.Collection{},
+			want:  system.Collection{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := impl.HTMLChecks(&expr.Context{}, tc.input)
+			if err != nil {
+				t.Fatalf("HTMLChecks returned unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("HTMLChecks returned unexpected diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+type fakeValidator struct {
+	profiles map[string]bool
+}
+
+func (v fakeValidator) ConformsTo(ctx context.Context, input fhir.Base, url string) (bool, error) {
+	conforms, ok := v.profiles[url]
+	if !ok {
+		return false, profile.ErrUnknownProfile
+	}
+	return conforms, nil
+}
+
+func TestConformsTo(t *testing.T) {
+	patient := fhirtest.NewResourceOf[*ppb.Patient](t)
+	validator := fakeValidator{
+		profiles: map[string]bool{
+			"http://example.com/conforming":     true,
+			"http://example.com/non-conforming": false,
+		},
+	}
+	testCases := []struct {
+		name      string
+		input     system.Collection
+		url       string
+		validator profile.Validator
+		want      system.Collection
+		wantErr   error
+	}{
+		{
+			name:  "conforms to own base definition",
+			input: system.Collection{patient},
+			url:   "http://hl7.org/fhir/StructureDefinition/Patient",
+			want:  system.Collection{system.Boolean(true)},
+		},
+		{
+			name:  "doesn't conform to other base definition",
+			input: system.Collection{patient},
+			url:   "http://hl7.org/fhir/StructureDefinition/Observation",
+			want:  system.Collection{system.Boolean(false)},
+		},
+		{
+			name:    "requires validator for other profiles",
+			input:   system.Collection{patient},
+			url:     "http://example.com/conforming",
+			wantErr: impl.ErrUnconfiguredValidator,
+		},
+		{
+			name:      "conforms to profile",
+			input:     system.Collection{patient},
+			url:       "http://example.com/conforming",
+			validator: validator,
+			want:      system.Collection{system.Boolean(true)},
+		},
+		{
+			name:      "doesn't conform to profile",
+			input:     system.Collection{patient},
+			url:       "http://example.com/non-conforming",
+			validator: validator,
+			want:      system.Collection{system.Boolean(false)},
+		},
+		{
+			name:      "unknown profile",
+			input:     system.Collection{patient},
+			url:       "http://example.com/unknown",
+			validator: validator,
+			want:      system.Collection{},
+		},
+		{
+			name:  "empty input",
+			input: system.Collection{},
+			url:   "http://example.com/conforming",
+			want:  system.Collection{},
+		},
+		{
+			name:    "multiple inputs",
+			input:   system.Collection{patient, patient},
+			url:     "http://example.com/conforming",
+			wantErr: impl.ErrNotSingleton,
+		},
+		{
+			name:    "system type input",
+			input:   system.Collection{system.String("hello")},
+			url:     "http://example.com/conforming",
+			wantErr: impl.ErrInvalidInput,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := &expr.Context{ProfileValidator: tc.validator}
+
+			got, err := impl.ConformsTo(ctx, tc.input, exprtest.Return(system.String(tc.url)))
+
+			if !cmp.Equal(err, tc.wantErr, cmpopts.EquateErrors()) {
+				t.Fatalf("ConformsTo returned unexpected error: got %v, want %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got, protocmp.Transform()); diff != "" {
+				t.Errorf("ConformsTo returned unexpected diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestComparable(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   system.Collection
+		arg     system.Collection
+		want    system.Collection
+		wantErr error
+	}{
+		{
+			name:  "commensurable units",
+			input: system.Collection{system.MustParseQuantity("1", "cm")},
+			arg:   system.Collection{system.MustParseQuantity("1", "[in_i]")},
+			want:  system.Collection{system.Boolean(true)},
+		},
+		{
+			name:  "incommensurable units",
+			input: system.Collection{system.MustParseQuantity("1", "cm")},
+			arg:   system.Collection{system.MustParseQuantity("1", "g")},
+			want:  system.Collection{system.Boolean(false)},
+		},
+		{
+			name:  "quantity proto",
+			input: system.Collection{&dtpb.Quantity{Value: &dtpb.Decimal{Value: "5"}, Unit: fhir.String("mg")}},
+			arg:   system.Collection{system.MustParseQuantity("1", "g")},
+			want:  system.Collection{system.Boolean(true)},
+		},
+		{
+			name:  "empty input",
+			input: system.Collection{},
+			arg:   system.Collection{system.MustParseQuantity("1", "g")},
+			want:  system.Collection{},
+		},
+		{
+			name:    "non-quantity input",
+			input:   system.Collection{system.Integer(1)},
+			arg:     system.Collection{system.MustParseQuantity("1", "g")},
+			wantErr: impl.ErrInvalidInput,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := impl.Comparable(&expr.Context{}, tc.input, exprtest.Return(tc.arg...))
+
+			if !cmp.Equal(err, tc.wantErr, cmpopts.EquateErrors()) {
+				t.Fatalf("Comparable returned unexpected error: got %v, want %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Comparable returned unexpected diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package impl
+
+import (
+	"fmt"
+
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/expr"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/reflection"
+	"github.com/verily-src/fhirpath-go/fhirpath/system"
+)
+
+// Type returns the type information of each item in the input collection.
+// System types and FHIR primitives are described by a SimpleTypeInfo, and
+// other FHIR types by a ClassInfo, whose properties can be navigated to, e.g.
+// `Observation.value.type().name`.
+// FHIRPath docs here: https://hl7.org/fhirpath/N1/#reflection
+func Type(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	if length := len(args); length != 0 {
+		return nil, fmt.Errorf("%w: received %v arguments, expected 0", ErrWrongArity, length)
+	}
+	result := system.Collection{}
+	for _, item := range input {
+		info, err := reflection.TypeInfoOf(item)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+		}
+		result = append(result, info)
+	}
+	return result, nil
+}
@@ -0,0 +1,61 @@
+package impl_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/expr"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/expr/exprtest"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/funcs/impl"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/reflection"
+	"github.com/verily-src/fhirpath-go/fhirpath/system"
+)
+
+func TestType(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   system.Collection
+		args    []expr.Expression
+		want    system.Collection
+		wantErr error
+	}{
+		{
+			name:  "returns type of each item",
+			input: system.Collection{system.Integer(1), system.String("a")},
+			want: system.Collection{
+				reflection.SimpleTypeInfo{Namespace: "System", Name: "Integer", BaseType: reflection.MustCreateTypeSpecifier("System", "Any")},
+				reflection.SimpleTypeInfo{Namespace: "System", Name: "String", BaseType: reflection.MustCreateTypeSpecifier("System", "Any")},
+			},
+		},
+		{
+			name:  "returns empty for empty input",
+			input: system.Collection{},
+			want:  system.Collection{},
+		},
+		{
+			name:    "errors if arguments are provided",
+			input:   system.Collection{system.Integer(1)},
+			args:    []expr.Expression{exprtest.Return(system.Integer(1))},
+			wantErr: impl.ErrWrongArity,
+		},
+		{
+			name:    "errors for unsupported input",
+			input:   system.Collection{struct{}{}},
+			wantErr: impl.ErrInvalidInput,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := impl.Type(&expr.Context{}, tc.input, tc.args...)
+
+			if !cmp.Equal(err, tc.wantErr, cmpopts.EquateErrors()) {
+				t.Fatalf("Type() returned unexpected error: got %v, want %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(reflection.TypeSpecifier{})); diff != "" {
+				t.Errorf("Type() returned unexpected diff (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
@@ -1,17 +1,26 @@
 package impl
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"html"
 	"regexp"
+	"strconv"
 	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
 
 	"github.com/verily-src/fhirpath-go/fhirpath/internal/expr"
 	"github.com/verily-src/fhirpath-go/fhirpath/system"
 )
 
 var (
-	ErrInvalidRegex = errors.New("invalid regex")
+	ErrInvalidRegex  = errors.New("invalid regex")
+	ErrInvalidFormat = errors.New("invalid format")
 )
 
 // StartsWith returns true if the input string starts with the given prefix.
@@ -531,3 +540,309 @@ func Split(ctx *expr.Context, input system.Collection, args ...expr.Expression)
 	}
 	return result, nil
 }
+
+// Trim returns the input string with leading and trailing whitespace removed.
+// FHIRPath docs here: https://build.fhir.org/ig/HL7/FHIRPath/#trim--string
+func Trim(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	if length := len(args); length != 0 {
+		return nil, fmt.Errorf("%w, received %v arguments, expected 0", ErrWrongArity, length)
+	}
+
+	// Validate single string input
+	if length := len(input); length > 1 {
+		return nil, fmt.Errorf("%w: input has length %v, expected 1", ErrWrongArity, length)
+	} else if length == 0 {
+		return system.Collection{}, nil
+	}
+	fullString, err := input.ToString()
+	if err != nil {
+		return nil, err
+	}
+
+	return system.Collection{system.String(strings.TrimSpace(fullString))}, nil
+}
+
+// LastIndexOf returns the 0-based index of the last position the substring is
+// found in the input string, or -1 if it is not found.
+// FHIRPath docs here: https://build.fhir.org/ig/HL7/FHIRPath/#lastindexofsubstring--string--integer
+func LastIndexOf(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	// Validate single string input
+	if length := len(input); length > 1 {
+		return nil, fmt.Errorf("%w: input has length %v, expected 1", ErrWrongArity, length)
+	} else if length == 0 {
+		return system.Collection{}, nil
+	}
+	fullString, err := input.ToString()
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate single string argument
+	if length := len(args); length != 1 {
+		return nil, fmt.Errorf("%w: received %v arguments, expected 1", ErrWrongArity, length)
+	}
+	output, err := args[0].Evaluate(ctx, input)
+	if err != nil {
+		return nil, err
+	} else if length := len(output); length == 0 {
+		// Return empty for empty argument
+		return system.Collection{}, nil
+	} else if length > 1 {
+		return nil, fmt.Errorf("%w: received %v arguments, expected 1", ErrWrongArity, length)
+	}
+	substring, err := output.ToString()
+	if err != nil {
+		return nil, err
+	}
+	// The spec defines the index of an empty substring as 0
+	if substring == "" {
+		return system.Collection{system.Integer(0)}, nil
+	}
+
+	result := system.Integer(strings.LastIndex(fullString, substring))
+	return system.Collection{result}, nil
+}
+
+// MatchesFull returns true if the given regex matches the entire input string.
+// FHIRPath docs here: https://build.fhir.org/ig/HL7/FHIRPath/#matchesfullregex--string--boolean
+func MatchesFull(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	// Validate single string input
+	if length := len(input); length > 1 {
+		return nil, fmt.Errorf("%w: input has length %v, expected 1", ErrWrongArity, length)
+	} else if length == 0 {
+		return system.Collection{}, nil
+	}
+	fullString, err := input.ToString()
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate single string argument
+	if length := len(args); length != 1 {
+		return nil, fmt.Errorf("%w: received %v arguments, expected 1", ErrWrongArity, length)
+	}
+	output, err := args[0].Evaluate(ctx, input)
+	if err != nil {
+		return nil, err
+	} else if length := len(output); length == 0 {
+		return system.Collection{}, nil
+	} else if length != 1 {
+		return nil, fmt.Errorf("%w: received %v arguments, expected 1", ErrWrongArity, length)
+	}
+	regexString, err := output.ToString()
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile("^(?:" + regexString + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidRegex, regexString)
+	}
+
+	result := system.Boolean(re.MatchString(fullString))
+	return system.Collection{result}, nil
+}
+
+// Encode returns the input string encoded with the given format, which is one
+// of 'hex', 'base64' or 'urlbase64'.
+// FHIRPath docs here: https://build.fhir.org/ig/HL7/FHIRPath/#encodeformat--string--string
+func Encode(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	fullString, format, ok, err := stringWithFormat(ctx, input, args...)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return system.Collection{}, nil
+	}
+
+	var result string
+	switch format {
+	case "hex":
+		result = hex.EncodeToString([]byte(fullString))
+	case "base64":
+		result = base64.StdEncoding.EncodeToString([]byte(fullString))
+	case "urlbase64":
+		result = base64.URLEncoding.EncodeToString([]byte(fullString))
+	default:
+		return nil, fmt.Errorf("%w: unsupported encoding '%s'", ErrInvalidFormat, format)
+	}
+	return system.Collection{system.String(result)}, nil
+}
+
+// Decode returns the input string decoded from the given format, which is one
+// of 'hex', 'base64' or 'urlbase64'.
+// FHIRPath docs here: https://build.fhir.org/ig/HL7/FHIRPath/#decodeformat--string--string
+func Decode(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	fullString, format, ok, err := stringWithFormat(ctx, input, args...)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return system.Collection{}, nil
+	}
+
+	var result []byte
+	switch format {
+	case "hex":
+		result, err = hex.DecodeString(fullString)
+	case "base64":
+		result, err = base64.StdEncoding.DecodeString(fullString)
+	case "urlbase64":
+		result, err = base64.URLEncoding.DecodeString(fullString)
+	default:
+		return nil, fmt.Errorf("%w: unsupported encoding '%s'", ErrInvalidFormat, format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to decode %s: %v", ErrInvalidInput, format, err)
+	}
+	return system.Collection{system.String(result)}, nil
+}
+
+// Escape returns the input string escaped for the given target, which is one
+// of 'html' or 'json'.
+// FHIRPath docs here: https://build.fhir.org/ig/HL7/FHIRPath/#escapetarget--string--string
+func Escape(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	fullString, target, ok, err := stringWithFormat(ctx, input, args...)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return system.Collection{}, nil
+	}
+
+	var result string
+	switch target {
+	case "html":
+		result = html.EscapeString(fullString)
+	case "json":
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(fullString); err != nil {
+			return nil, err
+		}
+		// Strip the surrounding quotes and trailing newline added by the encoder.
+		encoded := strings.TrimSuffix(buf.String(), "\n")
+		result = encoded[1 : len(encoded)-1]
+	default:
+		return nil, fmt.Errorf("%w: unsupported escape target '%s'", ErrInvalidFormat, target)
+	}
+	return system.Collection{system.String(result)}, nil
+}
+
+// Unescape returns the input string unescaped from the given target, which is
+// one of 'html' or 'json'.
+// FHIRPath docs here: https://build.fhir.org/ig/HL7/FHIRPath/#unescapetarget--string--string
+func Unescape(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	fullString, target, ok, err := stringWithFormat(ctx, input, args...)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return system.Collection{}, nil
+	}
+
+	var result string
+	switch target {
+	case "html":
+		result = html.UnescapeString(fullString)
+	case "json":
+		if result, err = unescapeJSON(fullString); err != nil {
+			return nil, fmt.Errorf("%w: unable to unescape json: %v", ErrInvalidInput, err)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported escape target '%s'", ErrInvalidFormat, target)
+	}
+	return system.Collection{system.String(result)}, nil
+}
+
+// unescapeJSON replaces the JSON escape sequences in s with the characters
+// they represent. Other characters, including unescaped quotes and newlines,
+// are kept as they are.
+func unescapeJSON(s string) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			sb.WriteByte(s[i])
+			continue
+		}
+		if i++; i == len(s) {
+			return "", errors.New("unterminated escape sequence")
+		}
+		switch s[i] {
+		case '"', '\\', '/':
+			sb.WriteByte(s[i])
+		case 'b':
+			sb.WriteByte('\b')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 't':
+			sb.WriteByte('\t')
+		case 'u':
+			r, ok := hexRune(s[i+1:])
+			if !ok {
+				return "", fmt.Errorf("invalid escape sequence '\\%s'", s[i:min(i+5, len(s))])
+			}
+			i += 4
+			// Characters outside the Basic Multilingual Plane are escaped as
+			// a surrogate pair.
+			if utf16.IsSurrogate(r) && strings.HasPrefix(s[i+1:], `\u`) {
+				if low, ok := hexRune(s[i+3:]); ok {
+					if pair := utf16.DecodeRune(r, low); pair != utf8.RuneError {
+						r = pair
+						i += 6
+					}
+				}
+			}
+			sb.WriteRune(r)
+		default:
+			return "", fmt.Errorf("invalid escape sequence '\\%c'", s[i])
+		}
+	}
+	return sb.String(), nil
+}
+
+// hexRune parses the four hexadecimal digits at the start of s.
+func hexRune(s string) (rune, bool) {
+	if len(s) < 4 {
+		return 0, false
+	}
+	value, err := strconv.ParseUint(s[:4], 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	return rune(value), true
+}
+
+// stringWithFormat validates the single string input and single string format
+// argument shared by the encoding and escaping functions. Returns false if
+// either the input or the argument is empty, in which case the result is empty.
+func stringWithFormat(ctx *expr.Context, input system.Collection, args ...expr.Expression) (string, string, bool, error) {
+	// Validate single string input
+	if length := len(input); length > 1 {
+		return "", "", false, fmt.Errorf("%w: input has length %v, expected 1", ErrWrongArity, length)
+	} else if length == 0 {
+		return "", "", false, nil
+	}
+	fullString, err := input.ToString()
+	if err != nil {
+		return "", "", false, err
+	}
+
+	// Validate single string argument
+	if length := len(args); length != 1 {
+		return "", "", false, fmt.Errorf("%w: received %v arguments, expected 1", ErrWrongArity, length)
+	}
+	output, err := args[0].Evaluate(ctx, input)
+	if err != nil {
+		return "", "", false, err
+	} else if length := len(output); length == 0 {
+		return "", "", false, nil
+	} else if length != 1 {
+		return "", "", false, fmt.Errorf("%w: received %v arguments, expected 1", ErrWrongArity, length)
+	}
+	format, err := output.ToString()
+	if err != nil {
+		return "", "", false, err
+	}
+	return fullString, format, true, nil
+}
@@ -1436,3 +1436,489 @@ func TestSplit(t *testing.T) {
 		})
 	}
 }
+
+func TestTrim(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   system.Collection
+		args    []expr.Expression
+		want    system.Collection
+		wantErr error
+	}{
+		{
+			name:    "returns empty for empty input",
+			input:   system.Collection{},
+			want:    system.Collection{},
+			wantErr: nil,
+		},
+		{
+			name:    "removes leading and trailing whitespace",
+			input:   system.Collection{system.String(" \t Lee Jieun \n")},
+			want:    system.Collection{system.String("Lee Jieun")},
+			wantErr: nil,
+		},
+		{
+			name:    "returns empty string for whitespace input",
+			input:   system.Collection{system.String("   ")},
+			want:    system.Collection{system.String("")},
+			wantErr: nil,
+		},
+		{
+			name:    "errors if input length is more than 1",
+			input:   system.Collection{system.String("a"), system.String("b")},
+			want:    nil,
+			wantErr: impl.ErrWrongArity,
+		},
+		{
+			name:    "errors if input is not a string",
+			input:   system.Collection{system.Integer(516)},
+			want:    nil,
+			wantErr: system.ErrNotConvertible,
+		},
+		{
+			name:  "errors if args are provided",
+			input: system.Collection{system.String("a")},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String(" ")},
+			},
+			want:    nil,
+			wantErr: impl.ErrWrongArity,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := impl.Trim(&expr.Context{}, tc.input, tc.args...)
+
+			if !cmp.Equal(err, tc.wantErr, cmpopts.EquateErrors()) {
+				t.Fatalf("Trim got unexpected error: got %v, want %v", err, tc.wantErr)
+			}
+			if !cmp.Equal(tc.want, got) {
+				t.Errorf("Trim returned unexpected result: got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLastIndexOf(t *testing.T) {
+	fullString := system.String("abcabc")
+
+	testCases := []struct {
+		name    string
+		input   system.Collection
+		args    []expr.Expression
+		want    system.Collection
+		wantErr error
+	}{
+		{
+			name:  "returns empty for empty input",
+			input: system.Collection{},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String("a")},
+			},
+			want:    system.Collection{},
+			wantErr: nil,
+		},
+		{
+			name:  "returns empty for empty argument",
+			input: system.Collection{fullString},
+			args: []expr.Expression{
+				&expr.LiteralExpression{},
+			},
+			want:    system.Collection{},
+			wantErr: nil,
+		},
+		{
+			name:  "returns index of last match",
+			input: system.Collection{fullString},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String("bc")},
+			},
+			want:    system.Collection{system.Integer(4)},
+			wantErr: nil,
+		},
+		{
+			name:  "returns 0 for empty substring",
+			input: system.Collection{fullString},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String("")},
+			},
+			want:    system.Collection{system.Integer(0)},
+			wantErr: nil,
+		},
+		{
+			name:  "returns -1 for no match",
+			input: system.Collection{fullString},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String("z")},
+			},
+			want:    system.Collection{system.Integer(-1)},
+			wantErr: nil,
+		},
+		{
+			name:  "errors if input length is more than 1",
+			input: system.Collection{fullString, fullString},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String("a")},
+			},
+			want:    nil,
+			wantErr: impl.ErrWrongArity,
+		},
+		{
+			name:    "errors if args length is not 1",
+			input:   system.Collection{fullString},
+			want:    nil,
+			wantErr: impl.ErrWrongArity,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := impl.LastIndexOf(&expr.Context{}, tc.input, tc.args...)
+
+			if !cmp.Equal(err, tc.wantErr, cmpopts.EquateErrors()) {
+				t.Fatalf("LastIndexOf got unexpected error: got %v, want %v", err, tc.wantErr)
+			}
+			if !cmp.Equal(tc.want, got) {
+				t.Errorf("LastIndexOf returned unexpected result: got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMatchesFull(t *testing.T) {
+	fullString := system.String("N8000123123")
+
+	testCases := []struct {
+		name    string
+		input   system.Collection
+		args    []expr.Expression
+		want    system.Collection
+		wantErr error
+	}{
+		{
+			name:  "returns empty for empty input",
+			input: system.Collection{},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String("N[0-9]{8}")},
+			},
+			want:    system.Collection{},
+			wantErr: nil,
+		},
+		{
+			name:  "returns true for full match",
+			input: system.Collection{fullString},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String("N[0-9]{10}")},
+			},
+			want:    system.Collection{system.Boolean(true)},
+			wantErr: nil,
+		},
+		{
+			name:  "returns false for partial match",
+			input: system.Collection{fullString},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String("N[0-9]{8}")},
+			},
+			want:    system.Collection{system.Boolean(false)},
+			wantErr: nil,
+		},
+		{
+			name:  "anchors each alternative",
+			input: system.Collection{fullString},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String("N8|123")},
+			},
+			want:    system.Collection{system.Boolean(false)},
+			wantErr: nil,
+		},
+		{
+			name:  "errors for invalid regex",
+			input: system.Collection{fullString},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String("N[0-9")},
+			},
+			want:    nil,
+			wantErr: impl.ErrInvalidRegex,
+		},
+		{
+			name:    "errors if args length is not 1",
+			input:   system.Collection{fullString},
+			want:    nil,
+			wantErr: impl.ErrWrongArity,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := impl.MatchesFull(&expr.Context{}, tc.input, tc.args...)
+
+			if !cmp.Equal(err, tc.wantErr, cmpopts.EquateErrors()) {
+				t.Fatalf("MatchesFull got unexpected error: got %v, want %v", err, tc.wantErr)
+			}
+			if !cmp.Equal(tc.want, got) {
+				t.Errorf("MatchesFull returned unexpected result: got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	testCases := []struct {
+		name    string
+		fn      func(*expr.Context, system.Collection, ...expr.Expression) (system.Collection, error)
+		input   system.Collection
+		args    []expr.Expression
+		want    system.Collection
+		wantErr error
+	}{
+		{
+			name:  "encode returns empty for empty input",
+			fn:    impl.Encode,
+			input: system.Collection{},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String("hex")},
+			},
+			want: system.Collection{},
+		},
+		{
+			name:  "encode returns empty for empty format",
+			fn:    impl.Encode,
+			input: system.Collection{system.String("test")},
+			args: []expr.Expression{
+				&expr.LiteralExpression{},
+			},
+			want: system.Collection{},
+		},
+		{
+			name:  "encodes hex",
+			fn:    impl.Encode,
+			input: system.Collection{system.String("test")},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String("hex")},
+			},
+			want: system.Collection{system.String("74657374")},
+		},
+		{
+			name:  "encodes base64",
+			fn:    impl.Encode,
+			input: system.Collection{system.String("subjects?")},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String("base64")},
+			},
+			want: system.Collection{system.String("c3ViamVjdHM/")},
+		},
+		{
+			name:  "encodes urlbase64",
+			fn:    impl.Encode,
+			input: system.Collection{system.String("subjects?")},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String("urlbase64")},
+			},
+			want: system.Collection{system.String("c3ViamVjdHM_")},
+		},
+		{
+			name:  "encode errors for unsupported format",
+			fn:    impl.Encode,
+			input: system.Collection{system.String("test")},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String("rot13")},
+			},
+			wantErr: impl.ErrInvalidFormat,
+		},
+		{
+			name:  "encode errors if input length is more than 1",
+			fn:    impl.Encode,
+			input: system.Collection{system.String("a"), system.String("b")},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String("hex")},
+			},
+			wantErr: impl.ErrWrongArity,
+		},
+		{
+			name:    "encode errors if args length is not 1",
+			fn:      impl.Encode,
+			input:   system.Collection{system.String("test")},
+			wantErr: impl.ErrWrongArity,
+		},
+		{
+			name:  "decodes hex",
+			fn:    impl.Decode,
+			input: system.Collection{system.String("74657374")},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String("hex")},
+			},
+			want: system.Collection{system.String("test")},
+		},
+		{
+			name:  "decodes base64",
+			fn:    impl.Decode,
+			input: system.Collection{system.String("c3ViamVjdHM/")},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String("base64")},
+			},
+			want: system.Collection{system.String("subjects?")},
+		},
+		{
+			name:  "decodes urlbase64",
+			fn:    impl.Decode,
+			input: system.Collection{system.String("c3ViamVjdHM_")},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String("urlbase64")},
+			},
+			want: system.Collection{system.String("subjects?")},
+		},
+		{
+			name:  "decode errors for malformed input",
+			fn:    impl.Decode,
+			input: system.Collection{system.String("zz")},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String("hex")},
+			},
+			wantErr: impl.ErrInvalidInput,
+		},
+		{
+			name:  "decode errors for unsupported format",
+			fn:    impl.Decode,
+			input: system.Collection{system.String("test")},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String("rot13")},
+			},
+			wantErr: impl.ErrInvalidFormat,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.fn(&expr.Context{}, tc.input, tc.args...)
+
+			if !cmp.Equal(err, tc.wantErr, cmpopts.EquateErrors()) {
+				t.Fatalf("got unexpected error: got %v, want %v", err, tc.wantErr)
+			}
+			if !cmp.Equal(tc.want, got) {
+				t.Errorf("returned unexpected result: got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEscapeUnescape(t *testing.T) {
+	testCases := []struct {
+		name    string
+		fn      func(*expr.Context, system.Collection, ...expr.Expression) (system.Collection, error)
+		input   system.Collection
+		args    []expr.Expression
+		want    system.Collection
+		wantErr error
+	}{
+		{
+			name:  "escape returns empty for empty input",
+			fn:    impl.Escape,
+			input: system.Collection{},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String("html")},
+			},
+			want: system.Collection{},
+		},
+		{
+			name:  "escapes html",
+			fn:    impl.Escape,
+			input: system.Collection{system.String(`"1 < 5"`)},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String("html")},
+			},
+			want: system.Collection{system.String("&#34;1 &lt; 5&#34;")},
+		},
+		{
+			name:  "escapes json",
+			fn:    impl.Escape,
+			input: system.Collection{system.String("\"1 < 5\"\n")},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String("json")},
+			},
+			want: system.Collection{system.String(`\"1 < 5\"\n`)},
+		},
+		{
+			name:  "escape errors for unsupported target",
+			fn:    impl.Escape,
+			input: system.Collection{system.String("test")},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String("xml")},
+			},
+			wantErr: impl.ErrInvalidFormat,
+		},
+		{
+			name:    "escape errors if args length is not 1",
+			fn:      impl.Escape,
+			input:   system.Collection{system.String("test")},
+			wantErr: impl.ErrWrongArity,
+		},
+		{
+			name:  "unescapes html",
+			fn:    impl.Unescape,
+			input: system.Collection{system.String("&quot;1 &lt; 5&quot;")},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String("html")},
+			},
+			want: system.Collection{system.String(`"1 < 5"`)},
+		},
+		{
+			name:  "unescapes json",
+			fn:    impl.Unescape,
+			input: system.Collection{system.String(`\"1 < 5\"!`)},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String("json")},
+			},
+			want: system.Collection{system.String(`"1 < 5"!`)},
+		},
+		{
+			name:  "unescape json keeps unescaped quotes and newlines",
+			fn:    impl.Unescape,
+			input: system.Collection{system.String("say \"hi\"\n\\n\"")},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String("json")},
+			},
+			want: system.Collection{system.String("say \"hi\"\n\n\"")},
+		},
+		{
+			name:  "unescapes json unicode escapes",
+			fn:    impl.Unescape,
+			input: system.Collection{system.String(`\u00e9\/\ud83d\ude00`)},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String("json")},
+			},
+			want: system.Collection{system.String("é/😀")},
+		},
+		{
+			name:  "unescape errors for malformed json",
+			fn:    impl.Unescape,
+			input: system.Collection{system.String(`\x`)},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String("json")},
+			},
+			wantErr: impl.ErrInvalidInput,
+		},
+		{
+			name:  "unescape errors for unsupported target",
+			fn:    impl.Unescape,
+			input: system.Collection{system.String("test")},
+			args: []expr.Expression{
+				&expr.LiteralExpression{Literal: system.String("xml")},
+			},
+			wantErr: impl.ErrInvalidFormat,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.fn(&expr.Context{}, tc.input, tc.args...)
+
+			if !cmp.Equal(err, tc.wantErr, cmpopts.EquateErrors()) {
+				t.Fatalf("got unexpected error: got %v, want %v", err, tc.wantErr)
+			}
+			if !cmp.Equal(tc.want, got) {
+				t.Errorf("returned unexpected result: got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
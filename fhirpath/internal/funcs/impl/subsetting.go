@@ -8,6 +8,20 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
+// Single Returns the single item in the input if there is just one item.
+// If the input collection is empty, the result is empty.
+// If there are multiple items, an ErrNotSingleton error is raised.
+// FHIRPath docs here: https://hl7.org/fhirpath/N1/#single-collection
+func Single(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("%w: received %v arguments, expected 0", ErrWrongArity, len(args))
+	}
+	if len(input) > 1 {
+		return nil, fmt.Errorf("%w: input has %d elements", ErrNotSingleton, len(input))
+	}
+	return append(system.Collection{}, input...), nil
+}
+
 // First Returns a collection containing only the first item in the input collection.
 // This function is equivalent to item[0], so it will return an empty collection if the input collection has no items.
 // FHIRPath docs here: https://hl7.org/fhirpath/N1/#first-collection
@@ -15,6 +15,50 @@ import (
 	"github.com/verily-src/fhirpath-go/fhirpath/system"
 )
 
+func TestSingle(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   system.Collection
+		args    []expr.Expression
+		want    system.Collection
+		wantErr error
+	}{
+		{
+			name:  "returns an empty collection if input is empty",
+			input: system.Collection{},
+			want:  system.Collection{},
+		},
+		{
+			name:  "returns the single element",
+			input: system.Collection{system.Integer(1)},
+			want:  system.Collection{system.Integer(1)},
+		},
+		{
+			name:    "raises error for multiple elements",
+			input:   system.Collection{system.Integer(1), system.Integer(2)},
+			wantErr: impl.ErrNotSingleton,
+		},
+		{
+			name:    "raises error for arguments",
+			input:   system.Collection{system.Integer(1)},
+			args:    []expr.Expression{exprtest.Return(system.Integer(1))},
+			wantErr: impl.ErrWrongArity,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := impl.Single(&expr.Context{}, tc.input, tc.args...)
+			if !cmp.Equal(err, tc.wantErr, cmpopts.EquateErrors()) {
+				t.Fatalf("Single() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got, protocmp.Transform()); diff != "" {
+				t.Errorf("Single() returned unexpected diff (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestFirst(t *testing.T) {
 	testCases := []struct {
 		name    string
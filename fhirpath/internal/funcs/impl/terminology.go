@@ -3,8 +3,11 @@ package impl
 import (
 	"errors"
 	"fmt"
+	"net/url"
+	"strings"
 
 	dtpb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/datatypes_go_proto"
+	pgp "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/parameters_go_proto"
 	"github.com/verily-src/fhirpath-go/fhirpath/internal/expr"
 	"github.com/verily-src/fhirpath-go/fhirpath/system"
 	"github.com/verily-src/fhirpath-go/fhirpath/terminology"
@@ -15,8 +18,15 @@ var (
 	ErrNotSupported       = errors.New("Not Supported, memberOf must be called on a single Coding or a CodeableConcept")
 )
 
-// MemberOf takes a Coding or a Codeable concept and a ValueSet internal id
-// and determines if the coding of any of the coding is inside the ValueSet
+// MemberOf returns true if the single input is a member of the value set
+// given by the argument, which is a canonical URL that may carry a version,
+// e.g. 'http://hl7.org/fhir/ValueSet/example|4.0.1'. The input may be a
+// Coding, a CodeableConcept, whose codings are checked one by one, or a code,
+// string or uri element, which is validated without a code system, as implied
+// by its binding. Errors from the Terminology Service evaluate to false,
+// unless strict terminology is enabled.
+//
+// For more details, see https://hl7.org/fhir/R4/fhirpath.html#functions
 func MemberOf(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
 	if length := len(args); length != 1 {
 		return nil, fmt.Errorf("%w: received %d arguments, expected 1", ErrWrongArity, length)
@@ -35,76 +45,536 @@ func MemberOf(ctx *expr.Context, input system.Collection, args ...expr.Expressio
 		return nil, fmt.Errorf("empty argument content")
 	}
 
-	var valueSetId string
-	switch content[0].(type) {
-	case system.String:
-		valueSetId = string(content[0].(system.String))
-	case *dtpb.String:
-		valueSetId = content[0].(*dtpb.String).GetValue()
-	default:
+	valueSet, err := system.From(content[0])
+	if err != nil {
+		return nil, fmt.Errorf("unsupported argument type")
+	}
+	valueSetStr, ok := valueSet.(system.String)
+	if !ok {
 		return nil, fmt.Errorf("unsupported argument type")
 	}
 
-	validateResult := false
-	for _, item := range input {
-		switch res := item.(type) {
-		case *dtpb.Coding:
-			result, err := validateCoding(ctx, res.GetCode().GetValue(), res.GetSystem().GetValue(), valueSetId)
-			if err != nil {
-				return system.Collection{system.Boolean(false)}, nil
-			}
-			validateResult = result
-
-		case *dtpb.CodeableConcept:
-			// If it's a Codeable Concept, we will checking the coding inside one by one
-			for _, coding := range res.GetCoding() {
-				result, err := validateCoding(ctx, coding.GetCode().GetValue(), coding.GetSystem().GetValue(), valueSetId)
-				if err != nil {
-					continue
-				}
-				if result {
-					validateResult = result
-					break
-				}
-			}
-		default:
+	var codings []*dtpb.Coding
+	bound := false
+	switch res := input[0].(type) {
+	case *dtpb.Coding:
+		codings = []*dtpb.Coding{res}
+	case *dtpb.CodeableConcept:
+		codings = res.GetCoding()
+	default:
+		// Codes, strings and uris take their code system from their binding.
+		code, err := system.From(res)
+		if err != nil {
+			return nil, ErrNotSupported
+		}
+		str, ok := code.(system.String)
+		if !ok {
 			return nil, ErrNotSupported
 		}
+		codings = []*dtpb.Coding{{Code: &dtpb.Code{Value: string(str)}}}
+		bound = true
 	}
-	return system.Collection{system.Boolean(validateResult)}, nil
-}
 
-func validateCoding(ctx *expr.Context, code string, system string, valueSet string) (bool, error) {
-	// We will not evaluate code without system
-	if system == "" {
-		return false, nil
+	for _, coding := range codings {
+		// We will not evaluate a coding without system
+		if !bound && coding.GetSystem().GetValue() == "" {
+			continue
+		}
+		result, err := validateCoding(ctx, coding, string(valueSetStr))
+		if err != nil {
+			if ctx.StrictTerminology {
+				return nil, err
+			}
+			continue
+		}
+		if result {
+			return system.Collection{system.Boolean(true)}, nil
+		}
 	}
+	return system.Collection{system.Boolean(false)}, nil
+}
 
+func validateCoding(ctx *expr.Context, coding *dtpb.Coding, valueSet string) (bool, error) {
 	ts := ctx.TermService
 	if ts == nil {
 		return false, ErrUnconfiguredClient
 	}
 
-	opt := &terminology.ValueSetValidateCodeOptions{
-		Code:   code,
-		System: system,
-		ID:     valueSet,
-	}
+	opt := valueSetValidateCodeOptions(valueSet)
+	opt.System = coding.GetSystem().GetValue()
+	opt.SystemVersion = coding.GetVersion().GetValue()
+	opt.Code = coding.GetCode().GetValue()
+	opt.Display = coding.GetDisplay().GetValue()
 
 	response, err := ts.ValueSetValidateCode(ctx, opt)
 	if err != nil {
-		return false, fmt.Errorf("validating valueSet code: %s", err)
+		return false, fmt.Errorf("validating valueSet code: %w", err)
 	}
 
-	result := false
-	for _, item := range response.Parameter {
-		switch item.GetName().GetValue() {
-		case "result":
-			result = item.GetValue().GetBoolean().GetValue()
-		default:
-			continue
+	return parameterBoolean(response, "result"), nil
+}
+
+// valueSetValidateCodeOptions returns the options identifying the given
+// value set, which is either an id or a canonical URL with an optional
+// version.
+func valueSetValidateCodeOptions(valueSet string) *terminology.ValueSetValidateCodeOptions {
+	if !strings.Contains(valueSet, ":") {
+		return &terminology.ValueSetValidateCodeOptions{ID: valueSet}
+	}
+	url, version, _ := strings.Cut(valueSet, "|")
+	return &terminology.ValueSetValidateCodeOptions{
+		ID:              url,
+		URL:             url,
+		ValueSetVersion: version,
+	}
+}
+
+var (
+	ErrUnsupportedOperation = errors.New("operation is not supported by the configured Terminology Service")
+	ErrIncomparableCodes    = errors.New("codes are from different code systems")
+)
+
+// Subsumption outcomes of the CodeSystem $subsumes operation.
+const (
+	outcomeEquivalent = "equivalent"
+	outcomeSubsumes   = "subsumes"
+	outcomeSubsumedBy = "subsumed-by"
+)
+
+// Subsumes returns true if the single input Coding or CodeableConcept is
+// equivalent to, or subsumes, the single Coding or CodeableConcept argument,
+// and false otherwise. Returns empty if the input or argument is not a single
+// item.
+//
+// When invoked on %terminologies, with a code system, two codes and optional
+// params, this instead returns the outcome code of the $subsumes operation.
+//
+// For more details, see https://hl7.org/fhir/R4/fhirpath.html#functions
+func Subsumes(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	if isTerminologies(input) {
+		return terminologySubsumes(ctx, input, args...)
+	}
+	return subsumption(ctx, input, outcomeSubsumes, args...)
+}
+
+// SubsumedBy returns true if the single input Coding or CodeableConcept is
+// equivalent to, or subsumed by, the single Coding or CodeableConcept
+// argument, and false otherwise. Returns empty if the input or argument is not
+// a single item.
+//
+// For more details, see https://hl7.org/fhir/R4/fhirpath.html#functions
+func SubsumedBy(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	return subsumption(ctx, input, outcomeSubsumedBy, args...)
+}
+
+// subsumption tests whether any Coding of the input has the given
+// relationship to any Coding of the argument, in a common code system.
+func subsumption(ctx *expr.Context, input system.Collection, relationship string, args ...expr.Expression) (system.Collection, error) {
+	if length := len(args); length != 1 {
+		return nil, fmt.Errorf("%w: received %d arguments, expected 1", ErrWrongArity, length)
+	}
+	arg, err := args[0].Evaluate(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if !input.IsSingleton() || !arg.IsSingleton() {
+		return system.Collection{}, nil
+	}
+	source, err := codingsOf(input[0])
+	if err != nil {
+		return nil, err
+	}
+	given, err := codingsOf(arg[0])
+	if err != nil {
+		return nil, err
+	}
+	subsumer, err := termService[terminology.Subsumer](ctx, "$subsumes")
+	if err != nil {
+		return nil, err
+	}
+
+	compared := false
+	for _, a := range source {
+		for _, b := range given {
+			codeSystem := a.GetSystem().GetValue()
+			if codeSystem == "" || codeSystem != b.GetSystem().GetValue() {
+				continue
+			}
+			compared = true
+			outcome, err := subsumesOutcome(ctx, subsumer, &terminology.SubsumesOptions{
+				System:  codeSystem,
+				Version: a.GetVersion().GetValue(),
+				CodeA:   a.GetCode().GetValue(),
+				CodeB:   b.GetCode().GetValue(),
+			})
+			if err != nil {
+				return nil, err
+			}
+			if outcome == outcomeEquivalent || outcome == relationship {
+				return system.Collection{system.Boolean(true)}, nil
+			}
+		}
+	}
+	if !compared {
+		return nil, ErrIncomparableCodes
+	}
+	return system.Collection{system.Boolean(false)}, nil
+}
+
+// terminologySubsumes implements %terminologies.subsumes(system, coded1,
+// coded2, params), which returns the outcome code of the $subsumes operation.
+func terminologySubsumes(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	if length := len(args); length < 3 || length > 4 {
+		return nil, fmt.Errorf("%w: received %d arguments, expected 3 or 4", ErrWrongArity, length)
+	}
+	codeSystem, err := stringArg(ctx, input, args[0])
+	if err != nil {
+		return nil, err
+	}
+	a, err := codingArg(ctx, input, args[1])
+	if err != nil {
+		return nil, err
+	}
+	b, err := codingArg(ctx, input, args[2])
+	if err != nil {
+		return nil, err
+	}
+	params, err := paramsArg(ctx, input, args, 3)
+	if err != nil {
+		return nil, err
+	}
+	subsumer, err := termService[terminology.Subsumer](ctx, "$subsumes")
+	if err != nil {
+		return nil, err
+	}
+	outcome, err := subsumesOutcome(ctx, subsumer, &terminology.SubsumesOptions{
+		System:  codeSystem,
+		Version: a.GetVersion().GetValue(),
+		CodeA:   a.GetCode().GetValue(),
+		CodeB:   b.GetCode().GetValue(),
+		Params:  params,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return system.Collection{system.String(outcome)}, nil
+}
+
+func subsumesOutcome(ctx *expr.Context, subsumer terminology.Subsumer, opts *terminology.SubsumesOptions) (string, error) {
+	response, err := subsumer.Subsumes(ctx, opts)
+	if err != nil {
+		return "", fmt.Errorf("testing subsumption: %w", err)
+	}
+	for _, param := range response.GetParameter() {
+		if param.GetName().GetValue() == "outcome" {
+			value := param.GetValue()
+			if code := value.GetCode(); code != nil {
+				return code.GetValue(), nil
+			}
+			return value.GetStringValue().GetValue(), nil
+		}
+	}
+	return "", fmt.Errorf("testing subsumption: response has no outcome")
+}
+
+// Expand implements %terminologies.expand(valueSet, params), which returns
+// the expansion of the value set with the given URL, as a ValueSet resource.
+//
+// For more details, see https://hl7.org/fhir/R4/fhirpath.html#txapi
+func Expand(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	if err := validateTerminologies(input, args, 1, 2); err != nil {
+		return nil, err
+	}
+	valueSet, err := stringArg(ctx, input, args[0])
+	if err != nil {
+		return nil, err
+	}
+	params, err := paramsArg(ctx, input, args, 1)
+	if err != nil {
+		return nil, err
+	}
+	expander, err := termService[terminology.Expander](ctx, "$expand")
+	if err != nil {
+		return nil, err
+	}
+	result, err := expander.Expand(ctx, &terminology.ExpandOptions{URL: valueSet, Params: params})
+	if err != nil {
+		return nil, fmt.Errorf("expanding valueSet: %w", err)
+	}
+	return system.Collection{result}, nil
+}
+
+// Lookup implements %terminologies.lookup(coded, params), which returns the
+// details of the given Coding as a Parameters resource.
+//
+// For more details, see https://hl7.org/fhir/R4/fhirpath.html#txapi
+func Lookup(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	if err := validateTerminologies(input, args, 1, 2); err != nil {
+		return nil, err
+	}
+	coding, err := codingArg(ctx, input, args[0])
+	if err != nil {
+		return nil, err
+	}
+	params, err := paramsArg(ctx, input, args, 1)
+	if err != nil {
+		return nil, err
+	}
+	lookuper, err := termService[terminology.Lookuper](ctx, "$lookup")
+	if err != nil {
+		return nil, err
+	}
+	result, err := lookuper.Lookup(ctx, &terminology.LookupOptions{
+		System:  coding.GetSystem().GetValue(),
+		Version: coding.GetVersion().GetValue(),
+		Code:    coding.GetCode().GetValue(),
+		Params:  params,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("looking up code: %w", err)
+	}
+	return system.Collection{result}, nil
+}
+
+// ValidateVS implements %terminologies.validateVS(valueSet, coded, params),
+// which validates the given code, Coding or CodeableConcept against the value
+// set, and returns the result as a Parameters resource. For CodeableConcepts,
+// the result for the first valid Coding is returned.
+//
+// For more details, see https://hl7.org/fhir/R4/fhirpath.html#txapi
+func ValidateVS(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	if err := validateTerminologies(input, args, 2, 3); err != nil {
+		return nil, err
+	}
+	valueSet, err := stringArg(ctx, input, args[0])
+	if err != nil {
+		return nil, err
+	}
+	coded, err := args[1].Evaluate(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if !coded.IsSingleton() {
+		return nil, fmt.Errorf("%w: coded argument has %d items", ErrNotSingleton, len(coded))
+	}
+	codings, err := codingsOf(coded[0])
+	if err != nil {
+		return nil, err
+	}
+	params, err := paramsArg(ctx, input, args, 2)
+	if err != nil {
+		return nil, err
+	}
+	service := ctx.TermService
+	if service == nil {
+		return nil, fmt.Errorf("%w: no Terminology Service configured", ErrUnsupportedOperation)
+	}
+
+	var result *pgp.Parameters
+	for _, coding := range codings {
+		opts := valueSetValidateCodeOptions(valueSet)
+		opts.System = coding.GetSystem().GetValue()
+		opts.SystemVersion = coding.GetVersion().GetValue()
+		opts.Code = coding.GetCode().GetValue()
+		opts.Display = coding.GetDisplay().GetValue()
+		opts.Date = params.Get("date")
+		if version := params.Get("valueSetVersion"); version != "" {
+			opts.ValueSetVersion = version
 		}
+		result, err = service.ValueSetValidateCode(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("validating valueSet code: %w", err)
+		}
+		if parameterBoolean(result, "result") {
+			break
+		}
+	}
+	if result == nil {
+		return system.Collection{}, nil
 	}
+	return system.Collection{result}, nil
+}
 
-	return result, nil
+// ValidateCS implements %terminologies.validateCS(codeSystem, coded, params),
+// which validates the given code or Coding against the code system, and
+// returns the result as a Parameters resource.
+//
+// For more details, see https://hl7.org/fhir/R4/fhirpath.html#txapi
+func ValidateCS(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	if err := validateTerminologies(input, args, 2, 3); err != nil {
+		return nil, err
+	}
+	codeSystem, err := stringArg(ctx, input, args[0])
+	if err != nil {
+		return nil, err
+	}
+	coding, err := codingArg(ctx, input, args[1])
+	if err != nil {
+		return nil, err
+	}
+	params, err := paramsArg(ctx, input, args, 2)
+	if err != nil {
+		return nil, err
+	}
+	validator, err := termService[terminology.CodeSystemValidator](ctx, "CodeSystem $validate-code")
+	if err != nil {
+		return nil, err
+	}
+	result, err := validator.CodeSystemValidateCode(ctx, &terminology.CodeSystemValidateCodeOptions{
+		URL:     codeSystem,
+		Version: coding.GetVersion().GetValue(),
+		Code:    coding.GetCode().GetValue(),
+		Display: coding.GetDisplay().GetValue(),
+		Params:  params,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("validating codeSystem code: %w", err)
+	}
+	return system.Collection{result}, nil
+}
+
+// Translate implements %terminologies.translate(conceptMap, code, params),
+// which translates the given code or Coding using the concept map, and
+// returns the result as a Parameters resource.
+//
+// For more details, see https://hl7.org/fhir/R4/fhirpath.html#txapi
+func Translate(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	if err := validateTerminologies(input, args, 2, 3); err != nil {
+		return nil, err
+	}
+	conceptMap, err := stringArg(ctx, input, args[0])
+	if err != nil {
+		return nil, err
+	}
+	coding, err := codingArg(ctx, input, args[1])
+	if err != nil {
+		return nil, err
+	}
+	params, err := paramsArg(ctx, input, args, 2)
+	if err != nil {
+		return nil, err
+	}
+	translator, err := termService[terminology.Translator](ctx, "$translate")
+	if err != nil {
+		return nil, err
+	}
+	result, err := translator.Translate(ctx, &terminology.TranslateOptions{
+		ConceptMap: conceptMap,
+		System:     coding.GetSystem().GetValue(),
+		Code:       coding.GetCode().GetValue(),
+		Params:     params,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("translating code: %w", err)
+	}
+	return system.Collection{result}, nil
+}
+
+// isTerminologies returns true if the input is the %terminologies object.
+func isTerminologies(input system.Collection) bool {
+	if !input.IsSingleton() {
+		return false
+	}
+	_, ok := input[0].(expr.Terminologies)
+	return ok
+}
+
+// validateTerminologies returns an error if the input is not the
+// %terminologies object, or if the number of arguments is out of range.
+func validateTerminologies(input system.Collection, args []expr.Expression, min, max int) error {
+	if !isTerminologies(input) {
+		return fmt.Errorf("%w: function must be invoked on %%terminologies", ErrInvalidInput)
+	}
+	if length := len(args); length < min || length > max {
+		return fmt.Errorf("%w: received %d arguments, expected %d to %d", ErrWrongArity, length, min, max)
+	}
+	return nil
+}
+
+// termService returns the configured Terminology Service as the interface
+// of the given operation, or an error if it doesn't support the operation.
+func termService[T any](ctx *expr.Context, operation string) (T, error) {
+	service, ok := ctx.TermService.(T)
+	if !ok {
+		var zero T
+		if ctx.TermService == nil {
+			return zero, fmt.Errorf("%w: no Terminology Service configured for %s", ErrUnsupportedOperation, operation)
+		}
+		return zero, fmt.Errorf("%w: %s", ErrUnsupportedOperation, operation)
+	}
+	return service, nil
+}
+
+func stringArg(ctx *expr.Context, input system.Collection, arg expr.Expression) (string, error) {
+	result, err := arg.Evaluate(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	return result.ToString()
+}
+
+// paramsArg returns the optional URL-encoded params argument at the given
+// index, e.g. 'displayLanguage=en&property=parent'.
+func paramsArg(ctx *expr.Context, input system.Collection, args []expr.Expression, index int) (url.Values, error) {
+	if len(args) <= index {
+		return url.Values{}, nil
+	}
+	str, err := stringArg(ctx, input, args[index])
+	if err != nil {
+		return nil, err
+	}
+	params, err := url.ParseQuery(str)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid params '%s'", ErrInvalidInput, str)
+	}
+	return params, nil
+}
+
+// codingArg evaluates an argument that must be a single code or Coding.
+func codingArg(ctx *expr.Context, input system.Collection, arg expr.Expression) (*dtpb.Coding, error) {
+	result, err := arg.Evaluate(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if !result.IsSingleton() {
+		return nil, fmt.Errorf("%w: coded argument has %d items", ErrNotSingleton, len(result))
+	}
+	codings, err := codingsOf(result[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(codings) != 1 {
+		return nil, fmt.Errorf("%w: expected a code or Coding", ErrInvalidInput)
+	}
+	return codings[0], nil
+}
+
+// codingsOf returns the Codings of a code, Coding or CodeableConcept. Codes
+// are returned as a Coding without a system.
+func codingsOf(item any) ([]*dtpb.Coding, error) {
+	switch item := item.(type) {
+	case *dtpb.Coding:
+		return []*dtpb.Coding{item}, nil
+	case *dtpb.CodeableConcept:
+		return item.GetCoding(), nil
+	}
+	value, err := system.From(item)
+	if err != nil {
+		return nil, fmt.Errorf("%w: expected a code, Coding or CodeableConcept, got %T", ErrInvalidInput, item)
+	}
+	code, ok := value.(system.String)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected a code, Coding or CodeableConcept, got %T", ErrInvalidInput, item)
+	}
+	return []*dtpb.Coding{{Code: &dtpb.Code{Value: string(code)}}}, nil
+}
+
+// parameterBoolean returns the boolean value of the named parameter.
+func parameterBoolean(params *pgp.Parameters, name string) bool {
+	for _, param := range params.GetParameter() {
+		if param.GetName().GetValue() == name {
+			return param.GetValue().GetBoolean().GetValue()
+		}
+	}
+	return false
 }
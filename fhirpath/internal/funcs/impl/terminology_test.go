@@ -2,13 +2,17 @@ package impl_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
 
 	dtpb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/datatypes_go_proto"
 	pgp "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/parameters_go_proto"
+	vspb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/value_set_go_proto"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/verily-src/fhirpath-go/fhirpath/internal/expr"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/expr/exprtest"
 	"github.com/verily-src/fhirpath-go/fhirpath/internal/funcs/impl"
 	"github.com/verily-src/fhirpath-go/fhirpath/system"
 	"github.com/verily-src/fhirpath-go/fhirpath/terminology"
@@ -18,10 +22,14 @@ import (
 
 type fakeValueSet struct {
 	valueSetId string
+	url        string
+	version    string
 	system     string
 	codes      []string
 }
 
+var errUnknownValueSet = errors.New("unknown value set")
+
 type fakeTerminologyService struct {
 	dbItems []*fakeValueSet
 }
@@ -49,21 +57,27 @@ func (fts *fakeTerminologyService) ValueSetValidateCode(ctx context.Context, opt
 	targetCode := opts.Code
 	targetSystem := opts.System
 
-	if targetValueSet == "" || targetSystem == "" {
+	if targetValueSet == "" {
 		return buildParameters("result", false), nil
 	}
 
 	for _, item := range fts.dbItems {
-		if item.valueSetId == targetValueSet {
-			for _, code := range item.codes {
-				if targetCode == code && targetSystem == item.system {
-					return buildParameters("result", true), nil
-				}
+		if item.valueSetId != targetValueSet && (opts.URL == "" || item.url != opts.URL) {
+			continue
+		}
+		if opts.ValueSetVersion != "" && opts.ValueSetVersion != item.version {
+			continue
+		}
+		for _, code := range item.codes {
+			// Codes without a system take it from their binding.
+			if targetCode == code && (targetSystem == "" || targetSystem == item.system) {
+				return buildParameters("result", true), nil
 			}
 		}
+		return buildParameters("result", false), nil
 	}
 
-	return buildParameters("result", false), nil
+	return nil, fmt.Errorf("%w: %s", errUnknownValueSet, targetValueSet)
 }
 
 func TestMemberOf(t *testing.T) {
@@ -71,6 +85,8 @@ func TestMemberOf(t *testing.T) {
 	var fakeTerminology = []*fakeValueSet{
 		{
 			valueSetId: "testValueSet",
+			url:        "http://example.com/ValueSet/marital-status",
+			version:    "1.0.0",
 			system:     "http://terminology.hl7.org/CodeSystem/v3-MaritalStatus",
 			codes:      []string{"M", "D", "S", "W", "A", "L", "C", "P", "T", "U", "I"},
 			// https://terminology.hl7.org/6.1.0/CodeSystem-v3-MaritalStatus.html
@@ -136,6 +152,7 @@ func TestMemberOf(t *testing.T) {
 		inputCollection system.Collection
 		termServiceImpl terminology.Service
 		args            []expr.Expression
+		strict          bool
 		wantCollection  system.Collection
 		wantErr         error
 	}{
@@ -167,11 +184,76 @@ func TestMemberOf(t *testing.T) {
 			args:            []expr.Expression{valueSetExpr},
 			wantCollection:  system.Collection{system.Boolean(true)},
 		},
+		{
+			name:            "code is in the value set of its binding",
+			inputCollection: system.Collection{fhir.Code("M")},
+			termServiceImpl: fakeTerminologyService,
+			args:            []expr.Expression{valueSetExpr},
+			wantCollection:  system.Collection{system.Boolean(true)},
+		},
+		{
+			name:            "string is not in the value set",
+			inputCollection: system.Collection{fhir.String("not included")},
+			termServiceImpl: fakeTerminologyService,
+			args:            []expr.Expression{valueSetExpr},
+			wantCollection:  system.Collection{system.Boolean(false)},
+		},
+		{
+			name:            "Coding is in the value set with canonical URL",
+			inputCollection: system.Collection{includedCoding},
+			termServiceImpl: fakeTerminologyService,
+			args:            []expr.Expression{exprtest.Return(fhir.URI("http://example.com/ValueSet/marital-status"))},
+			wantCollection:  system.Collection{system.Boolean(true)},
+		},
+		{
+			name:            "Coding is in the value set with versioned canonical URL",
+			inputCollection: system.Collection{includedCoding},
+			termServiceImpl: fakeTerminologyService,
+			args:            []expr.Expression{exprtest.Return(system.String("http://example.com/ValueSet/marital-status|1.0.0"))},
+			wantCollection:  system.Collection{system.Boolean(true)},
+		},
+		{
+			name:            "Coding is not in other version of the value set",
+			inputCollection: system.Collection{includedCoding},
+			termServiceImpl: fakeTerminologyService,
+			args:            []expr.Expression{exprtest.Return(system.String("http://example.com/ValueSet/marital-status|2.0.0"))},
+			wantCollection:  system.Collection{system.Boolean(false)},
+		},
+		{
+			name:            "unknown value set is false",
+			inputCollection: system.Collection{includedCoding},
+			termServiceImpl: fakeTerminologyService,
+			args:            []expr.Expression{exprtest.Return(system.String("unknown"))},
+			wantCollection:  system.Collection{system.Boolean(false)},
+		},
+		{
+			name:            "unknown value set returns error in strict mode",
+			inputCollection: system.Collection{includedCodeableConcept},
+			termServiceImpl: fakeTerminologyService,
+			args:            []expr.Expression{exprtest.Return(system.String("unknown"))},
+			strict:          true,
+			wantErr:         errUnknownValueSet,
+		},
+		{
+			name:            "missing Terminology Service returns error in strict mode",
+			inputCollection: system.Collection{includedCoding},
+			args:            []expr.Expression{valueSetExpr},
+			strict:          true,
+			wantErr:         impl.ErrUnconfiguredClient,
+		},
+		{
+			name:            "integer is not supported",
+			inputCollection: system.Collection{system.Integer(1)},
+			termServiceImpl: fakeTerminologyService,
+			args:            []expr.Expression{valueSetExpr},
+			wantErr:         impl.ErrNotSupported,
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			gotCollection, gotErr := impl.MemberOf(&expr.Context{TermService: tc.termServiceImpl}, tc.inputCollection, tc.args...)
+			ctx := &expr.Context{TermService: tc.termServiceImpl, StrictTerminology: tc.strict}
+			gotCollection, gotErr := impl.MemberOf(ctx, tc.inputCollection, tc.args...)
 
 			if !cmp.Equal(gotErr, tc.wantErr, cmpopts.EquateErrors()) {
 				t.Errorf("MemberOf() gotErr = %v, wantErr = %v", gotErr, tc.wantErr)
@@ -182,3 +264,295 @@ func TestMemberOf(t *testing.T) {
 		})
 	}
 }
+
+// fakeHierarchyService is a Terminology Service supporting the optional
+// operations, over a single code system with the given parent codes.
+type fakeHierarchyService struct {
+	fakeTerminologyService
+	system  string
+	parents map[string]string
+}
+
+func (fhs *fakeHierarchyService) isAncestor(ancestor, code string) bool {
+	for parent, ok := fhs.parents[code]; ok; parent, ok = fhs.parents[parent] {
+		if parent == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+func (fhs *fakeHierarchyService) Subsumes(ctx context.Context, opts *terminology.SubsumesOptions) (*pgp.Parameters, error) {
+	outcome := "not-subsumed"
+	switch {
+	case opts.CodeA == opts.CodeB:
+		outcome = "equivalent"
+	case fhs.isAncestor(opts.CodeA, opts.CodeB):
+		outcome = "subsumes"
+	case fhs.isAncestor(opts.CodeB, opts.CodeA):
+		outcome = "subsumed-by"
+	}
+	return &pgp.Parameters{
+		Parameter: []*pgp.Parameters_Parameter{
+			{
+				Name: fhir.String("outcome"),
+				Value: &pgp.Parameters_Parameter_ValueX{
+					Choice: &pgp.Parameters_Parameter_ValueX_Code{
+						Code: fhir.Code(outcome),
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+func (fhs *fakeHierarchyService) Expand(ctx context.Context, opts *terminology.ExpandOptions) (*vspb.ValueSet, error) {
+	return &vspb.ValueSet{Url: fhir.URI(opts.URL)}, nil
+}
+
+func (fhs *fakeHierarchyService) Lookup(ctx context.Context, opts *terminology.LookupOptions) (*pgp.Parameters, error) {
+	_, ok := fhs.parents[opts.Code]
+	return buildParameters("found", ok && opts.System == fhs.system), nil
+}
+
+func (fhs *fakeHierarchyService) CodeSystemValidateCode(ctx context.Context, opts *terminology.CodeSystemValidateCodeOptions) (*pgp.Parameters, error) {
+	_, ok := fhs.parents[opts.Code]
+	return buildParameters("result", ok && opts.URL == fhs.system), nil
+}
+
+func (fhs *fakeHierarchyService) Translate(ctx context.Context, opts *terminology.TranslateOptions) (*pgp.Parameters, error) {
+	return buildParameters("result", opts.Params.Get("reverse") == "true"), nil
+}
+
+func TestSubsumption(t *testing.T) {
+	const sct = "http://snomed.info/sct"
+	service := &fakeHierarchyService{
+		system: sct,
+		parents: map[string]string{
+			"73211009": "126877002", // Diabetes mellitus
+			"44054006": "73211009",  // Type 2 diabetes mellitus
+		},
+	}
+	coding := func(system, code string) *dtpb.Coding {
+		return &dtpb.Coding{System: fhir.URI(system), Code: fhir.Code(code)}
+	}
+
+	testCases := []struct {
+		name            string
+		fn              func(*expr.Context, system.Collection, ...expr.Expression) (system.Collection, error)
+		inputCollection system.Collection
+		argCollection   system.Collection
+		termServiceImpl terminology.Service
+		wantCollection  system.Collection
+		wantErr         error
+	}{
+		{
+			name:            "subsumes() returns true for an ancestor",
+			fn:              impl.Subsumes,
+			inputCollection: system.Collection{coding(sct, "73211009")},
+			argCollection:   system.Collection{coding(sct, "44054006")},
+			termServiceImpl: service,
+			wantCollection:  system.Collection{system.Boolean(true)},
+		},
+		{
+			name:            "subsumes() returns true for an equivalent code",
+			fn:              impl.Subsumes,
+			inputCollection: system.Collection{coding(sct, "73211009")},
+			argCollection:   system.Collection{coding(sct, "73211009")},
+			termServiceImpl: service,
+			wantCollection:  system.Collection{system.Boolean(true)},
+		},
+		{
+			name:            "subsumes() returns false for a descendant",
+			fn:              impl.Subsumes,
+			inputCollection: system.Collection{coding(sct, "44054006")},
+			argCollection:   system.Collection{coding(sct, "73211009")},
+			termServiceImpl: service,
+			wantCollection:  system.Collection{system.Boolean(false)},
+		},
+		{
+			name:            "subsumedBy() returns true for a descendant",
+			fn:              impl.SubsumedBy,
+			inputCollection: system.Collection{coding(sct, "44054006")},
+			argCollection:   system.Collection{coding(sct, "126877002")},
+			termServiceImpl: service,
+			wantCollection:  system.Collection{system.Boolean(true)},
+		},
+		{
+			name: "subsumedBy() compares codings of a CodeableConcept in the same system",
+			fn:   impl.SubsumedBy,
+			inputCollection: system.Collection{&dtpb.CodeableConcept{
+				Coding: []*dtpb.Coding{coding("http://loinc.org", "73211009"), coding(sct, "44054006")},
+			}},
+			argCollection:   system.Collection{coding(sct, "73211009")},
+			termServiceImpl: service,
+			wantCollection:  system.Collection{system.Boolean(true)},
+		},
+		{
+			name:            "returns empty for an empty argument",
+			fn:              impl.Subsumes,
+			inputCollection: system.Collection{coding(sct, "73211009")},
+			argCollection:   system.Collection{},
+			termServiceImpl: service,
+			wantCollection:  system.Collection{},
+		},
+		{
+			name:            "returns an error for codes from different systems",
+			fn:              impl.Subsumes,
+			inputCollection: system.Collection{coding(sct, "73211009")},
+			argCollection:   system.Collection{coding("http://loinc.org", "73211009")},
+			termServiceImpl: service,
+			wantErr:         impl.ErrIncomparableCodes,
+		},
+		{
+			name:            "returns an error if the service doesn't support $subsumes",
+			fn:              impl.Subsumes,
+			inputCollection: system.Collection{coding(sct, "73211009")},
+			argCollection:   system.Collection{coding(sct, "44054006")},
+			termServiceImpl: &fakeTerminologyService{},
+			wantErr:         impl.ErrUnsupportedOperation,
+		},
+		{
+			name:            "returns an error without a service",
+			fn:              impl.SubsumedBy,
+			inputCollection: system.Collection{coding(sct, "73211009")},
+			argCollection:   system.Collection{coding(sct, "44054006")},
+			wantErr:         impl.ErrUnsupportedOperation,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			arg := exprtest.Return(tc.argCollection...)
+			gotCollection, gotErr := tc.fn(&expr.Context{TermService: tc.termServiceImpl}, tc.inputCollection, arg)
+
+			if !cmp.Equal(gotErr, tc.wantErr, cmpopts.EquateErrors()) {
+				t.Errorf("subsumption gotErr = %v, wantErr = %v", gotErr, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.wantCollection, gotCollection, protocmp.Transform()); diff != "" {
+				t.Errorf("subsumption mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTerminologies(t *testing.T) {
+	const sct = "http://snomed.info/sct"
+	service := &fakeHierarchyService{
+		fakeTerminologyService: fakeTerminologyService{
+			dbItems: []*fakeValueSet{{valueSetId: "diabetes", system: sct, codes: []string{"44054006"}}},
+		},
+		system:  sct,
+		parents: map[string]string{"44054006": "73211009"},
+	}
+	terminologies := system.Collection{expr.Terminologies{}}
+	included := &dtpb.Coding{System: fhir.URI(sct), Code: fhir.Code("44054006")}
+	excluded := &dtpb.Coding{System: fhir.URI(sct), Code: fhir.Code("73211009")}
+
+	testCases := []struct {
+		name            string
+		fn              func(*expr.Context, system.Collection, ...expr.Expression) (system.Collection, error)
+		inputCollection system.Collection
+		args            []expr.Expression
+		termServiceImpl terminology.Service
+		wantCollection  system.Collection
+		wantErr         error
+	}{
+		{
+			name:            "expand() returns the ValueSet",
+			fn:              impl.Expand,
+			inputCollection: terminologies,
+			args:            []expr.Expression{exprtest.Return(system.String("http://example.com/vs"))},
+			termServiceImpl: service,
+			wantCollection:  system.Collection{&vspb.ValueSet{Url: fhir.URI("http://example.com/vs")}},
+		},
+		{
+			name:            "lookup() returns the result Parameters",
+			fn:              impl.Lookup,
+			inputCollection: terminologies,
+			args:            []expr.Expression{exprtest.Return(included)},
+			termServiceImpl: service,
+			wantCollection:  system.Collection{buildParameters("found", true)},
+		},
+		{
+			name:            "validateVS() validates a CodeableConcept",
+			fn:              impl.ValidateVS,
+			inputCollection: terminologies,
+			args: []expr.Expression{
+				exprtest.Return(system.String("diabetes")),
+				exprtest.Return(&dtpb.CodeableConcept{Coding: []*dtpb.Coding{excluded, included}}),
+			},
+			termServiceImpl: service,
+			wantCollection:  system.Collection{buildParameters("result", true)},
+		},
+		{
+			name:            "validateCS() validates a Coding",
+			fn:              impl.ValidateCS,
+			inputCollection: terminologies,
+			args:            []expr.Expression{exprtest.Return(system.String(sct)), exprtest.Return(excluded)},
+			termServiceImpl: service,
+			wantCollection:  system.Collection{buildParameters("result", false)},
+		},
+		{
+			name:            "translate() passes params to the service",
+			fn:              impl.Translate,
+			inputCollection: terminologies,
+			args: []expr.Expression{
+				exprtest.Return(system.String("http://example.com/cm")),
+				exprtest.Return(included),
+				exprtest.Return(system.String("reverse=true")),
+			},
+			termServiceImpl: service,
+			wantCollection:  system.Collection{buildParameters("result", true)},
+		},
+		{
+			name:            "subsumes() returns the outcome code",
+			fn:              impl.Subsumes,
+			inputCollection: terminologies,
+			args: []expr.Expression{
+				exprtest.Return(system.String(sct)),
+				exprtest.Return(excluded),
+				exprtest.Return(included),
+			},
+			termServiceImpl: service,
+			wantCollection:  system.Collection{system.String("subsumes")},
+		},
+		{
+			name:            "returns an error if not invoked on %terminologies",
+			fn:              impl.Expand,
+			inputCollection: system.Collection{system.String("http://example.com/vs")},
+			args:            []expr.Expression{exprtest.Return(system.String("http://example.com/vs"))},
+			termServiceImpl: service,
+			wantErr:         impl.ErrInvalidInput,
+		},
+		{
+			name:            "returns an error for invalid params",
+			fn:              impl.Lookup,
+			inputCollection: terminologies,
+			args:            []expr.Expression{exprtest.Return(included), exprtest.Return(system.String("a=%zz"))},
+			termServiceImpl: service,
+			wantErr:         impl.ErrInvalidInput,
+		},
+		{
+			name:            "returns an error if the service doesn't support the operation",
+			fn:              impl.Lookup,
+			inputCollection: terminologies,
+			args:            []expr.Expression{exprtest.Return(included)},
+			termServiceImpl: &fakeTerminologyService{},
+			wantErr:         impl.ErrUnsupportedOperation,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gotCollection, gotErr := tc.fn(&expr.Context{TermService: tc.termServiceImpl}, tc.inputCollection, tc.args...)
+
+			if !cmp.Equal(gotErr, tc.wantErr, cmpopts.EquateErrors()) {
+				t.Errorf("terminologies gotErr = %v, wantErr = %v", gotErr, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.wantCollection, gotCollection, protocmp.Transform()); diff != "" {
+				t.Errorf("terminologies mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package impl
 
 import (
+	"fmt"
+
 	"github.com/verily-src/fhirpath-go/fhirpath/internal/expr"
 	"github.com/verily-src/fhirpath-go/fhirpath/system"
 )
@@ -22,3 +24,33 @@ func Now(ctx *expr.Context, input system.Collection, args ...expr.Expression) (s
 	dateTimeString := ctx.Now.Format("2006-01-02T15:04:05.000Z07:00")
 	return system.Collection{system.MustParseDateTime(dateTimeString)}, nil
 }
+
+// Trace sends the input collection to the trace sink configured in the
+// context under the name given by args[0], then returns the input unchanged.
+// If the projection args[1] is provided, the result of evaluating it against
+// each item of the input, as with select(), is traced instead. Traced output is
+// discarded if no sink is set.
+// FHIRPath docs here: https://hl7.org/fhirpath/N1/#tracename-string-projection-expression-collection
+func Trace(ctx *expr.Context, input system.Collection, args ...expr.Expression) (system.Collection, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, fmt.Errorf("%w: received %v arguments, expected 1 or 2", ErrWrongArity, len(args))
+	}
+	nameResult, err := args[0].Evaluate(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	name, err := nameResult.ToString()
+	if err != nil {
+		return nil, err
+	}
+	traced := input
+	if len(args) == 2 {
+		if traced, err = Select(ctx, input, args[1]); err != nil {
+			return nil, err
+		}
+	}
+	if ctx.TraceSink != nil {
+		ctx.TraceSink(name, traced)
+	}
+	return input, nil
+}
@@ -1,11 +1,13 @@
 package impl_test
 
 import (
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/verily-src/fhirpath-go/fhirpath/internal/expr"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/expr/exprtest"
 	"github.com/verily-src/fhirpath-go/fhirpath/internal/funcs/impl"
 	"github.com/verily-src/fhirpath-go/fhirpath/system"
 )
@@ -48,3 +50,101 @@ func TestNow(t *testing.T) {
 		t.Errorf("impl.Now() returned unexpected result: got %v, want %v", got, wantCollection)
 	}
 }
+
+type traced struct {
+	name       string
+	collection system.Collection
+}
+
+func TestTrace(t *testing.T) {
+	input := system.Collection{system.Integer(1), system.Integer(2)}
+
+	testCases := []struct {
+		name       string
+		args       []expr.Expression
+		wantTraced []traced
+	}{
+		{
+			name:       "traces input",
+			args:       []expr.Expression{exprtest.Return(system.String("numbers"))},
+			wantTraced: []traced{{"numbers", input}},
+		},
+		{
+			name: "traces projection",
+			args: []expr.Expression{
+				exprtest.Return(system.String("projected")),
+				exprtest.Return(system.String("result")),
+			},
+			wantTraced: []traced{{"projected", system.Collection{system.String("result"), system.String("result")}}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []traced
+			ctx := &expr.Context{
+				TraceSink: func(name string, c system.Collection) {
+					got = append(got, traced{name, c})
+				},
+			}
+
+			result, err := impl.Trace(ctx, input, tc.args...)
+			if err != nil {
+				t.Fatalf("impl.Trace() returned unexpected error: %v", err)
+			}
+			if !cmp.Equal(result, input) {
+				t.Errorf("impl.Trace() returned unexpected result: got %v, want %v", result, input)
+			}
+			if diff := cmp.Diff(tc.wantTraced, got, cmp.AllowUnexported(traced{})); diff != "" {
+				t.Errorf("impl.Trace() traced unexpected output (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTrace_WithoutSink_ReturnsInput(t *testing.T) {
+	input := system.Collection{system.Integer(1)}
+
+	got, err := impl.Trace(&expr.Context{}, input, exprtest.Return(system.String("name")))
+	if err != nil {
+		t.Fatalf("impl.Trace() returned unexpected error: %v", err)
+	}
+	if !cmp.Equal(got, input) {
+		t.Errorf("impl.Trace() returned unexpected result: got %v, want %v", got, input)
+	}
+}
+
+func TestTrace_RaisesError(t *testing.T) {
+	testCases := []struct {
+		name string
+		args []expr.Expression
+	}{
+		{
+			name: "no arguments",
+			args: []expr.Expression{},
+		},
+		{
+			name: "non-string name",
+			args: []expr.Expression{exprtest.Return(system.Integer(1))},
+		},
+		{
+			name: "name raises error",
+			args: []expr.Expression{exprtest.Error(errors.New("some error"))},
+		},
+		{
+			name: "projection raises error",
+			args: []expr.Expression{
+				exprtest.Return(system.String("name")),
+				exprtest.Error(errors.New("some error")),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := impl.Trace(&expr.Context{}, system.Collection{system.Integer(1)}, tc.args...); err == nil {
+				t.Errorf("impl.Trace() didn't return an error when expected")
+			}
+		})
+	}
+}
@@ -62,7 +62,12 @@ var baseTable = FunctionTable{
 		1,
 		false,
 	},
-	"supersetOf": notImplemented,
+	"supersetOf": Function{
+		impl.SupersetOf,
+		1,
+		1,
+		false,
+	},
 	"count": Function{
 		impl.Count,
 		0,
@@ -93,14 +98,24 @@ var baseTable = FunctionTable{
 		1,
 		false,
 	},
-	"repeat": notImplemented,
+	"repeat": Function{
+		impl.Repeat,
+		1,
+		1,
+		false,
+	},
 	"ofType": Function{
 		impl.OfType,
 		1,
 		1,
 		true,
 	},
-	"single": notImplemented,
+	"single": Function{
+		impl.Single,
+		0,
+		0,
+		false,
+	},
 	"first": Function{
 		impl.First,
 		0,
@@ -143,7 +158,12 @@ var baseTable = FunctionTable{
 		1,
 		false,
 	},
-	"union": notImplemented,
+	"union": Function{
+		impl.Union,
+		1,
+		1,
+		false,
+	},
 	"combine": Function{
 		impl.Combine,
 		0,
@@ -217,7 +237,7 @@ var baseTable = FunctionTable{
 		false,
 	},
 	"toQuantity": Function{
-		impl.ToInteger,
+		impl.ToQuantity,
 		0,
 		1,
 		false,
@@ -356,20 +376,20 @@ var baseTable = FunctionTable{
 	},
 	"log": Function{
 		impl.Log,
-		0,
-		0,
+		1,
+		1,
 		false,
 	},
 	"power": Function{
 		impl.Power,
-		0,
-		0,
+		1,
+		1,
 		false,
 	},
 	"round": Function{
 		impl.Round,
 		0,
-		0,
+		1,
 		false,
 	},
 	"sqrt": Function{
@@ -396,7 +416,18 @@ var baseTable = FunctionTable{
 		0,
 		false,
 	},
-	"trace": notImplemented,
+	"type": Function{
+		impl.Type,
+		0,
+		0,
+		false,
+	},
+	"trace": Function{
+		impl.Trace,
+		1,
+		2,
+		false,
+	},
 	"now": Function{
 		impl.Now,
 		0,
@@ -415,6 +446,12 @@ var baseTable = FunctionTable{
 		0,
 		false,
 	},
+	"aggregate": Function{
+		impl.Aggregate,
+		1,
+		2,
+		false,
+	},
 	"not": Function{
 		impl.Not,
 		0,
@@ -429,29 +466,252 @@ var baseTable = FunctionTable{
 	},
 }
 
+// r4Table holds the mapping of the FHIR-specific functions defined
+// by the R4 FHIRPath page, other than extension(). These are used by
+// the invariants of the core StructureDefinitions.
+// See https://hl7.org/fhir/R4/fhirpath.html#functions
+var r4Table = FunctionTable{
+	"comparable": Function{
+		impl.Comparable,
+		1,
+		1,
+		false,
+	},
+	"conformsTo": Function{
+		impl.ConformsTo,
+		1,
+		1,
+		false,
+	},
+	"getValue": Function{
+		impl.GetValue,
+		0,
+		0,
+		false,
+	},
+	"hasValue": Function{
+		impl.HasValue,
+		0,
+		0,
+		false,
+	},
+	"htmlChecks": Function{
+		impl.HTMLChecks,
+		0,
+		0,
+		false,
+	},
+}
+
 // ExperimentalTable holds the mapping of all
 // experimental FHIRPath functions. These functions
 // are not a part of the N1 normative spec.
 // See https://build.fhir.org/ig/HL7/FHIRPath/
 var experimentalTable = FunctionTable{
+	"convertsToLong": Function{
+		impl.ConvertsToLong,
+		0,
+		0,
+		false,
+	},
+	"dateOf": Function{
+		impl.DateOf,
+		0,
+		0,
+		false,
+	},
+	"dayOf": Function{
+		impl.DayOf,
+		0,
+		0,
+		false,
+	},
+	"decode": Function{
+		impl.Decode,
+		1,
+		1,
+		false,
+	},
+	"difference": Function{
+		impl.Difference,
+		2,
+		2,
+		false,
+	},
+	"duration": Function{
+		impl.Duration,
+		2,
+		2,
+		false,
+	},
+	"encode": Function{
+		impl.Encode,
+		1,
+		1,
+		false,
+	},
+	"escape": Function{
+		impl.Escape,
+		1,
+		1,
+		false,
+	},
+	"expand": Function{
+		impl.Expand,
+		1,
+		2,
+		false,
+	},
+	"highBoundary": Function{
+		impl.HighBoundary,
+		0,
+		1,
+		false,
+	},
+	"hourOf": Function{
+		impl.HourOf,
+		0,
+		0,
+		false,
+	},
 	"join": Function{
 		impl.Join,
 		0,
 		1,
 		false,
 	},
+	"lastIndexOf": Function{
+		impl.LastIndexOf,
+		1,
+		1,
+		false,
+	},
+	"lookup": Function{
+		impl.Lookup,
+		1,
+		2,
+		false,
+	},
+	"lowBoundary": Function{
+		impl.LowBoundary,
+		0,
+		1,
+		false,
+	},
+	"matchesFull": Function{
+		impl.MatchesFull,
+		1,
+		1,
+		false,
+	},
 	"memberOf": Function{
 		impl.MemberOf,
 		1,
 		1,
 		false,
 	},
+	"millisecondOf": Function{
+		impl.MillisecondOf,
+		0,
+		0,
+		false,
+	},
+	"minuteOf": Function{
+		impl.MinuteOf,
+		0,
+		0,
+		false,
+	},
+	"monthOf": Function{
+		impl.MonthOf,
+		0,
+		0,
+		false,
+	},
+	"precision": Function{
+		impl.Precision,
+		0,
+		0,
+		false,
+	},
+	"secondOf": Function{
+		impl.SecondOf,
+		0,
+		0,
+		false,
+	},
 	"split": Function{
 		impl.Split,
 		1,
 		1,
 		false,
 	},
+	"subsumedBy": Function{
+		impl.SubsumedBy,
+		1,
+		1,
+		false,
+	},
+	"subsumes": Function{
+		impl.Subsumes,
+		1,
+		4,
+		false,
+	},
+	"timeOf": Function{
+		impl.TimeOf,
+		0,
+		0,
+		false,
+	},
+	"timezoneOffsetOf": Function{
+		impl.TimezoneOffsetOf,
+		0,
+		0,
+		false,
+	},
+	"toLong": Function{
+		impl.ToLong,
+		0,
+		0,
+		false,
+	},
+	"translate": Function{
+		impl.Translate,
+		2,
+		3,
+		false,
+	},
+	"trim": Function{
+		impl.Trim,
+		0,
+		0,
+		false,
+	},
+	"unescape": Function{
+		impl.Unescape,
+		1,
+		1,
+		false,
+	},
+	"validateCS": Function{
+		impl.ValidateCS,
+		2,
+		3,
+		false,
+	},
+	"validateVS": Function{
+		impl.ValidateVS,
+		2,
+		3,
+		false,
+	},
+	"yearOf": Function{
+		impl.YearOf,
+		0,
+		0,
+		false,
+	},
 }
 
 // Clone returns a deep copy of the base
@@ -477,3 +737,16 @@ func AddExperimentalFuncs(table FunctionTable) FunctionTable {
 	}
 	return table
 }
+
+// AddR4Funcs adds the FHIR-specific R4 functions
+// to the given function table and returns it.
+// If a function already exists in the table, it is not overridden.
+func AddR4Funcs(table FunctionTable) FunctionTable {
+	for k, v := range r4Table {
+		if _, exists := table[k]; exists {
+			continue
+		}
+		table[k] = v
+	}
+	return table
+}
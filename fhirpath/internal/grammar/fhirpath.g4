@@ -149,6 +149,7 @@ STRING
         ;
 
 // Also allows leading zeroes now (just like CQL and XSD)
+// Long literals (e.g. 123L) are recognized by WithLongNumbers, in long_numbers.go
 NUMBER
         : [0-9]+('.' [0-9]+)?
         ;
@@ -0,0 +1,51 @@
+package grammar
+
+import (
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// longNumberSuffix is the suffix of Long literals, e.g. 123L.
+const longNumberSuffix = "L"
+
+// WithLongNumbers wraps a fhirpath lexer, so that it additionally recognizes
+// the Long literals of FHIRPath 2.0, such as 123L. The lexer tokenizes these
+// as a NUMBER followed by an IDENTIFIER, which are merged into a single NUMBER
+// token with the 'L' suffix.
+func WithLongNumbers(lexer antlr.Lexer) antlr.Lexer {
+	return &longNumberLexer{Lexer: lexer}
+}
+
+type longNumberLexer struct {
+	antlr.Lexer
+	pending antlr.Token
+}
+
+// NextToken returns the next token from the underlying lexer, merging
+// integer NUMBER tokens with an immediately following 'L' IDENTIFIER.
+func (l *longNumberLexer) NextToken() antlr.Token {
+	token := l.next()
+	if token.GetTokenType() != fhirpathLexerNUMBER || strings.Contains(token.GetText(), ".") {
+		return token
+	}
+	suffix := l.next()
+	if suffix.GetTokenType() == fhirpathLexerIDENTIFIER && suffix.GetText() == longNumberSuffix && suffix.GetStart() == token.GetStop()+1 {
+		// Tokens can't be extended in place, so the merged token is created
+		// anew, spanning both tokens.
+		return antlr.CommonTokenFactoryDEFAULT.Create(
+			token.GetSource(), token.GetTokenType(), token.GetText()+longNumberSuffix, token.GetChannel(),
+			token.GetStart(), suffix.GetStop(), token.GetLine(), token.GetColumn(),
+		)
+	}
+	l.pending = suffix
+	return token
+}
+
+func (l *longNumberLexer) next() antlr.Token {
+	if token := l.pending; token != nil {
+		l.pending = nil
+		return token
+	}
+	return l.Lexer.NextToken()
+}
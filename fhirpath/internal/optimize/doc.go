@@ -0,0 +1,9 @@
+/*
+Package optimize simplifies compiled FHIRPath expressions, so that work that
+doesn't depend on the input is done once at compile time rather than on every
+evaluation. Literal-only sub-expressions, including boolean operators such as
+'(1 > 2) and true', are folded into literals, no-op '$this' steps are removed,
+and sub-expressions that always evaluate to an empty collection are reported.
+The result of every evaluation, including any error, is unchanged.
+*/
+package optimize
@@ -0,0 +1,470 @@
+package optimize
+
+import (
+	"errors"
+
+	"github.com/verily-src/fhirpath-go/fhirpath/ast"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/diagnostic"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/expr"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/parser"
+	"github.com/verily-src/fhirpath-go/fhirpath/system"
+)
+
+var ErrAlwaysEmpty = errors.New("expression always evaluates to an empty collection")
+
+// pureFunctions are the functions whose result only depends on their input
+// and arguments, which can be evaluated at compile time when both are
+// literals.
+var pureFunctions = map[string]bool{
+	"empty": true, "exists": true, "all": true, "allTrue": true, "anyTrue": true,
+	"allFalse": true, "anyFalse": true, "count": true, "distinct": true, "isDistinct": true,
+	"not": true, "single": true, "first": true, "last": true, "tail": true, "skip": true,
+	"take": true,
+
+	"toBoolean": true, "convertsToBoolean": true, "toInteger": true, "convertsToInteger": true,
+	"toDate": true, "convertsToDate": true, "toDateTime": true, "convertToDateTime": true,
+	"toDecimal": true, "convertsToDecimal": true, "toQuantity": true, "convertsToQuantity": true,
+	"toString": true, "convertsToString": true, "toTime": true, "convertsToTime": true,
+
+	"indexOf": true, "substring": true, "startsWith": true, "endsWith": true, "contains": true,
+	"upper": true, "lower": true, "replace": true, "matches": true, "replaceMatches": true,
+	"length": true, "toChars": true,
+
+	"abs": true, "ceiling": true, "exp": true, "floor": true, "ln": true, "log": true,
+	"power": true, "round": true, "sqrt": true, "truncate": true,
+}
+
+// emptyPreservingFunctions are the functions that always return an empty
+// collection when their input is empty.
+var emptyPreservingFunctions = map[string]bool{
+	"where": true, "select": true, "repeat": true, "ofType": true, "extension": true,
+	"single": true, "first": true, "last": true, "tail": true, "skip": true, "take": true,
+	"intersect": true, "exclude": true, "distinct": true, "children": true, "descendants": true,
+}
+
+// Optimize simplifies the expression, returning an expression that evaluates
+// to the same result for every input, along with errors locating the
+// sub-expressions that always evaluate to an empty collection. The sources
+// recorded by the parser.FHIRPathVisitor locate the sub-expressions; the given
+// expression may be modified.
+func Optimize(e expr.Expression, sources parser.Sources) (expr.Expression, []*diagnostic.Error) {
+	o := &optimizer{
+		ctx:     expr.InitializeContext(nil),
+		origins: map[expr.Expression]origin{},
+		written: map[expr.Expression]bool{},
+	}
+	for e, ctx := range sources {
+		o.origins[e] = origin{span: diagnostic.SpanOf(ctx), token: ctx.GetStart().GetText()}
+	}
+	o.markWritten(e)
+	e = o.simplify(e)
+	return e, o.report(e)
+}
+
+// origin is the source that an expression was constructed from.
+type origin struct {
+	span  ast.Span
+	token string
+}
+
+type optimizer struct {
+	// ctx is the context that constant expressions are evaluated with.
+	ctx *expr.Context
+
+	origins map[expr.Expression]origin
+
+	// written holds the empty literals that are written in the source as
+	// '{}', which are deliberately empty and aren't reported.
+	written map[expr.Expression]bool
+}
+
+func (o *optimizer) markWritten(e expr.Expression) {
+	if isEmptyLiteral(e) {
+		o.written[e] = true
+	}
+	for _, child := range children(e) {
+		o.markWritten(child)
+	}
+}
+
+// replace returns the replacement of the given expression, which is located
+// at the same source.
+func (o *optimizer) replace(e, replacement expr.Expression) expr.Expression {
+	if origin, ok := o.origins[e]; ok {
+		o.origins[replacement] = origin
+	}
+	return replacement
+}
+
+// simplify simplifies the sub-expressions of the expression, and then the
+// expression itself.
+func (o *optimizer) simplify(e expr.Expression) expr.Expression {
+	switch e := e.(type) {
+	case *expr.ExpressionSequence:
+		return o.sequence(e)
+	case *expr.IndexExpression:
+		e.Index = o.simplify(e.Index)
+	case *expr.EqualityExpression:
+		e.Left, e.Right = o.simplify(e.Left), o.simplify(e.Right)
+		return o.fold(e, e.Left, e.Right)
+	case *expr.EquivalenceExpression:
+		e.Left, e.Right = o.simplify(e.Left), o.simplify(e.Right)
+		return o.fold(e, e.Left, e.Right)
+	case *expr.ComparisonExpression:
+		e.Left, e.Right = o.simplify(e.Left), o.simplify(e.Right)
+		return o.fold(e, e.Left, e.Right)
+	case *expr.ArithmeticExpression:
+		e.Left, e.Right = o.simplify(e.Left), o.simplify(e.Right)
+		return o.fold(e, e.Left, e.Right)
+	case *expr.ConcatExpression:
+		e.Left, e.Right = o.simplify(e.Left), o.simplify(e.Right)
+		return o.fold(e, e.Left, e.Right)
+	case *expr.MembershipExpression:
+		e.Left, e.Right = o.simplify(e.Left), o.simplify(e.Right)
+		return o.fold(e, e.Left, e.Right)
+	case *expr.UnionExpression:
+		e.Left, e.Right = o.simplify(e.Left), o.simplify(e.Right)
+		return o.fold(e, e.Left, e.Right)
+	case *expr.BooleanExpression:
+		e.Left, e.Right = o.simplify(e.Left), o.simplify(e.Right)
+		return o.fold(e, e.Left, e.Right)
+	case *expr.NegationExpression:
+		e.Expr = o.simplify(e.Expr)
+		return o.fold(e, e.Expr)
+	case *expr.IsExpression:
+		e.Expr = o.simplify(e.Expr)
+		return o.fold(e, e.Expr)
+	case *expr.AsExpression:
+		e.Expr = o.simplify(e.Expr)
+		return o.fold(e, e.Expr)
+	case *expr.FunctionExpression:
+		for i, arg := range e.Args {
+			e.Args[i] = o.simplify(arg)
+		}
+		return o.function(e)
+	case *expr.DefineVariableExpression:
+		if e.Value != nil {
+			e.Value = o.simplify(e.Value)
+		}
+	}
+	return e
+}
+
+// sequence flattens nested sequences, removes '$this' steps, and folds steps
+// whose input and arguments are literals.
+func (o *optimizer) sequence(e *expr.ExpressionSequence) expr.Expression {
+	var steps []expr.Expression
+	for _, step := range e.Expressions {
+		switch step := o.simplify(step).(type) {
+		case *expr.ExpressionSequence:
+			steps = append(steps, step.Expressions...)
+		case *expr.IdentityExpression:
+		default:
+			steps = append(steps, step)
+		}
+	}
+
+	for i := 0; i+1 < len(steps); {
+		folded, ok := o.step(steps[i], steps[i+1])
+		if !ok {
+			i++
+			continue
+		}
+		steps[i] = folded
+		steps = append(steps[:i+1], steps[i+2:]...)
+	}
+
+	switch len(steps) {
+	case 0:
+		return o.replace(e, &expr.IdentityExpression{})
+	case 1:
+		return steps[0]
+	}
+	e.Expressions = steps
+	return e
+}
+
+// step folds a step of a sequence whose input is a literal, if it is an
+// index or a call to a pure function with literal arguments.
+func (o *optimizer) step(input, step expr.Expression) (expr.Expression, bool) {
+	literal, ok := input.(*expr.LiteralExpression)
+	if !ok {
+		return nil, false
+	}
+	switch step := step.(type) {
+	case *expr.IndexExpression:
+		if !isLiteral(step.Index) {
+			return nil, false
+		}
+	case *expr.FunctionExpression:
+		if !pureFunctions[step.Name] || !allLiterals(step.Args) {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+	inputCollection, _ := literal.Evaluate(o.ctx, nil)
+	folded, ok := o.evaluate(step, inputCollection)
+	if !ok {
+		return nil, false
+	}
+	first, firstOk := o.origins[input]
+	last, lastOk := o.origins[step]
+	if firstOk && lastOk {
+		o.origins[folded] = origin{span: ast.Span{Start: first.span.Start, End: last.span.End}, token: first.token}
+	}
+	return folded, true
+}
+
+// function folds calls to 'iif' and 'where' whose criterion is a literal.
+func (o *optimizer) function(e *expr.FunctionExpression) expr.Expression {
+	switch e.Name {
+	case "iif":
+		if len(e.Args) < 2 || !isLiteral(e.Args[0]) {
+			return e
+		}
+		criterion, _ := e.Args[0].Evaluate(o.ctx, nil)
+		value, err := criterion.ToBool()
+		if err != nil {
+			return e
+		}
+		if !value && len(e.Args) < 3 {
+			return o.replace(e, &expr.LiteralExpression{})
+		}
+		result := e.Args[1]
+		if !value {
+			result = e.Args[2]
+		}
+		if isLiteral(result) {
+			return result
+		}
+		return o.replace(e, &scopedExpression{result})
+	case "where":
+		if len(e.Args) != 1 {
+			return e
+		}
+		value, ok := booleanLiteral(e.Args[0])
+		if isEmptyLiteral(e.Args[0]) || (ok && !value) {
+			return o.replace(e, &expr.LiteralExpression{})
+		}
+	}
+	return e
+}
+
+// fold replaces the expression with the literal that it evaluates to, if all
+// its operands are literals.
+func (o *optimizer) fold(e expr.Expression, operands ...expr.Expression) expr.Expression {
+	if !allLiterals(operands) {
+		return e
+	}
+	if folded, ok := o.evaluate(e, nil); ok {
+		return o.replace(e, folded)
+	}
+	return e
+}
+
+// evaluate evaluates the expression against the given input, returning the
+// result as a literal. Expressions that raise an error, panic, or return more
+// than one item aren't folded, so that they still do at evaluation time.
+func (o *optimizer) evaluate(e expr.Expression, input system.Collection) (literal *expr.LiteralExpression, ok bool) {
+	defer func() {
+		if recover() != nil {
+			literal, ok = nil, false
+		}
+	}()
+	result, err := e.Evaluate(o.ctx.Clone(), input)
+	if err != nil || len(result) > 1 {
+		return nil, false
+	}
+	if len(result) == 0 {
+		return &expr.LiteralExpression{}, true
+	}
+	value, ok := result[0].(system.Any)
+	if !ok {
+		return nil, false
+	}
+	return &expr.LiteralExpression{Literal: value}, true
+}
+
+// report returns errors locating the outermost sub-expressions that always
+// evaluate to an empty collection. Each error is located at the span of the
+// sub-expression that causes it to be empty, such as a 'where(false)' step.
+func (o *optimizer) report(e expr.Expression) []*diagnostic.Error {
+	if o.written[e] {
+		return nil
+	}
+	if _, ok := o.origins[e]; ok && alwaysEmpty(e) {
+		origin := o.origins[o.cause(e)]
+		return []*diagnostic.Error{{
+			Code:  diagnostic.CodeAlwaysEmpty,
+			Span:  origin.span,
+			Token: origin.token,
+			Err:   ErrAlwaysEmpty,
+		}}
+	}
+	var errs []*diagnostic.Error
+	for _, child := range children(e) {
+		errs = append(errs, o.report(child)...)
+	}
+	return errs
+}
+
+// cause returns the innermost located sub-expression of an expression that
+// always evaluates to an empty collection, which makes it empty. Empty
+// literals written in the source aren't causes, as the expression using them
+// is the one reported.
+func (o *optimizer) cause(e expr.Expression) expr.Expression {
+	for _, child := range children(e) {
+		if o.written[child] || !alwaysEmpty(child) {
+			continue
+		}
+		if cause := o.cause(child); o.hasOrigin(cause) {
+			return cause
+		}
+	}
+	return e
+}
+
+func (o *optimizer) hasOrigin(e expr.Expression) bool {
+	_, ok := o.origins[e]
+	return ok
+}
+
+// alwaysEmpty returns true if the expression evaluates to an empty
+// collection, or raises an error, for every input.
+func alwaysEmpty(e expr.Expression) bool {
+	switch e := e.(type) {
+	case *expr.LiteralExpression:
+		return isEmptyLiteral(e)
+	case *expr.ExpressionSequence:
+		for i := len(e.Expressions) - 1; i >= 0; i-- {
+			if alwaysEmpty(e.Expressions[i]) {
+				return true
+			}
+			if !preservesEmpty(e.Expressions[i]) {
+				return false
+			}
+		}
+	case *expr.IndexExpression:
+		return alwaysEmpty(e.Index)
+	case *expr.EqualityExpression:
+		return alwaysEmpty(e.Left) || alwaysEmpty(e.Right)
+	case *expr.ComparisonExpression:
+		return alwaysEmpty(e.Left) || alwaysEmpty(e.Right)
+	case *expr.ArithmeticExpression:
+		return alwaysEmpty(e.Left) || alwaysEmpty(e.Right)
+	case *expr.BooleanExpression:
+		return e.Op == expr.Xor && (alwaysEmpty(e.Left) || alwaysEmpty(e.Right))
+	case *expr.MembershipExpression:
+		if e.Operator == expr.In {
+			return alwaysEmpty(e.Left)
+		}
+		return alwaysEmpty(e.Right)
+	case *expr.UnionExpression:
+		return alwaysEmpty(e.Left) && alwaysEmpty(e.Right)
+	case *expr.NegationExpression:
+		return alwaysEmpty(e.Expr)
+	case *expr.IsExpression:
+		return alwaysEmpty(e.Expr)
+	case *expr.AsExpression:
+		return alwaysEmpty(e.Expr)
+	case *scopedExpression:
+		return alwaysEmpty(e.Expression)
+	}
+	return false
+}
+
+// preservesEmpty returns true if the step of a sequence always evaluates to
+// an empty collection when its input is empty.
+func preservesEmpty(e expr.Expression) bool {
+	switch e := e.(type) {
+	case *expr.FieldExpression, *expr.TypeExpression, *expr.IndexExpression:
+		return true
+	case *expr.FunctionExpression:
+		return emptyPreservingFunctions[e.Name]
+	}
+	return false
+}
+
+// children returns the sub-expressions of the expression.
+func children(e expr.Expression) []expr.Expression {
+	switch e := e.(type) {
+	case *expr.ExpressionSequence:
+		return e.Expressions
+	case *expr.IndexExpression:
+		return []expr.Expression{e.Index}
+	case *expr.EqualityExpression:
+		return []expr.Expression{e.Left, e.Right}
+	case *expr.EquivalenceExpression:
+		return []expr.Expression{e.Left, e.Right}
+	case *expr.ComparisonExpression:
+		return []expr.Expression{e.Left, e.Right}
+	case *expr.ArithmeticExpression:
+		return []expr.Expression{e.Left, e.Right}
+	case *expr.ConcatExpression:
+		return []expr.Expression{e.Left, e.Right}
+	case *expr.MembershipExpression:
+		return []expr.Expression{e.Left, e.Right}
+	case *expr.UnionExpression:
+		return []expr.Expression{e.Left, e.Right}
+	case *expr.BooleanExpression:
+		return []expr.Expression{e.Left, e.Right}
+	case *expr.NegationExpression:
+		return []expr.Expression{e.Expr}
+	case *expr.IsExpression:
+		return []expr.Expression{e.Expr}
+	case *expr.AsExpression:
+		return []expr.Expression{e.Expr}
+	case *expr.FunctionExpression:
+		return e.Args
+	case *expr.DefineVariableExpression:
+		if e.Value != nil {
+			return []expr.Expression{e.Value}
+		}
+	case *scopedExpression:
+		return []expr.Expression{e.Expression}
+	}
+	return nil
+}
+
+func isLiteral(e expr.Expression) bool {
+	_, ok := e.(*expr.LiteralExpression)
+	return ok
+}
+
+func isEmptyLiteral(e expr.Expression) bool {
+	literal, ok := e.(*expr.LiteralExpression)
+	return ok && literal.Literal == nil
+}
+
+func allLiterals(exprs []expr.Expression) bool {
+	for _, e := range exprs {
+		if !isLiteral(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// booleanLiteral returns the value of a boolean literal.
+func booleanLiteral(e expr.Expression) (bool, bool) {
+	literal, ok := e.(*expr.LiteralExpression)
+	if !ok {
+		return false, false
+	}
+	value, ok := literal.Literal.(system.Boolean)
+	return bool(value), ok
+}
+
+// scopedExpression evaluates the contained expression with a clone of the
+// context, so that variables it defines aren't visible outside of it, as for
+// the arguments of a function.
+type scopedExpression struct {
+	expr.Expression
+}
+
+func (e *scopedExpression) Evaluate(ctx *expr.Context, input system.Collection) (system.Collection, error) {
+	return e.Expression.Evaluate(ctx.Clone(), input)
+}
+
+var _ expr.Expression = (*scopedExpression)(nil)
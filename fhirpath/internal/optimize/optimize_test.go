@@ -0,0 +1,150 @@
+package optimize_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/compile"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/diagnostic"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/expr"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/optimize"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/parser"
+	"github.com/verily-src/fhirpath-go/fhirpath/system"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func visit(t *testing.T, path string) (expr.Expression, parser.Sources) {
+	t.Helper()
+	config, err := compile.PopulateConfig()
+	if err != nil {
+		t.Fatalf("compile.PopulateConfig() returned unexpected error: %v", err)
+	}
+	tree, err := compile.Tree(path)
+	if err != nil {
+		t.Fatalf("compile.Tree(%q) returned unexpected error: %v", path, err)
+	}
+	visitor := &parser.FHIRPathVisitor{Functions: config.Table, Sources: parser.Sources{}}
+	result := visitor.Visit(tree).(*parser.VisitResult)
+	if result.Error != nil {
+		t.Fatalf("Visit(%q) returned unexpected error: %v", path, result.Error)
+	}
+	return result.Result, visitor.Sources
+}
+
+func TestOptimize_FoldsConstants(t *testing.T) {
+	testCases := []struct {
+		name string
+		path string
+		want system.Any
+	}{
+		{"arithmetic", "1 + 2 * 3", system.Integer(7)},
+		{"concatenation", "'a' & 'b'", system.String("ab")},
+		{"comparison", "2 > 1", system.Boolean(true)},
+		{"equality", "'a' = 'b'", system.Boolean(false)},
+		{"negation", "-(1 + 1)", system.Integer(-2)},
+		{"boolean operator", "true xor false", system.Boolean(true)},
+		{"membership", "1 in 1", system.Boolean(true)},
+		{"type operator", "1 is Integer", system.Boolean(true)},
+		{"pure functions", "'abc'.substring(1).upper()", system.String("BC")},
+		{"index", "'a'[0]", system.String("a")},
+		{"iif with literal branch", "iif(1 > 2, 'a', 'b')", system.String("b")},
+		{"false and", "false and {}", system.Boolean(false)},
+		{"or true", "(1 > 2) or true", system.Boolean(true)},
+		{"implies true", "'a' implies true", system.Boolean(true)},
+		{"misspelled function", "'2020-01-01T10:00:00'.convertToDateTime()", system.Boolean(true)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e, sources := visit(t, tc.path)
+
+			got, _ := optimize.Optimize(e, sources)
+
+			literal, ok := got.(*expr.LiteralExpression)
+			if !ok {
+				t.Fatalf("Optimize(%q) returned %T, want *expr.LiteralExpression", tc.path, got)
+			}
+			if diff := cmp.Diff(tc.want, literal.Literal, protocmp.Transform()); diff != "" {
+				t.Errorf("Optimize(%q) returned unexpected literal (-want, +got):\n%s", tc.path, diff)
+			}
+		})
+	}
+}
+
+func TestOptimize_KeepsExpressionsThatDependOnInput(t *testing.T) {
+	testCases := []struct {
+		name string
+		path string
+	}{
+		{"field", "name.given"},
+		{"operand depends on input", "name.given.count() + 1"},
+		{"impure function", "now() > @2020-01-01"},
+		{"error at evaluation", "1.toString() & 1"},
+		{"more than one item", "1 | 2"},
+		{"true and", "true and name.exists()"},
+		{"and false", "name.given and false"},
+		{"or true", "true or name.given"},
+		{"false implies", "false implies name.given"},
+		{"implies true", "name.given.single() implies true"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e, sources := visit(t, tc.path)
+
+			got, _ := optimize.Optimize(e, sources)
+
+			if _, ok := got.(*expr.LiteralExpression); ok {
+				t.Errorf("Optimize(%q) returned a literal, want expression to be kept", tc.path)
+			}
+		})
+	}
+}
+
+func TestOptimize_RemovesIdentitySteps(t *testing.T) {
+	e, sources := visit(t, "$this.name.$this")
+
+	got, _ := optimize.Optimize(e, sources)
+
+	want := &expr.FieldExpression{FieldName: "name"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Optimize returned unexpected expression (-want, +got):\n%s", diff)
+	}
+}
+
+func TestOptimize_ReportsAlwaysEmpty(t *testing.T) {
+	testCases := []struct {
+		name       string
+		path       string
+		wantTokens []string
+	}{
+		{"where false", "Patient.name.where(false)", []string{"where"}},
+		{"argument of function", "name.exists(given.where({}).first())", []string{"where"}},
+		{"operand of operator", "name = {}", []string{"name"}},
+		{"folded to empty", "'a'.substring(5) | 1/0", []string{"'a'"}},
+		{"iif without otherwise", "name.exists() and iif(false, true)", []string{"iif"}},
+		{"written empty", "{}", nil},
+		{"empty operand of union", "name | {}", nil},
+		{"not empty", "Patient.name.where(false).exists()", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e, sources := visit(t, tc.path)
+
+			_, errs := optimize.Optimize(e, sources)
+
+			var gotTokens []string
+			for _, err := range errs {
+				if !errors.Is(err, optimize.ErrAlwaysEmpty) || err.Code != diagnostic.CodeAlwaysEmpty {
+					t.Errorf("Optimize(%q) returned unexpected error: %v", tc.path, err)
+				}
+				gotTokens = append(gotTokens, err.Token)
+			}
+			if diff := cmp.Diff(tc.wantTokens, gotTokens); diff != "" {
+				t.Errorf("Optimize(%q) reported unexpected tokens (-want, +got):\n%s", tc.path, diff)
+			}
+		})
+	}
+}
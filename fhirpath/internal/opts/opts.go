@@ -23,6 +23,13 @@ type CompileConfig struct {
 	// Permissive is a legacy option to allow FHIRpaths with *invalid* fields to be
 	// compiled (to reduce breakages).
 	Permissive bool
+
+	// InputType is the name of the FHIR type that the expression is evaluated
+	// against. If set, the expression is type checked during compilation.
+	InputType string
+
+	// Optimize enables simplification of the compiled expression.
+	Optimize bool
 }
 
 // EvaluateConfig provides the configuration values for the Evaluate command.
@@ -5,15 +5,34 @@ import (
 	"fmt"
 
 	"github.com/antlr4-go/antlr/v4"
+	"github.com/verily-src/fhirpath-go/fhirpath/ast"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/diagnostic"
 )
 
+var errSyntax = errors.New("syntax error")
+
 type FHIRPathErrorListener struct {
 	*antlr.DefaultErrorListener
+	// Source is the expression being parsed, which is used to locate errors
+	// that have no offending token, such as lexer errors.
+	Source string
 	errors []error
 }
 
 func (l *FHIRPathErrorListener) SyntaxError(recognizer antlr.Recognizer, offendingSymbol interface{}, line, column int, msg string, e antlr.RecognitionException) {
-	err := fmt.Errorf("syntax error on line %d:%d - %s", line, column, msg)
+	err := &diagnostic.Error{Code: diagnostic.CodeSyntax, Err: fmt.Errorf("%w: %s", errSyntax, msg)}
+	if token, ok := offendingSymbol.(antlr.Token); ok {
+		err.Span = diagnostic.TokenSpan(token)
+		err.Token = token.GetText()
+	} else {
+		start := diagnostic.PositionAt(l.Source, line, column)
+		err.Span = ast.Span{Start: start, End: start}
+		if source := []rune(l.Source); start.Offset < len(source) {
+			err.Token = string(source[start.Offset])
+			err.Span.End.Offset++
+			err.Span.End.Column++
+		}
+	}
 	l.errors = append(l.errors, err)
 }
 
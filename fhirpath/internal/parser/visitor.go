@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/antlr4-go/antlr/v4"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/diagnostic"
 	"github.com/verily-src/fhirpath-go/fhirpath/internal/expr"
 	"github.com/verily-src/fhirpath-go/fhirpath/internal/funcs"
 	"github.com/verily-src/fhirpath-go/fhirpath/internal/funcs/impl"
@@ -21,6 +22,9 @@ var (
 	errTooManyQualifiers  = errors.New("too many type qualifiers")
 	errVisitingChildren   = errors.New("error while visiting child expressions")
 	errUnresolvedFunction = errors.New("function identifier can't be resolved")
+	errInvalidVariable    = errors.New("invalid variable definition")
+	errExistingVariable   = errors.New("variable already defined")
+	errInvalidReceiver    = errors.New("function can't be invoked on this input")
 )
 
 type FHIRPathVisitor struct {
@@ -29,8 +33,19 @@ type FHIRPathVisitor struct {
 	Functions   funcs.FunctionTable
 	Transform   VisitorTransform
 	Permissive  bool
+
+	// Sources, if set, records the sub-tree that each visited expression was
+	// constructed from.
+	Sources Sources
+
+	// variables holds the names of the variables defined with 'defineVariable'
+	// that are in scope of the expression currently being visited.
+	variables []string
 }
 
+// Sources maps expressions to the sub-trees that they were constructed from.
+type Sources map[expr.Expression]antlr.ParserRuleContext
+
 type VisitResult struct {
 	Result expr.Expression
 	Error  error
@@ -47,10 +62,19 @@ func (v *FHIRPathVisitor) clone() *FHIRPathVisitor {
 		Functions:   v.Functions,
 		Transform:   v.Transform,
 		Permissive:  v.Permissive,
+		Sources:     v.Sources,
 		visitedRoot: false,
+		variables:   append([]string(nil), v.variables...),
 	}
 }
 
+// visitScoped visits the given tree, discarding any variables defined within
+// it once the visit is complete.
+func (v *FHIRPathVisitor) visitScoped(tree antlr.ParseTree) interface{} {
+	defer func(n int) { v.variables = v.variables[:n] }(len(v.variables))
+	return v.Visit(tree)
+}
+
 func (v *FHIRPathVisitor) transformedVisitResult(resultExpr expr.Expression) *VisitResult {
 	if v.Transform == nil {
 		v.Transform = IdentityTransform
@@ -58,8 +82,40 @@ func (v *FHIRPathVisitor) transformedVisitResult(resultExpr expr.Expression) *Vi
 	return &VisitResult{v.Transform(resultExpr), nil}
 }
 
+// Visit visits the given tree. Errors found while visiting it are located at
+// the span of the tree, unless they were already located at a sub-tree.
 func (v *FHIRPathVisitor) Visit(tree antlr.ParseTree) interface{} {
-	return tree.Accept(v)
+	result := tree.Accept(v)
+	vr, ok := result.(*VisitResult)
+	if !ok {
+		return result
+	}
+	if ctx, ok := tree.(antlr.ParserRuleContext); ok {
+		if vr.Error != nil {
+			vr.Error = diagnostic.Locate(vr.Error, ctx, codeOf(vr.Error, tree))
+		} else if v.Sources != nil && vr.Result != nil {
+			// Sub-trees that only wrap another, such as parenthesized
+			// expressions, produce the same expression. Keep the innermost.
+			if _, ok := v.Sources[vr.Result]; !ok {
+				v.Sources[vr.Result] = ctx
+			}
+		}
+	}
+	return result
+}
+
+// codeOf classifies an error found while visiting the given tree.
+func codeOf(err error, tree antlr.ParseTree) string {
+	switch {
+	case errors.Is(err, impl.ErrWrongArity):
+		return diagnostic.CodeWrongArity
+	case errors.Is(err, errInvalidVariable), errors.Is(err, errExistingVariable):
+		return diagnostic.CodeInvalidVariable
+	}
+	if _, ok := tree.(grammar.ILiteralContext); ok {
+		return diagnostic.CodeInvalidLiteral
+	}
+	return diagnostic.CodeInvalidExpression
 }
 
 func (v *FHIRPathVisitor) VisitProg(ctx *grammar.ProgContext) interface{} {
@@ -72,14 +128,11 @@ func (v *FHIRPathVisitor) VisitProg(ctx *grammar.ProgContext) interface{} {
 func (v *FHIRPathVisitor) VisitIndexerExpression(ctx *grammar.IndexerExpressionContext) interface{} {
 	// visit left side expression
 	leftResult := v.Visit(ctx.Expression(0)).(*VisitResult)
-	if leftResult.Error != nil {
-		return &VisitResult{nil, leftResult.Error}
-	}
 
 	// visit contained expression with new Visitor to reset root node, and construct index
 	rightResult := v.clone().Visit(ctx.Expression(1)).(*VisitResult)
-	if rightResult.Error != nil {
-		return &VisitResult{nil, rightResult.Error}
+	if err := errors.Join(leftResult.Error, rightResult.Error); err != nil {
+		return &VisitResult{nil, err}
 	}
 	indexExpr := &expr.IndexExpression{Index: rightResult.Result}
 
@@ -103,13 +156,10 @@ func (v *FHIRPathVisitor) VisitPolarityExpression(ctx *grammar.PolarityExpressio
 }
 
 func (v *FHIRPathVisitor) VisitAdditiveExpression(ctx *grammar.AdditiveExpressionContext) interface{} {
-	leftResult := v.Visit(ctx.Expression(0)).(*VisitResult)
-	if leftResult.Error != nil {
-		return &VisitResult{nil, leftResult.Error}
-	}
+	leftResult := v.visitScoped(ctx.Expression(0)).(*VisitResult)
 	rightResult := v.clone().Visit(ctx.Expression(1)).(*VisitResult)
-	if rightResult.Error != nil {
-		return &VisitResult{nil, rightResult.Error}
+	if err := errors.Join(leftResult.Error, rightResult.Error); err != nil {
+		return &VisitResult{nil, err}
 	}
 
 	operator := expr.Operator(ctx.GetChild(1).(antlr.TerminalNode).GetText())
@@ -127,13 +177,10 @@ func (v *FHIRPathVisitor) VisitAdditiveExpression(ctx *grammar.AdditiveExpressio
 }
 
 func (v *FHIRPathVisitor) VisitMultiplicativeExpression(ctx *grammar.MultiplicativeExpressionContext) interface{} {
-	leftResult := v.Visit(ctx.Expression(0)).(*VisitResult)
-	if leftResult.Error != nil {
-		return &VisitResult{nil, leftResult.Error}
-	}
+	leftResult := v.visitScoped(ctx.Expression(0)).(*VisitResult)
 	rightResult := v.clone().Visit(ctx.Expression(1)).(*VisitResult)
-	if rightResult.Error != nil {
-		return &VisitResult{nil, rightResult.Error}
+	if err := errors.Join(leftResult.Error, rightResult.Error); err != nil {
+		return &VisitResult{nil, err}
 	}
 
 	operator := expr.Operator(ctx.GetChild(1).(antlr.TerminalNode).GetText())
@@ -157,13 +204,10 @@ func (v *FHIRPathVisitor) VisitMultiplicativeExpression(ctx *grammar.Multiplicat
 }
 
 func (v *FHIRPathVisitor) VisitUnionExpression(ctx *grammar.UnionExpressionContext) interface{} {
-	leftResult := v.Visit(ctx.Expression(0)).(*VisitResult)
-	if leftResult.Error != nil {
-		return &VisitResult{nil, leftResult.Error}
-	}
+	leftResult := v.visitScoped(ctx.Expression(0)).(*VisitResult)
 	rightResult := v.clone().Visit(ctx.Expression(1)).(*VisitResult)
-	if rightResult.Error != nil {
-		return &VisitResult{nil, rightResult.Error}
+	if err := errors.Join(leftResult.Error, rightResult.Error); err != nil {
+		return &VisitResult{nil, err}
 	}
 
 	expression := &expr.UnionExpression{Left: leftResult.Result, Right: rightResult.Result}
@@ -171,13 +215,10 @@ func (v *FHIRPathVisitor) VisitUnionExpression(ctx *grammar.UnionExpressionConte
 }
 
 func (v *FHIRPathVisitor) VisitOrExpression(ctx *grammar.OrExpressionContext) interface{} {
-	leftResult := v.Visit(ctx.Expression(0)).(*VisitResult)
-	if leftResult.Error != nil {
-		return &VisitResult{nil, leftResult.Error}
-	}
+	leftResult := v.visitScoped(ctx.Expression(0)).(*VisitResult)
 	rightResult := v.clone().Visit(ctx.Expression(1)).(*VisitResult)
-	if rightResult.Error != nil {
-		return &VisitResult{nil, rightResult.Error}
+	if err := errors.Join(leftResult.Error, rightResult.Error); err != nil {
+		return &VisitResult{nil, err}
 	}
 
 	operator := expr.Operator(ctx.GetChild(1).(antlr.TerminalNode).GetText())
@@ -187,13 +228,10 @@ func (v *FHIRPathVisitor) VisitOrExpression(ctx *grammar.OrExpressionContext) in
 }
 
 func (v *FHIRPathVisitor) VisitAndExpression(ctx *grammar.AndExpressionContext) interface{} {
-	leftResult := v.Visit(ctx.Expression(0)).(*VisitResult)
-	if leftResult.Error != nil {
-		return &VisitResult{nil, leftResult.Error}
-	}
+	leftResult := v.visitScoped(ctx.Expression(0)).(*VisitResult)
 	rightResult := v.clone().Visit(ctx.Expression(1)).(*VisitResult)
-	if rightResult.Error != nil {
-		return &VisitResult{nil, rightResult.Error}
+	if err := errors.Join(leftResult.Error, rightResult.Error); err != nil {
+		return &VisitResult{nil, err}
 	}
 
 	expression := &expr.BooleanExpression{Left: leftResult.Result, Right: rightResult.Result, Op: expr.And}
@@ -201,13 +239,10 @@ func (v *FHIRPathVisitor) VisitAndExpression(ctx *grammar.AndExpressionContext)
 }
 
 func (v *FHIRPathVisitor) VisitMembershipExpression(ctx *grammar.MembershipExpressionContext) interface{} {
-	leftResult := v.Visit(ctx.Expression(0)).(*VisitResult)
-	if leftResult.Error != nil {
-		return &VisitResult{nil, leftResult.Error}
-	}
+	leftResult := v.visitScoped(ctx.Expression(0)).(*VisitResult)
 	rightResult := v.clone().Visit(ctx.Expression(1)).(*VisitResult)
-	if rightResult.Error != nil {
-		return &VisitResult{nil, rightResult.Error}
+	if err := errors.Join(leftResult.Error, rightResult.Error); err != nil {
+		return &VisitResult{nil, err}
 	}
 
 	operator := expr.Operator(ctx.GetChild(1).(antlr.TerminalNode).GetText())
@@ -217,13 +252,10 @@ func (v *FHIRPathVisitor) VisitMembershipExpression(ctx *grammar.MembershipExpre
 }
 
 func (v *FHIRPathVisitor) VisitInequalityExpression(ctx *grammar.InequalityExpressionContext) interface{} {
-	leftResult := v.Visit(ctx.Expression(0)).(*VisitResult)
-	if leftResult.Error != nil {
-		return &VisitResult{nil, leftResult.Error}
-	}
+	leftResult := v.visitScoped(ctx.Expression(0)).(*VisitResult)
 	rightResult := v.clone().Visit(ctx.Expression(1)).(*VisitResult)
-	if rightResult.Error != nil {
-		return &VisitResult{nil, rightResult.Error}
+	if err := errors.Join(leftResult.Error, rightResult.Error); err != nil {
+		return &VisitResult{nil, err}
 	}
 
 	operator := expr.Operator(ctx.GetChild(1).(antlr.TerminalNode).GetText())
@@ -234,16 +266,11 @@ func (v *FHIRPathVisitor) VisitInequalityExpression(ctx *grammar.InequalityExpre
 
 // VisitInvocationExpression visits both sides, and constructs an expression sequence.
 func (v *FHIRPathVisitor) VisitInvocationExpression(ctx *grammar.InvocationExpressionContext) interface{} {
-	// Visit left side with new visitor, raising error if necessary
+	// Visit both sides, collecting the errors of each
 	leftResult := v.Visit(ctx.Expression()).(*VisitResult)
-	if leftResult.Error != nil {
-		return &VisitResult{nil, leftResult.Error}
-	}
-
-	// Visit right side, raising error if necessary
 	rightResult := v.Visit(ctx.Invocation()).(*VisitResult)
-	if rightResult.Error != nil {
-		return &VisitResult{nil, rightResult.Error}
+	if err := errors.Join(leftResult.Error, rightResult.Error); err != nil {
+		return &VisitResult{nil, err}
 	}
 
 	// Construct and return ExpressionSequence
@@ -255,14 +282,10 @@ func (v *FHIRPathVisitor) VisitInvocationExpression(ctx *grammar.InvocationExpre
 // VisitEqualityExpression both equality subexpressions and constructs an Equality Expression
 // from the results of each subexpression
 func (v *FHIRPathVisitor) VisitEqualityExpression(ctx *grammar.EqualityExpressionContext) interface{} {
-	leftResult := v.Visit(ctx.Expression(0)).(*VisitResult)
-	if leftResult.Error != nil {
-		return &VisitResult{nil, leftResult.Error}
-	}
-
+	leftResult := v.visitScoped(ctx.Expression(0)).(*VisitResult)
 	rightResult := v.clone().Visit(ctx.Expression(1)).(*VisitResult)
-	if rightResult.Error != nil {
-		return &VisitResult{nil, rightResult.Error}
+	if err := errors.Join(leftResult.Error, rightResult.Error); err != nil {
+		return &VisitResult{nil, err}
 	}
 	operator := ctx.GetChild(1).(antlr.TerminalNode).GetText()
 	var expression expr.Expression
@@ -272,21 +295,18 @@ func (v *FHIRPathVisitor) VisitEqualityExpression(ctx *grammar.EqualityExpressio
 	case expr.NotEquals:
 		expression = &expr.EqualityExpression{Left: leftResult.Result, Right: rightResult.Result, Not: true}
 	case expr.Equivalence:
-		// TODO: Implement equivalence expressions
+		expression = &expr.EquivalenceExpression{Left: leftResult.Result, Right: rightResult.Result}
 	case expr.Inequivalence:
-		// TODO: Implement non-equivalence expressions
+		expression = &expr.EquivalenceExpression{Left: leftResult.Result, Right: rightResult.Result, Not: true}
 	}
 	return v.transformedVisitResult(expression)
 }
 
 func (v *FHIRPathVisitor) VisitImpliesExpression(ctx *grammar.ImpliesExpressionContext) interface{} {
-	leftResult := v.Visit(ctx.Expression(0)).(*VisitResult)
-	if leftResult.Error != nil {
-		return &VisitResult{nil, leftResult.Error}
-	}
+	leftResult := v.visitScoped(ctx.Expression(0)).(*VisitResult)
 	rightResult := v.clone().Visit(ctx.Expression(1)).(*VisitResult)
-	if rightResult.Error != nil {
-		return &VisitResult{nil, rightResult.Error}
+	if err := errors.Join(leftResult.Error, rightResult.Error); err != nil {
+		return &VisitResult{nil, err}
 	}
 
 	expression := &expr.BooleanExpression{Left: leftResult.Result, Right: rightResult.Result, Op: expr.Implies}
@@ -299,12 +319,12 @@ func (v *FHIRPathVisitor) VisitTermExpression(ctx *grammar.TermExpressionContext
 
 func (v *FHIRPathVisitor) VisitTypeExpression(ctx *grammar.TypeExpressionContext) interface{} {
 	expression := v.Visit(ctx.Expression()).(*VisitResult)
-	if expression.Error != nil {
-		return &VisitResult{nil, expression.Error}
-	}
 	typeSpecifier := v.Visit(ctx.TypeSpecifier()).(*typeResult)
 	if typeSpecifier.err != nil {
-		return &VisitResult{nil, typeSpecifier.err}
+		typeSpecifier.err = diagnostic.At(ctx.TypeSpecifier(), diagnostic.CodeInvalidType, typeSpecifier.err)
+	}
+	if err := errors.Join(expression.Error, typeSpecifier.err); err != nil {
+		return &VisitResult{nil, err}
 	}
 	operator := ctx.GetChild(1).(antlr.TerminalNode).GetText()
 	var typeExpression expr.Expression
@@ -325,12 +345,34 @@ func (v *FHIRPathVisitor) VisitLiteralTerm(ctx *grammar.LiteralTermContext) inte
 	return v.Visit(ctx.Literal())
 }
 
+// unquoteIdentifier removes the backticks around a delimited identifier.
+func unquoteIdentifier(identifier string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(identifier, "`"), "`")
+}
+
+// VisitExternalConstantTerm returns an ExternalConstantExpression. The constant
+// may be named by an identifier, a delimited identifier (e.g. %`vs-name`) or a
+// string (e.g. %'vs-name').
 func (v *FHIRPathVisitor) VisitExternalConstantTerm(ctx *grammar.ExternalConstantTermContext) interface{} {
-	ident := ctx.ExternalConstant().GetText()
-	ident = strings.TrimPrefix(ident, "%")
+	ident, err := constantName(ctx.ExternalConstant())
+	if err != nil {
+		return &VisitResult{nil, err}
+	}
 	return v.transformedVisitResult(&expr.ExternalConstantExpression{Identifier: ident})
 }
 
+// constantName returns the name of an external constant.
+func constantName(constant grammar.IExternalConstantContext) (string, error) {
+	if str := constant.STRING(); str != nil {
+		value, err := system.ParseString(str.GetText())
+		if err != nil {
+			return "", err
+		}
+		return string(value), nil
+	}
+	return unquoteIdentifier(constant.Identifier().GetText()), nil
+}
+
 func (v *FHIRPathVisitor) VisitParenthesizedTerm(ctx *grammar.ParenthesizedTermContext) interface{} {
 	return v.Visit(ctx.Expression())
 }
@@ -363,12 +405,21 @@ func (v *FHIRPathVisitor) VisitStringLiteral(ctx *grammar.StringLiteralContext)
 	return v.transformedVisitResult(expr)
 }
 
-// VisitNumberLiteral returns either an integer or decimal, depending on whether or not
-// the number contains a decimal. Returns an error if there is an error during creation
-// of the number.
+// VisitNumberLiteral returns either an integer, long or decimal, depending on whether or not
+// the number contains a decimal or a long suffix. Returns an error if there is an error during
+// creation of the number.
 func (v *FHIRPathVisitor) VisitNumberLiteral(ctx *grammar.NumberLiteralContext) interface{} {
 	number := ctx.NUMBER().GetText()
 
+	if strings.HasSuffix(number, "L") {
+		result, err := system.ParseLong(number)
+		if err != nil {
+			return &VisitResult{nil, err}
+		}
+		expr := &expr.LiteralExpression{Literal: result}
+		return v.transformedVisitResult(expr)
+	}
+
 	if strings.Contains(number, ".") {
 		result, err := system.ParseDecimal(number)
 		if err != nil {
@@ -426,6 +477,13 @@ func (v *FHIRPathVisitor) VisitQuantityLiteral(ctx *grammar.QuantityLiteralConte
 	unit := ctx.Quantity().Unit().GetText()
 	unit = strings.TrimPrefix(unit, "'")
 	unit = strings.TrimSuffix(unit, "'")
+	// Units written as strings must be valid UCUM units; calendar duration
+	// keywords are checked by the grammar.
+	if ctx.Quantity().Unit().STRING() != nil {
+		if err := system.ValidateUnit(unit); err != nil {
+			return &VisitResult{nil, err}
+		}
+	}
 
 	quantity, err := system.ParseQuantity(ctx.Quantity().NUMBER().GetText(), unit)
 	if err != nil {
@@ -441,8 +499,9 @@ func (v *FHIRPathVisitor) VisitExternalConstant(ctx *grammar.ExternalConstantCon
 
 // VisitMemberInvocation checks to see if the identifier corresponds to a resource type and is the
 // root of the expression. If so, it will return a TypeExpression. Otherwise, it returns a FieldExpression.
+// Delimited identifiers, such as `div`, allow navigating to fields named by keywords.
 func (v *FHIRPathVisitor) VisitMemberInvocation(ctx *grammar.MemberInvocationContext) interface{} {
-	identifier := ctx.GetText()
+	identifier := unquoteIdentifier(ctx.GetText())
 	var expression expr.Expression
 
 	if resource.IsType(identifier) && !v.visitedRoot {
@@ -464,30 +523,36 @@ func (v *FHIRPathVisitor) VisitThisInvocation(ctx *grammar.ThisInvocationContext
 }
 
 func (v *FHIRPathVisitor) VisitIndexInvocation(ctx *grammar.IndexInvocationContext) interface{} {
-	return &VisitResult{nil, errNotSupported}
+	return v.transformedVisitResult(&expr.IndexInvocationExpression{})
 }
 
 func (v *FHIRPathVisitor) VisitTotalInvocation(ctx *grammar.TotalInvocationContext) interface{} {
-	return &VisitResult{nil, errNotSupported}
+	return v.transformedVisitResult(&expr.TotalInvocationExpression{})
 }
 
 func (v *FHIRPathVisitor) VisitFunction(ctx *grammar.FunctionContext) interface{} {
-	var fn funcs.Function
-	if ctx.Identifier() == nil {
-		fn = v.Functions["ofType"]
-	} else {
-		var ok bool
-		fn, ok = v.Functions[ctx.Identifier().GetText()]
-		if !ok {
-			return &VisitResult{nil, fmt.Errorf("%w: %s", errUnresolvedFunction, ctx.Identifier().GetText())}
-		}
+	if ctx.Identifier() != nil && ctx.Identifier().GetText() == "defineVariable" {
+		return v.visitDefineVariable(ctx)
+	}
+
+	name := "ofType"
+	if ctx.Identifier() != nil {
+		name = ctx.Identifier().GetText()
+	}
+	fn, ok := v.Functions[name]
+	if !ok {
+		return v.unresolvedFunction(ctx)
+	}
+	if terminologyFunctions[name] && !invokedOnTerminologies(ctx) {
+		err := fmt.Errorf("%w: %s must be invoked on %%terminologies", errInvalidReceiver, name)
+		return &VisitResult{nil, diagnostic.At(ctx.Identifier(), diagnostic.CodeInvalidExpression, err)}
 	}
 
 	// Handling for type functions
 	if fn.IsTypeFunction {
 		paramList := ctx.ParamList()
 		if paramList == nil || len(paramList.AllExpression()) != 1 {
-			return &VisitResult{nil, fmt.Errorf("type function expects exactly one argument")}
+			return &VisitResult{nil, fmt.Errorf("%w: type function expects exactly one argument", impl.ErrWrongArity)}
 		}
 
 		// Visit the first argument as a type specifier
@@ -497,20 +562,21 @@ func (v *FHIRPathVisitor) VisitFunction(ctx *grammar.FunctionContext) interface{
 
 		typeSpecifier, err := reflection.NewTypeSpecifier(typeName)
 		if err != nil {
-			return &VisitResult{nil, err}
+			return &VisitResult{nil, diagnostic.At(typeExpr, diagnostic.CodeInvalidType, err)}
 		}
 
 		return v.transformedVisitResult(
 			&expr.FunctionExpression{
 				Fn:   fn.Func,
 				Args: []expr.Expression{&expr.TypeExpression{Type: typeSpecifier.String()}},
+				Name: name,
 			},
 		)
 	}
 
 	results := []*VisitResult{}
 	if args := ctx.ParamList(); args != nil {
-		results = v.Visit(args).([]*VisitResult)
+		results = v.visitScoped(args).([]*VisitResult)
 	}
 
 	errs := slices.Map(results, func(r *VisitResult) error { return r.Error })
@@ -522,7 +588,119 @@ func (v *FHIRPathVisitor) VisitFunction(ctx *grammar.FunctionContext) interface{
 	if len(expressions) < fn.MinArity || len(expressions) > fn.MaxArity {
 		return &VisitResult{nil, fmt.Errorf("%w: input arity outside of function arity bounds", impl.ErrWrongArity)}
 	}
-	return v.transformedVisitResult(&expr.FunctionExpression{Fn: fn.Func, Args: expressions})
+	return v.transformedVisitResult(&expr.FunctionExpression{Fn: fn.Func, Args: expressions, Name: name})
+}
+
+// terminologyFunctions are the functions of the %terminologies API, which have
+// no meaning on any other input.
+var terminologyFunctions = map[string]bool{
+	"expand":     true,
+	"lookup":     true,
+	"translate":  true,
+	"validateCS": true,
+	"validateVS": true,
+}
+
+// invokedOnTerminologies returns true if the function is invoked on the
+// %terminologies environment variable, e.g. %terminologies.expand(url).
+func invokedOnTerminologies(ctx *grammar.FunctionContext) bool {
+	invocation, ok := ctx.GetParent().GetParent().(*grammar.InvocationExpressionContext)
+	if !ok {
+		return false
+	}
+	receiver := invocation.Expression()
+	for {
+		term, ok := receiver.(*grammar.TermExpressionContext)
+		if !ok {
+			return false
+		}
+		switch term := term.Term().(type) {
+		case *grammar.ParenthesizedTermContext:
+			receiver = term.Expression()
+		case *grammar.ExternalConstantTermContext:
+			name, err := constantName(term.ExternalConstant())
+			return err == nil && name == "terminologies"
+		default:
+			return false
+		}
+	}
+}
+
+// unresolvedFunction reports a function that isn't in the function table,
+// suggesting the closest function name. The arguments are still visited, so
+// that errors within them are reported too.
+func (v *FHIRPathVisitor) unresolvedFunction(ctx *grammar.FunctionContext) interface{} {
+	name := ctx.Identifier().GetText()
+	err := diagnostic.At(ctx.Identifier(), diagnostic.CodeUnknownFunction, fmt.Errorf("%w: %s", errUnresolvedFunction, name))
+	names := make([]string, 0, len(v.Functions))
+	for fn := range v.Functions {
+		names = append(names, fn)
+	}
+	err.Suggestion = diagnostic.Suggest(name, names)
+
+	errs := []error{err}
+	if args := ctx.ParamList(); args != nil {
+		for _, result := range v.visitScoped(args).([]*VisitResult) {
+			errs = append(errs, result.Error)
+		}
+	}
+	return &VisitResult{nil, errors.Join(errs...)}
+}
+
+// visitDefineVariable constructs a DefineVariableExpression, and brings the
+// variable into scope for the remainder of the invocation chain. The variable
+// name must be a string literal, and must not already be defined.
+func (v *FHIRPathVisitor) visitDefineVariable(ctx *grammar.FunctionContext) interface{} {
+	var params []grammar.IExpressionContext
+	if paramList := ctx.ParamList(); paramList != nil {
+		params = paramList.AllExpression()
+	}
+	if len(params) < 1 || len(params) > 2 {
+		return &VisitResult{nil, fmt.Errorf("%w: input arity outside of function arity bounds", impl.ErrWrongArity)}
+	}
+
+	name, ok := stringLiteral(params[0])
+	if !ok {
+		err := fmt.Errorf("%w: name must be a string literal, got %s", errInvalidVariable, params[0].GetText())
+		return &VisitResult{nil, diagnostic.At(params[0], diagnostic.CodeInvalidVariable, err)}
+	}
+	if expr.IsSystemConstant(name) || slices.Includes(v.variables, name) {
+		err := fmt.Errorf("%w: %s", errExistingVariable, name)
+		return &VisitResult{nil, diagnostic.At(params[0], diagnostic.CodeInvalidVariable, err)}
+	}
+
+	expression := &expr.DefineVariableExpression{Name: name}
+	if len(params) == 2 {
+		result := v.visitScoped(params[1]).(*VisitResult)
+		if result.Error != nil {
+			return &VisitResult{nil, fmt.Errorf("%w: %w", errVisitingChildren, result.Error)}
+		}
+		expression.Value = result.Result
+	}
+	v.variables = append(v.variables, name)
+	return v.transformedVisitResult(expression)
+}
+
+// stringLiteral returns the value of the given expression if it consists of
+// only a string literal.
+func stringLiteral(e grammar.IExpressionContext) (string, bool) {
+	term, ok := e.(*grammar.TermExpressionContext)
+	if !ok {
+		return "", false
+	}
+	literalTerm, ok := term.Term().(*grammar.LiteralTermContext)
+	if !ok {
+		return "", false
+	}
+	literal, ok := literalTerm.Literal().(*grammar.StringLiteralContext)
+	if !ok {
+		return "", false
+	}
+	value, err := system.ParseString(literal.STRING().GetText())
+	if err != nil {
+		return "", false
+	}
+	return string(value), true
 }
 
 func (v *FHIRPathVisitor) VisitParamList(ctx *grammar.ParamListContext) interface{} {
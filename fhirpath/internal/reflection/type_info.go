@@ -0,0 +1,179 @@
+package reflection
+
+import (
+	"strings"
+
+	"github.com/verily-src/fhirpath-go/fhirpath/system"
+	"github.com/verily-src/fhirpath-go/internal/fhir"
+	"github.com/verily-src/fhirpath-go/internal/protofields"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Navigable is implemented by the values of the reflection types, whose
+// properties can be navigated to from FHIRPath like the fields of a FHIR
+// element, e.g. `value.type().name`.
+type Navigable interface {
+	// Field returns the value of the property with the given name, and false
+	// if there is no such property.
+	Field(name string) (system.Collection, bool)
+}
+
+// SimpleTypeInfo is the type information of a primitive type, which has no
+// elements of its own.
+type SimpleTypeInfo struct {
+	Namespace string
+	Name      string
+	BaseType  TypeSpecifier
+}
+
+// Field returns the value of the 'namespace', 'name' or 'baseType' property.
+func (i SimpleTypeInfo) Field(name string) (system.Collection, bool) {
+	switch name {
+	case "namespace":
+		return system.Collection{system.String(i.Namespace)}, true
+	case "name":
+		return system.Collection{system.String(i.Name)}, true
+	case "baseType":
+		return system.Collection{system.String(i.BaseType.String())}, true
+	}
+	return nil, false
+}
+
+// ClassInfo is the type information of a complex type, such as a FHIR
+// resource or datatype, which is made up of elements.
+type ClassInfo struct {
+	Namespace string
+	Name      string
+	BaseType  TypeSpecifier
+	Element   []ClassInfoElement
+}
+
+// Field returns the value of the 'namespace', 'name', 'baseType' or 'element'
+// property.
+func (i ClassInfo) Field(name string) (system.Collection, bool) {
+	switch name {
+	case "namespace":
+		return system.Collection{system.String(i.Namespace)}, true
+	case "name":
+		return system.Collection{system.String(i.Name)}, true
+	case "baseType":
+		return system.Collection{system.String(i.BaseType.String())}, true
+	case "element":
+		result := system.Collection{}
+		for _, element := range i.Element {
+			result = append(result, element)
+		}
+		return result, true
+	}
+	return nil, false
+}
+
+// ClassInfoElement is a single element of a ClassInfo. The type of elements
+// that can repeat is written as a list, e.g. 'List<FHIR.HumanName>'.
+type ClassInfoElement struct {
+	Name       string
+	Type       string
+	IsOneBased bool
+}
+
+// Field returns the value of the 'name', 'type' or 'isOneBased' property.
+func (e ClassInfoElement) Field(name string) (system.Collection, bool) {
+	switch name {
+	case "name":
+		return system.Collection{system.String(e.Name)}, true
+	case "type":
+		return system.Collection{system.String(e.Type)}, true
+	case "isOneBased":
+		return system.Collection{system.Boolean(e.IsOneBased)}, true
+	}
+	return nil, false
+}
+
+var (
+	_ Navigable = SimpleTypeInfo{}
+	_ Navigable = ClassInfo{}
+	_ Navigable = ClassInfoElement{}
+)
+
+// TypeInfoOf returns the type information of the input, which is a
+// SimpleTypeInfo for System types and FHIR primitives, and a ClassInfo for
+// all other FHIR types. Returns an error if the input is not a supported
+// FHIRPath type.
+func TypeInfoOf(input any) (Navigable, error) {
+	typeSpecifier, err := TypeOf(input)
+	if err != nil {
+		return nil, err
+	}
+	if typeSpecifier.namespace == System || isPrimitive(typeSpecifier.typeName) {
+		return SimpleTypeInfo{
+			Namespace: typeSpecifier.namespace,
+			Name:      typeSpecifier.typeName,
+			BaseType:  typeSpecifier.parent(),
+		}, nil
+	}
+	message := input.(fhir.Base)
+	if oneOf := protofields.UnwrapOneofField(message, "choice"); oneOf != nil {
+		message = oneOf
+	}
+	return ClassInfo{
+		Namespace: typeSpecifier.namespace,
+		Name:      typeSpecifier.typeName,
+		BaseType:  typeSpecifier.parent(),
+		Element:   elementsOf(message.ProtoReflect().Descriptor()),
+	}, nil
+}
+
+// elementsOf returns the elements of the FHIR type with the given descriptor.
+// Fields that are alternatives of a oneof, such as the typed ids of a
+// Reference, are represented by a single element named after the oneof.
+func elementsOf(descriptor protoreflect.MessageDescriptor) []ClassInfoElement {
+	var elements []ClassInfoElement
+	seen := map[protoreflect.Name]bool{}
+	fields := descriptor.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if oneOf := field.ContainingOneof(); oneOf != nil && !oneOf.IsSynthetic() {
+			if !seen[oneOf.Name()] {
+				seen[oneOf.Name()] = true
+				elements = append(elements, ClassInfoElement{Name: string(oneOf.Name()), Type: FHIR + ".string"})
+			}
+			continue
+		}
+		typeName := FHIR + "." + fieldTypeName(field)
+		if field.IsList() {
+			typeName = "List<" + typeName + ">"
+		}
+		elements = append(elements, ClassInfoElement{Name: field.JSONName(), Type: typeName})
+	}
+	return elements
+}
+
+// fieldTypeName returns the name of the FHIR type of the given field.
+func fieldTypeName(field protoreflect.FieldDescriptor) string {
+	message := field.Message()
+	if message == nil {
+		return "string"
+	}
+	switch {
+	case isCodeDescriptor(message):
+		return "code"
+	case message.Oneofs().ByName("choice") != nil:
+		return "Element"
+	case message.FullName() == "google.protobuf.Any", message.Name() == "ContainedResource":
+		return "Resource"
+	}
+	if _, ok := message.Parent().(protoreflect.MessageDescriptor); ok {
+		return "BackboneElement"
+	}
+	return primitiveToLowercase(string(message.Name()))
+}
+
+// isCodeDescriptor returns true if the descriptor is that of a FHIR code type,
+// mirroring protofields.IsCodeField.
+func isCodeDescriptor(message protoreflect.MessageDescriptor) bool {
+	field := message.Fields().ByName("value")
+	if field == nil || !strings.HasSuffix(string(message.Name()), "Code") {
+		return false
+	}
+	return field.Kind() == protoreflect.EnumKind || field.Kind() == protoreflect.StringKind
+}
@@ -0,0 +1,143 @@
+package reflection_test
+
+import (
+	"testing"
+
+	dtpb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/datatypes_go_proto"
+	opb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/observation_go_proto"
+	"github.com/google/go-cmp/cmp"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/reflection"
+	"github.com/verily-src/fhirpath-go/fhirpath/system"
+	"github.com/verily-src/fhirpath-go/internal/fhir"
+)
+
+func TestTypeInfoOf_SimpleTypes(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input any
+		want  reflection.Navigable
+	}{
+		{
+			name:  "system type",
+			input: system.Integer(1),
+			want: reflection.SimpleTypeInfo{
+				Namespace: "System",
+				Name:      "Integer",
+				BaseType:  reflection.MustCreateTypeSpecifier("System", "Any"),
+			},
+		},
+		{
+			name:  "FHIR primitive",
+			input: fhir.Boolean(true),
+			want: reflection.SimpleTypeInfo{
+				Namespace: "FHIR",
+				Name:      "boolean",
+				BaseType:  reflection.MustCreateTypeSpecifier("FHIR", "Element"),
+			},
+		},
+		{
+			name:  "FHIR primitive specialization",
+			input: fhir.Code("final"),
+			want: reflection.SimpleTypeInfo{
+				Namespace: "FHIR",
+				Name:      "code",
+				BaseType:  reflection.MustCreateTypeSpecifier("FHIR", "string"),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := reflection.TypeInfoOf(tc.input)
+			if err != nil {
+				t.Fatalf("TypeInfoOf(%v) returned unexpected error: %v", tc.input, err)
+			}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(reflection.TypeSpecifier{})); diff != "" {
+				t.Errorf("TypeInfoOf(%v) returned unexpected diff (-want, +got)\n%s", tc.input, diff)
+			}
+		})
+	}
+}
+
+func TestTypeInfoOf_ClassInfo(t *testing.T) {
+	value := &opb.Observation_ValueX{
+		Choice: &opb.Observation_ValueX_Quantity{
+			Quantity: &dtpb.Quantity{Value: &dtpb.Decimal{Value: "1.5"}},
+		},
+	}
+
+	got, err := reflection.TypeInfoOf(value)
+	if err != nil {
+		t.Fatalf("TypeInfoOf returned unexpected error: %v", err)
+	}
+
+	info, ok := got.(reflection.ClassInfo)
+	if !ok {
+		t.Fatalf("TypeInfoOf returned %T, want ClassInfo", got)
+	}
+	if info.Namespace != "FHIR" || info.Name != "Quantity" || info.BaseType.String() != "FHIR.Element" {
+		t.Errorf("TypeInfoOf returned unexpected type: %v.%v with base %v", info.Namespace, info.Name, info.BaseType)
+	}
+	wantElements := map[string]string{
+		"extension":  "List<FHIR.Extension>",
+		"value":      "FHIR.decimal",
+		"comparator": "FHIR.code",
+		"unit":       "FHIR.string",
+	}
+	for _, element := range info.Element {
+		if want, ok := wantElements[element.Name]; ok {
+			if element.Type != want {
+				t.Errorf("TypeInfoOf element %s has type %s, want %s", element.Name, element.Type, want)
+			}
+			delete(wantElements, element.Name)
+		}
+	}
+	if len(wantElements) != 0 {
+		t.Errorf("TypeInfoOf is missing elements: %v", wantElements)
+	}
+}
+
+func TestTypeInfoOf_ResourceElements(t *testing.T) {
+	got, err := reflection.TypeInfoOf(&opb.Observation{})
+	if err != nil {
+		t.Fatalf("TypeInfoOf returned unexpected error: %v", err)
+	}
+
+	elements, _ := got.Field("element")
+	types := map[string]string{}
+	for _, element := range elements {
+		element := element.(reflection.ClassInfoElement)
+		types[element.Name] = element.Type
+	}
+	want := map[string]string{
+		"value":     "FHIR.Element",
+		"component": "List<FHIR.BackboneElement>",
+		"subject":   "FHIR.Reference",
+		"contained": "List<FHIR.Resource>",
+		"status":    "FHIR.code",
+	}
+	for name, wantType := range want {
+		if got := types[name]; got != wantType {
+			t.Errorf("Observation element %s has type %q, want %q", name, got, wantType)
+		}
+	}
+}
+
+func TestTypeInfo_Field(t *testing.T) {
+	info := reflection.SimpleTypeInfo{
+		Namespace: "System",
+		Name:      "String",
+		BaseType:  reflection.MustCreateTypeSpecifier("System", "Any"),
+	}
+
+	got, ok := info.Field("baseType")
+	if !ok {
+		t.Fatalf("SimpleTypeInfo.Field(baseType) returned false")
+	}
+	if diff := cmp.Diff(system.Collection{system.String("System.Any")}, got); diff != "" {
+		t.Errorf("SimpleTypeInfo.Field(baseType) returned unexpected diff (-want, +got)\n%s", diff)
+	}
+	if _, ok := info.Field("element"); ok {
+		t.Errorf("SimpleTypeInfo.Field(element) returned true for missing property")
+	}
+}
@@ -5,9 +5,16 @@ import (
 	"fmt"
 	"strings"
 
+	apb "github.com/google/fhir/go/proto/google/fhir/proto/annotations_go_proto"
+	dtpb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/datatypes_go_proto"
+	bcrpb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/bundle_and_contained_resource_go_proto"
+	"github.com/iancoleman/strcase"
 	"github.com/verily-src/fhirpath-go/fhirpath/system"
+	"github.com/verily-src/fhirpath-go/internal/containedresource"
 	"github.com/verily-src/fhirpath-go/internal/fhir"
 	"github.com/verily-src/fhirpath-go/internal/protofields"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 var (
@@ -72,11 +79,64 @@ func TypeOf(input any) (TypeSpecifier, error) {
 	if oneOf := protofields.UnwrapOneofField(item, "choice"); oneOf != nil {
 		item = oneOf
 	}
-	name := string(item.ProtoReflect().Descriptor().Name())
-	if protofields.IsCodeField(item) {
-		return TypeSpecifier{FHIR, "code"}, nil
+	if contained, ok := item.(*bcrpb.ContainedResource); ok {
+		item = containedresource.Unwrap(contained)
 	}
-	return TypeSpecifier{FHIR, primitiveToLowercase(name)}, nil
+	return TypeOfDescriptor(item.ProtoReflect().Descriptor()), nil
+}
+
+// TypeOfDescriptor retrieves the Type Specifier of the FHIR type that is
+// modeled by the given message descriptor.
+func TypeOfDescriptor(descriptor protoreflect.MessageDescriptor) TypeSpecifier {
+	if isCodeDescriptor(descriptor) {
+		return TypeSpecifier{FHIR, "code"}
+	}
+	if _, ok := descriptor.Parent().(protoreflect.MessageDescriptor); ok {
+		// Backbone elements are nested messages, and are named by their path
+		// from the containing type, e.g. 'Bundle.Entry'.
+		name := strings.TrimPrefix(string(descriptor.FullName()), string(descriptor.ParentFile().Package())+".")
+		return TypeSpecifier{FHIR, name}
+	}
+	return TypeSpecifier{FHIR, primitiveToLowercase(string(descriptor.Name()))}
+}
+
+// datatypes holds the descriptors of the R4 datatypes.
+var datatypes = dtpb.File_proto_google_fhir_proto_r4_core_datatypes_proto.Messages()
+
+// DescriptorOf returns the message descriptor that models the given FHIR type.
+// Returns nil for System types and for abstract FHIR types, such as 'Resource'
+// or 'Element', which aren't modeled by a single message.
+func DescriptorOf(ts TypeSpecifier) protoreflect.MessageDescriptor {
+	if ts.namespace != FHIR {
+		return nil
+	}
+	container, path, nested := strings.Cut(ts.typeName, ".")
+	descriptor := datatypes.ByName(protoreflect.Name(strcase.ToCamel(container)))
+	if refs, ok := protofields.Resources[container]; ok {
+		descriptor = refs.New().ProtoReflect().Descriptor()
+	}
+	if descriptor == nil || proto.GetExtension(descriptor.Options(), apb.E_IsAbstractType).(bool) {
+		return nil
+	}
+	if !nested {
+		return descriptor
+	}
+	for _, name := range strings.Split(path, ".") {
+		if descriptor = descriptor.Messages().ByName(protoreflect.Name(name)); descriptor == nil {
+			return nil
+		}
+	}
+	return descriptor
+}
+
+// Namespace returns the namespace of the type specifier, e.g. 'FHIR'.
+func (ts TypeSpecifier) Namespace() string {
+	return ts.namespace
+}
+
+// Name returns the name of the type within its namespace, e.g. 'Patient'.
+func (ts TypeSpecifier) Name() string {
+	return ts.typeName
 }
 
 // String returns a string representation of the type specifier in the format:
@@ -114,11 +174,18 @@ func MustCreateTypeSpecifier(namespace string, typeName string) TypeSpecifier {
 	return typeSpecifier
 }
 
+// parent returns the base type of the type specifier, following the R4 type
+// hierarchy. Element and Resource are the roots of the FHIR types, and Any is
+// the root of the System types. The hierarchy is derived from the descriptor
+// of the type where it's modeled: profiles such as 'SimpleQuantity' are
+// annotated with their base type, backbone elements have a modifierExtension
+// field, and domain resources have a text field. Specializations of primitive
+// types and of Quantity aren't modeled, so are listed here.
+// See https://hl7.org/fhir/R4/types-map.html
 func (ts TypeSpecifier) parent() TypeSpecifier {
 	if ts.namespace == System {
 		return TypeSpecifier{"System", "Any"}
 	}
-
 	switch ts.typeName {
 	case "code", "markdown", "id":
 		return TypeSpecifier{ts.namespace, "string"}
@@ -126,20 +193,44 @@ func (ts TypeSpecifier) parent() TypeSpecifier {
 		return TypeSpecifier{ts.namespace, "integer"}
 	case "url", "canonical", "uuid", "oid":
 		return TypeSpecifier{ts.namespace, "uri"}
-	case "Duration", "MoneyQuantity", "Age", "Count", "Distance", "SimpleQuantity":
+	case "Duration", "Age", "Count", "Distance":
 		return TypeSpecifier{ts.namespace, "Quantity"}
-	case "Timing", "Dosage", "ElementDefinition":
-		return TypeSpecifier{ts.namespace, "BackboneElement"}
-	case "Bundle", "Binary", "Parameters", "DomainResource":
+	case "BackboneElement":
+		return TypeSpecifier{ts.namespace, "Element"}
+	case "DomainResource":
 		return TypeSpecifier{ts.namespace, "Resource"}
 	case "Element", "Resource":
 		return ts
-	default:
-		if IsValidFHIRPathElement(ts.typeName) {
-			return TypeSpecifier{ts.namespace, "Element"}
+	}
+	descriptor := DescriptorOf(ts)
+	if descriptor == nil {
+		return TypeSpecifier{ts.namespace, "Element"}
+	}
+	if base, ok := profileBase(descriptor); ok {
+		return TypeSpecifier{ts.namespace, base}
+	}
+	if protofields.IsValidResourceType(ts.typeName) {
+		if descriptor.Fields().ByName("text") != nil {
+			return TypeSpecifier{ts.namespace, "DomainResource"}
 		}
-		return TypeSpecifier{ts.namespace, "DomainResource"}
+		return TypeSpecifier{ts.namespace, "Resource"}
+	}
+	if descriptor.Fields().ByName("modifier_extension") != nil {
+		return TypeSpecifier{ts.namespace, "BackboneElement"}
 	}
+	return TypeSpecifier{ts.namespace, "Element"}
+}
+
+// profileBase returns the name of the type that the given descriptor is a
+// profile of, e.g. 'Quantity' for 'SimpleQuantity'. Returns false if the
+// descriptor doesn't model a profile.
+func profileBase(descriptor protoreflect.MessageDescriptor) (string, bool) {
+	bases := proto.GetExtension(descriptor.Options(), apb.E_FhirProfileBase).([]string)
+	if len(bases) == 0 {
+		return "", false
+	}
+	url := bases[0]
+	return url[strings.LastIndex(url, "/")+1:], true
 }
 
 func isBaseType(name string) bool {
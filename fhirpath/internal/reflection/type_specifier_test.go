@@ -1,13 +1,19 @@
 package reflection_test
 
 import (
+	"strings"
 	"testing"
 
+	apb "github.com/google/fhir/go/proto/google/fhir/proto/annotations_go_proto"
 	dtpb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/datatypes_go_proto"
+	bcrpb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/bundle_and_contained_resource_go_proto"
 	ppb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/patient_go_proto"
 	"github.com/verily-src/fhirpath-go/fhirpath/internal/reflection"
 	"github.com/verily-src/fhirpath-go/fhirpath/system"
 	"github.com/verily-src/fhirpath-go/internal/fhir"
+	"github.com/verily-src/fhirpath-go/internal/protofields"
+	"github.com/verily-src/fhirpath-go/internal/slices"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestTypeSpecifier_Is(t *testing.T) {
@@ -82,6 +88,68 @@ func TestTypeSpecifier_Is(t *testing.T) {
 			typeOne: reflection.MustCreateTypeSpecifier("FHIR", "Patient"),
 			typeTwo: reflection.MustCreateTypeSpecifier("FHIR", "Practitioner"),
 		},
+		{
+			name:    "Patient is Resource",
+			typeOne: reflection.MustCreateTypeSpecifier("FHIR", "Patient"),
+			typeTwo: reflection.MustCreateTypeSpecifier("FHIR", "Resource"),
+			want:    true,
+		},
+		{
+			name:    "Patient is not Element",
+			typeOne: reflection.MustCreateTypeSpecifier("FHIR", "Patient"),
+			typeTwo: reflection.MustCreateTypeSpecifier("FHIR", "Element"),
+		},
+		{
+			name:    "Bundle is not DomainResource",
+			typeOne: reflection.MustCreateTypeSpecifier("FHIR", "Bundle"),
+			typeTwo: reflection.MustCreateTypeSpecifier("FHIR", "DomainResource"),
+		},
+		{
+			name:    "Age is Quantity",
+			typeOne: reflection.MustCreateTypeSpecifier("FHIR", "Age"),
+			typeTwo: reflection.MustCreateTypeSpecifier("FHIR", "Quantity"),
+			want:    true,
+		},
+		{
+			name:    "Quantity is not SimpleQuantity",
+			typeOne: reflection.MustCreateTypeSpecifier("FHIR", "Quantity"),
+			typeTwo: reflection.MustCreateTypeSpecifier("FHIR", "SimpleQuantity"),
+		},
+		{
+			name:    "id is string",
+			typeOne: reflection.MustCreateTypeSpecifier("FHIR", "id"),
+			typeTwo: reflection.MustCreateTypeSpecifier("FHIR", "string"),
+			want:    true,
+		},
+		{
+			name:    "uuid is uri",
+			typeOne: reflection.MustCreateTypeSpecifier("FHIR", "uuid"),
+			typeTwo: reflection.MustCreateTypeSpecifier("FHIR", "uri"),
+			want:    true,
+		},
+		{
+			name:    "Dosage is BackboneElement",
+			typeOne: reflection.MustCreateTypeSpecifier("FHIR", "Dosage"),
+			typeTwo: reflection.MustCreateTypeSpecifier("FHIR", "BackboneElement"),
+			want:    true,
+		},
+		{
+			name:    "BackboneElement is Element",
+			typeOne: reflection.MustCreateTypeSpecifier("FHIR", "BackboneElement"),
+			typeTwo: reflection.MustCreateTypeSpecifier("FHIR", "Element"),
+			want:    true,
+		},
+		{
+			name:    "Coding is not BackboneElement",
+			typeOne: reflection.MustCreateTypeSpecifier("FHIR", "Coding"),
+			typeTwo: reflection.MustCreateTypeSpecifier("FHIR", "BackboneElement"),
+		},
+		{
+			name:    "xhtml is Element",
+			typeOne: reflection.MustCreateTypeSpecifier("FHIR", "xhtml"),
+			typeTwo: reflection.MustCreateTypeSpecifier("FHIR", "Element"),
+			want:    true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -301,8 +369,147 @@ func TestTypeOf_ReturnsTS(t *testing.T) {
 	}
 }
 
+func TestTypeOf_NestedTypes(t *testing.T) {
+	testCases := []struct {
+		name      string
+		input     any
+		wantName  string
+		wantIs    reflection.TypeSpecifier
+		wantIsNot reflection.TypeSpecifier
+	}{
+		{
+			name:      "backbone element of resource",
+			input:     &bcrpb.Bundle_Entry{},
+			wantName:  "FHIR.Bundle.Entry",
+			wantIs:    reflection.MustCreateTypeSpecifier("FHIR", "BackboneElement"),
+			wantIsNot: reflection.MustCreateTypeSpecifier("FHIR", "Resource"),
+		},
+		{
+			name:      "element of datatype",
+			input:     &dtpb.Timing_Repeat{},
+			wantName:  "FHIR.Timing.Repeat",
+			wantIs:    reflection.MustCreateTypeSpecifier("FHIR", "Element"),
+			wantIsNot: reflection.MustCreateTypeSpecifier("FHIR", "BackboneElement"),
+		},
+		{
+			name: "contained resource",
+			input: &bcrpb.ContainedResource{
+				OneofResource: &bcrpb.ContainedResource_Patient{Patient: &ppb.Patient{}},
+			},
+			wantName:  "FHIR.Patient",
+			wantIs:    reflection.MustCreateTypeSpecifier("FHIR", "DomainResource"),
+			wantIsNot: reflection.MustCreateTypeSpecifier("FHIR", "Element"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := reflection.TypeOf(tc.input)
+
+			if err != nil {
+				t.Fatalf("TypeOf returned unexpected error: %v", err)
+			}
+			if got.String() != tc.wantName {
+				t.Errorf("TypeOf returned incorrect type specifier: got %v, want %v", got, tc.wantName)
+			}
+			if !got.Is(tc.wantIs) {
+				t.Errorf("TypeOf(%v).Is(%v) = false, want true", tc.name, tc.wantIs)
+			}
+			if got.Is(tc.wantIsNot) {
+				t.Errorf("TypeOf(%v).Is(%v) = true, want false", tc.name, tc.wantIsNot)
+			}
+		})
+	}
+}
+
 func TestGetTypeSpecifier_ReturnsError(t *testing.T) {
 	if _, err := reflection.TypeOf("unsupported type"); err == nil {
 		t.Fatalf("GetTypeSpecifier didn't return error for unsupported type")
 	}
 }
+
+func TestDescriptorOf(t *testing.T) {
+	entry, err := reflection.TypeOf(&bcrpb.Bundle_Entry{})
+	if err != nil {
+		t.Fatalf("TypeOf returned unexpected error: %v", err)
+	}
+	testCases := []struct {
+		name      string
+		input     reflection.TypeSpecifier
+		wantFound bool
+	}{
+		{"resource", reflection.MustCreateTypeSpecifier("FHIR", "Patient"), true},
+		{"complex type", reflection.MustCreateTypeSpecifier("FHIR", "HumanName"), true},
+		{"primitive type", reflection.MustCreateTypeSpecifier("FHIR", "dateTime"), true},
+		{"primitive type with digits", reflection.MustCreateTypeSpecifier("FHIR", "base64Binary"), true},
+		{"backbone element", entry, true},
+		{"abstract type", reflection.MustCreateTypeSpecifier("FHIR", "Resource"), false},
+		{"abstract datatype", reflection.MustCreateTypeSpecifier("FHIR", "Element"), false},
+		{"datatype that isn't an extension value", reflection.TypeOfDescriptor((&dtpb.Population{}).ProtoReflect().Descriptor()), true},
+		{"system type", reflection.MustCreateTypeSpecifier("System", "String"), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			descriptor := reflection.DescriptorOf(tc.input)
+
+			if got := descriptor != nil; got != tc.wantFound {
+				t.Fatalf("DescriptorOf(%v) found = %v, want %v", tc.input, got, tc.wantFound)
+			}
+			if descriptor == nil {
+				return
+			}
+			if got := reflection.TypeOfDescriptor(descriptor); got != tc.input {
+				t.Errorf("TypeOfDescriptor(DescriptorOf(%v)) = %v, want %v", tc.input, got, tc.input)
+			}
+		})
+	}
+}
+
+func TestTypeSpecifier_Is_R4TypeHierarchy(t *testing.T) {
+	element := reflection.MustCreateTypeSpecifier("FHIR", "Element")
+	backboneElement := reflection.MustCreateTypeSpecifier("FHIR", "BackboneElement")
+	resource := reflection.MustCreateTypeSpecifier("FHIR", "Resource")
+	domainResource := reflection.MustCreateTypeSpecifier("FHIR", "DomainResource")
+	// The datatypes and resources that are specializations of BackboneElement
+	// and Resource respectively. See https://hl7.org/fhir/R4/types-map.html
+	backboneElements := []string{
+		"Timing", "Dosage", "ElementDefinition", "MarketingStatus", "Population",
+		"ProdCharacteristic", "ProductShelfLife", "SubstanceAmount",
+	}
+	resources := []string{"Bundle", "Binary", "Parameters"}
+
+	messages := dtpb.File_proto_google_fhir_proto_r4_core_datatypes_proto.Messages()
+	for i := 0; i < messages.Len(); i++ {
+		descriptor := messages.Get(i)
+		url := proto.GetExtension(descriptor.Options(), apb.E_FhirStructureDefinitionUrl).(string)
+		if !strings.HasPrefix(url, "http://hl7.org/fhir/") {
+			continue
+		}
+		datatype := reflection.TypeOfDescriptor(descriptor)
+		t.Run(datatype.Name(), func(t *testing.T) {
+			if !datatype.Is(element) {
+				t.Errorf("%v.Is(%v) = false, want true", datatype, element)
+			}
+			if datatype == backboneElement {
+				return
+			}
+			want := slices.Includes(backboneElements, datatype.Name())
+			if got := datatype.Is(backboneElement); bool(got) != want {
+				t.Errorf("%v.Is(%v) = %v, want %v", datatype, backboneElement, got, want)
+			}
+		})
+	}
+	for name := range protofields.Resources {
+		r := reflection.MustCreateTypeSpecifier("FHIR", name)
+		t.Run(name, func(t *testing.T) {
+			if !r.Is(resource) {
+				t.Errorf("%v.Is(%v) = false, want true", r, resource)
+			}
+			want := !slices.Includes(resources, name)
+			if got := r.Is(domainResource); bool(got) != want {
+				t.Errorf("%v.Is(%v) = %v, want %v", r, domainResource, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,381 @@
+package typecheck
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/iancoleman/strcase"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/diagnostic"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/expr"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/grammar"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/reflection"
+	"github.com/verily-src/fhirpath-go/fhirpath/system"
+	"github.com/verily-src/fhirpath-go/internal/resource"
+	"github.com/verily-src/fhirpath-go/internal/slices"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+var (
+	ErrImpossibleType  = errors.New("type can never match")
+	ErrInvalidArgument = errors.New("invalid argument type")
+)
+
+// nonEvaluableFields are the fields of the temporal protos that aren't part
+// of the FHIR spec, and can't be navigated to.
+var nonEvaluableFields = []string{
+	"valueUs", "precision", "timezone",
+}
+
+// Check infers the type of the parsed expression, when evaluated against an
+// input of the given type. All errors found in the expression are returned
+// together. If permissive is set, navigation to unknown fields isn't reported,
+// mirroring the legacy permissive evaluation.
+func Check(tree grammar.IProgContext, input Type, permissive bool) (Type, error) {
+	c := &checker{root: input, permissive: permissive, errs: &[]error{}}
+	result := c.expression(tree.Expression(), input)
+	return result, errors.Join(*c.errs...)
+}
+
+// checker infers types while walking the parse tree. It mirrors the scoping
+// of the parser's visitor, so that root resource types are resolved the same
+// way that they are during compilation.
+type checker struct {
+	// root is the type of the input of the whole expression.
+	root        Type
+	permissive  bool
+	visitedRoot bool
+	errs        *[]error
+}
+
+// clone produces a checker for sub-expressions, which resets the root node.
+func (c *checker) clone() *checker {
+	return &checker{root: c.root, permissive: c.permissive, errs: c.errs}
+}
+
+// errorf reports an error located at the given parse tree.
+func (c *checker) errorf(ctx antlr.ParserRuleContext, code, format string, args ...any) *diagnostic.Error {
+	err := diagnostic.At(ctx, code, fmt.Errorf(format, args...))
+	*c.errs = append(*c.errs, err)
+	return err
+}
+
+// binaryExpression is implemented by the parse trees of all binary operators.
+type binaryExpression interface {
+	Expression(i int) grammar.IExpressionContext
+	GetChild(i int) antlr.Tree
+}
+
+func (c *checker) expression(tree grammar.IExpressionContext, input Type) Type {
+	switch ctx := tree.(type) {
+	case *grammar.TermExpressionContext:
+		return c.term(ctx.Term(), input)
+	case *grammar.InvocationExpressionContext:
+		left := c.expression(ctx.Expression(), input)
+		return c.invocation(ctx.Invocation(), left)
+	case *grammar.IndexerExpressionContext:
+		left := c.expression(ctx.Expression(0), input)
+		index := c.clone().expression(ctx.Expression(1), left)
+		if !accepts("Integer", index) && !accepts("Long", index) {
+			c.errorf(ctx.Expression(1), diagnostic.CodeInvalidArgument, "%w: index must be an Integer, got %v", ErrInvalidArgument, index)
+		}
+		return left.item()
+	case *grammar.PolarityExpressionContext:
+		return c.expression(ctx.Expression(), input)
+	case *grammar.TypeExpressionContext:
+		left := c.expression(ctx.Expression(), input)
+		return c.typeOperator(ctx, left)
+	case binaryExpression:
+		left := c.expression(ctx.Expression(0), input)
+		right := c.clone().expression(ctx.Expression(1), input)
+		return binaryOperator(operatorOf(ctx), left, right)
+	}
+	return Type{}
+}
+
+// operatorOf returns the text of the operator of a binary expression.
+func operatorOf(ctx binaryExpression) string {
+	return ctx.GetChild(1).(antlr.TerminalNode).GetText()
+}
+
+// binaryOperator returns the type of the result of a binary operator, given
+// the types of its operands.
+func binaryOperator(op string, left, right Type) Type {
+	switch op {
+	case "|":
+		return returnsUnion(left, []Type{right})
+	case "&":
+		return systemType("String")
+	case "+", "-", "*", "/", "div", "mod":
+		break
+	default:
+		return systemType("Boolean")
+	}
+	l, lok := systemEquivalent(left)
+	r, rok := systemEquivalent(right)
+	if !lok || !rok {
+		return Type{Singleton: true}
+	}
+	switch {
+	case op == "+" && l == "String" && r == "String":
+		return systemType("String")
+	case (l == "Date" || l == "DateTime" || l == "Time") && r == "Quantity":
+		return systemType(l)
+	case l == "Quantity" || r == "Quantity":
+		return systemType("Quantity")
+	case op == "/":
+		return systemType("Decimal")
+	case op == "div":
+		return systemType("Integer")
+	case l == "Decimal" || r == "Decimal":
+		return systemType("Decimal")
+	case l == "Long" || r == "Long":
+		return systemType("Long")
+	case l == "Integer" && r == "Integer":
+		return systemType("Integer")
+	}
+	return Type{Singleton: true}
+}
+
+// typeOperator returns the type of an 'is' or 'as' expression, reporting
+// casts that can never succeed.
+func (c *checker) typeOperator(ctx *grammar.TypeExpressionContext, left Type) Type {
+	operator := ctx.GetChild(1).(antlr.TerminalNode).GetText()
+	if operator == expr.Is {
+		return systemType("Boolean")
+	}
+	identifiers := slices.Map(ctx.TypeSpecifier().QualifiedIdentifier().AllIdentifier(),
+		func(i grammar.IIdentifierContext) string { return i.GetText() })
+	target, err := reflection.NewTypeSpecifier(strings.Join(identifiers, "."))
+	if err != nil {
+		return Type{Singleton: left.Singleton}
+	}
+	return c.cast(ctx.TypeSpecifier(), left, target)
+}
+
+// cast returns the type of the items of the input that are of the target
+// type, reporting targets that no item can ever be of at the given parse tree.
+func (c *checker) cast(ctx antlr.ParserRuleContext, input Type, target reflection.TypeSpecifier) Type {
+	if !possible(input, target) {
+		c.errorf(ctx, diagnostic.CodeImpossibleType, "%w: %v can never be %v", ErrImpossibleType, input.item(), target)
+	}
+	return Type{spec: target, descriptor: reflection.DescriptorOf(target), Singleton: input.Singleton}
+}
+
+func (c *checker) term(tree grammar.ITermContext, input Type) Type {
+	switch ctx := tree.(type) {
+	case *grammar.InvocationTermContext:
+		return c.invocation(ctx.Invocation(), input)
+	case *grammar.LiteralTermContext:
+		return literal(ctx.Literal())
+	case *grammar.ExternalConstantTermContext:
+		return c.constant(ctx.ExternalConstant())
+	case *grammar.ParenthesizedTermContext:
+		return c.expression(ctx.Expression(), input)
+	}
+	return Type{}
+}
+
+func literal(tree grammar.ILiteralContext) Type {
+	switch ctx := tree.(type) {
+	case *grammar.BooleanLiteralContext:
+		return systemType("Boolean")
+	case *grammar.StringLiteralContext:
+		return systemType("String")
+	case *grammar.NumberLiteralContext:
+		number := ctx.NUMBER().GetText()
+		if strings.HasSuffix(number, "L") {
+			return systemType("Long")
+		}
+		if strings.Contains(number, ".") {
+			return systemType("Decimal")
+		}
+		return systemType("Integer")
+	case *grammar.DateLiteralContext:
+		return systemType("Date")
+	case *grammar.DateTimeLiteralContext:
+		return systemType("DateTime")
+	case *grammar.TimeLiteralContext:
+		return systemType("Time")
+	case *grammar.QuantityLiteralContext:
+		return systemType("Quantity")
+	}
+	return Type{Singleton: true}
+}
+
+// constant returns the type of an environment variable. Only the variables
+// that refer to the input, and the predefined URLs, are known.
+func (c *checker) constant(ctx grammar.IExternalConstantContext) Type {
+	var name string
+	if str := ctx.STRING(); str != nil {
+		value, _ := system.ParseString(str.GetText())
+		name = string(value)
+	} else {
+		name = strings.Trim(ctx.Identifier().GetText(), "`")
+	}
+	switch name {
+	case "context", "resource", "rootResource":
+		return c.root
+	case "ucum", "sct", "loinc":
+		return systemType("String")
+	}
+	return Type{}
+}
+
+func (c *checker) invocation(tree grammar.IInvocationContext, input Type) Type {
+	switch ctx := tree.(type) {
+	case *grammar.MemberInvocationContext:
+		return c.member(ctx, strings.Trim(ctx.GetText(), "`"), input)
+	case *grammar.FunctionInvocationContext:
+		return c.function(ctx.Function(), input)
+	case *grammar.ThisInvocationContext:
+		return input
+	case *grammar.IndexInvocationContext:
+		return systemType("Integer")
+	}
+	return Type{}
+}
+
+// member returns the type of a member invocation, which is either the root
+// resource type of the expression, or a field of the input.
+func (c *checker) member(ctx antlr.ParserRuleContext, name string, input Type) Type {
+	if resource.IsType(name) && !c.visitedRoot {
+		c.visitedRoot = true
+		target, _ := Of(name)
+		if !possible(input, target.spec) {
+			c.errorf(ctx, diagnostic.CodeImpossibleType, "%w: %v can never be %v", ErrImpossibleType, input.item(), target)
+		}
+		target.Singleton = input.Singleton
+		return target
+	}
+	if !input.Known() && !input.isChoice() {
+		return Type{}
+	}
+	var found []Type
+	for _, option := range input.options() {
+		if result, ok := field(option, name); ok {
+			found = append(found, result)
+		}
+	}
+	if len(found) == 0 {
+		if !c.permissive {
+			err := c.errorf(ctx, diagnostic.CodeUnknownField, "%w: %s not a field on %v", expr.ErrInvalidField, name, input.item())
+			err.Suggestion = diagnostic.Suggest(name, fieldNames(input))
+		}
+		return Type{}
+	}
+	for _, result := range found[1:] {
+		if result.spec != found[0].spec {
+			return Type{}
+		}
+	}
+	return found[0]
+}
+
+// fieldNames returns the names of the fields that can be navigated to on the
+// given type.
+func fieldNames(t Type) []string {
+	var names []string
+	for _, option := range t.options() {
+		if option.descriptor == nil {
+			continue
+		}
+		fields := option.descriptor.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			name := strcase.ToLowerCamel(strings.TrimSuffix(string(fields.Get(i).Name()), "_value"))
+			if isTemporal(option) && slices.Includes(nonEvaluableFields, name) {
+				continue
+			}
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// field returns the type of the named field of the given type. Returns false
+// if the type has no such field.
+func field(t Type, name string) (Type, bool) {
+	if t.descriptor == nil {
+		// System types can't be navigated, while the fields of abstract FHIR
+		// types are unknown.
+		return Type{}, t.spec.Namespace() != reflection.System
+	}
+	if strcase.ToLowerCamel(name) != name {
+		return Type{}, false
+	}
+	if isTemporal(t) && slices.Includes(nonEvaluableFields, name) {
+		return Type{}, false
+	}
+	fieldName := protoreflect.Name(strcase.ToSnake(name))
+	fd := t.descriptor.Fields().ByName(fieldName)
+	if fd == nil {
+		switch {
+		case fieldName == "reference" && t.spec.Name() == "Reference":
+			result, _ := Of("string")
+			result.Singleton = t.Singleton
+			return result, true
+		case fieldName == "value" && isTemporal(t):
+			return primitiveValue(t)
+		}
+		if fd = t.descriptor.Fields().ByName(fieldName + "_value"); fd == nil {
+			return Type{}, false
+		}
+	}
+	if fd.Message() == nil {
+		if result, ok := primitiveValue(t); ok {
+			return result, true
+		}
+		return Type{Singleton: t.Singleton}, true
+	}
+	return messageType(fd.Message(), t.Singleton && !fd.IsList()), true
+}
+
+// function returns the type of the output of a function, checking the types
+// of its arguments against its signature.
+func (c *checker) function(ctx grammar.IFunctionContext, input Type) Type {
+	name := "ofType"
+	if ctx.Identifier() != nil {
+		name = ctx.Identifier().GetText()
+	}
+	var params []grammar.IExpressionContext
+	if paramList := ctx.ParamList(); paramList != nil {
+		params = paramList.AllExpression()
+	}
+
+	switch name {
+	case "defineVariable":
+		if len(params) == 2 {
+			c.expression(params[1], input)
+		}
+		return input
+	case "ofType":
+		if len(params) != 1 {
+			return Type{}
+		}
+		target, err := reflection.NewTypeSpecifier(params[0].GetText())
+		if err != nil {
+			return Type{}
+		}
+		return c.cast(params[0], input, target)
+	}
+
+	sig, ok := signatures[name]
+	scope := input
+	if sig.iterates {
+		scope = input.item()
+	}
+	args := make([]Type, len(params))
+	for i, param := range params {
+		args[i] = c.expression(param, scope)
+	}
+	if !ok || sig.result == nil {
+		return Type{}
+	}
+	for i, want := range sig.params {
+		if i < len(args) && !accepts(want, args[i]) {
+			c.errorf(params[i], diagnostic.CodeInvalidArgument, "%w: %s expects %s for argument %d, got %v", ErrInvalidArgument, name, want, i+1, args[i])
+		}
+	}
+	return sig.result(input, args)
+}
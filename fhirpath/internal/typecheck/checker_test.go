@@ -0,0 +1,162 @@
+package typecheck_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/compile"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/expr"
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/typecheck"
+)
+
+func check(t *testing.T, path, input string, permissive bool) (typecheck.Type, error) {
+	t.Helper()
+	tree, err := compile.Tree(path)
+	if err != nil {
+		t.Fatalf("compile.Tree(%q) returned unexpected error: %v", path, err)
+	}
+	inputType, ok := typecheck.Of(input)
+	if !ok {
+		t.Fatalf("typecheck.Of(%q) returned false", input)
+	}
+	return typecheck.Check(tree, inputType, permissive)
+}
+
+func TestCheck_InfersType(t *testing.T) {
+	testCases := []struct {
+		name          string
+		path          string
+		input         string
+		wantType      string
+		wantSingleton bool
+	}{
+		{"root resource", "Patient", "Patient", "FHIR.Patient", true},
+		{"singleton field", "Patient.birthDate", "Patient", "FHIR.date", true},
+		{"repeated field", "Patient.name", "Patient", "FHIR.HumanName", false},
+		{"field of collection", "Patient.name.family", "Patient", "FHIR.string", false},
+		{"field without root type", "name.given", "Patient", "FHIR.string", false},
+		{"backbone element", "Patient.contact", "Patient", "FHIR.Patient.Contact", false},
+		{"code field", "Patient.gender", "Patient", "FHIR.code", true},
+		{"primitive value", "Patient.active.value", "Patient", "System.Boolean", true},
+		{"temporal value", "Patient.birthDate.value", "Patient", "System.String", true},
+		{"reference", "Patient.managingOrganization.reference", "Patient", "FHIR.string", true},
+		{"choice field", "Observation.value", "Observation", "", true},
+		{"choice cast with ofType", "Observation.value.ofType(Quantity).unit", "Observation", "FHIR.string", true},
+		{"choice cast with as", "(Observation.value as Quantity).value", "Observation", "FHIR.decimal", true},
+		{"field shared by choices", "Observation.value.id", "Observation", "FHIR.string", true},
+		{"contained resource", "Patient.contained", "Patient", "FHIR.Resource", false},
+		{"resource cast", "Bundle.entry.resource.ofType(Patient).name", "Bundle", "FHIR.HumanName", false},
+		{"index", "Patient.name[0]", "Patient", "FHIR.HumanName", true},
+		{"long index", "Patient.name[0L]", "Patient", "FHIR.HumanName", true},
+		{"where", "Patient.name.where(use = 'official')", "Patient", "FHIR.HumanName", false},
+		{"select", "Patient.name.select(family)", "Patient", "FHIR.string", false},
+		{"first", "Patient.name.first().given", "Patient", "FHIR.string", false},
+		{"existence", "Patient.name.exists()", "Patient", "System.Boolean", true},
+		{"count", "Patient.name.count()", "Patient", "System.Integer", true},
+		{"comparison", "Patient.birthDate < @2000-01-01", "Patient", "System.Boolean", true},
+		{"arithmetic", "1 + 2.5", "Patient", "System.Decimal", true},
+		{"long rounding", "5L.floor()", "Patient", "System.Long", true},
+		{"long to decimal", "2.log(8L)", "Patient", "System.Decimal", true},
+		{"string concatenation", "Patient.id & 'a'", "Patient", "System.String", true},
+		{"union of same type", "Patient.name | Patient.name", "Patient", "FHIR.HumanName", false},
+		{"union of different types", "Patient.name | Patient.address", "Patient", "", false},
+		{"context", "%resource.id", "Patient", "FHIR.id", true},
+		{"extension", "Patient.extension('http://example.com').value", "Patient", "", false},
+		{"abstract input", "Resource.id", "Resource", "", false},
+		{"custom function", "Patient.unknownFunction()", "Patient", "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := check(t, tc.path, tc.input, false)
+
+			if err != nil {
+				t.Fatalf("Check(%q) returned unexpected error: %v", tc.path, err)
+			}
+			if got.Name() != tc.wantType {
+				t.Errorf("Check(%q) returned type %q, want %q", tc.path, got.Name(), tc.wantType)
+			}
+			if got.Singleton != tc.wantSingleton {
+				t.Errorf("Check(%q) returned singleton = %v, want %v", tc.path, got.Singleton, tc.wantSingleton)
+			}
+		})
+	}
+}
+
+func TestCheck_ReturnsError(t *testing.T) {
+	testCases := []struct {
+		name    string
+		path    string
+		input   string
+		wantErr error
+	}{
+		{"unknown field", "Patient.nmae.given", "Patient", expr.ErrInvalidField},
+		{"unknown nested field", "Patient.name.givn", "Patient", expr.ErrInvalidField},
+		{"snake case field", "Patient.birth_date", "Patient", expr.ErrInvalidField},
+		{"proto-only field", "Patient.birthDate.valueUs", "Patient", expr.ErrInvalidField},
+		{"field of system type", "Patient.id.value.length", "Patient", expr.ErrInvalidField},
+		{"field of no choice", "Observation.value.given", "Observation", expr.ErrInvalidField},
+		{"unknown field in argument", "Patient.name.where(fmaily = 'Doe')", "Patient", expr.ErrInvalidField},
+		{"mismatched root type", "Observation.status", "Patient", typecheck.ErrImpossibleType},
+		{"impossible ofType", "Patient.name.ofType(Quantity)", "Patient", typecheck.ErrImpossibleType},
+		{"impossible as", "Patient.birthDate as dateTime", "Patient", typecheck.ErrImpossibleType},
+		{"impossible choice", "Observation.value.ofType(HumanName)", "Observation", typecheck.ErrImpossibleType},
+		{"wrong argument type", "Patient.name.family.substring('a')", "Patient", typecheck.ErrInvalidArgument},
+		{"complex argument", "Patient.id.startsWith(%resource.name)", "Patient", typecheck.ErrInvalidArgument},
+		{"wrong index type", "Patient.name['a']", "Patient", typecheck.ErrInvalidArgument},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := check(t, tc.path, tc.input, false)
+
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("Check(%q) returned error %v, want %v", tc.path, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheck_ReportsAllErrors(t *testing.T) {
+	_, err := check(t, "Patient.nmae | Patient.name.ofType(Quantity)", "Patient", false)
+
+	if !errors.Is(err, expr.ErrInvalidField) {
+		t.Errorf("Check returned error %v, want %v", err, expr.ErrInvalidField)
+	}
+	if !errors.Is(err, typecheck.ErrImpossibleType) {
+		t.Errorf("Check returned error %v, want %v", err, typecheck.ErrImpossibleType)
+	}
+}
+
+func TestCheck_Permissive_IgnoresUnknownFields(t *testing.T) {
+	got, err := check(t, "Patient.nmae.given", "Patient", true)
+
+	if err != nil {
+		t.Fatalf("Check returned unexpected error: %v", err)
+	}
+	if got.Known() {
+		t.Errorf("Check returned type %v, want unknown type", got)
+	}
+}
+
+func TestOf(t *testing.T) {
+	testCases := []struct {
+		name   string
+		input  string
+		wantOK bool
+	}{
+		{"resource", "Patient", true},
+		{"complex type", "HumanName", true},
+		{"abstract type", "DomainResource", true},
+		{"system type", "String", false},
+		{"unknown type", "Patinet", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, got := typecheck.Of(tc.input); got != tc.wantOK {
+				t.Errorf("Of(%q) returned %v, want %v", tc.input, got, tc.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,7 @@
+/*
+Package typecheck infers the types of FHIRPath expressions at compile time,
+from the R4 proto descriptors of the input type. Navigation to unknown fields,
+type casts that can never succeed and arguments of the wrong type are reported
+as errors before the expression is ever evaluated.
+*/
+package typecheck
@@ -0,0 +1,241 @@
+package typecheck
+
+// signature describes the static typing of a FHIRPath function.
+type signature struct {
+	// iterates is true if the arguments are evaluated once for each item of
+	// the input, rather than once for the whole input.
+	iterates bool
+
+	// params holds the System types expected of the arguments. An empty name
+	// accepts an argument of any type.
+	params []string
+
+	// result returns the type of the output, given the types of the input and
+	// of the arguments.
+	result func(input Type, args []Type) Type
+}
+
+// signatures holds the signatures of the built-in functions. Functions that
+// aren't listed, such as custom functions, have an unknown output type.
+var signatures = map[string]signature{
+	// Existence
+	"empty":      {result: returns("Boolean")},
+	"exists":     {iterates: true, result: returns("Boolean")},
+	"all":        {iterates: true, result: returns("Boolean")},
+	"allTrue":    {result: returns("Boolean")},
+	"anyTrue":    {result: returns("Boolean")},
+	"allFalse":   {result: returns("Boolean")},
+	"anyFalse":   {result: returns("Boolean")},
+	"subsetOf":   {result: returns("Boolean")},
+	"supersetOf": {result: returns("Boolean")},
+	"count":      {result: returns("Integer")},
+	"distinct":   {result: returnsInput},
+	"isDistinct": {result: returns("Boolean")},
+
+	// Filtering and projection
+	"where":     {iterates: true, result: returnsInput},
+	"select":    {iterates: true, result: returnsProjection},
+	"repeat":    {iterates: true, result: returnsRepetition},
+	"extension": {params: []string{"String"}, result: returnsExtensions},
+
+	// Subsetting
+	"single":    {result: returnsItem},
+	"first":     {result: returnsItem},
+	"last":      {result: returnsItem},
+	"tail":      {result: returnsCollection},
+	"skip":      {params: []string{"Integer"}, result: returnsCollection},
+	"take":      {params: []string{"Integer"}, result: returnsCollection},
+	"intersect": {result: returnsCollection},
+	"exclude":   {result: returnsCollection},
+
+	// Combining
+	"union":   {result: returnsUnion},
+	"combine": {result: returnsUnion},
+
+	// Conversion
+	"iif":                {result: returnsBranch},
+	"toBoolean":          {result: returns("Boolean")},
+	"convertsToBoolean":  {result: returns("Boolean")},
+	"toInteger":          {result: returns("Integer")},
+	"convertsToInteger":  {result: returns("Boolean")},
+	"toLong":             {result: returns("Long")},
+	"convertsToLong":     {result: returns("Boolean")},
+	"toDate":             {result: returns("Date")},
+	"convertsToDate":     {result: returns("Boolean")},
+	"toDateTime":         {result: returns("DateTime")},
+	"convertToDateTime":  {result: returns("Boolean")},
+	"toDecimal":          {result: returns("Decimal")},
+	"convertsToDecimal":  {result: returns("Boolean")},
+	"toQuantity":         {params: []string{"String"}, result: returns("Quantity")},
+	"convertsToQuantity": {params: []string{"String"}, result: returns("Boolean")},
+	"toString":           {result: returns("String")},
+	"convertsToString":   {result: returns("Boolean")},
+	"toTime":             {result: returns("Time")},
+	"convertsToTime":     {result: returns("Boolean")},
+
+	// String manipulation
+	"indexOf":        {params: []string{"String"}, result: returns("Integer")},
+	"lastIndexOf":    {params: []string{"String"}, result: returns("Integer")},
+	"substring":      {params: []string{"Integer", "Integer"}, result: returns("String")},
+	"startsWith":     {params: []string{"String"}, result: returns("Boolean")},
+	"endsWith":       {params: []string{"String"}, result: returns("Boolean")},
+	"contains":       {params: []string{"String"}, result: returns("Boolean")},
+	"upper":          {result: returns("String")},
+	"lower":          {result: returns("String")},
+	"replace":        {params: []string{"String", "String"}, result: returns("String")},
+	"matches":        {params: []string{"String"}, result: returns("Boolean")},
+	"matchesFull":    {params: []string{"String"}, result: returns("Boolean")},
+	"replaceMatches": {params: []string{"String", "String"}, result: returns("String")},
+	"length":         {result: returns("Integer")},
+	"toChars":        {result: returnsStrings},
+	"trim":           {result: returns("String")},
+	"split":          {params: []string{"String"}, result: returnsStrings},
+	"join":           {params: []string{"String"}, result: returns("String")},
+	"encode":         {params: []string{"String"}, result: returns("String")},
+	"decode":         {params: []string{"String"}, result: returns("String")},
+	"escape":         {params: []string{"String"}, result: returns("String")},
+	"unescape":       {params: []string{"String"}, result: returns("String")},
+
+	// Math
+	"abs":      {result: returnsValue},
+	"ceiling":  {result: returnsWhole},
+	"exp":      {result: returns("Decimal")},
+	"floor":    {result: returnsWhole},
+	"ln":       {result: returns("Decimal")},
+	"log":      {params: []string{"Decimal"}, result: returns("Decimal")},
+	"power":    {params: []string{"Decimal"}, result: returnsValue},
+	"round":    {params: []string{"Integer"}, result: returns("Decimal")},
+	"sqrt":     {result: returns("Decimal")},
+	"truncate": {result: returnsWhole},
+
+	// Boundaries and precision
+	"lowBoundary":  {params: []string{"Integer"}, result: returnsInput},
+	"highBoundary": {params: []string{"Integer"}, result: returnsInput},
+	"precision":    {result: returns("Integer")},
+
+	// Date and time
+	"now":              {result: returns("DateTime")},
+	"timeOfDay":        {result: returns("Time")},
+	"today":            {result: returns("Date")},
+	"dateOf":           {result: returns("Date")},
+	"timeOf":           {result: returns("Time")},
+	"yearOf":           {result: returns("Integer")},
+	"monthOf":          {result: returns("Integer")},
+	"dayOf":            {result: returns("Integer")},
+	"hourOf":           {result: returns("Integer")},
+	"minuteOf":         {result: returns("Integer")},
+	"secondOf":         {result: returns("Integer")},
+	"millisecondOf":    {result: returns("Integer")},
+	"timezoneOffsetOf": {result: returns("Decimal")},
+
+	// Utility
+	"trace":     {iterates: true, params: []string{"String"}, result: returnsInput},
+	"aggregate": {iterates: true},
+	"not":       {result: returns("Boolean")},
+
+	// FHIR
+	"resolve":    {result: returnsResources},
+	"comparable": {result: returns("Boolean")},
+	"conformsTo": {params: []string{"String"}, result: returns("Boolean")},
+	"getValue":   {result: returnsValue},
+	"hasValue":   {result: returns("Boolean")},
+	"htmlChecks": {result: returns("Boolean")},
+	"memberOf":   {params: []string{"String"}, result: returns("Boolean")},
+	"subsumedBy": {result: returns("Boolean")},
+}
+
+// returns creates a result function for functions that return a single item
+// of the named System type.
+func returns(name string) func(Type, []Type) Type {
+	return func(Type, []Type) Type {
+		return systemType(name)
+	}
+}
+
+// returnsWhole returns the type of the functions that round a number to a
+// whole number, which is a Long for a Long input and an Integer otherwise.
+func returnsWhole(input Type, _ []Type) Type {
+	if name, ok := systemEquivalent(input); ok && name == "Long" {
+		return systemType("Long")
+	}
+	return systemType("Integer")
+}
+
+func returnsInput(input Type, _ []Type) Type {
+	return input
+}
+
+func returnsItem(input Type, _ []Type) Type {
+	return input.item()
+}
+
+func returnsCollection(input Type, _ []Type) Type {
+	return input.collection()
+}
+
+func returnsStrings(Type, []Type) Type {
+	return systemType("String").collection()
+}
+
+func returnsResources(Type, []Type) Type {
+	return fhirType("Resource").collection()
+}
+
+func returnsExtensions(Type, []Type) Type {
+	extension, _ := Of("Extension")
+	return extension.collection()
+}
+
+// returnsProjection returns the type of 'select', which is the type of the
+// projection of each item.
+func returnsProjection(input Type, args []Type) Type {
+	if len(args) != 1 {
+		return Type{}
+	}
+	result := args[0]
+	result.Singleton = input.Singleton && args[0].Singleton
+	return result
+}
+
+func returnsRepetition(_ Type, args []Type) Type {
+	if len(args) != 1 {
+		return Type{}
+	}
+	return args[0].collection()
+}
+
+// returnsUnion returns the type of the union of the input and the argument,
+// which is only known if both are of the same type.
+func returnsUnion(input Type, args []Type) Type {
+	if len(args) != 1 || input.spec != args[0].spec || input.descriptor != args[0].descriptor {
+		return Type{}
+	}
+	return input.collection()
+}
+
+// returnsBranch returns the type of 'iif', which is only known if both
+// branches are of the same type.
+func returnsBranch(_ Type, args []Type) Type {
+	switch len(args) {
+	case 2:
+		return args[1]
+	case 3:
+		if args[1].spec != args[2].spec || args[1].descriptor != args[2].descriptor {
+			return Type{}
+		}
+		result := args[1]
+		result.Singleton = args[1].Singleton && args[2].Singleton
+		return result
+	}
+	return Type{}
+}
+
+// returnsValue returns the System type that the input is converted to, for
+// functions that operate on the value of a primitive.
+func returnsValue(input Type, _ []Type) Type {
+	name, ok := systemEquivalent(input)
+	if !ok {
+		return Type{Singleton: true}
+	}
+	return systemType(name)
+}
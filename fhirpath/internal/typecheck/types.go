@@ -0,0 +1,211 @@
+package typecheck
+
+import (
+	"strings"
+
+	"github.com/verily-src/fhirpath-go/fhirpath/internal/reflection"
+	"github.com/verily-src/fhirpath-go/internal/slices"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Type is the statically inferred type of a FHIRPath expression, which is
+// the type of the items of the collection it evaluates to, along with its
+// cardinality. The zero value is an unknown type, which is never reported as
+// an error.
+type Type struct {
+	spec reflection.TypeSpecifier
+
+	// descriptor is the message descriptor through which the fields of FHIR
+	// types are resolved. For choice types, such as 'Observation.value', this
+	// is the descriptor of the choice message, and the specifier is unknown.
+	descriptor protoreflect.MessageDescriptor
+
+	// Singleton is true if the expression evaluates to at most one item.
+	Singleton bool
+}
+
+// Of returns the type of a single item of the named FHIR type. The fields of
+// abstract types, such as 'Resource', are unknown. Returns false if the name
+// doesn't refer to a FHIR type.
+func Of(name string) (Type, bool) {
+	spec, err := reflection.NewTypeSpecifier(name)
+	if err != nil || spec.Namespace() != reflection.FHIR {
+		return Type{}, false
+	}
+	return Type{spec: spec, descriptor: reflection.DescriptorOf(spec), Singleton: true}, true
+}
+
+// Known returns true if the type of the items could be inferred.
+func (t Type) Known() bool {
+	return t.spec != reflection.TypeSpecifier{}
+}
+
+// Name returns the qualified name of the type of the items, e.g.
+// 'FHIR.HumanName', or an empty string if the type is unknown.
+func (t Type) Name() string {
+	if !t.Known() {
+		return ""
+	}
+	return t.spec.String()
+}
+
+// String returns the name of the type, marking collections with brackets,
+// e.g. 'FHIR.HumanName[]'. Choice types list the types they may hold.
+func (t Type) String() string {
+	name := t.Name()
+	if t.isChoice() {
+		name = strings.Join(slices.Map(t.options(), Type.Name), "|")
+	} else if name == "" {
+		name = "unknown"
+	}
+	if !t.Singleton {
+		name += "[]"
+	}
+	return name
+}
+
+func (t Type) isChoice() bool {
+	return t.descriptor != nil && !t.Known()
+}
+
+// item returns the type of a single item of the collection.
+func (t Type) item() Type {
+	t.Singleton = true
+	return t
+}
+
+// collection returns the type of a collection of any number of items.
+func (t Type) collection() Type {
+	t.Singleton = false
+	return t
+}
+
+// options returns the types that a choice type may hold.
+func (t Type) options() []Type {
+	if !t.isChoice() {
+		return []Type{t}
+	}
+	var options []Type
+	fields := t.descriptor.Oneofs().ByName("choice").Fields()
+	for i := 0; i < fields.Len(); i++ {
+		options = append(options, messageType(fields.Get(i).Message(), t.Singleton))
+	}
+	return options
+}
+
+// systemType returns the type of a single item of the named System type.
+func systemType(name string) Type {
+	return Type{spec: reflection.MustCreateTypeSpecifier(reflection.System, name), Singleton: true}
+}
+
+// fhirType returns the type of a single item of the named abstract FHIR type,
+// whose fields can't be resolved.
+func fhirType(name string) Type {
+	return Type{spec: reflection.MustCreateTypeSpecifier(reflection.FHIR, name), Singleton: true}
+}
+
+// messageType returns the type of the FHIR element modeled by the given
+// message descriptor, unwrapping choices and contained resources.
+func messageType(descriptor protoreflect.MessageDescriptor, singleton bool) Type {
+	switch {
+	case descriptor.FullName() == "google.protobuf.Any", descriptor.Name() == "ContainedResource":
+		return Type{spec: fhirType("Resource").spec, Singleton: singleton}
+	case descriptor.Oneofs().ByName("choice") != nil:
+		return Type{descriptor: descriptor, Singleton: singleton}
+	}
+	return Type{
+		spec:       reflection.TypeOfDescriptor(descriptor),
+		descriptor: descriptor,
+		Singleton:  singleton,
+	}
+}
+
+// systemEquivalent returns the System type that values of the given type are
+// converted to when used as operands or arguments. Returns false for complex
+// FHIR types, which have no System equivalent.
+func systemEquivalent(t Type) (string, bool) {
+	if t.spec.Namespace() == reflection.System {
+		return t.spec.Name(), true
+	}
+	if t.spec.Is(fhirType("Quantity").spec) {
+		return "Quantity", true
+	}
+	switch t.spec.Name() {
+	case "boolean":
+		return "Boolean", true
+	case "integer", "unsignedInt", "positiveInt":
+		return "Integer", true
+	case "decimal":
+		return "Decimal", true
+	case "date":
+		return "Date", true
+	case "dateTime", "instant":
+		return "DateTime", true
+	case "time":
+		return "Time", true
+	case "string", "code", "id", "markdown", "uri", "url", "canonical", "oid",
+		"uuid", "base64Binary", "xhtml":
+		return "String", true
+	}
+	return "", false
+}
+
+// primitiveValue returns the type of the 'value' field of a FHIR primitive.
+// Temporal values are represented as strings, mirroring evaluation.
+func primitiveValue(t Type) (Type, bool) {
+	name, ok := systemEquivalent(t)
+	if !ok || t.spec.Namespace() != reflection.FHIR || name == "Quantity" {
+		return Type{}, false
+	}
+	switch name {
+	case "Date", "DateTime", "Time":
+		name = "String"
+	}
+	result := systemType(name)
+	result.Singleton = t.Singleton
+	return result, true
+}
+
+// isTemporal returns true if the type is one of the FHIR date and time
+// primitives, which are modeled with additional fields in the protos.
+func isTemporal(t Type) bool {
+	switch t.spec.Name() {
+	case "date", "dateTime", "time", "instant":
+		return t.spec.Namespace() == reflection.FHIR
+	}
+	return false
+}
+
+// accepts returns true if an argument or operand of the given type may be
+// used where the named System type is expected. Integers and Longs are
+// implicitly converted to decimals.
+func accepts(want string, got Type) bool {
+	if want == "" || !got.Known() {
+		return true
+	}
+	name, ok := systemEquivalent(got)
+	if !ok {
+		return false
+	}
+	return name == want || (want == "Decimal" && (name == "Integer" || name == "Long"))
+}
+
+// possible returns true if items of the given type may also be of the target
+// type, i.e. if a cast to the target type can ever succeed.
+func possible(t Type, target reflection.TypeSpecifier) bool {
+	if t.isChoice() {
+		if target.Namespace() != reflection.FHIR {
+			return true
+		}
+		for _, option := range t.options() {
+			if option.spec.Is(target) {
+				return true
+			}
+		}
+		return false
+	}
+	if !t.Known() || t.spec.Namespace() != target.Namespace() {
+		return true
+	}
+	return bool(t.spec.Is(target) || target.Is(t.spec))
+}
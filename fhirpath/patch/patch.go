@@ -43,8 +43,9 @@ type Options struct {
 // Expression is the FHIRPath Patch expression that will be
 // compiled from a FHIRPath string.
 type Expression struct {
-	expression expr.Expression
-	path       string
+	expression  expr.Expression
+	path        string
+	trackOwners bool
 }
 
 // String returns the underlying FHIRPath expression.
@@ -86,8 +87,9 @@ func Compile(path string, options ...opts.CompileOption) (*Expression, error) {
 		return nil, vr.Error
 	}
 	return &Expression{
-		expression: vr.Result,
-		path:       path,
+		expression:  vr.Result,
+		path:        path,
+		trackOwners: compile.UsesConstant(tree, "resource", "rootResource"),
 	}, nil
 }
 
@@ -261,6 +263,9 @@ func (e *Expression) evaluate(res fhirpath.Resource, options ...fhirpath.Evaluat
 	config := &opts.EvaluateConfig{
 		Context: expr.InitializeContext(collection),
 	}
+	if e.trackOwners {
+		config.Context.TrackOwners()
+	}
 	config, err := opts.ApplyOptions(config, options...)
 	if err != nil {
 		return nil, nil, err
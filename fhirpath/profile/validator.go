@@ -0,0 +1,23 @@
+// Package profile defines the Validator interface that implements the
+// conformsTo FHIRPath functionality.
+package profile
+
+import (
+	"context"
+	"errors"
+
+	"github.com/verily-src/fhirpath-go/internal/fhir"
+)
+
+var (
+	// ErrUnknownProfile is returned by a Validator when the given profile URL
+	// can't be resolved to a StructureDefinition. The conformsTo function
+	// evaluates to empty in that case.
+	ErrUnknownProfile = errors.New("unknown profile")
+)
+
+// Validator interface defines the ConformsTo() method for validating a FHIR
+// resource or element against the profile with the given canonical URL.
+type Validator interface {
+	ConformsTo(ctx context.Context, input fhir.Base, profile string) (bool, error)
+}
@@ -1,6 +1,9 @@
 package system
 
-import "reflect"
+import (
+	"reflect"
+	"strings"
+)
 
 // Equal compares two FHIRPath System types for equality. This uses standard
 // equality semantics and will return true if the value should yield a value
@@ -79,3 +82,50 @@ func callBinaryComparator(name string, lhs, rhs any) (any, bool) {
 	}
 	return nil, false
 }
+
+// Equivalent compares two FHIRPath System types for equivalence. Unlike
+// equality, equivalence always yields a value: values that can't be compared
+// (such as dates of differing precision) are simply not equivalent.
+//
+// See https://hl7.org/fhirpath/n1/#equivalence
+func Equivalent(lhs, rhs Any) bool {
+	lhs, rhs = Normalize(lhs, rhs), Normalize(rhs, lhs)
+	switch l := lhs.(type) {
+	case String:
+		r, ok := rhs.(String)
+		return ok && strings.EqualFold(normalizeWhitespace(string(l)), normalizeWhitespace(string(r)))
+	case Decimal:
+		r, ok := rhs.(Decimal)
+		return ok && decimalEquivalent(l, r)
+	case Quantity:
+		r, ok := rhs.(Quantity)
+		return ok && l.equivalent(r)
+	default:
+		result, ok := TryEqual(lhs, rhs)
+		return ok && result
+	}
+}
+
+// normalizeWhitespace collapses all sequences of whitespace characters into a
+// single space, and trims leading and trailing whitespace.
+func normalizeWhitespace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// decimalEquivalent compares two decimals after rounding both values to the
+// precision of the least precise operand. Trailing zeroes are not considered
+// when determining precision.
+func decimalEquivalent(lhs, rhs Decimal) bool {
+	precision := min(decimalPrecision(lhs), decimalPrecision(rhs))
+	return lhs.Round(int32(precision)).Equal(rhs.Round(int32(precision)))
+}
+
+// decimalPrecision returns the number of significant digits after the decimal
+// point of d.
+func decimalPrecision(d Decimal) int {
+	_, fraction, ok := strings.Cut(d.String(), ".")
+	if !ok {
+		return 0
+	}
+	return len(fraction)
+}
@@ -3,12 +3,14 @@ package system
 import (
 	"errors"
 	"fmt"
+	"reflect"
 
 	dtpb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/datatypes_go_proto"
 	"github.com/shopspring/decimal"
 	"github.com/verily-src/fhirpath-go/internal/fhir"
 	"github.com/verily-src/fhirpath-go/internal/narrow"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 var (
@@ -47,8 +49,20 @@ func (c Collection) TryEqual(other Collection) (bool, bool) {
 		if okOne != okTwo {
 			return false, true
 		}
-		if !okOne && !proto.Equal(c[i].(fhir.Base), other[i].(fhir.Base)) {
-			return false, true
+		if !okOne {
+			// Values that are neither primitives nor FHIR elements, such as
+			// the type information returned by type(), are compared by value.
+			one, isBase := c[i].(fhir.Base)
+			two, isBaseToo := other[i].(fhir.Base)
+			if !isBase || !isBaseToo {
+				if !reflect.DeepEqual(c[i], other[i]) {
+					return false, true
+				}
+				continue
+			}
+			if !proto.Equal(one, two) {
+				return false, true
+			}
 		}
 		if !okOne {
 			return true, true
@@ -74,6 +88,97 @@ func (c Collection) TryEqual(other Collection) (bool, bool) {
 	return true, true
 }
 
+// Equivalent compares this collection to the supplied collection for
+// equivalence. Both collections must have the same number of items, and every
+// item in c must have an equivalent item in other; order is not considered.
+// Two empty collections are equivalent.
+//
+// See https://hl7.org/fhirpath/n1/#equivalence
+func (c Collection) Equivalent(other Collection) bool {
+	if len(c) != len(other) {
+		return false
+	}
+	used := make([]bool, len(other))
+	for _, item := range c {
+		found := false
+		for i, candidate := range other {
+			if used[i] || !equivalentItems(item, candidate) {
+				continue
+			}
+			used[i] = true
+			found = true
+			break
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// equivalentItems compares two collection entries for equivalence. Primitive
+// values are compared as System types, while FHIR complex types are compared
+// recursively by their child elements. Values that are neither, such as the
+// type information returned by type(), are compared by value.
+func equivalentItems(lhs, rhs any) bool {
+	if IsPrimitive(lhs) && IsPrimitive(rhs) {
+		l, lerr := From(lhs)
+		r, rerr := From(rhs)
+		if lerr == nil && rerr == nil {
+			return Equivalent(l, r)
+		}
+	}
+	l, lok := lhs.(proto.Message)
+	r, rok := rhs.(proto.Message)
+	if !lok || !rok {
+		return !lok && !rok && !IsPrimitive(lhs) && !IsPrimitive(rhs) && reflect.DeepEqual(lhs, rhs)
+	}
+	return equivalentMessages(l.ProtoReflect(), r.ProtoReflect())
+}
+
+// equivalentMessages compares two proto messages by recursively comparing each
+// of their populated fields for equivalence.
+func equivalentMessages(lhs, rhs protoreflect.Message) bool {
+	if lhs.Descriptor().FullName() != rhs.Descriptor().FullName() {
+		return false
+	}
+	fields := lhs.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if lhs.Has(fd) != rhs.Has(fd) {
+			return false
+		}
+		if !lhs.Has(fd) {
+			continue
+		}
+		if !equivalentValues(fd, lhs.Get(fd), rhs.Get(fd)) {
+			return false
+		}
+	}
+	return true
+}
+
+func equivalentValues(fd protoreflect.FieldDescriptor, lhs, rhs protoreflect.Value) bool {
+	if fd.IsList() {
+		if fd.Kind() != protoreflect.MessageKind {
+			return lhs.Equal(rhs)
+		}
+		return listToCollection(lhs.List()).Equivalent(listToCollection(rhs.List()))
+	}
+	if fd.Kind() != protoreflect.MessageKind || fd.IsMap() {
+		return lhs.Equal(rhs)
+	}
+	return equivalentItems(lhs.Message().Interface(), rhs.Message().Interface())
+}
+
+func listToCollection(list protoreflect.List) Collection {
+	result := make(Collection, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		result = append(result, list.Get(i).Message().Interface())
+	}
+	return result
+}
+
 // ToSingletonBoolean evaluates a collection as a boolean with singleton evaluation of
 // collection rules. Returns a collection containing a single Boolean, or empty if the
 // input is empty.
@@ -152,8 +257,9 @@ func (c Collection) ToInt32() (int32, error) {
 
 // ToFloat64 converts this Collection into a Go native 'float64' type.
 // If this collection is empty, or contains more than 1 entry, it will return
-// an error. If the type in the collection is not a System.Integer, or something
-// derived from a FHIR.Integer, this will raise an ErrNotConvertible.
+// an error. If the type in the collection is not a System.Decimal, System.Integer,
+// System.Long, or something derived from a FHIR.Integer, this will raise an
+// ErrNotConvertible.
 func (c Collection) ToFloat64() (float64, error) {
 	v, err := c.ToSingleton()
 	if err != nil {
@@ -164,6 +270,8 @@ func (c Collection) ToFloat64() (float64, error) {
 		return decimal.Decimal(val).InexactFloat64(), nil
 	case Integer:
 		return float64(val), nil
+	case Long:
+		return float64(val), nil
 	case *dtpb.Integer:
 		return float64(val.GetValue()), nil
 	case *dtpb.PositiveInt:
@@ -219,6 +327,13 @@ func (c Collection) Contains(value any) bool {
 	if ok {
 		return c.containsProto(msg)
 	}
+	// Values that are neither primitives nor FHIR elements, such as the type
+	// information returned by type(), are compared by value.
+	for _, v := range c {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
 	return false
 }
 
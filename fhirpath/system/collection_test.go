@@ -98,6 +98,102 @@ func TestEqual_ReturnsResult(t *testing.T) {
 	}
 }
 
+func TestEquivalent_ReturnsResult(t *testing.T) {
+	testCases := []struct {
+		name            string
+		leftCollection  system.Collection
+		rightCollection system.Collection
+		want            bool
+	}{
+		{
+			name:            "empty collections",
+			leftCollection:  system.Collection{},
+			rightCollection: system.Collection{},
+			want:            true,
+		},
+		{
+			name:            "one empty collection",
+			leftCollection:  system.Collection{system.String("a")},
+			rightCollection: system.Collection{},
+			want:            false,
+		},
+		{
+			name:            "strings ignoring case and whitespace",
+			leftCollection:  system.Collection{system.String("John  Smith ")},
+			rightCollection: system.Collection{fhir.String("john\tsmith")},
+			want:            true,
+		},
+		{
+			name:            "decimals with differing precision",
+			leftCollection:  system.Collection{system.MustParseDecimal("1.23")},
+			rightCollection: system.Collection{system.MustParseDecimal("1.2")},
+			want:            true,
+		},
+		{
+			name:            "trailing zeroes don't count toward precision",
+			leftCollection:  system.Collection{system.MustParseDecimal("1.20")},
+			rightCollection: system.Collection{system.MustParseDecimal("1.23")},
+			want:            true,
+		},
+		{
+			name:            "dates with differing precision",
+			leftCollection:  system.Collection{system.MustParseDate("2020-01")},
+			rightCollection: system.Collection{system.MustParseDate("2020-01-01")},
+			want:            false,
+		},
+		{
+			name:            "quantities with same unit",
+			leftCollection:  system.Collection{system.MustParseQuantity("1.0", "kg")},
+			rightCollection: system.Collection{system.MustParseQuantity("1", "kg")},
+			want:            true,
+		},
+		{
+			name:            "unordered collections",
+			leftCollection:  system.Collection{system.Integer(1), system.Integer(2)},
+			rightCollection: system.Collection{system.Integer(2), system.Integer(1)},
+			want:            true,
+		},
+		{
+			name:            "duplicate items must all be matched",
+			leftCollection:  system.Collection{system.Integer(1), system.Integer(1)},
+			rightCollection: system.Collection{system.Integer(1), system.Integer(2)},
+			want:            false,
+		},
+		{
+			name: "complex types compared by children",
+			leftCollection: system.Collection{&dtpb.HumanName{
+				Family: fhir.String("SMITH"),
+				Given:  []*dtpb.String{fhir.String("a"), fhir.String("b")},
+			}},
+			rightCollection: system.Collection{&dtpb.HumanName{
+				Family: fhir.String("smith"),
+				Given:  []*dtpb.String{fhir.String("B"), fhir.String("A")},
+			}},
+			want: true,
+		},
+		{
+			name:            "complex types with differing children",
+			leftCollection:  system.Collection{&dtpb.HumanName{Family: fhir.String("Smith")}},
+			rightCollection: system.Collection{&dtpb.HumanName{Family: fhir.String("Smith"), Text: fhir.String("Bob")}},
+			want:            false,
+		},
+		{
+			name:            "mismatched types (primitive and complex)",
+			leftCollection:  system.Collection{system.String("abc")},
+			rightCollection: system.Collection{&ppb.Patient{}},
+			want:            false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.leftCollection.Equivalent(tc.rightCollection); got != tc.want {
+				t.Errorf("Collection.Equivalent returned incorrect result, got: %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
 func TestToSingletonBoolean_ConvertsToBool(t *testing.T) {
 	testCases := []struct {
 		name            string
@@ -1,6 +1,10 @@
 package system
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/verily-src/fhirpath-go/internal/ucum"
+)
 
 // Common errors.
 var (
@@ -10,6 +14,9 @@ var (
 	ErrMismatchedPrecision = errors.New("mismatched precision")
 	ErrMismatchedUnit      = errors.New("mismatched unit")
 	ErrIntOverflow         = errors.New("operation resulted in integer overflow")
+	ErrDivByZero           = errors.New("division by zero")
+	ErrInvalidUnit         = ucum.ErrInvalidUnit
+	ErrSpecialUnit         = ucum.ErrSpecialUnit
 )
 
 // Type names.
@@ -17,6 +24,7 @@ const (
 	stringType   = "String"
 	booleanType  = "Boolean"
 	integerType  = "Integer"
+	longType     = "Long"
 	decimalType  = "Decimal"
 	dateType     = "Date"
 	dateTimeType = "DateTime"
@@ -158,7 +158,11 @@ func (d Date) Add(input Quantity) (Date, error) {
 	case "day", "days":
 		result = d.date.AddDate(0, 0, value)
 	default:
-		return Date{}, fmt.Errorf("%w: can't add to date", ErrMismatchedUnit)
+		days, err := input.definiteDays()
+		if err != nil {
+			return Date{}, err
+		}
+		result = d.date.AddDate(0, 0, days)
 	}
 
 	// Reformat to truncate date to initial precision. This causes the addition result
@@ -205,7 +209,11 @@ func (d Date) Sub(input Quantity) (Date, error) {
 	case "day", "days":
 		result = d.date.AddDate(0, 0, value)
 	default:
-		return Date{}, fmt.Errorf("%w: can't add to date", ErrMismatchedUnit)
+		days, err := input.definiteDays()
+		if err != nil {
+			return Date{}, err
+		}
+		result = d.date.AddDate(0, 0, -days)
 	}
 
 	return Date{result, d.l}, nil
@@ -240,6 +248,52 @@ func (d Date) ToDateTime() DateTime {
 	return DateTime{d.date, dateToDateTime[d.l]}
 }
 
+// dateDigitLayouts maps the number of digits in a Date to its layout.
+var dateDigitLayouts = map[int]layout{
+	4: yearLayout,
+	6: monthLayout,
+	8: dayLayout,
+}
+
+// Precision returns the number of digits in d. Eg. @2014-01 has a
+// precision of 6.
+func (d Date) Precision() int {
+	return levelDigits[layoutLevels[d.l]]
+}
+
+// LowBoundary returns the earliest possible Date represented by d, at the
+// given precision. Returns false if the precision is not a valid number of
+// digits for a Date. Eg. @2014 has a low boundary of @2014-01-01 at a
+// precision of 8.
+func (d Date) LowBoundary(precision int) (Date, bool) {
+	l, ok := dateDigitLayouts[precision]
+	if !ok {
+		return Date{}, false
+	}
+	return Date{lowBoundaryAt(d.date, layoutLevels[d.l], layoutLevels[l]), l}, true
+}
+
+// HighBoundary returns the latest possible Date represented by d, at the
+// given precision. Returns false if the precision is not a valid number of
+// digits for a Date. Eg. @2014-02 has a high boundary of @2014-02-28 at a
+// precision of 8.
+func (d Date) HighBoundary(precision int) (Date, bool) {
+	l, ok := dateDigitLayouts[precision]
+	if !ok {
+		return Date{}, false
+	}
+	return Date{highBoundaryAt(d.date, layoutLevels[d.l], layoutLevels[l]), l}, true
+}
+
+// Component returns the value of the given component of d. Returns false if
+// c is not a component of a Date, or if d is not precise enough to have it.
+func (d Date) Component(c Component) (Integer, bool) {
+	if c > DayComponent || c > layoutLevels[d.l] {
+		return 0, false
+	}
+	return Integer(timeComponents(d.date)[c]), true
+}
+
 func min(x, y int) int {
 	if x < y {
 		return x
@@ -316,6 +316,24 @@ func TestDateAdd_ReturnsSum(t *testing.T) {
 			input:   system.MustParseQuantity("12", "hours"),
 			wantErr: system.ErrMismatchedUnit,
 		},
+		{
+			name:  "adds UCUM months as a definite duration",
+			date:  system.MustParseDate("2021-01-31"),
+			input: system.MustParseQuantity("1", "mo"),
+			want:  system.MustParseDate("2021-03-02"),
+		},
+		{
+			name:  "adds UCUM years as a definite duration",
+			date:  system.MustParseDate("2020-02-29"),
+			input: system.MustParseQuantity("4", "a"),
+			want:  system.MustParseDate("2024-02-29"),
+		},
+		{
+			name:  "adds UCUM weeks",
+			date:  system.MustParseDate("1974-10-30"),
+			input: system.MustParseQuantity("18", "wk"),
+			want:  system.MustParseDate("1975-03-05"),
+		},
 	}
 
 	for _, tc := range testCases {
@@ -388,6 +406,18 @@ func TestDateSub_ReturnsSum(t *testing.T) {
 			input:   system.MustParseQuantity("12", "kg"),
 			wantErr: system.ErrMismatchedUnit,
 		},
+		{
+			name:  "subtracts UCUM months as a definite duration",
+			date:  system.MustParseDate("2021-03-31"),
+			input: system.MustParseQuantity("1", "mo"),
+			want:  system.MustParseDate("2021-03-01"),
+		},
+		{
+			name:  "subtracts UCUM days from year partial by rounding down to definite years",
+			date:  system.MustParseDate("1997"),
+			input: system.MustParseQuantity("365", "d"),
+			want:  system.MustParseDate("1997"),
+		},
 	}
 
 	for _, tc := range testCases {
@@ -436,3 +466,96 @@ func TestDate_ToDateTime(t *testing.T) {
 		})
 	}
 }
+
+func TestDateBoundaries(t *testing.T) {
+	testCases := []struct {
+		name          string
+		date          system.Date
+		precision     int
+		wantPrecision int
+		wantLow       system.Date
+		wantHigh      system.Date
+		wantOK        bool
+	}{
+		{
+			name:          "extends year to day precision",
+			date:          system.MustParseDate("2014"),
+			precision:     8,
+			wantPrecision: 4,
+			wantLow:       system.MustParseDate("2014-01-01"),
+			wantHigh:      system.MustParseDate("2014-12-31"),
+			wantOK:        true,
+		},
+		{
+			name:          "extends month to the last day of the month",
+			date:          system.MustParseDate("2024-02"),
+			precision:     8,
+			wantPrecision: 6,
+			wantLow:       system.MustParseDate("2024-02-01"),
+			wantHigh:      system.MustParseDate("2024-02-29"),
+			wantOK:        true,
+		},
+		{
+			name:          "extends year to month precision",
+			date:          system.MustParseDate("2014"),
+			precision:     6,
+			wantPrecision: 4,
+			wantLow:       system.MustParseDate("2014-01"),
+			wantHigh:      system.MustParseDate("2014-12"),
+			wantOK:        true,
+		},
+		{
+			name:          "truncates to lower precision",
+			date:          system.MustParseDate("2014-06-15"),
+			precision:     4,
+			wantPrecision: 8,
+			wantLow:       system.MustParseDate("2014"),
+			wantHigh:      system.MustParseDate("2014"),
+			wantOK:        true,
+		},
+		{
+			name:          "returns false for invalid precision",
+			date:          system.MustParseDate("2014"),
+			precision:     5,
+			wantPrecision: 4,
+			wantOK:        false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.date.Precision(); got != tc.wantPrecision {
+				t.Errorf("Date.Precision returned unexpected result: got %v, want %v", got, tc.wantPrecision)
+			}
+			low, lowOK := tc.date.LowBoundary(tc.precision)
+			high, highOK := tc.date.HighBoundary(tc.precision)
+
+			if lowOK != tc.wantOK || highOK != tc.wantOK {
+				t.Fatalf("Date boundaries returned unexpected ok: got (%v, %v), want %v", lowOK, highOK, tc.wantOK)
+			}
+			if diff := cmp.Diff(tc.wantLow, low); diff != "" {
+				t.Errorf("Date.LowBoundary returned unexpected result: (-want, +got)\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantHigh, high); diff != "" {
+				t.Errorf("Date.HighBoundary returned unexpected result: (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDateComponent(t *testing.T) {
+	date := system.MustParseDate("2014-06")
+
+	if got, ok := date.Component(system.YearComponent); got != 2014 || !ok {
+		t.Errorf("Date.Component returned unexpected year: got (%v, %v), want (2014, true)", got, ok)
+	}
+	if got, ok := date.Component(system.MonthComponent); got != 6 || !ok {
+		t.Errorf("Date.Component returned unexpected month: got (%v, %v), want (6, true)", got, ok)
+	}
+	if _, ok := date.Component(system.DayComponent); ok {
+		t.Errorf("Date.Component returned a day for a month precision date")
+	}
+	if _, ok := system.MustParseDate("2014-06-01").Component(system.HourComponent); ok {
+		t.Errorf("Date.Component returned an hour")
+	}
+}
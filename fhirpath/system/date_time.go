@@ -258,6 +258,150 @@ func (dt DateTime) Equal(dt2 DateTime) bool {
 	return dt.dateTime.Format(string(dt.l)) == dt2.dateTime.Format(string(dt2.l))
 }
 
+// dateTimeDigitLayouts maps the number of digits in a DateTime to its
+// layout, for DateTimes without a time zone.
+var dateTimeDigitLayouts = map[int]layout{
+	4:  dtYearLayout,
+	6:  dtMonthLayout,
+	8:  dtDayLayout,
+	10: dtHourLayout,
+	12: dtMinuteLayout,
+	14: dtSecondLayout,
+	17: dtMillisecondLayout,
+}
+
+// dateTimeDigitLayoutsTZ maps the number of digits in a DateTime to its
+// layout, for DateTimes with a time zone. Time zones are only present on
+// DateTimes with a time component.
+var dateTimeDigitLayoutsTZ = map[int]layout{
+	4:  dtYearLayout,
+	6:  dtMonthLayout,
+	8:  dtDayLayout,
+	10: dtHourLayoutTZ,
+	12: dtMinuteLayoutTZ,
+	14: dtSecondLayoutTZ,
+	17: dtMillisecondLayoutTZ,
+}
+
+// Precision returns the number of digits in dt. Eg. @2014-01-05T10:30:00.000
+// has a precision of 17.
+func (dt DateTime) Precision() int {
+	return levelDigits[layoutLevels[dt.l]]
+}
+
+// Time zone offsets, in seconds, of the boundaries of DateTimes without a
+// time zone. The earliest instant is in the earliest time zone, and the latest
+// instant is in the latest time zone.
+const (
+	lowBoundaryOffset  = 14 * 60 * 60
+	highBoundaryOffset = -12 * 60 * 60
+)
+
+// LowBoundary returns the earliest possible DateTime represented by dt, at
+// the given precision. Returns false if the precision is not a valid number
+// of digits for a DateTime. Eg. @2014-01-01T08 has a low boundary of
+// @2014-01-01T08:00:00.000+14:00 at a precision of 17.
+func (dt DateTime) LowBoundary(precision int) (DateTime, bool) {
+	return dt.boundary(precision, lowBoundaryAt, lowBoundaryOffset)
+}
+
+// HighBoundary returns the latest possible DateTime represented by dt, at
+// the given precision. Returns false if the precision is not a valid number
+// of digits for a DateTime. Eg. @2014-01-01T08 has a high boundary of
+// @2014-01-01T08:59:59.999-12:00 at a precision of 17.
+func (dt DateTime) HighBoundary(precision int) (DateTime, bool) {
+	return dt.boundary(precision, highBoundaryAt, highBoundaryOffset)
+}
+
+// boundary returns the boundary of dt computed by boundaryAt, at the given
+// precision. Boundaries with a time component of DateTimes without a time
+// zone are given the time zone of the given offset.
+func (dt DateTime) boundary(precision int, boundaryAt func(time.Time, Component, Component) time.Time, offset int) (DateTime, bool) {
+	l, ok := dt.digitLayout(precision)
+	if !ok {
+		return DateTime{}, false
+	}
+	t := boundaryAt(dt.dateTime, layoutLevels[dt.l], layoutLevels[l])
+	if !dt.hasTimezone() && layoutLevels[l] >= HourComponent {
+		l = dateTimeDigitLayoutsTZ[precision]
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.FixedZone("", offset))
+	}
+	return DateTime{t, l}, true
+}
+
+// digitLayout returns the layout for the given number of digits, preserving
+// the time zone of dt if it has one.
+func (dt DateTime) digitLayout(precision int) (layout, bool) {
+	if dt.hasTimezone() {
+		l, ok := dateTimeDigitLayoutsTZ[precision]
+		return l, ok
+	}
+	l, ok := dateTimeDigitLayouts[precision]
+	return l, ok
+}
+
+// Component returns the value of the given component of dt. Returns false if
+// dt is not precise enough to have the component.
+func (dt DateTime) Component(c Component) (Integer, bool) {
+	if c > layoutLevels[dt.l] {
+		return 0, false
+	}
+	return Integer(timeComponents(dt.dateTime)[c]), true
+}
+
+// TimezoneOffset returns the time zone offset of dt in hours. Returns false
+// if dt doesn't have a time zone.
+func (dt DateTime) TimezoneOffset() (Decimal, bool) {
+	if !dt.hasTimezone() {
+		return Decimal(decimal.Zero), false
+	}
+	_, offset := dt.dateTime.Zone()
+	return Decimal(decimal.NewFromInt(int64(offset)).Div(decimal.NewFromInt(3600))), true
+}
+
+// Date returns the date component of dt, with a precision of at most a day.
+func (dt DateTime) Date() Date {
+	level := layoutLevels[dt.l]
+	if level > DayComponent {
+		level = DayComponent
+	}
+	t := dt.dateTime
+	return Date{time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), dateDigitLayouts[levelDigits[level]]}
+}
+
+// Time returns the time component of dt. Returns false if dt does not have
+// a time component.
+func (dt DateTime) Time() (Time, bool) {
+	level := layoutLevels[dt.l]
+	if level < HourComponent {
+		return Time{}, false
+	}
+	t := dt.dateTime
+	value := time.Date(0, time.January, 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	return Time{value, timeDigitLayouts[levelDigits[level]-levelDigits[DayComponent]]}, true
+}
+
+// Difference returns the number of boundaries of the given calendar duration
+// unit crossed between dt and other. The result is positive if other is after
+// dt. Returns false if the unit is not a calendar duration, or if either
+// DateTime is not precise enough for it.
+// Eg. @2020-12-31 and @2021-01-01 differ by 1 year, but have a duration of 0 years.
+func (dt DateTime) Difference(other DateTime, unit string) (Quantity, bool) {
+	return calendarDuration(dt.dateTime, other.dateTime, layoutLevels[dt.l], layoutLevels[other.l], unit, false)
+}
+
+// Duration returns the number of whole periods of the given calendar duration
+// unit between dt and other. The result is positive if other is after dt.
+// Returns false if the unit is not a calendar duration, or if either DateTime
+// is not precise enough for it.
+func (dt DateTime) Duration(other DateTime, unit string) (Quantity, bool) {
+	return calendarDuration(dt.dateTime, other.dateTime, layoutLevels[dt.l], layoutLevels[other.l], unit, true)
+}
+
+func (dt DateTime) hasTimezone() bool {
+	return strings.HasSuffix(string(dt.l), "Z07:00")
+}
+
 func (dt DateTime) getComponents() []int {
 	return []int{
 		dt.dateTime.Year(),
@@ -274,15 +418,15 @@ func (dt DateTime) getComponents() []int {
 func roundToDateTimePrecision(p dateTimePrecision, d time.Duration) time.Duration {
 	switch p {
 	case dtYear:
-		return d / (time.Hour * 24 * 365)
+		return d.Truncate(time.Hour * 24 * 365)
 	case dtMonth:
-		return d / (time.Hour * 24 * 30)
+		return d.Truncate(time.Hour * 24 * 30)
 	case dtDay:
-		return d / (time.Hour * 24)
+		return d.Truncate(time.Hour * 24)
 	case dtHour:
-		return d / time.Hour
+		return d.Truncate(time.Hour)
 	case dtMinute:
-		return d / time.Minute
+		return d.Truncate(time.Minute)
 	default:
 		return d
 	}
@@ -367,6 +367,24 @@ func TestDateTimeAdd_ReturnsSum(t *testing.T) {
 			input:       system.MustParseQuantity("18", "lbs"),
 			wantErr:     system.ErrMismatchedUnit,
 		},
+		{
+			name:        "adds UCUM months as a definite duration",
+			dateTimeOne: system.MustParseDateTime("2021-01-31T00:00:00Z"),
+			input:       system.MustParseQuantity("1", "mo"),
+			want:        system.MustParseDateTime("2021-03-02T10:30:00Z"),
+		},
+		{
+			name:        "adds UCUM years as a definite duration",
+			dateTimeOne: system.MustParseDateTime("2021-01-01T00:00:00Z"),
+			input:       system.MustParseQuantity("1", "a"),
+			want:        system.MustParseDateTime("2022-01-01T06:00:00Z"),
+		},
+		{
+			name:        "adds UCUM minutes",
+			dateTimeOne: system.MustParseDateTime("2021-01-01T00:00:00Z"),
+			input:       system.MustParseQuantity("90", "min"),
+			want:        system.MustParseDateTime("2021-01-01T01:30:00Z"),
+		},
 	}
 
 	for _, tc := range testCases {
@@ -457,6 +475,24 @@ func TestDateTimeSub_ReturnsResults(t *testing.T) {
 			input:       system.MustParseQuantity("18", "lbs"),
 			wantErr:     system.ErrMismatchedUnit,
 		},
+		{
+			name:        "subtracts UCUM months as a definite duration",
+			dateTimeOne: system.MustParseDateTime("2021-03-31T10:30:00Z"),
+			input:       system.MustParseQuantity("1", "mo"),
+			want:        system.MustParseDateTime("2021-03-01T00:00:00Z"),
+		},
+		{
+			name:        "subtracts UCUM years from a year partial",
+			dateTimeOne: system.MustParseDateTime("2021T"),
+			input:       system.MustParseQuantity("2", "a"),
+			want:        system.MustParseDateTime("2019T"),
+		},
+		{
+			name:        "subtracts minutes from an hour partial",
+			dateTimeOne: system.MustParseDateTime("2021-03-31T10"),
+			input:       system.MustParseQuantity("150", "minutes"),
+			want:        system.MustParseDateTime("2021-03-31T08"),
+		},
 	}
 
 	for _, tc := range testCases {
@@ -472,3 +508,290 @@ func TestDateTimeSub_ReturnsResults(t *testing.T) {
 		})
 	}
 }
+
+func TestDateTimeBoundaries(t *testing.T) {
+	testCases := []struct {
+		name          string
+		dateTime      system.DateTime
+		precision     int
+		wantPrecision int
+		wantLow       system.DateTime
+		wantHigh      system.DateTime
+		wantOK        bool
+	}{
+		{
+			name:          "extends hour to millisecond precision",
+			dateTime:      system.MustParseDateTime("2014-01-01T08"),
+			precision:     17,
+			wantPrecision: 10,
+			wantLow:       system.MustParseDateTime("2014-01-01T08:00:00.000+14:00"),
+			wantHigh:      system.MustParseDateTime("2014-01-01T08:59:59.999-12:00"),
+			wantOK:        true,
+		},
+		{
+			name:          "extends month across the end of the year",
+			dateTime:      system.MustParseDateTime("2014-12T"),
+			precision:     12,
+			wantPrecision: 6,
+			wantLow:       system.MustParseDateTime("2014-12-01T00:00+14:00"),
+			wantHigh:      system.MustParseDateTime("2014-12-31T23:59-12:00"),
+			wantOK:        true,
+		},
+		{
+			name:          "adds no time zone to date boundaries",
+			dateTime:      system.MustParseDateTime("2014-01T"),
+			precision:     8,
+			wantPrecision: 6,
+			wantLow:       system.MustParseDateTime("2014-01-01T"),
+			wantHigh:      system.MustParseDateTime("2014-01-31T"),
+			wantOK:        true,
+		},
+		{
+			name:          "preserves time zone",
+			dateTime:      system.MustParseDateTime("2014-01-01T08:30+05:00"),
+			precision:     14,
+			wantPrecision: 12,
+			wantLow:       system.MustParseDateTime("2014-01-01T08:30:00+05:00"),
+			wantHigh:      system.MustParseDateTime("2014-01-01T08:30:59+05:00"),
+			wantOK:        true,
+		},
+		{
+			name:          "truncates to lower precision",
+			dateTime:      system.MustParseDateTime("2014-01-05T10:30:00.000"),
+			precision:     8,
+			wantPrecision: 17,
+			wantLow:       system.MustParseDateTime("2014-01-05T"),
+			wantHigh:      system.MustParseDateTime("2014-01-05T"),
+			wantOK:        true,
+		},
+		{
+			name:          "returns false for invalid precision",
+			dateTime:      system.MustParseDateTime("2014-01-01T08"),
+			precision:     16,
+			wantPrecision: 10,
+			wantOK:        false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.dateTime.Precision(); got != tc.wantPrecision {
+				t.Errorf("DateTime.Precision returned unexpected result: got %v, want %v", got, tc.wantPrecision)
+			}
+			low, lowOK := tc.dateTime.LowBoundary(tc.precision)
+			high, highOK := tc.dateTime.HighBoundary(tc.precision)
+
+			if lowOK != tc.wantOK || highOK != tc.wantOK {
+				t.Fatalf("DateTime boundaries returned unexpected ok: got (%v, %v), want %v", lowOK, highOK, tc.wantOK)
+			}
+			if diff := cmp.Diff(tc.wantLow, low); diff != "" {
+				t.Errorf("DateTime.LowBoundary returned unexpected result: (-want, +got)\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantHigh, high); diff != "" {
+				t.Errorf("DateTime.HighBoundary returned unexpected result: (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDateTimeComponent(t *testing.T) {
+	dateTime := system.MustParseDateTime("2014-01-05T10:30:15.250+05:30")
+	testCases := []struct {
+		name      string
+		dateTime  system.DateTime
+		component system.Component
+		want      system.Integer
+		wantOK    bool
+	}{
+		{"returns year", dateTime, system.YearComponent, 2014, true},
+		{"returns month", dateTime, system.MonthComponent, 1, true},
+		{"returns day", dateTime, system.DayComponent, 5, true},
+		{"returns hour in own time zone", dateTime, system.HourComponent, 10, true},
+		{"returns minute", dateTime, system.MinuteComponent, 30, true},
+		{"returns second", dateTime, system.SecondComponent, 15, true},
+		{"returns millisecond", dateTime, system.MillisecondComponent, 250, true},
+		{"returns false for missing component", system.MustParseDateTime("2014-01T"), system.DayComponent, 0, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := tc.dateTime.Component(tc.component)
+
+			if got != tc.want || ok != tc.wantOK {
+				t.Errorf("DateTime.Component returned unexpected result: got (%v, %v), want (%v, %v)", got, ok, tc.want, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestDateTimeDateAndTime(t *testing.T) {
+	testCases := []struct {
+		name       string
+		dateTime   system.DateTime
+		wantDate   system.Date
+		wantTime   system.Time
+		wantTimeOK bool
+		wantOffset system.Decimal
+		wantZoneOK bool
+	}{
+		{
+			name:       "splits full date time",
+			dateTime:   system.MustParseDateTime("2014-01-05T10:30:15.250-05:30"),
+			wantDate:   system.MustParseDate("2014-01-05"),
+			wantTime:   system.MustParseTime("10:30:15.250"),
+			wantTimeOK: true,
+			wantOffset: system.MustParseDecimal("-5.5"),
+			wantZoneOK: true,
+		},
+		{
+			name:       "preserves precision of partial date time",
+			dateTime:   system.MustParseDateTime("2014-01-05T10"),
+			wantDate:   system.MustParseDate("2014-01-05"),
+			wantTime:   system.MustParseTime("10"),
+			wantTimeOK: true,
+		},
+		{
+			name:     "has no time for date precision",
+			dateTime: system.MustParseDateTime("2014-01T"),
+			wantDate: system.MustParseDate("2014-01"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.wantDate, tc.dateTime.Date()); diff != "" {
+				t.Errorf("DateTime.Date returned unexpected result: (-want, +got)\n%s", diff)
+			}
+			gotTime, ok := tc.dateTime.Time()
+			if ok != tc.wantTimeOK {
+				t.Fatalf("DateTime.Time returned unexpected ok: got %v, want %v", ok, tc.wantTimeOK)
+			}
+			if diff := cmp.Diff(tc.wantTime, gotTime); diff != "" {
+				t.Errorf("DateTime.Time returned unexpected result: (-want, +got)\n%s", diff)
+			}
+			gotOffset, ok := tc.dateTime.TimezoneOffset()
+			if ok != tc.wantZoneOK {
+				t.Fatalf("DateTime.TimezoneOffset returned unexpected ok: got %v, want %v", ok, tc.wantZoneOK)
+			}
+			if ok && !gotOffset.Equal(tc.wantOffset) {
+				t.Errorf("DateTime.TimezoneOffset returned unexpected result: got %v, want %v", gotOffset, tc.wantOffset)
+			}
+		})
+	}
+}
+
+func TestDateTimeDurationAndDifference(t *testing.T) {
+	testCases := []struct {
+		name           string
+		from           system.DateTime
+		to             system.DateTime
+		unit           string
+		wantDuration   system.Quantity
+		wantDifference system.Quantity
+		wantOK         bool
+	}{
+		{
+			name:           "counts whole years before anniversary",
+			from:           system.MustParseDateTime("2000-06-15T"),
+			to:             system.MustParseDateTime("2018-06-14T"),
+			unit:           "years",
+			wantDuration:   system.MustParseQuantity("17", "years"),
+			wantDifference: system.MustParseQuantity("18", "years"),
+			wantOK:         true,
+		},
+		{
+			name:           "counts whole years on anniversary",
+			from:           system.MustParseDateTime("2000-06-15T"),
+			to:             system.MustParseDateTime("2018-06-15T"),
+			unit:           "year",
+			wantDuration:   system.MustParseQuantity("18", "years"),
+			wantDifference: system.MustParseQuantity("18", "years"),
+			wantOK:         true,
+		},
+		{
+			name:           "counts boundaries crossed between adjacent days",
+			from:           system.MustParseDateTime("2020-12-31T23:00"),
+			to:             system.MustParseDateTime("2021-01-01T01:00"),
+			unit:           "days",
+			wantDuration:   system.MustParseQuantity("0", "days"),
+			wantDifference: system.MustParseQuantity("1", "days"),
+			wantOK:         true,
+		},
+		{
+			name:           "counts days in the time zone of the input",
+			from:           system.MustParseDateTime("2020-01-01T01:00:00+05:00"),
+			to:             system.MustParseDateTime("2020-01-01T23:00:00+05:00"),
+			unit:           "days",
+			wantDuration:   system.MustParseQuantity("0", "days"),
+			wantDifference: system.MustParseQuantity("0", "days"),
+			wantOK:         true,
+		},
+		{
+			name:           "converts value to the time zone of the input",
+			from:           system.MustParseDateTime("2020-01-01T01:00:00+05:00"),
+			to:             system.MustParseDateTime("2020-01-01T22:00:00+04:00"),
+			unit:           "days",
+			wantDuration:   system.MustParseQuantity("0", "days"),
+			wantDifference: system.MustParseQuantity("0", "days"),
+			wantOK:         true,
+		},
+		{
+			name:           "counts months",
+			from:           system.MustParseDateTime("2014-01-31T"),
+			to:             system.MustParseDateTime("2014-06-01T"),
+			unit:           "months",
+			wantDuration:   system.MustParseQuantity("4", "months"),
+			wantDifference: system.MustParseQuantity("5", "months"),
+			wantOK:         true,
+		},
+		{
+			name:           "returns negative result when value is before input",
+			from:           system.MustParseDateTime("2014-01-01T10:00"),
+			to:             system.MustParseDateTime("2014-01-01T08:30"),
+			unit:           "hours",
+			wantDuration:   system.MustParseQuantity("-1", "hours"),
+			wantDifference: system.MustParseQuantity("-2", "hours"),
+			wantOK:         true,
+		},
+		{
+			name:           "counts weeks",
+			from:           system.MustParseDateTime("2014-01-01T"),
+			to:             system.MustParseDateTime("2014-01-20T"),
+			unit:           "weeks",
+			wantDuration:   system.MustParseQuantity("2", "weeks"),
+			wantDifference: system.MustParseQuantity("2", "weeks"),
+			wantOK:         true,
+		},
+		{
+			name:   "returns false if a value is not precise enough",
+			from:   system.MustParseDateTime("2014T"),
+			to:     system.MustParseDateTime("2015-01-20T"),
+			unit:   "days",
+			wantOK: false,
+		},
+		{
+			name:   "returns false for non-calendar unit",
+			from:   system.MustParseDateTime("2014T"),
+			to:     system.MustParseDateTime("2015T"),
+			unit:   "a",
+			wantOK: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gotDuration, durationOK := tc.from.Duration(tc.to, tc.unit)
+			gotDifference, differenceOK := tc.from.Difference(tc.to, tc.unit)
+
+			if durationOK != tc.wantOK || differenceOK != tc.wantOK {
+				t.Fatalf("DateTime durations returned unexpected ok: got (%v, %v), want %v", durationOK, differenceOK, tc.wantOK)
+			}
+			if diff := cmp.Diff(tc.wantDuration, gotDuration); diff != "" {
+				t.Errorf("DateTime.Duration returned unexpected result: (-want, +got)\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantDifference, gotDifference); diff != "" {
+				t.Errorf("DateTime.Difference returned unexpected result: (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package system
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// calendarUnits maps each calendar duration keyword to the Component that it
+// measures. Weeks are measured in days.
+var calendarUnits = map[string]Component{
+	"year":         YearComponent,
+	"years":        YearComponent,
+	"month":        MonthComponent,
+	"months":       MonthComponent,
+	"week":         DayComponent,
+	"weeks":        DayComponent,
+	"day":          DayComponent,
+	"days":         DayComponent,
+	"hour":         HourComponent,
+	"hours":        HourComponent,
+	"minute":       MinuteComponent,
+	"minutes":      MinuteComponent,
+	"second":       SecondComponent,
+	"seconds":      SecondComponent,
+	"millisecond":  MillisecondComponent,
+	"milliseconds": MillisecondComponent,
+}
+
+// unitMilliseconds holds the number of milliseconds in each fixed length
+// calendar duration, keyed by the plural keyword.
+var unitMilliseconds = map[string]int64{
+	"weeks":        7 * 24 * 60 * 60 * 1000,
+	"days":         24 * 60 * 60 * 1000,
+	"hours":        60 * 60 * 1000,
+	"minutes":      60 * 1000,
+	"seconds":      1000,
+	"milliseconds": 1,
+}
+
+// definiteUnits maps the calendar duration keywords of a fixed length to the
+// equivalent UCUM definite-duration units, keyed by the plural keyword.
+// Calendar years and months vary in length, so have no UCUM equivalent.
+var definiteUnits = map[string]string{
+	"weeks":        "wk",
+	"days":         "d",
+	"hours":        "h",
+	"minutes":      "min",
+	"seconds":      "s",
+	"milliseconds": "ms",
+}
+
+// equivalentUnits maps the calendar durations of a varying length to the UCUM
+// definite-duration units that they are equivalent, but not equal, to.
+var equivalentUnits = map[string]string{
+	"years":  "a",
+	"months": "mo",
+}
+
+// unitMonths holds the number of months in each calendar duration that is
+// measured in months, keyed by the plural keyword.
+var unitMonths = map[string]int64{
+	"years":  12,
+	"months": 1,
+}
+
+// calendarUnit returns the plural form of the calendar duration keyword, or
+// false if the unit is not a calendar duration keyword.
+func calendarUnit(unit string) (string, bool) {
+	if _, ok := calendarUnits[unit]; !ok {
+		return "", false
+	}
+	if unit[len(unit)-1] != 's' {
+		unit += "s"
+	}
+	return unit, true
+}
+
+// definiteUnit returns the UCUM unit that is equivalent to the given unit,
+// replacing calendar durations of a fixed length with their UCUM equivalent.
+// Returns ErrMismatchedUnit for calendar years and months.
+func definiteUnit(unit string) (string, error) {
+	calendar, ok := calendarUnit(unit)
+	if !ok {
+		return unit, nil
+	}
+	if definite, ok := definiteUnits[calendar]; ok {
+		return definite, nil
+	}
+	return "", fmt.Errorf("%w: calendar duration '%s' has no definite length", ErrMismatchedUnit, unit)
+}
+
+// convertCalendarMonths converts the value between the calendar durations
+// 'years' and 'months'. Returns false if either unit isn't one of these.
+func convertCalendarMonths(value *big.Rat, from, to string) (*big.Rat, bool) {
+	fromMonths, ok := unitMonths[from]
+	if !ok {
+		return nil, false
+	}
+	toMonths, ok := unitMonths[to]
+	if !ok {
+		return nil, false
+	}
+	return new(big.Rat).Mul(value, big.NewRat(fromMonths, toMonths)), true
+}
+
+// calendarDuration returns the calendar duration between from and to, which
+// have the given precisions, in the given unit. If whole is true, the number
+// of whole periods between them is returned. Otherwise, the number of period
+// boundaries crossed between them is returned.
+func calendarDuration(from, to time.Time, fromLevel, toLevel Component, unit string, whole bool) (Quantity, bool) {
+	level, ok := calendarUnits[unit]
+	if !ok || fromLevel < level || toLevel < level {
+		return Quantity{}, false
+	}
+	// Results are always reported in the plural form of the keyword.
+	unit, _ = calendarUnit(unit)
+
+	// Compare the values in the offset of from, so that period boundaries are
+	// those of its local calendar.
+	to = to.In(from.Location())
+	if whole {
+		// Only compare the components that are present in both values.
+		common := min(int(fromLevel), int(toLevel))
+		from, to = truncateToLevel(from, Component(common)), truncateToLevel(to, Component(common))
+	} else {
+		from, to = truncateToLevel(from, level), truncateToLevel(to, level)
+	}
+
+	var periods int64
+	switch unit {
+	case "years":
+		years := to.Year() - from.Year()
+		if years > 0 && addYear(from, years).After(to) {
+			years--
+		} else if years < 0 && addYear(from, years).Before(to) {
+			years++
+		}
+		periods = int64(years)
+	case "months":
+		months := (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+		if months > 0 && addMonth(from, months).After(to) {
+			months--
+		} else if months < 0 && addMonth(from, months).Before(to) {
+			months++
+		}
+		periods = int64(months)
+	default:
+		periods = (to.UnixMilli() - from.UnixMilli()) / unitMilliseconds[unit]
+	}
+	return Quantity{Decimal(decimal.NewFromInt(periods)), unit}, true
+}
+
+// IsCalendarDuration returns true if the unit is one of the calendar duration
+// keywords, in singular or plural form.
+func IsCalendarDuration(unit string) bool {
+	_, ok := calendarUnits[unit]
+	return ok
+}
@@ -1,5 +1,7 @@
 package system
 
+import "time"
+
 // datePrecision enumerates date precision constants.
 type datePrecision int
 
@@ -84,3 +86,91 @@ var dateTimeMap = map[layout]dateTimePrecision{
 	dtMonthLayout:         dtMonth,
 	dtYearLayout:          dtYear,
 }
+
+// Component identifies a component of a Date, DateTime or Time value. The
+// components are ordered from coarsest to finest, so that each one also
+// identifies the level of precision of values that end with it.
+type Component int
+
+// Component constants.
+const (
+	YearComponent Component = iota
+	MonthComponent
+	DayComponent
+	HourComponent
+	MinuteComponent
+	SecondComponent
+	MillisecondComponent
+)
+
+// levelDigits maps each precision level to the number of digits in a
+// DateTime of that precision, as returned by the precision() function.
+var levelDigits = []int{4, 6, 8, 10, 12, 14, 17}
+
+var layoutLevels = map[layout]Component{
+	yearLayout:            YearComponent,
+	monthLayout:           MonthComponent,
+	dayLayout:             DayComponent,
+	hourLayout:            HourComponent,
+	minuteLayout:          MinuteComponent,
+	secondLayout:          SecondComponent,
+	millisecondLayout:     MillisecondComponent,
+	dtYearLayout:          YearComponent,
+	dtMonthLayout:         MonthComponent,
+	dtDayLayout:           DayComponent,
+	dtHourLayout:          HourComponent,
+	dtHourLayoutTZ:        HourComponent,
+	dtMinuteLayout:        MinuteComponent,
+	dtMinuteLayoutTZ:      MinuteComponent,
+	dtSecondLayout:        SecondComponent,
+	dtSecondLayoutTZ:      SecondComponent,
+	dtMillisecondLayout:   MillisecondComponent,
+	dtMillisecondLayoutTZ: MillisecondComponent,
+}
+
+// timeComponents returns the value of each Component of t.
+func timeComponents(t time.Time) []int {
+	return []int{t.Year(), int(t.Month()), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond() / int(time.Millisecond)}
+}
+
+// truncateToLevel returns t with every component finer than the given
+// precision level set to its minimum value.
+func truncateToLevel(t time.Time, level Component) time.Time {
+	components := timeComponents(t)
+	minimums := []int{0, 1, 1, 0, 0, 0, 0}
+	for i := int(level) + 1; i < len(components); i++ {
+		components[i] = minimums[i]
+	}
+	return time.Date(components[0], time.Month(components[1]), components[2], components[3],
+		components[4], components[5], components[6]*int(time.Millisecond), t.Location())
+}
+
+// lowBoundaryAt returns the earliest instant represented by t, which has the
+// precision of the from level, truncated to the precision of the to level.
+func lowBoundaryAt(t time.Time, from, to Component) time.Time {
+	return truncateToLevel(truncateToLevel(t, from), to)
+}
+
+// highBoundaryAt returns the latest instant represented by t, which has the
+// precision of the from level, truncated to the precision of the to level.
+func highBoundaryAt(t time.Time, from, to Component) time.Time {
+	start := truncateToLevel(t, from)
+	var end time.Time
+	switch from {
+	case YearComponent:
+		end = start.AddDate(1, 0, 0)
+	case MonthComponent:
+		end = start.AddDate(0, 1, 0)
+	case DayComponent:
+		end = start.AddDate(0, 0, 1)
+	case HourComponent:
+		end = start.Add(time.Hour)
+	case MinuteComponent:
+		end = start.Add(time.Minute)
+	case SecondComponent:
+		end = start.Add(time.Second)
+	default:
+		end = start.Add(time.Millisecond)
+	}
+	return truncateToLevel(end.Add(-time.Millisecond), to)
+}
@@ -181,20 +181,31 @@ func (i Integer) Mul(input Integer) (Integer, error) {
 	return 0, ErrIntOverflow
 }
 
-// Div divides i by input. Returns a Decimal.
-func (i Integer) Div(input Integer) Decimal {
+// Div divides i by input. Returns a Decimal, or ErrDivByZero if input is
+// zero.
+func (i Integer) Div(input Integer) (Decimal, error) {
+	if input == 0 {
+		return Decimal{}, ErrDivByZero
+	}
 	lhs, rhs := decimal.NewFromInt32(int32(i)), decimal.NewFromInt32(int32(input))
-	return Decimal(lhs.Div(rhs))
+	return Decimal(lhs.Div(rhs)), nil
 }
 
-// FloorDiv divides i by input and rounds down.
-func (i Integer) FloorDiv(input Integer) Integer {
-	return i / input
+// FloorDiv divides i by input and rounds down. Returns ErrDivByZero if input
+// is zero.
+func (i Integer) FloorDiv(input Integer) (Integer, error) {
+	if input == 0 {
+		return 0, ErrDivByZero
+	}
+	return i / input, nil
 }
 
-// Mod returns i % integer.
-func (i Integer) Mod(input Integer) Integer {
-	return i % input
+// Mod returns i % integer, or ErrDivByZero if input is zero.
+func (i Integer) Mod(input Integer) (Integer, error) {
+	if input == 0 {
+		return 0, ErrDivByZero
+	}
+	return i % input, nil
 }
 
 // ToProtoInteger returns the proto representation of the system integer.
@@ -202,6 +213,124 @@ func (i Integer) ToProtoInteger() *dtpb.Integer {
 	return fhir.Integer(int32(i))
 }
 
+// Long represents 64-bit integer values, written as literals with an 'L'
+// suffix, e.g. 123L.
+type Long int64
+
+// ParseLong parses a string into an int64 value, and returns an error if the
+// input does not represent a valid int64. The 'L' suffix of Long literals is
+// optional.
+func ParseLong(value string) (Long, error) {
+	l, err := strconv.ParseInt(strings.TrimSuffix(value, "L"), 10, 64)
+	if err != nil {
+		return Long(0), err
+	}
+	return Long(l), nil
+}
+
+// MustParseLong converts a string into a Long type. If the string is not
+// parseable it will throw a panic().
+func MustParseLong(value string) Long {
+	l, err := ParseLong(value)
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
+
+// Equal returns true if the input value is a System Long, and contains the
+// same int64 value.
+func (l Long) Equal(input Any) bool {
+	val, ok := input.(Long)
+	if !ok {
+		return false
+	}
+	return l == val
+}
+
+// Name returns the type name.
+func (l Long) Name() string {
+	return longType
+}
+
+// Less returns true if l is less than input.(Long).
+// If input is not a Long, returns an error.
+func (l Long) Less(input Any) (Boolean, error) {
+	val, ok := input.(Long)
+	if !ok {
+		return false, fmt.Errorf("%w: %T, %T,", ErrTypeMismatch, l, input)
+	}
+	return l < val, nil
+}
+
+// Add adds l to the input Long. Returns an error if the result overflows.
+func (l Long) Add(input Long) (Long, error) {
+	result := l + input
+	if (result > l) == (input > 0) {
+		return result, nil
+	}
+	return 0, ErrIntOverflow
+}
+
+// Sub subtracts the input Long from l. Returns an error if the result
+// overflows.
+func (l Long) Sub(input Long) (Long, error) {
+	result := l - input
+	if (result < l) == (input > 0) {
+		return result, nil
+	}
+	return 0, ErrIntOverflow
+}
+
+// Mul multiplies the two longs together. Returns an error if the result
+// overflows.
+func (l Long) Mul(input Long) (Long, error) {
+	if l == 0 || input == 0 {
+		return 0, nil
+	}
+	result := l * input
+	if (result < 0) == ((l < 0) != (input < 0)) && (result/input) == l {
+		return result, nil
+	}
+	return 0, ErrIntOverflow
+}
+
+// Div divides l by input. Returns a Decimal, or ErrDivByZero if input is
+// zero.
+func (l Long) Div(input Long) (Decimal, error) {
+	if input == 0 {
+		return Decimal{}, ErrDivByZero
+	}
+	lhs, rhs := decimal.NewFromInt(int64(l)), decimal.NewFromInt(int64(input))
+	return Decimal(lhs.Div(rhs)), nil
+}
+
+// FloorDiv divides l by input and rounds down. Returns ErrDivByZero if input
+// is zero.
+func (l Long) FloorDiv(input Long) (Long, error) {
+	if input == 0 {
+		return 0, ErrDivByZero
+	}
+	return l / input, nil
+}
+
+// Mod returns l % input, or ErrDivByZero if input is zero.
+func (l Long) Mod(input Long) (Long, error) {
+	if input == 0 {
+		return 0, ErrDivByZero
+	}
+	return l % input, nil
+}
+
+// ToInteger converts l to an Integer. Returns false if l is out of the range
+// of an Integer.
+func (l Long) ToInteger() (Integer, bool) {
+	if l < math.MinInt32 || l > math.MaxInt32 {
+		return 0, false
+	}
+	return Integer(l), true
+}
+
 // Decimal represents fixed-point decimals. Must use
 // utilities provided by "github.com/shopspring/decimal" to
 // perform arithmetic.
@@ -273,13 +402,20 @@ func (d Decimal) Mul(input Decimal) Decimal {
 	return Decimal(decimal.Decimal(d).Mul(decimal.Decimal(input)))
 }
 
-// Div divides d by input.
-func (d Decimal) Div(input Decimal) Decimal {
-	return Decimal(decimal.Decimal(d).Div(decimal.Decimal(input)))
+// Div divides d by input. Returns ErrDivByZero if input is zero.
+func (d Decimal) Div(input Decimal) (Decimal, error) {
+	if decimal.Decimal(input).IsZero() {
+		return Decimal{}, ErrDivByZero
+	}
+	return Decimal(decimal.Decimal(d).Div(decimal.Decimal(input))), nil
 }
 
-// FloorDiv divides d by input and rounds down.
+// FloorDiv divides d by input and rounds down. Returns ErrDivByZero if input
+// is zero.
 func (d Decimal) FloorDiv(input Decimal) (Integer, error) {
+	if decimal.Decimal(input).IsZero() {
+		return 0, ErrDivByZero
+	}
 	result := decimal.Decimal(d).Div(decimal.Decimal(input)).IntPart()
 	if (result < math.MinInt32) || (result > math.MaxInt32) {
 		return 0, ErrIntOverflow
@@ -287,9 +423,12 @@ func (d Decimal) FloorDiv(input Decimal) (Integer, error) {
 	return Integer(int32(result)), nil
 }
 
-// Mod computes d % input.
-func (d Decimal) Mod(input Decimal) Decimal {
-	return Decimal(decimal.Decimal(d).Mod(decimal.Decimal(input)))
+// Mod computes d % input, or returns ErrDivByZero if input is zero.
+func (d Decimal) Mod(input Decimal) (Decimal, error) {
+	if decimal.Decimal(input).IsZero() {
+		return Decimal{}, ErrDivByZero
+	}
+	return Decimal(decimal.Decimal(d).Mod(decimal.Decimal(input))), nil
 }
 
 // ToProtoDecimal returns the proto Decimal representation of decimal.
@@ -301,3 +440,46 @@ func (d Decimal) ToProtoDecimal() *dtpb.Decimal {
 func (d Decimal) Round(precision int32) Decimal {
 	return Decimal(decimal.Decimal(d).Round(precision))
 }
+
+// MaxDecimalPrecision is the maximum number of decimal places that the
+// boundaries of a Decimal can be computed to.
+const MaxDecimalPrecision = 28
+
+// Precision returns the number of digits after the decimal point in d,
+// including trailing zeroes. Eg. 1.58700 has a precision of 5.
+func (d Decimal) Precision() int {
+	if exponent := decimal.Decimal(d).Exponent(); exponent < 0 {
+		return int(-exponent)
+	}
+	return 0
+}
+
+// LowBoundary returns the least possible value of d given its precision,
+// rounded down to the given number of decimal places. Returns false if the
+// precision is negative or greater than MaxDecimalPrecision.
+// Eg. 1.587 has a low boundary of 1.58650000 at a precision of 8.
+func (d Decimal) LowBoundary(precision int) (Decimal, bool) {
+	if precision < 0 || precision > MaxDecimalPrecision {
+		return Decimal(decimal.Zero), false
+	}
+	low := decimal.Decimal(d).Sub(d.halfUnit())
+	return Decimal(decimal.NewFromBigInt(low.Shift(int32(precision)).Floor().BigInt(), -int32(precision))), true
+}
+
+// HighBoundary returns the greatest possible value of d given its precision,
+// rounded up to the given number of decimal places. Returns false if the
+// precision is negative or greater than MaxDecimalPrecision.
+// Eg. 1.587 has a high boundary of 1.58750000 at a precision of 8.
+func (d Decimal) HighBoundary(precision int) (Decimal, bool) {
+	if precision < 0 || precision > MaxDecimalPrecision {
+		return Decimal(decimal.Zero), false
+	}
+	high := decimal.Decimal(d).Add(d.halfUnit())
+	return Decimal(decimal.NewFromBigInt(high.Shift(int32(precision)).Ceil().BigInt(), -int32(precision))), true
+}
+
+// halfUnit returns half of the smallest unit representable at the precision
+// of d, eg. 0.0005 for 1.587.
+func (d Decimal) halfUnit() decimal.Decimal {
+	return decimal.New(5, -int32(d.Precision()+1))
+}
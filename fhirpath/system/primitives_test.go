@@ -400,3 +400,266 @@ func TestIntegerMul_ReturnsResult(t *testing.T) {
 		})
 	}
 }
+
+func TestParseLong_ReturnsLong(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		want  system.Long
+	}{
+		{
+			name:  "value beyond 32-bit range",
+			input: "2147483648",
+			want:  system.Long(2147483648),
+		},
+		{
+			name:  "literal with suffix",
+			input: "1700000000000L",
+			want:  system.Long(1700000000000),
+		},
+		{
+			name:  "negative edge",
+			input: "-9223372036854775808",
+			want:  system.Long(math.MinInt64),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l, err := system.ParseLong(tc.input)
+
+			if err != nil {
+				t.Fatalf("ParseLong(%s) returns unexpected error: %v", tc.input, err)
+			}
+			if got, want := l, tc.want; got != want {
+				t.Errorf("ParseLong(%s) parsed incorrectly: got %v, want %v", tc.input, got, want)
+			}
+		})
+	}
+}
+
+func TestParseLong_ReturnsError_IfOutOfRange(t *testing.T) {
+	input := "9223372036854775808"
+
+	if _, err := system.ParseLong(input); err == nil {
+		t.Fatalf("ParseLong(%s) doesn't return error when expected to", input)
+	}
+}
+
+func TestLongArithmetic(t *testing.T) {
+	testCases := []struct {
+		name    string
+		op      func(system.Long, system.Long) (system.Long, error)
+		left    system.Long
+		right   system.Long
+		want    system.Long
+		wantErr error
+	}{
+		{
+			name:  "adds beyond 32-bit range",
+			op:    system.Long.Add,
+			left:  math.MaxInt32,
+			right: 1,
+			want:  2147483648,
+		},
+		{
+			name:    "returns error when addition overflows",
+			op:      system.Long.Add,
+			left:    math.MaxInt64,
+			right:   1,
+			wantErr: system.ErrIntOverflow,
+		},
+		{
+			name:    "returns error when subtraction overflows",
+			op:      system.Long.Sub,
+			left:    math.MinInt64,
+			right:   1,
+			wantErr: system.ErrIntOverflow,
+		},
+		{
+			name:  "multiplies beyond 32-bit range",
+			op:    system.Long.Mul,
+			left:  1700000000,
+			right: 1000,
+			want:  1700000000000,
+		},
+		{
+			name:    "returns error when multiplication overflows",
+			op:      system.Long.Mul,
+			left:    math.MinInt64,
+			right:   -1,
+			wantErr: system.ErrIntOverflow,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.op(tc.left, tc.right)
+
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Long arithmetic returned unexpected error: got %v, want %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Long arithmetic returned unexpected result: (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDivision_ReturnsError_IfDivisorIsZero(t *testing.T) {
+	testCases := []struct {
+		name string
+		op   func() error
+	}{
+		{
+			name: "integer division",
+			op: func() error {
+				_, err := system.Integer(5).Div(0)
+				return err
+			},
+		},
+		{
+			name: "integer floor division",
+			op: func() error {
+				_, err := system.Integer(5).FloorDiv(0)
+				return err
+			},
+		},
+		{
+			name: "integer modulus",
+			op: func() error {
+				_, err := system.Integer(5).Mod(0)
+				return err
+			},
+		},
+		{
+			name: "decimal division",
+			op: func() error {
+				_, err := system.MustParseDecimal("1.0").Div(system.MustParseDecimal("0"))
+				return err
+			},
+		},
+		{
+			name: "decimal floor division",
+			op: func() error {
+				_, err := system.MustParseDecimal("1.5").FloorDiv(system.MustParseDecimal("0.0"))
+				return err
+			},
+		},
+		{
+			name: "decimal modulus",
+			op: func() error {
+				_, err := system.MustParseDecimal("1.5").Mod(system.MustParseDecimal("0"))
+				return err
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.op(); !errors.Is(err, system.ErrDivByZero) {
+				t.Errorf("%s returned error %v, want %v", tc.name, err, system.ErrDivByZero)
+			}
+		})
+	}
+}
+
+func TestLongToInteger(t *testing.T) {
+	if got, ok := system.Long(42).ToInteger(); !ok || got != 42 {
+		t.Errorf("Long.ToInteger() = %v, %v; want 42, true", got, ok)
+	}
+	if _, ok := system.Long(math.MaxInt32 + 1).ToInteger(); ok {
+		t.Errorf("Long.ToInteger() returned ok for value outside of 32-bit range")
+	}
+}
+
+func TestDecimalBoundaries(t *testing.T) {
+	testCases := []struct {
+		name          string
+		decimal       system.Decimal
+		precision     int
+		wantPrecision int
+		wantLow       system.Decimal
+		wantHigh      system.Decimal
+		wantOK        bool
+	}{
+		{
+			name:          "computes boundaries at default precision",
+			decimal:       system.MustParseDecimal("1.587"),
+			precision:     8,
+			wantPrecision: 3,
+			wantLow:       system.MustParseDecimal("1.5865"),
+			wantHigh:      system.MustParseDecimal("1.5875"),
+			wantOK:        true,
+		},
+		{
+			name:          "rounds boundaries outwards at lower precision",
+			decimal:       system.MustParseDecimal("1.587"),
+			precision:     2,
+			wantPrecision: 3,
+			wantLow:       system.MustParseDecimal("1.58"),
+			wantHigh:      system.MustParseDecimal("1.59"),
+			wantOK:        true,
+		},
+		{
+			name:          "computes boundaries of negative decimals",
+			decimal:       system.MustParseDecimal("-1.587"),
+			precision:     8,
+			wantPrecision: 3,
+			wantLow:       system.MustParseDecimal("-1.5875"),
+			wantHigh:      system.MustParseDecimal("-1.5865"),
+			wantOK:        true,
+		},
+		{
+			name:          "counts trailing zeroes in precision",
+			decimal:       system.MustParseDecimal("1.58700"),
+			precision:     8,
+			wantPrecision: 5,
+			wantLow:       system.MustParseDecimal("1.586995"),
+			wantHigh:      system.MustParseDecimal("1.587005"),
+			wantOK:        true,
+		},
+		{
+			name:          "computes boundaries of whole numbers",
+			decimal:       system.MustParseDecimal("1"),
+			precision:     0,
+			wantPrecision: 0,
+			wantLow:       system.MustParseDecimal("0"),
+			wantHigh:      system.MustParseDecimal("2"),
+			wantOK:        true,
+		},
+		{
+			name:          "returns false for precision out of range",
+			decimal:       system.MustParseDecimal("1.587"),
+			precision:     system.MaxDecimalPrecision + 1,
+			wantPrecision: 3,
+			wantOK:        false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.decimal.Precision(); got != tc.wantPrecision {
+				t.Errorf("Decimal.Precision returned unexpected result: got %v, want %v", got, tc.wantPrecision)
+			}
+			low, lowOK := tc.decimal.LowBoundary(tc.precision)
+			high, highOK := tc.decimal.HighBoundary(tc.precision)
+
+			if lowOK != tc.wantOK || highOK != tc.wantOK {
+				t.Fatalf("Decimal boundaries returned unexpected ok: got (%v, %v), want %v", lowOK, highOK, tc.wantOK)
+			}
+			if !tc.wantOK {
+				return
+			}
+			if !low.Equal(tc.wantLow) {
+				t.Errorf("Decimal.LowBoundary returned unexpected result: got %v, want %v", low, tc.wantLow)
+			}
+			if !high.Equal(tc.wantHigh) {
+				t.Errorf("Decimal.HighBoundary returned unexpected result: got %v, want %v", high, tc.wantHigh)
+			}
+			if low.Precision() != tc.precision || high.Precision() != tc.precision {
+				t.Errorf("Decimal boundaries have unexpected precision: got (%v, %v), want %v", low.Precision(), high.Precision(), tc.precision)
+			}
+		})
+	}
+}
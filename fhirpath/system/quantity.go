@@ -2,11 +2,13 @@ package system
 
 import (
 	"fmt"
+	"math/big"
 	"time"
 
 	dtpb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/datatypes_go_proto"
 	"github.com/shopspring/decimal"
 	"github.com/verily-src/fhirpath-go/internal/fhir"
+	"github.com/verily-src/fhirpath-go/internal/ucum"
 )
 
 // Quantity type represents a decimal value along with a UCUM unit or
@@ -17,7 +19,9 @@ type Quantity struct {
 }
 
 // ParseQuantity takes as input a number string and a unit string and constructs
-// a Quantity object. Returns error if the input does not fit into a valid Quantity
+// a Quantity object. Returns error if the input does not fit into a valid Quantity.
+// The unit is not validated, so that quantities from real world data can be
+// represented; use ValidateUnit to reject units that aren't valid.
 func ParseQuantity(number string, unit string) (Quantity, error) {
 	d, err := ParseDecimal(number)
 	if err != nil {
@@ -40,13 +44,132 @@ func MustParseQuantity(number string, unit string) Quantity {
 	return q
 }
 
+const (
+	// ucumSystem is the code system of UCUM units in FHIR Quantity elements.
+	ucumSystem = "http://unitsofmeasure.org"
+
+	// dimensionlessUnit is the UCUM unit of quantities without dimension,
+	// such as numbers implicitly converted to quantities.
+	dimensionlessUnit = "1"
+)
+
 // newQuantity constructs a system Quantity type, given a decimal
-// value and a UCUM unit identifier.
+// value and a UCUM unit identifier. Units that are not valid UCUM units are
+// still accepted, for leniency with real world data, but can only be compared
+// with quantities of the exact same unit. See ValidateUnit.
 func newQuantity(value Decimal, unit string) (Quantity, error) {
-	// check isValidUnit(unit) *To be implemented
 	return Quantity{value, unit}, nil
 }
 
+// ValidateUnit returns an error if the unit is neither a valid UCUM unit nor
+// a calendar duration keyword.
+func ValidateUnit(unit string) error {
+	if _, ok := calendarUnit(unit); ok {
+		return nil
+	}
+	return ucum.Validate(unit)
+}
+
+// Value returns the numeric value of q.
+func (q Quantity) Value() Decimal {
+	return q.value
+}
+
+// Unit returns the unit of q.
+func (q Quantity) Unit() string {
+	return q.unit
+}
+
+// ToUnit converts q to the given UCUM unit or calendar duration keyword.
+// Calendar durations of a fixed length, such as 'days', are converted as
+// their UCUM equivalent, such as 'd'. Calendar years and months can only be
+// converted between each other, as their length varies. Returns
+// ErrMismatchedUnit if either unit isn't valid, or the units aren't
+// commensurable.
+func (q Quantity) ToUnit(unit string) (Quantity, error) {
+	if q.unit == unit {
+		return q, nil
+	}
+	value := decimal.Decimal(q.value).Rat()
+	fromCalendar, fromOk := calendarUnit(q.unit)
+	toCalendar, toOk := calendarUnit(unit)
+	if fromOk && toOk {
+		if fromCalendar == toCalendar {
+			return Quantity{q.value, unit}, nil
+		}
+		if converted, ok := convertCalendarMonths(value, fromCalendar, toCalendar); ok {
+			return Quantity{ratToDecimal(converted), unit}, nil
+		}
+	}
+
+	from, err := definiteUnit(q.unit)
+	if err != nil {
+		return Quantity{}, err
+	}
+	to, err := definiteUnit(unit)
+	if err != nil {
+		return Quantity{}, err
+	}
+	converted, err := ucum.Convert(value, from, to)
+	if err != nil {
+		return Quantity{}, fmt.Errorf("%w: %v", ErrMismatchedUnit, err)
+	}
+	return Quantity{ratToDecimal(converted), unit}, nil
+}
+
+// canonical returns q and input converted to a common unit, so that their
+// values can be compared. Returns false if the units are not the same, and
+// are not commensurable UCUM units.
+func (q Quantity) canonical(input Quantity) (Decimal, Decimal, bool) {
+	if q.unit == input.unit {
+		return q.value, input.value, true
+	}
+	converted, err := input.ToUnit(q.unit)
+	if err != nil {
+		return Decimal{}, Decimal{}, false
+	}
+	return q.value, converted.value, true
+}
+
+// Comparable returns true if q can be compared to input, which is the case
+// when they have the same unit or commensurable UCUM units.
+func (q Quantity) Comparable(input Quantity) bool {
+	_, _, ok := q.canonical(input)
+	return ok
+}
+
+// equivalent returns true if q is equivalent to input. Each quantity is
+// converted to the unit of the other, so that a conversion to a coarser unit
+// can't hide a difference at the precision of the finer unit.
+func (q Quantity) equivalent(input Quantity) bool {
+	left, right, ok := q.equivalentValues(input)
+	if !ok || !decimalEquivalent(left, right) {
+		return false
+	}
+	left, right, ok = input.equivalentValues(q)
+	return ok && decimalEquivalent(left, right)
+}
+
+// equivalentValues is like canonical, but additionally treats calendar years
+// and months as equivalent to the UCUM definite durations 'a' and 'mo'.
+func (q Quantity) equivalentValues(input Quantity) (Decimal, Decimal, bool) {
+	if left, right, ok := q.canonical(input); ok {
+		return left, right, true
+	}
+	return q.toEquivalentUnit().canonical(input.toEquivalentUnit())
+}
+
+// toEquivalentUnit returns q with calendar years and months replaced with
+// their equivalent UCUM unit.
+func (q Quantity) toEquivalentUnit() Quantity {
+	if calendar, ok := calendarUnit(q.unit); ok {
+		if unit, ok := equivalentUnits[calendar]; ok {
+			return Quantity{q.value, unit}
+		}
+	}
+	return q
+}
+
 // TryEqual returns a bool representing whether or not the
 // value represented by q is equal to the value of q2.
 // The comparison is not symmetric and may not return a value, represented by
@@ -56,10 +179,11 @@ func (q Quantity) TryEqual(input Any) (bool, bool) {
 	if !ok {
 		return false, true
 	}
-	if q.unit != val.unit {
+	left, right, ok := q.canonical(val)
+	if !ok {
 		return false, false
 	}
-	return q.value.Equal(val.value), true
+	return left.Equal(right), true
 }
 
 // Less returns true if q is less than input.(Quantity). If the units
@@ -70,30 +194,101 @@ func (q Quantity) Less(input Any) (Boolean, error) {
 	if !ok {
 		return false, fmt.Errorf("%w: %T, %T", ErrTypeMismatch, q, input)
 	}
-	if q.unit != val.unit {
+	left, right, ok := q.canonical(val)
+	if !ok {
 		return false, ErrMismatchedUnit
 	}
-	return q.value.Less(val.value)
+	return left.Less(right)
 }
 
-// Add returns q + input. Returns an error if the units are mismatched.
+// Add returns q + input, in the unit of q. Returns an error if the units
+// are mismatched.
 func (q Quantity) Add(input Quantity) (Quantity, error) {
-	if q.unit != input.unit {
+	left, right, ok := q.canonical(input)
+	if !ok {
 		return Quantity{}, ErrMismatchedUnit
 	}
-	value := Decimal(decimal.Decimal(q.value).Add(decimal.Decimal(input.value)))
+	value := Decimal(decimal.Decimal(left).Add(decimal.Decimal(right)))
 	return Quantity{value, q.unit}, nil
 }
 
-// Sub returns q - input. Returns an error if the units are mismatched.
+// Sub returns q - input, in the unit of q. Returns an error if the units
+// are mismatched.
 func (q Quantity) Sub(input Quantity) (Quantity, error) {
-	if q.unit != input.unit {
+	left, right, ok := q.canonical(input)
+	if !ok {
 		return Quantity{}, ErrMismatchedUnit
 	}
-	value := Decimal(decimal.Decimal(q.value).Sub(decimal.Decimal(input.value)))
+	value := Decimal(decimal.Decimal(left).Sub(decimal.Decimal(right)))
 	return Quantity{value, q.unit}, nil
 }
 
+// Mul returns q * input. The unit of the result is the product of the units,
+// with commensurable units being converted to the unit of q so that they can
+// be combined, e.g. 'm' * 'cm' is 'm2'. Returns ErrSpecialUnit if either unit
+// is a special unit, such as 'Cel'.
+func (q Quantity) Mul(input Quantity) (Quantity, error) {
+	if isSpecial(q.unit) || isSpecial(input.unit) {
+		return Quantity{}, ErrSpecialUnit
+	}
+	input = input.commensurateWith(q)
+	return Quantity{q.value.Mul(input.value), ucum.Multiply(q.unit, input.unit)}.reduce(q, input), nil
+}
+
+// Div returns q / input. The unit of the result is the quotient of the units,
+// with commensurable units being converted to the unit of q so that they
+// cancel, e.g. 'kg' / 'g' is '1'. Returns ErrDivByZero if input is zero, and
+// ErrSpecialUnit if either unit is a special unit.
+func (q Quantity) Div(input Quantity) (Quantity, error) {
+	if isSpecial(q.unit) || isSpecial(input.unit) {
+		return Quantity{}, ErrSpecialUnit
+	}
+	input = input.commensurateWith(q)
+	value, err := q.value.Div(input.value)
+	if err != nil {
+		return Quantity{}, err
+	}
+	return Quantity{value, ucum.Divide(q.unit, input.unit)}.reduce(q, input), nil
+}
+
+// commensurateWith returns q converted to the unit of other, if the units
+// are commensurable. Otherwise q is returned unchanged.
+func (q Quantity) commensurateWith(other Quantity) Quantity {
+	if isDimensionless(q.unit) || isDimensionless(other.unit) {
+		return q
+	}
+	if converted, err := q.ToUnit(other.unit); err == nil {
+		return converted
+	}
+	return q
+}
+
+// reduce converts the result of an operation between the two operands to the
+// unit '1', if both operands have units and the result is dimensionless.
+func (q Quantity) reduce(left, right Quantity) Quantity {
+	if isDimensionless(left.unit) || isDimensionless(right.unit) || isDimensionless(q.unit) {
+		return q
+	}
+	if unit, err := ucum.Parse(q.unit); err != nil || unit.Canonical() != dimensionlessUnit {
+		return q
+	}
+	if converted, err := q.ToUnit(dimensionlessUnit); err == nil {
+		return converted
+	}
+	return q
+}
+
+// isSpecial returns true if unit is a UCUM unit that is not a ratio of its
+// canonical unit, such as 'Cel'.
+func isSpecial(unit string) bool {
+	parsed, err := ucum.Parse(unit)
+	return err == nil && parsed.Special()
+}
+
+func isDimensionless(unit string) bool {
+	return unit == "" || unit == dimensionlessUnit
+}
+
 // Name returns the type name.
 func (q Quantity) Name() string {
 	return quantityType
@@ -147,16 +342,46 @@ func (q Quantity) timeDuration() (time.Duration, error) {
 	case "second", "seconds":
 		milliseconds := decimal.Decimal(q.value).Round(3).Shift(3).IntPart() // Keep decimal precision below seconds
 		duration = time.Millisecond * time.Duration(milliseconds)
-	case "millisecond":
+	case "millisecond", "milliseconds":
 		duration = time.Millisecond * time.Duration(value)
 	default:
-		return time.Duration(0), fmt.Errorf("%w: not a time-valued unit", ErrMismatchedUnit)
+		milliseconds, ok := q.definiteDuration("ms")
+		if !ok {
+			return time.Duration(0), fmt.Errorf("%w: not a time-valued unit", ErrMismatchedUnit)
+		}
+		duration = time.Millisecond * time.Duration(milliseconds)
 	}
 	return duration, nil
 }
 
+// definiteDays returns the number of whole days in a Quantity with a UCUM
+// definite-duration unit, for date arithmetic. Returns an error if q is not a
+// UCUM duration.
+func (q Quantity) definiteDays() (int, error) {
+	days, ok := q.definiteDuration("d")
+	if !ok {
+		return 0, fmt.Errorf("%w: can't add to date", ErrMismatchedUnit)
+	}
+	return int(days), nil
+}
+
+// definiteDuration returns the number of whole periods of the given UCUM
+// unit in a Quantity with a UCUM definite-duration unit, such as 'a' or 'mo'.
+// Returns false if q is a calendar duration, or isn't a duration.
+func (q Quantity) definiteDuration(unit string) (int64, bool) {
+	if IsCalendarDuration(q.unit) {
+		return 0, false
+	}
+	value, err := ucum.Convert(decimal.Decimal(q.value).Rat(), q.unit, unit)
+	if err != nil {
+		return 0, false
+	}
+	return decimal.Decimal(ratToDecimal(value)).IntPart(), true
+}
+
 // Converts valid time based quantities to a number of years,
-// by rounding down.
+// by rounding down. Calendar durations are approximated with years of 365
+// days, whereas UCUM durations use the definite year 'a' of 365.25 days.
 func (q Quantity) toYears() (int, error) {
 	value := int(decimal.Decimal(q.value).IntPart())
 
@@ -179,12 +404,16 @@ func (q Quantity) toYears() (int, error) {
 	case "millisecond", "milliseconds":
 		return value / (365 * 24 * 60 * 60) / 1000, nil
 	default:
+		if periods, ok := q.definiteDuration("a"); ok {
+			return int(periods), nil
+		}
 		return 0, fmt.Errorf("%w: not a time-valued unit", ErrMismatchedUnit)
 	}
 }
 
 // Converts a valid time based quantity to a number of months,
-// by rounding down.
+// by rounding down. Calendar durations are approximated with months of 30
+// days, whereas UCUM durations use the definite month 'mo' of 30.4375 days.
 func (q Quantity) toMonths() (int, error) {
 	value := int(decimal.Decimal(q.value).IntPart())
 
@@ -207,6 +436,38 @@ func (q Quantity) toMonths() (int, error) {
 	case "millisecond", "milliseconds":
 		return value / (30 * 24 * 60 * 60) / 1000, nil
 	default:
+		if periods, ok := q.definiteDuration("mo"); ok {
+			return int(periods), nil
+		}
 		return 0, fmt.Errorf("%w: not a time-valued unit", ErrMismatchedUnit)
 	}
 }
+
+// ratToDecimal converts a rational number to a Decimal. The conversion is
+// exact if the number has a finite decimal representation, and is otherwise
+// rounded to decimal.DivisionPrecision places.
+func ratToDecimal(r *big.Rat) Decimal {
+	numerator := new(big.Int).Set(r.Num())
+	denominator := new(big.Int).Set(r.Denom())
+	two, five := big.NewInt(2), big.NewInt(5)
+	remainder := new(big.Int)
+
+	// Scale the numerator to cancel each factor of 2 and 5 in the denominator.
+	exponent := int32(0)
+	for {
+		if _, rem := new(big.Int).QuoRem(denominator, two, remainder); rem.Sign() == 0 {
+			denominator.Quo(denominator, two)
+			numerator.Mul(numerator, five)
+		} else if _, rem := new(big.Int).QuoRem(denominator, five, remainder); rem.Sign() == 0 {
+			denominator.Quo(denominator, five)
+			numerator.Mul(numerator, two)
+		} else {
+			break
+		}
+		exponent--
+	}
+	if denominator.Cmp(big.NewInt(1)) == 0 {
+		return Decimal(decimal.NewFromBigInt(numerator, exponent))
+	}
+	return Decimal(decimal.NewFromBigInt(r.Num(), 0).DivRound(decimal.NewFromBigInt(r.Denom(), 0), int32(decimal.DivisionPrecision)))
+}
@@ -1,6 +1,7 @@
 package system_test
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/verily-src/fhirpath-go/fhirpath/system"
@@ -60,6 +61,43 @@ func TestParseQuantity_ReturnsQuantity(t *testing.T) {
 	}
 }
 
+func TestValidateUnit(t *testing.T) {
+	testCases := []struct {
+		name    string
+		unit    string
+		wantErr error
+	}{
+		{"ucum unit", "mg/dL", nil},
+		{"dimensionless unit", "1", nil},
+		{"calendar duration", "days", nil},
+		{"singular calendar duration", "week", nil},
+		{"unknown unit", "lbs", system.ErrInvalidUnit},
+		{"malformed unit", "[[[", system.ErrInvalidUnit},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := system.ValidateUnit(tc.unit); !errors.Is(err, tc.wantErr) {
+				t.Errorf("ValidateUnit(%q) = %v, want %v", tc.unit, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseQuantity_AcceptsInvalidUnit(t *testing.T) {
+	got, err := system.ParseQuantity("1", "foo")
+	if err != nil {
+		t.Fatalf("ParseQuantity(1, foo) returned unexpected error: %v", err)
+	}
+
+	if !got.Equal(system.MustParseQuantity("1", "foo")) {
+		t.Errorf("ParseQuantity(1, foo) is not equal to a quantity with the same unit")
+	}
+	if got.Comparable(system.MustParseQuantity("1", "bar")) {
+		t.Errorf("ParseQuantity(1, foo) is comparable to a quantity with a different invalid unit")
+	}
+}
+
 func TestQuantity_Equal(t *testing.T) {
 	onePound, _ := system.ParseQuantity("1", "lbs")
 	oneLb, _ := system.ParseQuantity("1", "lbs")
@@ -115,3 +153,342 @@ func TestQuantity_Equal(t *testing.T) {
 		})
 	}
 }
+
+func TestQuantity_CommensurableUnits(t *testing.T) {
+	oneKg := system.MustParseQuantity("1", "kg")
+	thousandGrams := system.MustParseQuantity("1000", "g")
+	fiveHundredGrams := system.MustParseQuantity("500", "g")
+	oneLiter := system.MustParseQuantity("1", "L")
+
+	testCases := []struct {
+		name        string
+		quantityOne system.Quantity
+		quantityTwo system.Quantity
+		wantEqual   bool
+		wantLess    bool
+		wantOk      bool
+	}{
+		{
+			name:        "equal quantities",
+			quantityOne: oneKg,
+			quantityTwo: thousandGrams,
+			wantEqual:   true,
+			wantOk:      true,
+		},
+		{
+			name:        "greater quantity",
+			quantityOne: oneKg,
+			quantityTwo: fiveHundredGrams,
+			wantOk:      true,
+		},
+		{
+			name:        "lesser quantity",
+			quantityOne: fiveHundredGrams,
+			quantityTwo: oneKg,
+			wantLess:    true,
+			wantOk:      true,
+		},
+		{
+			name:        "incommensurable units",
+			quantityOne: oneKg,
+			quantityTwo: oneLiter,
+			wantOk:      false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gotEqual, ok := tc.quantityOne.TryEqual(tc.quantityTwo)
+			if ok != tc.wantOk {
+				t.Fatalf("Quantity.TryEqual: ok got %v, want %v", ok, tc.wantOk)
+			}
+			gotLess, err := tc.quantityOne.Less(tc.quantityTwo)
+			if gotErr := err != nil; gotErr == tc.wantOk {
+				t.Fatalf("Quantity.Less returned unexpected error: %v", err)
+			}
+			if gotEqual != tc.wantEqual {
+				t.Errorf("Quantity.TryEqual returned unexpected equality: got %v, want %v", gotEqual, tc.wantEqual)
+			}
+			if bool(gotLess) != tc.wantLess {
+				t.Errorf("Quantity.Less returned unexpected result: got %v, want %v", gotLess, tc.wantLess)
+			}
+		})
+	}
+}
+
+func TestQuantity_ToUnit(t *testing.T) {
+	testCases := []struct {
+		name     string
+		quantity system.Quantity
+		unit     string
+		want     system.Quantity
+		wantErr  bool
+	}{
+		{
+			name:     "same unit",
+			quantity: system.MustParseQuantity("13.5", "lbs"),
+			unit:     "lbs",
+			want:     system.MustParseQuantity("13.5", "lbs"),
+		},
+		{
+			name:     "prefixed unit",
+			quantity: system.MustParseQuantity("1.5", "kg"),
+			unit:     "g",
+			want:     system.MustParseQuantity("1500", "g"),
+		},
+		{
+			name:     "compound unit",
+			quantity: system.MustParseQuantity("5", "mg/dL"),
+			unit:     "mg/L",
+			want:     system.MustParseQuantity("50", "mg/L"),
+		},
+		{
+			name:     "customary unit",
+			quantity: system.MustParseQuantity("1", "[lb_av]"),
+			unit:     "g",
+			want:     system.MustParseQuantity("453.59237", "g"),
+		},
+		{
+			name:     "inexact conversion",
+			quantity: system.MustParseQuantity("1", "g"),
+			unit:     "[lb_av]",
+			want:     system.MustParseQuantity("0.0022046226218488", "[lb_av]"),
+		},
+		{
+			name:     "incommensurable units",
+			quantity: system.MustParseQuantity("1", "kg"),
+			unit:     "L",
+			wantErr:  true,
+		},
+		{
+			name:     "invalid unit",
+			quantity: system.MustParseQuantity("1", "lbs"),
+			unit:     "g",
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.quantity.ToUnit(tc.unit)
+
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("Quantity.ToUnit(%v) got error %v, want error %v", tc.unit, err, tc.wantErr)
+			}
+			if equal, _ := got.TryEqual(tc.want); !tc.wantErr && (!equal || got.Unit() != tc.want.Unit()) {
+				t.Errorf("Quantity.ToUnit(%v) = %v, want %v", tc.unit, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestQuantity_AddCommensurableUnits(t *testing.T) {
+	got, err := system.MustParseQuantity("1", "kg").Add(system.MustParseQuantity("500", "g"))
+	if err != nil {
+		t.Fatalf("Quantity.Add returned unexpected error: %v", err)
+	}
+
+	want := system.MustParseQuantity("1.5", "kg")
+	if equal, _ := got.TryEqual(want); !equal || got.Unit() != want.Unit() {
+		t.Errorf("Quantity.Add = %v, want %v", got, want)
+	}
+}
+
+func TestQuantity_MulAndDiv(t *testing.T) {
+	testCases := []struct {
+		name string
+		got  func() (system.Quantity, error)
+		want system.Quantity
+	}{
+		{
+			name: "multiplies by dimensionless quantity",
+			got: func() (system.Quantity, error) {
+				return system.MustParseQuantity("2", "mg").Mul(system.MustParseQuantity("3", "1"))
+			},
+			want: system.MustParseQuantity("6", "mg"),
+		},
+		{
+			name: "multiplies commensurable units",
+			got: func() (system.Quantity, error) {
+				return system.MustParseQuantity("2", "m").Mul(system.MustParseQuantity("50", "cm"))
+			},
+			want: system.MustParseQuantity("1", "m2"),
+		},
+		{
+			name: "multiplies units that cancel",
+			got: func() (system.Quantity, error) {
+				return system.MustParseQuantity("60", "km/h").Mul(system.MustParseQuantity("2", "h"))
+			},
+			want: system.MustParseQuantity("120", "km"),
+		},
+		{
+			name: "divides distinct units",
+			got: func() (system.Quantity, error) {
+				return system.MustParseQuantity("72", "kg").Div(system.MustParseQuantity("4", "m2"))
+			},
+			want: system.MustParseQuantity("18", "kg/m2"),
+		},
+		{
+			name: "divides commensurable units",
+			got: func() (system.Quantity, error) {
+				return system.MustParseQuantity("1", "kg").Div(system.MustParseQuantity("250", "g"))
+			},
+			want: system.MustParseQuantity("4", "1"),
+		},
+		{
+			name: "reduces dimensionless units",
+			got: func() (system.Quantity, error) {
+				return system.MustParseQuantity("5", "mg/dL").Div(system.MustParseQuantity("1", "g/L"))
+			},
+			want: system.MustParseQuantity("0.05", "1"),
+		},
+		{
+			name: "divides units that are not UCUM units",
+			got: func() (system.Quantity, error) {
+				return system.MustParseQuantity("10", "lbs").Div(system.MustParseQuantity("2", "lbs"))
+			},
+			want: system.MustParseQuantity("5", "1"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.got()
+			if err != nil {
+				t.Fatalf("returned unexpected error: %v", err)
+			}
+			if !got.Equal(tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestQuantity_DivByZero(t *testing.T) {
+	_, err := system.MustParseQuantity("1", "g").Div(system.MustParseQuantity("0", "L"))
+
+	if !errors.Is(err, system.ErrDivByZero) {
+		t.Errorf("Quantity.Div got error %v, want %v", err, system.ErrDivByZero)
+	}
+}
+
+func TestQuantity_MulAndDiv_ReturnsError_ForSpecialUnits(t *testing.T) {
+	testCases := []struct {
+		name string
+		op   func() (system.Quantity, error)
+	}{
+		{
+			name: "multiplies special units",
+			op: func() (system.Quantity, error) {
+				return system.MustParseQuantity("1", "Cel").Mul(system.MustParseQuantity("2", "Cel"))
+			},
+		},
+		{
+			name: "multiplies by special unit",
+			op: func() (system.Quantity, error) {
+				return system.MustParseQuantity("2", "1").Mul(system.MustParseQuantity("1", "[degF]"))
+			},
+		},
+		{
+			name: "divides special unit",
+			op: func() (system.Quantity, error) {
+				return system.MustParseQuantity("1", "Cel").Div(system.MustParseQuantity("2", "s"))
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := tc.op(); !errors.Is(err, system.ErrSpecialUnit) {
+				t.Errorf("got error %v, want %v", err, system.ErrSpecialUnit)
+			}
+		})
+	}
+}
+
+func TestQuantity_CalendarDurations(t *testing.T) {
+	testCases := []struct {
+		name           string
+		quantityOne    system.Quantity
+		quantityTwo    system.Quantity
+		wantEqual      bool
+		wantOk         bool
+		wantEquivalent bool
+	}{
+		{
+			name:           "singular and plural keywords",
+			quantityOne:    system.MustParseQuantity("1", "year"),
+			quantityTwo:    system.MustParseQuantity("1", "years"),
+			wantEqual:      true,
+			wantOk:         true,
+			wantEquivalent: true,
+		},
+		{
+			name:           "calendar years and months",
+			quantityOne:    system.MustParseQuantity("1", "year"),
+			quantityTwo:    system.MustParseQuantity("12", "months"),
+			wantEqual:      true,
+			wantOk:         true,
+			wantEquivalent: true,
+		},
+		{
+			name:           "calendar and UCUM years",
+			quantityOne:    system.MustParseQuantity("1", "year"),
+			quantityTwo:    system.MustParseQuantity("1", "a"),
+			wantOk:         false,
+			wantEquivalent: true,
+		},
+		{
+			name:           "calendar and UCUM months",
+			quantityOne:    system.MustParseQuantity("2", "months"),
+			quantityTwo:    system.MustParseQuantity("2", "mo"),
+			wantOk:         false,
+			wantEquivalent: true,
+		},
+		{
+			name:           "calendar years and days",
+			quantityOne:    system.MustParseQuantity("1", "year"),
+			quantityTwo:    system.MustParseQuantity("365", "days"),
+			wantOk:         false,
+			wantEquivalent: true,
+		},
+		{
+			name:           "calendar years and fewer days",
+			quantityOne:    system.MustParseQuantity("1", "year"),
+			quantityTwo:    system.MustParseQuantity("300", "days"),
+			wantOk:         false,
+			wantEquivalent: false,
+		},
+		{
+			name:           "calendar and UCUM weeks",
+			quantityOne:    system.MustParseQuantity("1", "week"),
+			quantityTwo:    system.MustParseQuantity("7", "d"),
+			wantEqual:      true,
+			wantOk:         true,
+			wantEquivalent: true,
+		},
+		{
+			name:           "calendar and UCUM seconds",
+			quantityOne:    system.MustParseQuantity("1", "second"),
+			quantityTwo:    system.MustParseQuantity("1000", "ms"),
+			wantEqual:      true,
+			wantOk:         true,
+			wantEquivalent: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gotEqual, ok := tc.quantityOne.TryEqual(tc.quantityTwo)
+			if ok != tc.wantOk {
+				t.Fatalf("Quantity.TryEqual: ok got %v, want %v", ok, tc.wantOk)
+			}
+			if gotEqual != tc.wantEqual {
+				t.Errorf("Quantity.TryEqual returned unexpected equality: got %v, want %v", gotEqual, tc.wantEqual)
+			}
+			if got := system.Equivalent(tc.quantityOne, tc.quantityTwo); got != tc.wantEquivalent {
+				t.Errorf("Equivalent returned unexpected result: got %v, want %v", got, tc.wantEquivalent)
+			}
+		})
+	}
+}
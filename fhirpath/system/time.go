@@ -175,9 +175,9 @@ func (t Time) Sub(input Quantity) (Time, error) {
 func roundToTimePrecision(p timePrecision, d time.Duration) time.Duration {
 	switch p {
 	case hour:
-		return d / time.Hour
+		return d.Truncate(time.Hour)
 	case minute:
-		return d / time.Minute
+		return d.Truncate(time.Minute)
 	default:
 		return d
 	}
@@ -190,3 +190,70 @@ func (t Time) getComponents() []int {
 		t.time.Second()*1000000000 + t.time.Nanosecond(),
 	}
 }
+
+// timeDigitLayouts maps the number of digits in a Time to its layout.
+var timeDigitLayouts = map[int]layout{
+	2: hourLayout,
+	4: minuteLayout,
+	6: secondLayout,
+	9: millisecondLayout,
+}
+
+// Precision returns the number of digits in t. Eg. @T10:30 has a
+// precision of 4.
+func (t Time) Precision() int {
+	return levelDigits[layoutLevels[t.l]] - levelDigits[DayComponent]
+}
+
+// LowBoundary returns the earliest possible Time represented by t, at the
+// given precision. Returns false if the precision is not a valid number of
+// digits for a Time. Eg. @T10:30 has a low boundary of @T10:30:00.000 at a
+// precision of 9.
+func (t Time) LowBoundary(precision int) (Time, bool) {
+	l, ok := timeDigitLayouts[precision]
+	if !ok {
+		return Time{}, false
+	}
+	return Time{lowBoundaryAt(t.time, layoutLevels[t.l], layoutLevels[l]), l}, true
+}
+
+// HighBoundary returns the latest possible Time represented by t, at the
+// given precision. Returns false if the precision is not a valid number of
+// digits for a Time. Eg. @T10:30 has a high boundary of @T10:30:59.999 at a
+// precision of 9.
+func (t Time) HighBoundary(precision int) (Time, bool) {
+	l, ok := timeDigitLayouts[precision]
+	if !ok {
+		return Time{}, false
+	}
+	return Time{highBoundaryAt(t.time, layoutLevels[t.l], layoutLevels[l]), l}, true
+}
+
+// Component returns the value of the given component of t. Returns false if
+// c is not a component of a Time, or if t is not precise enough to have it.
+func (t Time) Component(c Component) (Integer, bool) {
+	if c < HourComponent || c > layoutLevels[t.l] {
+		return 0, false
+	}
+	return Integer(timeComponents(t.time)[c]), true
+}
+
+// Difference returns the number of boundaries of the given calendar duration
+// unit crossed between t and other. Returns false if the unit is not a valid
+// calendar duration for a Time, or if either Time is not precise enough for it.
+func (t Time) Difference(other Time, unit string) (Quantity, bool) {
+	if level, ok := calendarUnits[unit]; !ok || level < HourComponent {
+		return Quantity{}, false
+	}
+	return calendarDuration(t.time, other.time, layoutLevels[t.l], layoutLevels[other.l], unit, false)
+}
+
+// Duration returns the number of whole periods of the given calendar duration
+// unit between t and other. Returns false if the unit is not a valid calendar
+// duration for a Time, or if either Time is not precise enough for it.
+func (t Time) Duration(other Time, unit string) (Quantity, bool) {
+	if level, ok := calendarUnits[unit]; !ok || level < HourComponent {
+		return Quantity{}, false
+	}
+	return calendarDuration(t.time, other.time, layoutLevels[t.l], layoutLevels[other.l], unit, true)
+}
@@ -279,6 +279,12 @@ func TestTimeAdd_ReturnsSum(t *testing.T) {
 			input:   system.MustParseQuantity("12", "kg"),
 			wantErr: system.ErrMismatchedUnit,
 		},
+		{
+			name:  "adds UCUM duration",
+			time:  system.MustParseTime("08:00:00"),
+			input: system.MustParseQuantity("90", "min"),
+			want:  system.MustParseTime("09:30:00"),
+		},
 	}
 
 	for _, tc := range testCases {
@@ -345,6 +351,12 @@ func TestTimeSub_ReturnsResult(t *testing.T) {
 			input:   system.MustParseQuantity("12", "kg"),
 			wantErr: system.ErrMismatchedUnit,
 		},
+		{
+			name:  "subtracts hours from a minute partial",
+			time:  system.MustParseTime("10:30"),
+			input: system.MustParseQuantity("2", "h"),
+			want:  system.MustParseTime("08:30"),
+		},
 	}
 
 	for _, tc := range testCases {
@@ -381,3 +393,94 @@ func changeLocale(t *testing.T) {
 		}
 	})
 }
+
+func TestTimeBoundaries(t *testing.T) {
+	testCases := []struct {
+		name          string
+		time          system.Time
+		precision     int
+		wantPrecision int
+		wantLow       system.Time
+		wantHigh      system.Time
+		wantOK        bool
+	}{
+		{
+			name:          "extends minutes to millisecond precision",
+			time:          system.MustParseTime("10:30"),
+			precision:     9,
+			wantPrecision: 4,
+			wantLow:       system.MustParseTime("10:30:00.000"),
+			wantHigh:      system.MustParseTime("10:30:59.999"),
+			wantOK:        true,
+		},
+		{
+			name:          "extends hour to the end of the day",
+			time:          system.MustParseTime("23"),
+			precision:     6,
+			wantPrecision: 2,
+			wantLow:       system.MustParseTime("23:00:00"),
+			wantHigh:      system.MustParseTime("23:59:59"),
+			wantOK:        true,
+		},
+		{
+			name:          "truncates to lower precision",
+			time:          system.MustParseTime("10:30:15.250"),
+			precision:     2,
+			wantPrecision: 9,
+			wantLow:       system.MustParseTime("10"),
+			wantHigh:      system.MustParseTime("10"),
+			wantOK:        true,
+		},
+		{
+			name:          "returns false for invalid precision",
+			time:          system.MustParseTime("10:30"),
+			precision:     8,
+			wantPrecision: 4,
+			wantOK:        false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.time.Precision(); got != tc.wantPrecision {
+				t.Errorf("Time.Precision returned unexpected result: got %v, want %v", got, tc.wantPrecision)
+			}
+			low, lowOK := tc.time.LowBoundary(tc.precision)
+			high, highOK := tc.time.HighBoundary(tc.precision)
+
+			if lowOK != tc.wantOK || highOK != tc.wantOK {
+				t.Fatalf("Time boundaries returned unexpected ok: got (%v, %v), want %v", lowOK, highOK, tc.wantOK)
+			}
+			if diff := cmp.Diff(tc.wantLow, low); diff != "" {
+				t.Errorf("Time.LowBoundary returned unexpected result: (-want, +got)\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantHigh, high); diff != "" {
+				t.Errorf("Time.HighBoundary returned unexpected result: (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTimeComponentAndDuration(t *testing.T) {
+	from := system.MustParseTime("10:30:15.250")
+	to := system.MustParseTime("12:15")
+
+	if got, ok := from.Component(system.MillisecondComponent); got != 250 || !ok {
+		t.Errorf("Time.Component returned unexpected result: got (%v, %v), want (250, true)", got, ok)
+	}
+	if _, ok := from.Component(system.DayComponent); ok {
+		t.Errorf("Time.Component returned a day component")
+	}
+	if got, ok := from.Duration(to, "hour"); !ok || !got.Equal(system.MustParseQuantity("1", "hours")) {
+		t.Errorf("Time.Duration returned unexpected result: got (%v, %v), want (1 hours, true)", got, ok)
+	}
+	if got, ok := from.Difference(to, "minutes"); !ok || !got.Equal(system.MustParseQuantity("105", "minutes")) {
+		t.Errorf("Time.Difference returned unexpected result: got (%v, %v), want (105 minutes, true)", got, ok)
+	}
+	if _, ok := from.Difference(to, "seconds"); ok {
+		t.Errorf("Time.Difference returned a result for a value without seconds")
+	}
+	if _, ok := from.Duration(to, "days"); ok {
+		t.Errorf("Time.Duration returned a result for days")
+	}
+}
@@ -27,6 +27,7 @@ type Any interface {
 func (s String) isSystemType()   {}
 func (b Boolean) isSystemType()  {}
 func (i Integer) isSystemType()  {}
+func (l Long) isSystemType()     {}
 func (d Decimal) isSystemType()  {}
 func (d Date) isSystemType()     {}
 func (t Time) isSystemType()     {}
@@ -37,7 +38,7 @@ func (q Quantity) isSystemType() {}
 // a valid system type name.
 func IsValid(typeName string) bool {
 	switch typeName {
-	case stringType, booleanType, integerType, decimalType,
+	case stringType, booleanType, integerType, longType, decimalType,
 		dateType, timeType, dateTimeType, quantityType, anyType:
 		return true
 	default:
@@ -122,6 +123,9 @@ func From(input any) (Any, error) {
 		return value, nil
 	case *dtpb.Quantity:
 		unit := v.GetUnit().GetValue()
+		if v.GetSystem().GetValue() == ucumSystem && v.GetCode().GetValue() != "" {
+			unit = v.GetCode().GetValue()
+		}
 		if v.GetValue() == nil {
 			return Quantity{unit: unit}, nil
 		}
@@ -148,6 +152,9 @@ func From(input any) (Any, error) {
 func Normalize(from Any, to Any) Any {
 	switch v := from.(type) {
 	case Integer:
+		if _, ok := to.(Long); ok {
+			return Long(v)
+		}
 		if _, ok := to.(Decimal); ok {
 			return Decimal(decimal.NewFromInt32(int32(v)))
 		}
@@ -155,6 +162,14 @@ func Normalize(from Any, to Any) Any {
 			dec := Decimal(decimal.NewFromInt32(int32(v)))
 			return Quantity{dec, q.unit}
 		}
+	case Long:
+		if _, ok := to.(Decimal); ok {
+			return Decimal(decimal.NewFromInt(int64(v)))
+		}
+		if q, ok := to.(Quantity); ok {
+			dec := Decimal(decimal.NewFromInt(int64(v)))
+			return Quantity{dec, q.unit}
+		}
 	case Decimal:
 		if q, ok := to.(Quantity); ok {
 			return Quantity{v, q.unit}
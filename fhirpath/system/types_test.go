@@ -151,6 +151,17 @@ var testCases []testCase = []testCase{
 		want:       quantity,
 		shouldCast: true,
 	},
+	{
+		name: "converts quantity with UCUM code",
+		input: &dtpb.Quantity{
+			Value:  fhir.Decimal(1.234),
+			Unit:   fhir.String("meters"),
+			Code:   fhir.Code("m"),
+			System: fhir.URI("http://unitsofmeasure.org"),
+		},
+		want:       quantity,
+		shouldCast: true,
+	},
 	{
 		name: "converts quantity without value",
 		input: &dtpb.Quantity{
@@ -0,0 +1,183 @@
+package terminology
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	cpb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/codes_go_proto"
+	cspb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/code_system_go_proto"
+	vspb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/value_set_go_proto"
+)
+
+// codeSystem indexes the concepts of a CodeSystem resource by code, along
+// with the parents of each concept.
+type codeSystem struct {
+	resource *cspb.CodeSystem
+	// codes holds the codes of the code system, in definition order.
+	codes    []string
+	concepts map[string]*cspb.CodeSystem_ConceptDefinition
+	parents  map[string][]string
+
+	patternsMu sync.Mutex
+	// patterns holds the compiled patterns of regex filters, by pattern.
+	patterns map[string]*regexp.Regexp
+}
+
+func newCodeSystem(resource *cspb.CodeSystem) *codeSystem {
+	cs := &codeSystem{
+		resource: resource,
+		concepts: map[string]*cspb.CodeSystem_ConceptDefinition{},
+		parents:  map[string][]string{},
+		patterns: map[string]*regexp.Regexp{},
+	}
+	cs.addConcepts(resource.GetConcept(), "")
+	return cs
+}
+
+// addConcepts indexes the given concepts, and their nested concepts, which
+// are children of the given parent code.
+func (cs *codeSystem) addConcepts(concepts []*cspb.CodeSystem_ConceptDefinition, parent string) {
+	for _, concept := range concepts {
+		code := concept.GetCode().GetValue()
+		if _, ok := cs.concepts[code]; !ok {
+			cs.codes = append(cs.codes, code)
+			cs.concepts[code] = concept
+		}
+		if parent != "" {
+			cs.parents[code] = append(cs.parents[code], parent)
+		}
+		for _, property := range concept.GetProperty() {
+			switch property.GetCode().GetValue() {
+			case "parent", "subsumedBy":
+				if value := property.GetValue().GetCode().GetValue(); value != "" {
+					cs.parents[code] = append(cs.parents[code], value)
+				}
+			}
+		}
+		cs.addConcepts(concept.GetConcept(), code)
+	}
+}
+
+// has returns true if the code is defined by the code system.
+func (cs *codeSystem) has(code string) bool {
+	_, ok := cs.concepts[code]
+	return ok
+}
+
+// display returns the display of the given code, if any.
+func (cs *codeSystem) display(code string) string {
+	return cs.concepts[code].GetDisplay().GetValue()
+}
+
+// descendantOf returns true if the code is a strict descendant of the given
+// ancestor code.
+func (cs *codeSystem) descendantOf(code, ancestor string) bool {
+	visited := map[string]bool{code: true}
+	queue := cs.parents[code]
+	for len(queue) > 0 {
+		parent := queue[0]
+		queue = queue[1:]
+		if parent == ancestor {
+			return true
+		}
+		if visited[parent] {
+			continue
+		}
+		visited[parent] = true
+		queue = append(queue, cs.parents[parent]...)
+	}
+	return false
+}
+
+// properties returns the values of the named property of the given code, as
+// strings. The "code", "concept" and "display" properties refer to the
+// concept itself.
+func (cs *codeSystem) properties(code, name string) []string {
+	concept := cs.concepts[code]
+	switch name {
+	case "code", "concept":
+		return []string{code}
+	case "display":
+		return []string{concept.GetDisplay().GetValue()}
+	}
+	var values []string
+	for _, property := range concept.GetProperty() {
+		if property.GetCode().GetValue() != name {
+			continue
+		}
+		value := property.GetValue()
+		switch {
+		case value.GetCode() != nil:
+			values = append(values, value.GetCode().GetValue())
+		case value.GetStringValue() != nil:
+			values = append(values, value.GetStringValue().GetValue())
+		case value.GetCoding() != nil:
+			values = append(values, value.GetCoding().GetCode().GetValue())
+		case value.GetBoolean() != nil:
+			values = append(values, fmt.Sprint(value.GetBoolean().GetValue()))
+		case value.GetInteger() != nil:
+			values = append(values, fmt.Sprint(value.GetInteger().GetValue()))
+		}
+	}
+	return values
+}
+
+// matches returns true if the code is defined by the code system and
+// satisfies the given filter of a ValueSet compose element.
+func (cs *codeSystem) matches(filter *vspb.ValueSet_Compose_ConceptSet_Filter, code string) (bool, error) {
+	if !cs.has(code) {
+		return false, nil
+	}
+	property := filter.GetProperty().GetValue()
+	value := filter.GetValue().GetValue()
+	switch op := filter.GetOp().GetValue(); op {
+	case cpb.FilterOperatorCode_IS_A:
+		if property != "concept" {
+			return false, fmt.Errorf("%w: property '%s' with is-a", ErrUnsupportedFilter, property)
+		}
+		return code == value || cs.descendantOf(code, value), nil
+	case cpb.FilterOperatorCode_DESCENDENT_OF:
+		if property != "concept" {
+			return false, fmt.Errorf("%w: property '%s' with descendant-of", ErrUnsupportedFilter, property)
+		}
+		return cs.descendantOf(code, value), nil
+	case cpb.FilterOperatorCode_EQUALS:
+		for _, got := range cs.properties(code, property) {
+			if got == value {
+				return true, nil
+			}
+		}
+		return false, nil
+	case cpb.FilterOperatorCode_REGEX:
+		re, err := cs.pattern(value)
+		if err != nil {
+			return false, err
+		}
+		for _, got := range cs.properties(code, property) {
+			if re.MatchString(got) {
+				return true, nil
+			}
+		}
+		return false, nil
+	default:
+		return false, fmt.Errorf("%w: operator %v", ErrUnsupportedFilter, op)
+	}
+}
+
+// pattern returns the compiled pattern of a regex filter, which must match
+// the whole property value. Each pattern is compiled once, as a filter is
+// matched against every code of the code system.
+func (cs *codeSystem) pattern(value string) (*regexp.Regexp, error) {
+	cs.patternsMu.Lock()
+	defer cs.patternsMu.Unlock()
+	if re, ok := cs.patterns[value]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile("^(?:" + value + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid regex '%s'", ErrUnsupportedFilter, value)
+	}
+	cs.patterns[value] = re
+	return re, nil
+}
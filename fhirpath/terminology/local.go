@@ -0,0 +1,438 @@
+package terminology
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	dtpb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/datatypes_go_proto"
+	bcrpb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/bundle_and_contained_resource_go_proto"
+	cspb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/code_system_go_proto"
+	pgp "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/parameters_go_proto"
+	vspb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/value_set_go_proto"
+	"github.com/verily-src/fhirpath-go/internal/containedresource"
+	"github.com/verily-src/fhirpath-go/internal/fhir"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	ErrUnknownValueSet   = errors.New("unknown value set")
+	ErrUnknownCodeSystem = errors.New("unknown code system")
+	ErrUnsupportedFilter = errors.New("unsupported value set filter")
+	ErrCircularValueSet  = errors.New("value set includes itself")
+)
+
+// LocalService is a Service which answers terminology operations from
+// ValueSet and CodeSystem resources held in memory, without a terminology
+// server. Value sets are evaluated from their precomputed expansion, if they
+// have one, or otherwise from their compose element. Supported compose
+// elements are concept lists, nested value sets, and the is-a,
+// descendant-of, = and regex filters.
+//
+// Besides ValueSetValidateCode, LocalService implements the Expander,
+// Subsumer and CodeSystemValidator operations.
+type LocalService struct {
+	// valueSets holds value sets by id, canonical URL and versioned
+	// canonical URL.
+	valueSets map[string]*vspb.ValueSet
+	// codeSystems holds code systems by canonical URL and versioned
+	// canonical URL.
+	codeSystems map[string]*codeSystem
+}
+
+var (
+	_ Service             = (*LocalService)(nil)
+	_ Expander            = (*LocalService)(nil)
+	_ Subsumer            = (*LocalService)(nil)
+	_ CodeSystemValidator = (*LocalService)(nil)
+)
+
+// NewLocalService returns a LocalService for the given ValueSet and
+// CodeSystem resources. Other resources are ignored. When several value sets
+// or code systems share a canonical URL, the last one is used unless a
+// version is requested.
+func NewLocalService(resources []fhir.Resource) *LocalService {
+	service := &LocalService{
+		valueSets:   map[string]*vspb.ValueSet{},
+		codeSystems: map[string]*codeSystem{},
+	}
+	for _, resource := range resources {
+		switch resource := resource.(type) {
+		case *vspb.ValueSet:
+			if id := resource.GetId().GetValue(); id != "" {
+				service.valueSets[id] = resource
+			}
+			for _, key := range canonicalKeys(resource.GetUrl(), resource.GetVersion()) {
+				service.valueSets[key] = resource
+			}
+		case *cspb.CodeSystem:
+			cs := newCodeSystem(resource)
+			for _, key := range canonicalKeys(resource.GetUrl(), resource.GetVersion()) {
+				service.codeSystems[key] = cs
+			}
+		}
+	}
+	return service
+}
+
+// NewLocalServiceFromBundle returns a LocalService for the ValueSet and
+// CodeSystem entries of the given bundle.
+func NewLocalServiceFromBundle(bundle *bcrpb.Bundle) *LocalService {
+	var resources []fhir.Resource
+	for _, entry := range bundle.GetEntry() {
+		if resource := containedresource.Unwrap(entry.GetResource()); resource != nil {
+			resources = append(resources, resource)
+		}
+	}
+	return NewLocalService(resources)
+}
+
+// ValueSetValidateCode validates the code against the value set with the
+// given canonical URL or id. A code without a system matches codes of any
+// system in the value set. If a display is given, it must match the display
+// of the code in its code system, if known. The result is a Parameters
+// resource with a boolean 'result', and a 'display' or 'message'.
+func (s *LocalService) ValueSetValidateCode(ctx context.Context, opts *ValueSetValidateCodeOptions) (*pgp.Parameters, error) {
+	id := opts.URL
+	if id == "" {
+		id = opts.ID
+	}
+	vs, err := s.valueSet(id, opts.ValueSetVersion)
+	if err != nil {
+		return nil, err
+	}
+	found, err := s.contains(vs, opts.System, opts.Code, map[*vspb.ValueSet]bool{})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		message := fmt.Sprintf("code '%s' from system '%s' is not in value set '%s'", opts.Code, opts.System, id)
+		return validationResult(false, "", message), nil
+	}
+	display := ""
+	if cs, err := s.codeSystem(opts.System, opts.SystemVersion); err == nil {
+		display = cs.display(opts.Code)
+	}
+	if opts.Display != "" && display != "" && opts.Display != display {
+		message := fmt.Sprintf("display '%s' does not match '%s'", opts.Display, display)
+		return validationResult(false, display, message), nil
+	}
+	return validationResult(true, display, ""), nil
+}
+
+// CodeSystemValidateCode validates that the code is defined by the code
+// system with the given canonical URL.
+func (s *LocalService) CodeSystemValidateCode(ctx context.Context, opts *CodeSystemValidateCodeOptions) (*pgp.Parameters, error) {
+	cs, err := s.codeSystem(opts.URL, opts.Version)
+	if err != nil {
+		return nil, err
+	}
+	if !cs.has(opts.Code) {
+		message := fmt.Sprintf("code '%s' is not in code system '%s'", opts.Code, opts.URL)
+		return validationResult(false, "", message), nil
+	}
+	display := cs.display(opts.Code)
+	if opts.Display != "" && opts.Display != display {
+		message := fmt.Sprintf("display '%s' does not match '%s'", opts.Display, display)
+		return validationResult(false, display, message), nil
+	}
+	return validationResult(true, display, ""), nil
+}
+
+// Subsumes tests the subsumption relationship between two codes of the code
+// system, following the hierarchy of its concepts and their 'parent' and
+// 'subsumedBy' properties.
+func (s *LocalService) Subsumes(ctx context.Context, opts *SubsumesOptions) (*pgp.Parameters, error) {
+	cs, err := s.codeSystem(opts.System, opts.Version)
+	if err != nil {
+		return nil, err
+	}
+	for _, code := range []string{opts.CodeA, opts.CodeB} {
+		if !cs.has(code) {
+			return nil, fmt.Errorf("code '%s' is not in code system '%s'", code, opts.System)
+		}
+	}
+	outcome := "not-subsumed"
+	switch {
+	case opts.CodeA == opts.CodeB:
+		outcome = "equivalent"
+	case cs.descendantOf(opts.CodeB, opts.CodeA):
+		outcome = "subsumes"
+	case cs.descendantOf(opts.CodeA, opts.CodeB):
+		outcome = "subsumed-by"
+	}
+	return &pgp.Parameters{
+		Parameter: []*pgp.Parameters_Parameter{
+			parameter("outcome", &pgp.Parameters_Parameter_ValueX{
+				Choice: &pgp.Parameters_Parameter_ValueX_Code{Code: fhir.Code(outcome)},
+			}),
+		},
+	}, nil
+}
+
+// Expand returns a copy of the value set with the given id or canonical URL,
+// with its expansion. A precomputed expansion is returned as is, in the copy.
+func (s *LocalService) Expand(ctx context.Context, opts *ExpandOptions) (*vspb.ValueSet, error) {
+	vs, err := s.valueSet(opts.URL, opts.Params.Get("valueSetVersion"))
+	if err != nil {
+		return nil, err
+	}
+	if len(vs.GetExpansion().GetContains()) > 0 {
+		return proto.Clone(vs).(*vspb.ValueSet), nil
+	}
+	candidates, err := s.candidates(vs, map[*vspb.ValueSet]bool{})
+	if err != nil {
+		return nil, err
+	}
+	var contains []*vspb.ValueSet_Expansion_Contains
+	seen := map[string]bool{}
+	for _, candidate := range candidates {
+		system, code := candidate.GetSystem().GetValue(), candidate.GetCode().GetValue()
+		if key := system + "|" + code; !seen[key] {
+			seen[key] = true
+			found, err := s.contains(vs, system, code, map[*vspb.ValueSet]bool{})
+			if err != nil {
+				return nil, err
+			}
+			if found {
+				contains = append(contains, candidate)
+			}
+		}
+	}
+	expanded := proto.Clone(vs).(*vspb.ValueSet)
+	expanded.Expansion = &vspb.ValueSet_Expansion{
+		Timestamp: fhir.DateTimeNow(),
+		Total:     fhir.Integer(int32(len(contains))),
+		Contains:  contains,
+	}
+	return expanded, nil
+}
+
+// canonicalKeys returns the keys under which a resource with the given
+// canonical URL and version is held.
+func canonicalKeys(url *dtpb.Uri, version *dtpb.String) []string {
+	if url.GetValue() == "" {
+		return nil
+	}
+	keys := []string{url.GetValue()}
+	if version.GetValue() != "" {
+		keys = append(keys, url.GetValue()+"|"+version.GetValue())
+	}
+	return keys
+}
+
+// valueSet returns the value set with the given id or canonical URL, and
+// version if one is given.
+func (s *LocalService) valueSet(id, version string) (*vspb.ValueSet, error) {
+	key := id
+	if version != "" {
+		key = id + "|" + version
+	}
+	if vs, ok := s.valueSets[key]; ok {
+		return vs, nil
+	}
+	if vs, ok := s.valueSets[id]; ok && (version == "" || vs.GetVersion().GetValue() == version) {
+		return vs, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownValueSet, key)
+}
+
+// codeSystem returns the code system with the given canonical URL, and
+// version if one is given.
+func (s *LocalService) codeSystem(url, version string) (*codeSystem, error) {
+	key := url
+	if version != "" {
+		key = url + "|" + version
+	}
+	if cs, ok := s.codeSystems[key]; ok {
+		return cs, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownCodeSystem, key)
+}
+
+// contains returns true if the code is a member of the value set. The
+// visiting set holds the value sets being evaluated, to detect cycles.
+func (s *LocalService) contains(vs *vspb.ValueSet, system, code string, visiting map[*vspb.ValueSet]bool) (bool, error) {
+	if contains := vs.GetExpansion().GetContains(); len(contains) > 0 {
+		return expansionContains(contains, system, code), nil
+	}
+	if visiting[vs] {
+		return false, fmt.Errorf("%w: %s", ErrCircularValueSet, vs.GetUrl().GetValue())
+	}
+	visiting[vs] = true
+	defer delete(visiting, vs)
+
+	included := false
+	for _, include := range vs.GetCompose().GetInclude() {
+		ok, err := s.inConceptSet(include, system, code, visiting)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return false, nil
+	}
+	for _, exclude := range vs.GetCompose().GetExclude() {
+		ok, err := s.inConceptSet(exclude, system, code, visiting)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// inConceptSet returns true if the code matches the given include or
+// exclude element of a value set compose. All criteria of the element must
+// match: its system, its nested value sets, and its concepts or filters.
+func (s *LocalService) inConceptSet(set *vspb.ValueSet_Compose_ConceptSet, system, code string, visiting map[*vspb.ValueSet]bool) (bool, error) {
+	setSystem := set.GetSystem().GetValue()
+	if setSystem != "" && system != "" && setSystem != system {
+		return false, nil
+	}
+	for _, canonical := range set.GetValueSet() {
+		imported, ok := s.valueSets[canonical.GetValue()]
+		if !ok {
+			return false, fmt.Errorf("%w: %s", ErrUnknownValueSet, canonical.GetValue())
+		}
+		found, err := s.contains(imported, system, code, visiting)
+		if err != nil || !found {
+			return false, err
+		}
+	}
+	if setSystem == "" {
+		return len(set.GetValueSet()) > 0, nil
+	}
+	if concepts := set.GetConcept(); len(concepts) > 0 {
+		for _, concept := range concepts {
+			if concept.GetCode().GetValue() == code {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	cs, err := s.codeSystem(setSystem, set.GetVersion().GetValue())
+	if err != nil {
+		return false, err
+	}
+	if !cs.has(code) {
+		return false, nil
+	}
+	for _, filter := range set.GetFilter() {
+		ok, err := cs.matches(filter, code)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// candidates returns the codes that may be members of the value set, to be
+// filtered when expanding it.
+func (s *LocalService) candidates(vs *vspb.ValueSet, visiting map[*vspb.ValueSet]bool) ([]*vspb.ValueSet_Expansion_Contains, error) {
+	if contains := vs.GetExpansion().GetContains(); len(contains) > 0 {
+		return flatten(contains), nil
+	}
+	if visiting[vs] {
+		return nil, fmt.Errorf("%w: %s", ErrCircularValueSet, vs.GetUrl().GetValue())
+	}
+	visiting[vs] = true
+	defer delete(visiting, vs)
+
+	var result []*vspb.ValueSet_Expansion_Contains
+	for _, include := range vs.GetCompose().GetInclude() {
+		system := include.GetSystem().GetValue()
+		switch {
+		case len(include.GetConcept()) > 0:
+			for _, concept := range include.GetConcept() {
+				result = append(result, &vspb.ValueSet_Expansion_Contains{
+					System:  include.GetSystem(),
+					Code:    concept.GetCode(),
+					Display: concept.GetDisplay(),
+				})
+			}
+		case system != "":
+			cs, err := s.codeSystem(system, include.GetVersion().GetValue())
+			if err != nil {
+				return nil, err
+			}
+			for _, code := range cs.codes {
+				result = append(result, &vspb.ValueSet_Expansion_Contains{
+					System:  include.GetSystem(),
+					Code:    cs.concepts[code].GetCode(),
+					Display: cs.concepts[code].GetDisplay(),
+				})
+			}
+		default:
+			for _, canonical := range include.GetValueSet() {
+				imported, ok := s.valueSets[canonical.GetValue()]
+				if !ok {
+					return nil, fmt.Errorf("%w: %s", ErrUnknownValueSet, canonical.GetValue())
+				}
+				contains, err := s.candidates(imported, visiting)
+				if err != nil {
+					return nil, err
+				}
+				result = append(result, contains...)
+			}
+		}
+	}
+	return result, nil
+}
+
+// expansionContains returns true if the code is in the given expansion,
+// including its nested contains elements. An empty system matches any system.
+func expansionContains(contains []*vspb.ValueSet_Expansion_Contains, system, code string) bool {
+	for _, item := range flatten(contains) {
+		if (system == "" || item.GetSystem().GetValue() == system) && item.GetCode().GetValue() == code {
+			return true
+		}
+	}
+	return false
+}
+
+// flatten returns the given expansion contains elements, and their nested
+// contains elements, excluding abstract codes.
+func flatten(contains []*vspb.ValueSet_Expansion_Contains) []*vspb.ValueSet_Expansion_Contains {
+	var result []*vspb.ValueSet_Expansion_Contains
+	for _, item := range contains {
+		if !item.GetAbstract().GetValue() && item.GetCode() != nil {
+			result = append(result, &vspb.ValueSet_Expansion_Contains{
+				System:  item.GetSystem(),
+				Version: item.GetVersion(),
+				Code:    item.GetCode(),
+				Display: item.GetDisplay(),
+			})
+		}
+		result = append(result, flatten(item.GetContains())...)
+	}
+	return result
+}
+
+func validationResult(result bool, display, message string) *pgp.Parameters {
+	params := &pgp.Parameters{
+		Parameter: []*pgp.Parameters_Parameter{
+			parameter("result", &pgp.Parameters_Parameter_ValueX{
+				Choice: &pgp.Parameters_Parameter_ValueX_Boolean{Boolean: fhir.Boolean(result)},
+			}),
+		},
+	}
+	for _, param := range []struct{ name, value string }{{"display", display}, {"message", message}} {
+		if param.value != "" {
+			params.Parameter = append(params.Parameter, parameter(param.name, &pgp.Parameters_Parameter_ValueX{
+				Choice: &pgp.Parameters_Parameter_ValueX_StringValue{StringValue: fhir.String(param.value)},
+			}))
+		}
+	}
+	return params
+}
+
+func parameter(name string, value *pgp.Parameters_Parameter_ValueX) *pgp.Parameters_Parameter {
+	return &pgp.Parameters_Parameter{Name: fhir.String(name), Value: value}
+}
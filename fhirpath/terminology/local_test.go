@@ -0,0 +1,356 @@
+package terminology_test
+
+import (
+	"context"
+	"testing"
+
+	cpb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/codes_go_proto"
+	dtpb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/datatypes_go_proto"
+	bcrpb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/bundle_and_contained_resource_go_proto"
+	cspb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/code_system_go_proto"
+	pgp "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/parameters_go_proto"
+	vspb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/value_set_go_proto"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/verily-src/fhirpath-go/fhirpath/terminology"
+	"github.com/verily-src/fhirpath-go/internal/containedresource"
+	"github.com/verily-src/fhirpath-go/internal/fhir"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+const (
+	conditionsURL = "http://example.com/CodeSystem/conditions"
+	diabetes      = "73211009"
+	type1         = "46635009"
+	type2         = "44054006"
+	gestational   = "11687002"
+	hypertension  = "38341003"
+)
+
+func concept(code, display string, children ...*cspb.CodeSystem_ConceptDefinition) *cspb.CodeSystem_ConceptDefinition {
+	return &cspb.CodeSystem_ConceptDefinition{
+		Code:    fhir.Code(code),
+		Display: fhir.String(display),
+		Concept: children,
+	}
+}
+
+func filter(property string, op cpb.FilterOperatorCode_Value, value string) *vspb.ValueSet_Compose_ConceptSet_Filter {
+	return &vspb.ValueSet_Compose_ConceptSet_Filter{
+		Property: fhir.Code(property),
+		Op:       &vspb.ValueSet_Compose_ConceptSet_Filter_OpCode{Value: op},
+		Value:    fhir.String(value),
+	}
+}
+
+func valueSet(id string, include []*vspb.ValueSet_Compose_ConceptSet, exclude ...*vspb.ValueSet_Compose_ConceptSet) *vspb.ValueSet {
+	return &vspb.ValueSet{
+		Id:  fhir.ID(id),
+		Url: fhir.URI("http://example.com/ValueSet/" + id),
+		Compose: &vspb.ValueSet_Compose{
+			Include: include,
+			Exclude: exclude,
+		},
+	}
+}
+
+func newTestService() *terminology.LocalService {
+	conditions := &cspb.CodeSystem{
+		Url: fhir.URI(conditionsURL),
+		Concept: []*cspb.CodeSystem_ConceptDefinition{
+			concept(diabetes, "Diabetes mellitus",
+				concept(type1, "Type 1 diabetes mellitus"),
+				concept(type2, "Type 2 diabetes mellitus"),
+				concept(gestational, "Gestational diabetes mellitus"),
+			),
+			concept(hypertension, "Hypertensive disorder"),
+		},
+	}
+	system := fhir.URI(conditionsURL)
+	return terminology.NewLocalService([]fhir.Resource{
+		conditions,
+		valueSet("diabetes", []*vspb.ValueSet_Compose_ConceptSet{
+			{System: system, Filter: []*vspb.ValueSet_Compose_ConceptSet_Filter{filter("concept", cpb.FilterOperatorCode_IS_A, diabetes)}},
+		}),
+		valueSet("diabetes-types", []*vspb.ValueSet_Compose_ConceptSet{
+			{System: system, Filter: []*vspb.ValueSet_Compose_ConceptSet_Filter{filter("concept", cpb.FilterOperatorCode_DESCENDENT_OF, diabetes)}},
+		}, &vspb.ValueSet_Compose_ConceptSet{
+			System:  system,
+			Concept: []*vspb.ValueSet_Compose_ConceptSet_ConceptReference{{Code: fhir.Code(gestational)}},
+		}),
+		valueSet("hypertension", []*vspb.ValueSet_Compose_ConceptSet{
+			{System: system, Filter: []*vspb.ValueSet_Compose_ConceptSet_Filter{filter("display", cpb.FilterOperatorCode_REGEX, "Hypertensive.*")}},
+		}),
+		valueSet("cardiometabolic", []*vspb.ValueSet_Compose_ConceptSet{
+			{ValueSet: []*dtpb.Canonical{{Value: "http://example.com/ValueSet/diabetes-types"}}},
+			{System: system, Filter: []*vspb.ValueSet_Compose_ConceptSet_Filter{filter("code", cpb.FilterOperatorCode_EQUALS, hypertension)}},
+		}),
+		valueSet("circular", []*vspb.ValueSet_Compose_ConceptSet{
+			{ValueSet: []*dtpb.Canonical{{Value: "http://example.com/ValueSet/circular"}}},
+		}),
+		valueSet("unknown-system", []*vspb.ValueSet_Compose_ConceptSet{
+			{System: fhir.URI("http://example.com/CodeSystem/unknown")},
+		}),
+		&vspb.ValueSet{
+			Id: fhir.ID("expanded"),
+			Expansion: &vspb.ValueSet_Expansion{
+				Contains: []*vspb.ValueSet_Expansion_Contains{
+					{
+						Abstract: fhir.Boolean(true),
+						Contains: []*vspb.ValueSet_Expansion_Contains{
+							{System: system, Code: fhir.Code(hypertension)},
+						},
+					},
+				},
+			},
+		},
+	})
+}
+
+func parameterValue(params *pgp.Parameters, name string) *pgp.Parameters_Parameter_ValueX {
+	for _, param := range params.GetParameter() {
+		if param.GetName().GetValue() == name {
+			return param.GetValue()
+		}
+	}
+	return nil
+}
+
+func TestLocalService_ValueSetValidateCode(t *testing.T) {
+	service := newTestService()
+
+	testCases := []struct {
+		name       string
+		valueSet   string
+		system     string
+		code       string
+		wantResult bool
+		wantErr    error
+	}{
+		{"is-a includes the code itself", "diabetes", conditionsURL, diabetes, true, nil},
+		{"is-a includes nested descendants", "diabetes", conditionsURL, type2, true, nil},
+		{"is-a excludes other codes", "diabetes", conditionsURL, hypertension, false, nil},
+		{"value set can be referenced by URL", "http://example.com/ValueSet/diabetes", conditionsURL, type1, true, nil},
+		{"descendant-of excludes the code itself", "diabetes-types", conditionsURL, diabetes, false, nil},
+		{"exclude removes concepts", "diabetes-types", conditionsURL, gestational, false, nil},
+		{"regex matches property", "hypertension", conditionsURL, hypertension, true, nil},
+		{"imported value set is included", "cardiometabolic", conditionsURL, type1, true, nil},
+		{"equals filter is included", "cardiometabolic", conditionsURL, hypertension, true, nil},
+		{"code from other system is excluded", "diabetes", "http://snomed.info/sct", diabetes, false, nil},
+		{"code without system matches any system", "diabetes", "", type2, true, nil},
+		{"undefined code is excluded", "diabetes", conditionsURL, "123", false, nil},
+		{"precomputed expansion is used", "expanded", conditionsURL, hypertension, true, nil},
+		{"abstract expansion codes are excluded", "expanded", "", "", false, nil},
+		{"unknown value set", "missing", conditionsURL, diabetes, false, terminology.ErrUnknownValueSet},
+		{"unknown code system", "unknown-system", "http://example.com/CodeSystem/unknown", diabetes, false, terminology.ErrUnknownCodeSystem},
+		{"circular value set", "circular", conditionsURL, diabetes, false, terminology.ErrCircularValueSet},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := service.ValueSetValidateCode(context.Background(), &terminology.ValueSetValidateCodeOptions{
+				ID:     tc.valueSet,
+				System: tc.system,
+				Code:   tc.code,
+			})
+
+			if !cmp.Equal(err, tc.wantErr, cmpopts.EquateErrors()) {
+				t.Fatalf("ValueSetValidateCode() gotErr = %v, wantErr = %v", err, tc.wantErr)
+			}
+			if gotResult := parameterValue(got, "result").GetBoolean().GetValue(); gotResult != tc.wantResult {
+				t.Errorf("ValueSetValidateCode() result = %v, want %v", gotResult, tc.wantResult)
+			}
+		})
+	}
+}
+
+func TestLocalService_ValueSetValidateCode_ReturnsDisplay(t *testing.T) {
+	service := newTestService()
+
+	got, err := service.ValueSetValidateCode(context.Background(), &terminology.ValueSetValidateCodeOptions{
+		ID:     "diabetes",
+		System: conditionsURL,
+		Code:   type2,
+	})
+	if err != nil {
+		t.Fatalf("ValueSetValidateCode() returned unexpected error: %v", err)
+	}
+
+	if display := parameterValue(got, "display").GetStringValue().GetValue(); display != "Type 2 diabetes mellitus" {
+		t.Errorf("ValueSetValidateCode() display = %q, want %q", display, "Type 2 diabetes mellitus")
+	}
+}
+
+func TestLocalService_ValueSetValidateCode_ChecksURLAndDisplay(t *testing.T) {
+	service := newTestService()
+
+	testCases := []struct {
+		name       string
+		opts       *terminology.ValueSetValidateCodeOptions
+		wantResult bool
+		wantErr    error
+	}{
+		{
+			name:       "matching display",
+			opts:       &terminology.ValueSetValidateCodeOptions{URL: "http://example.com/ValueSet/diabetes", System: conditionsURL, Code: type1, Display: "Type 1 diabetes mellitus"},
+			wantResult: true,
+		},
+		{
+			name:       "wrong display",
+			opts:       &terminology.ValueSetValidateCodeOptions{URL: "http://example.com/ValueSet/diabetes", System: conditionsURL, Code: type1, Display: "Diabetes"},
+			wantResult: false,
+		},
+		{
+			name:    "unknown version",
+			opts:    &terminology.ValueSetValidateCodeOptions{URL: "http://example.com/ValueSet/diabetes", ValueSetVersion: "2.0.0", System: conditionsURL, Code: type1},
+			wantErr: terminology.ErrUnknownValueSet,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := service.ValueSetValidateCode(context.Background(), tc.opts)
+
+			if !cmp.Equal(err, tc.wantErr, cmpopts.EquateErrors()) {
+				t.Fatalf("ValueSetValidateCode() gotErr = %v, wantErr = %v", err, tc.wantErr)
+			}
+			if gotResult := parameterValue(got, "result").GetBoolean().GetValue(); gotResult != tc.wantResult {
+				t.Errorf("ValueSetValidateCode() result = %v, want %v", gotResult, tc.wantResult)
+			}
+		})
+	}
+}
+
+func TestLocalService_Subsumes(t *testing.T) {
+	service := newTestService()
+
+	testCases := []struct {
+		name        string
+		codeA       string
+		codeB       string
+		wantOutcome string
+	}{
+		{"equivalent", diabetes, diabetes, "equivalent"},
+		{"subsumes", diabetes, type2, "subsumes"},
+		{"subsumed-by", type1, diabetes, "subsumed-by"},
+		{"not-subsumed", type1, type2, "not-subsumed"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := service.Subsumes(context.Background(), &terminology.SubsumesOptions{
+				System: conditionsURL,
+				CodeA:  tc.codeA,
+				CodeB:  tc.codeB,
+			})
+			if err != nil {
+				t.Fatalf("Subsumes() returned unexpected error: %v", err)
+			}
+
+			if outcome := parameterValue(got, "outcome").GetCode().GetValue(); outcome != tc.wantOutcome {
+				t.Errorf("Subsumes() outcome = %q, want %q", outcome, tc.wantOutcome)
+			}
+		})
+	}
+}
+
+func TestLocalService_Expand(t *testing.T) {
+	service := newTestService()
+
+	got, err := service.Expand(context.Background(), &terminology.ExpandOptions{URL: "cardiometabolic"})
+	if err != nil {
+		t.Fatalf("Expand() returned unexpected error: %v", err)
+	}
+
+	want := []*vspb.ValueSet_Expansion_Contains{
+		{System: fhir.URI(conditionsURL), Code: fhir.Code(type1), Display: fhir.String("Type 1 diabetes mellitus")},
+		{System: fhir.URI(conditionsURL), Code: fhir.Code(type2), Display: fhir.String("Type 2 diabetes mellitus")},
+		{System: fhir.URI(conditionsURL), Code: fhir.Code(hypertension), Display: fhir.String("Hypertensive disorder")},
+	}
+	if diff := cmp.Diff(want, got.GetExpansion().GetContains(), protocmp.Transform()); diff != "" {
+		t.Errorf("Expand() mismatch (-want +got):\n%s", diff)
+	}
+	if total := got.GetExpansion().GetTotal().GetValue(); total != 3 {
+		t.Errorf("Expand() total = %d, want 3", total)
+	}
+}
+
+func TestLocalService_Expand_ReturnsCopyOfPrecomputedExpansion(t *testing.T) {
+	service := newTestService()
+	opts := &terminology.ExpandOptions{URL: "expanded"}
+
+	got, err := service.Expand(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("Expand() returned unexpected error: %v", err)
+	}
+	got.Expansion.Contains = nil
+
+	again, err := service.Expand(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("Expand() returned unexpected error: %v", err)
+	}
+	if len(again.GetExpansion().GetContains()) != 1 {
+		t.Errorf("Expand() returned %d items after modifying an earlier result, want 1", len(again.GetExpansion().GetContains()))
+	}
+}
+
+func TestLocalService_CodeSystemValidateCode(t *testing.T) {
+	service := newTestService()
+
+	testCases := []struct {
+		name       string
+		code       string
+		display    string
+		wantResult bool
+	}{
+		{"defined code", type1, "", true},
+		{"defined code with matching display", type1, "Type 1 diabetes mellitus", true},
+		{"defined code with wrong display", type1, "Diabetes", false},
+		{"undefined code", "123", "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := service.CodeSystemValidateCode(context.Background(), &terminology.CodeSystemValidateCodeOptions{
+				URL:     conditionsURL,
+				Code:    tc.code,
+				Display: tc.display,
+			})
+			if err != nil {
+				t.Fatalf("CodeSystemValidateCode() returned unexpected error: %v", err)
+			}
+
+			if gotResult := parameterValue(got, "result").GetBoolean().GetValue(); gotResult != tc.wantResult {
+				t.Errorf("CodeSystemValidateCode() result = %v, want %v", gotResult, tc.wantResult)
+			}
+		})
+	}
+}
+
+func TestNewLocalServiceFromBundle(t *testing.T) {
+	bundle := &bcrpb.Bundle{
+		Entry: []*bcrpb.Bundle_Entry{
+			{Resource: containedresource.Wrap(&cspb.CodeSystem{
+				Url:     fhir.URI(conditionsURL),
+				Concept: []*cspb.CodeSystem_ConceptDefinition{concept(diabetes, "Diabetes mellitus")},
+			})},
+			{Resource: containedresource.Wrap(valueSet("diabetes", []*vspb.ValueSet_Compose_ConceptSet{
+				{System: fhir.URI(conditionsURL)},
+			}))},
+		},
+	}
+	service := terminology.NewLocalServiceFromBundle(bundle)
+
+	got, err := service.ValueSetValidateCode(context.Background(), &terminology.ValueSetValidateCodeOptions{
+		ID:     "diabetes",
+		System: conditionsURL,
+		Code:   diabetes,
+	})
+	if err != nil {
+		t.Fatalf("ValueSetValidateCode() returned unexpected error: %v", err)
+	}
+
+	if !parameterValue(got, "result").GetBoolean().GetValue() {
+		t.Errorf("ValueSetValidateCode() result = false, want true")
+	}
+}
@@ -2,17 +2,28 @@ package terminology
 
 import (
 	"context"
+	"net/url"
 
 	pgp "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/parameters_go_proto"
+	vspb "github.com/google/fhir/go/proto/google/fhir/proto/r4/core/resources/value_set_go_proto"
 )
 
 type ValueSetValidateCodeOptions struct {
-	// The value set OID or UUID.
+	// The value set OID or UUID. For backwards compatibility, this is also
+	// set to the canonical URL of the value set, without its version.
 	ID string
-	// The code system ID, OID, or URI.
+	// The canonical URL of the value set, if the value set was referenced by
+	// one.
+	URL string
+	// The code system ID, OID, or URI. Empty if the code was given without a
+	// code system, e.g. a code element whose system is implied by its binding.
 	System string
+	// The version of the code system, if one was provided.
+	SystemVersion string
 	// The code to be checked for validity.
 	Code string
+	// The display to be checked against the display of the code, if any.
+	Display string
 	// The effective date for determining validity, format should be
 	// YYYY-MM-DD. If empty, the service will return a result based
 	// on the latest dated system.
@@ -24,3 +35,107 @@ type ValueSetValidateCodeOptions struct {
 type Service interface {
 	ValueSetValidateCode(ctx context.Context, opts *ValueSetValidateCodeOptions) (*pgp.Parameters, error)
 }
+
+// The operations below are optional, and are only available if the configured
+// Service also implements the corresponding interface. Each set of options
+// has a Params field, which holds any additional parameters of the operation,
+// such as the 'params' argument of the FHIRPath terminology functions.
+
+// SubsumesOptions are the parameters of the CodeSystem $subsumes operation.
+type SubsumesOptions struct {
+	// The code system in which subsumption testing is to be performed.
+	System string
+	// The version of the code system, if one was provided.
+	Version string
+	// The "A" code that is to be tested.
+	CodeA string
+	// The "B" code that is to be tested.
+	CodeB string
+	// Additional parameters of the operation.
+	Params url.Values
+}
+
+// Subsumer is a Service that can test the subsumption relationship between
+// two codes, using the CodeSystem $subsumes operation. The result is a
+// Parameters resource with an 'outcome' code, which is one of 'equivalent',
+// 'subsumes', 'subsumed-by' or 'not-subsumed'.
+// See https://hl7.org/fhir/R4/codesystem-operation-subsumes.html
+type Subsumer interface {
+	Subsumes(ctx context.Context, opts *SubsumesOptions) (*pgp.Parameters, error)
+}
+
+// TranslateOptions are the parameters of the ConceptMap $translate operation.
+type TranslateOptions struct {
+	// The canonical URL of the concept map to use for the translation.
+	ConceptMap string
+	// The system for the code that is to be translated.
+	System string
+	// The code that is to be translated.
+	Code string
+	// Additional parameters of the operation, such as 'target'.
+	Params url.Values
+}
+
+// Translator is a Service that can translate a code from one value set to
+// another, using the ConceptMap $translate operation.
+// See https://hl7.org/fhir/R4/conceptmap-operation-translate.html
+type Translator interface {
+	Translate(ctx context.Context, opts *TranslateOptions) (*pgp.Parameters, error)
+}
+
+// ExpandOptions are the parameters of the ValueSet $expand operation.
+type ExpandOptions struct {
+	// The canonical URL of the value set to expand.
+	URL string
+	// Additional parameters of the operation, such as 'filter' or 'count'.
+	Params url.Values
+}
+
+// Expander is a Service that can expand a value set, using the ValueSet
+// $expand operation.
+// See https://hl7.org/fhir/R4/valueset-operation-expand.html
+type Expander interface {
+	Expand(ctx context.Context, opts *ExpandOptions) (*vspb.ValueSet, error)
+}
+
+// LookupOptions are the parameters of the CodeSystem $lookup operation.
+type LookupOptions struct {
+	// The system for the code that is to be located.
+	System string
+	// The version of the code system, if one was provided.
+	Version string
+	// The code that is to be located.
+	Code string
+	// Additional parameters of the operation, such as 'property'.
+	Params url.Values
+}
+
+// Lookuper is a Service that can look up the details of a code, using the
+// CodeSystem $lookup operation.
+// See https://hl7.org/fhir/R4/codesystem-operation-lookup.html
+type Lookuper interface {
+	Lookup(ctx context.Context, opts *LookupOptions) (*pgp.Parameters, error)
+}
+
+// CodeSystemValidateCodeOptions are the parameters of the CodeSystem
+// $validate-code operation.
+type CodeSystemValidateCodeOptions struct {
+	// The canonical URL of the code system.
+	URL string
+	// The version of the code system, if one was provided.
+	Version string
+	// The code to be validated.
+	Code string
+	// The display associated with the code, if provided.
+	Display string
+	// Additional parameters of the operation.
+	Params url.Values
+}
+
+// CodeSystemValidator is a Service that can validate that a code is in a
+// code system, using the CodeSystem $validate-code operation. The result is a
+// Parameters resource with a boolean 'result'.
+// See https://hl7.org/fhir/R4/codesystem-operation-validate-code.html
+type CodeSystemValidator interface {
+	CodeSystemValidateCode(ctx context.Context, opts *CodeSystemValidateCodeOptions) (*pgp.Parameters, error)
+}
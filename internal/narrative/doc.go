@@ -0,0 +1,11 @@
+/*
+Package narrative provides validation of the XHTML content of FHIR narratives,
+according to the rules for the Narrative 'div' element.
+
+The narrative must be a well-formed XHTML 'div' element, that only contains the
+basic HTML formatting elements and attributes, with no active content such as
+scripts, forms or event handlers, and must have some non-whitespace content.
+
+See https://hl7.org/fhir/R4/narrative.html#rules for the full rules.
+*/
+package narrative
@@ -0,0 +1,167 @@
+package narrative
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/verily-src/fhirpath-go/internal/slices"
+)
+
+var (
+	ErrMalformed           = errors.New("malformed xhtml")
+	ErrInvalidRoot         = errors.New("narrative must be a single xhtml div element")
+	ErrDisallowedElement   = errors.New("element is not allowed in narrative")
+	ErrDisallowedAttribute = errors.New("attribute is not allowed in narrative")
+	ErrEmpty               = errors.New("narrative has no content")
+)
+
+const (
+	xhtmlNamespace = "http://www.w3.org/1999/xhtml"
+	xmlNamespace   = "http://www.w3.org/XML/1998/namespace"
+)
+
+// elements maps each of the elements allowed in narratives to the attributes
+// allowed on them, in addition to the global attributes.
+var elements = map[string][]string{
+	"a":          {"href", "name", "rel", "type", "hreflang", "charset", "shape", "coords"},
+	"abbr":       nil,
+	"acronym":    nil,
+	"address":    nil,
+	"area":       {"shape", "coords", "href", "nohref", "alt"},
+	"b":          nil,
+	"bdo":        nil,
+	"big":        nil,
+	"blockquote": {"cite"},
+	"br":         nil,
+	"caption":    {"align"},
+	"cite":       nil,
+	"code":       nil,
+	"col":        {"span", "width", "align", "valign", "char", "charoff"},
+	"colgroup":   {"span", "width", "align", "valign", "char", "charoff"},
+	"dd":         nil,
+	"del":        {"cite", "datetime"},
+	"dfn":        nil,
+	"div":        {"align"},
+	"dl":         nil,
+	"dt":         nil,
+	"em":         nil,
+	"h1":         {"align"},
+	"h2":         {"align"},
+	"h3":         {"align"},
+	"h4":         {"align"},
+	"h5":         {"align"},
+	"h6":         {"align"},
+	"hr":         {"align", "noshade", "size", "width"},
+	"i":          nil,
+	"img":        {"src", "alt", "longdesc", "height", "width", "usemap", "ismap", "align", "border", "hspace", "vspace"},
+	"ins":        {"cite", "datetime"},
+	"kbd":        nil,
+	"li":         {"type", "value"},
+	"map":        {"name"},
+	"ol":         {"type", "start", "compact"},
+	"p":          {"align"},
+	"pre":        {"width"},
+	"q":          {"cite"},
+	"samp":       nil,
+	"small":      nil,
+	"span":       nil,
+	"strong":     nil,
+	"sub":        nil,
+	"sup":        nil,
+	"table":      {"summary", "width", "border", "frame", "rules", "cellspacing", "cellpadding", "align", "bgcolor"},
+	"tbody":      {"align", "valign", "char", "charoff"},
+	"td":         {"abbr", "axis", "headers", "scope", "rowspan", "colspan", "align", "valign", "char", "charoff", "nowrap", "bgcolor", "width", "height"},
+	"tfoot":      {"align", "valign", "char", "charoff"},
+	"th":         {"abbr", "axis", "headers", "scope", "rowspan", "colspan", "align", "valign", "char", "charoff", "nowrap", "bgcolor", "width", "height"},
+	"thead":      {"align", "valign", "char", "charoff"},
+	"tr":         {"align", "valign", "char", "charoff", "bgcolor"},
+	"tt":         nil,
+	"ul":         {"type", "compact"},
+	"var":        nil,
+}
+
+// globalAttributes are the attributes allowed on all elements.
+var globalAttributes = []string{"id", "class", "style", "title", "lang", "dir", "accesskey", "tabindex"}
+
+// Check returns nil if the given XHTML is valid narrative content, or an error
+// describing the first rule that it breaks.
+func Check(content string) error {
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	depth, roots, hasContent := 0, 0, false
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrMalformed, err)
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				roots++
+				if roots > 1 || token.Name.Local != "div" || token.Name.Space != xhtmlNamespace {
+					return ErrInvalidRoot
+				}
+			}
+			if err := checkElement(token); err != nil {
+				return err
+			}
+			if token.Name.Local == "img" {
+				hasContent = true
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			if depth == 0 {
+				if strings.TrimSpace(string(token)) != "" {
+					return ErrInvalidRoot
+				}
+			} else if strings.TrimSpace(string(token)) != "" {
+				hasContent = true
+			}
+		case xml.Directive:
+			return fmt.Errorf("%w: directives are not allowed", ErrMalformed)
+		case xml.ProcInst:
+			if token.Target != "xml" {
+				return fmt.Errorf("%w: processing instructions are not allowed", ErrMalformed)
+			}
+		}
+	}
+	if roots == 0 {
+		return ErrInvalidRoot
+	}
+	if !hasContent {
+		return ErrEmpty
+	}
+	return nil
+}
+
+// checkElement returns an error if the element, or any of its attributes, is
+// not allowed in narratives.
+func checkElement(element xml.StartElement) error {
+	name := element.Name.Local
+	attributes, ok := elements[name]
+	if !ok || element.Name.Space != xhtmlNamespace {
+		return fmt.Errorf("%w: %s", ErrDisallowedElement, name)
+	}
+	for _, attr := range element.Attr {
+		switch {
+		case attr.Name.Space == "xmlns", attr.Name.Space == "" && attr.Name.Local == "xmlns":
+			continue
+		case attr.Name.Space == xmlNamespace && attr.Name.Local == "lang":
+			continue
+		case attr.Name.Space != "":
+			return fmt.Errorf("%w: %s:%s on %s", ErrDisallowedAttribute, attr.Name.Space, attr.Name.Local, name)
+		case slices.Includes(globalAttributes, attr.Name.Local), slices.Includes(attributes, attr.Name.Local):
+			continue
+		default:
+			return fmt.Errorf("%w: %s on %s", ErrDisallowedAttribute, attr.Name.Local, name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package narrative_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/verily-src/fhirpath-go/internal/narrative"
+)
+
+func TestCheck_ValidNarrative(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+	}{
+		{
+			name:    "paragraph",
+			content: `<div xmlns="http://www.w3.org/1999/xhtml"><p>Hello</p></div>`,
+		},
+		{
+			name: "table with attributes",
+			content: `<div xmlns="http://www.w3.org/1999/xhtml" xml:lang="en">
+				<table border="1"><tr><td colspan="2" style="color: red">Value</td></tr></table>
+			</div>`,
+		},
+		{
+			name:    "link and internal image only",
+			content: `<div xmlns="http://www.w3.org/1999/xhtml"><a href="#x" name="x"/><img src="#pic" alt=""/></div>`,
+		},
+		{
+			name:    "with xml declaration",
+			content: `<?xml version="1.0" encoding="UTF-8"?><div xmlns="http://www.w3.org/1999/xhtml">Text</div>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := narrative.Check(tc.content); err != nil {
+				t.Errorf("Check(%s) returned unexpected error: %v", tc.name, err)
+			}
+		})
+	}
+}
+
+func TestCheck_InvalidNarrative(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+		wantErr error
+	}{
+		{
+			name:    "malformed",
+			content: `<div xmlns="http://www.w3.org/1999/xhtml"><p>Hello</div>`,
+			wantErr: narrative.ErrMalformed,
+		},
+		{
+			name:    "html entity",
+			content: `<div xmlns="http://www.w3.org/1999/xhtml">a&nbsp;b</div>`,
+			wantErr: narrative.ErrMalformed,
+		},
+		{
+			name:    "missing namespace",
+			content: `<div><p>Hello</p></div>`,
+			wantErr: narrative.ErrInvalidRoot,
+		},
+		{
+			name:    "root is not a div",
+			content: `<p xmlns="http://www.w3.org/1999/xhtml">Hello</p>`,
+			wantErr: narrative.ErrInvalidRoot,
+		},
+		{
+			name:    "multiple roots",
+			content: `<div xmlns="http://www.w3.org/1999/xhtml">a</div><div xmlns="http://www.w3.org/1999/xhtml">b</div>`,
+			wantErr: narrative.ErrInvalidRoot,
+		},
+		{
+			name:    "not xml",
+			content: `Hello`,
+			wantErr: narrative.ErrInvalidRoot,
+		},
+		{
+			name:    "script element",
+			content: `<div xmlns="http://www.w3.org/1999/xhtml"><script>alert(1)</script></div>`,
+			wantErr: narrative.ErrDisallowedElement,
+		},
+		{
+			name:    "form element",
+			content: `<div xmlns="http://www.w3.org/1999/xhtml"><form><p>Hello</p></form></div>`,
+			wantErr: narrative.ErrDisallowedElement,
+		},
+		{
+			name:    "event handler attribute",
+			content: `<div xmlns="http://www.w3.org/1999/xhtml"><p onclick="alert(1)">Hello</p></div>`,
+			wantErr: narrative.ErrDisallowedAttribute,
+		},
+		{
+			name:    "namespaced attribute",
+			content: `<div xmlns="http://www.w3.org/1999/xhtml" xmlns:x="http://www.w3.org/1999/xlink"><a x:href="#y">Hello</a></div>`,
+			wantErr: narrative.ErrDisallowedAttribute,
+		},
+		{
+			name:    "whitespace only",
+			content: `<div xmlns="http://www.w3.org/1999/xhtml"> <p>  </p> </div>`,
+			wantErr: narrative.ErrEmpty,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := narrative.Check(tc.content); !errors.Is(err, tc.wantErr) {
+				t.Errorf("Check(%s) returned unexpected error: got %v, want %v", tc.name, err, tc.wantErr)
+			}
+		})
+	}
+}
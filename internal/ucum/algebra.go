@@ -0,0 +1,168 @@
+package ucum
+
+import (
+	"strconv"
+	"strings"
+)
+
+// factor is a single symbol of a unit expression raised to an exponent, such
+// as 'm2' or 's-1'.
+type factor struct {
+	symbol   string
+	exponent int
+}
+
+// Multiply returns a unit expression for the product of the two given unit
+// expressions. Symbols that appear in both expressions are combined, so that
+// for example 'm' multiplied by 'm' is 'm2', and 'km/h' multiplied by 'h' is
+// 'km'. Expressions that can't be combined are composed as-is.
+func Multiply(a, b string) string {
+	return combine(a, b, 1)
+}
+
+// Divide returns a unit expression for the quotient of the two given unit
+// expressions. Symbols that appear in both expressions are cancelled, so that
+// for example 'mg/dL' divided by 'mg' is '/dL'.
+func Divide(a, b string) string {
+	return combine(a, b, -1)
+}
+
+func combine(a, b string, sign int) string {
+	left, leftOk := factorsOf(a)
+	right, rightOk := factorsOf(b)
+	if !leftOk || !rightOk {
+		if sign < 0 {
+			return "(" + a + ")/(" + b + ")"
+		}
+		return "(" + a + ").(" + b + ")"
+	}
+
+	result := left
+	for _, f := range right {
+		result = addFactor(result, factor{f.symbol, f.exponent * sign})
+	}
+	return formatFactors(result)
+}
+
+// factorsOf splits a unit expression into its factors. Returns false if the
+// expression has parentheses, annotations or numeric factors, which can't be
+// combined.
+func factorsOf(code string) ([]factor, bool) {
+	if code == "" || code == "1" {
+		return nil, true
+	}
+	if strings.ContainsAny(code, "(){}") {
+		return nil, false
+	}
+
+	var factors []factor
+	sign, start, depth := 1, 0, 0
+	for i := 0; i <= len(code); i++ {
+		if i < len(code) {
+			switch code[i] {
+			case '[':
+				depth++
+				continue
+			case ']':
+				depth--
+				continue
+			case '.', '/':
+				if depth > 0 {
+					continue
+				}
+			default:
+				continue
+			}
+		}
+		if i > start {
+			f, ok := parseFactor(code[start:i])
+			if !ok {
+				return nil, false
+			}
+			f.exponent *= sign
+			factors = addFactor(factors, f)
+		} else if i > 0 {
+			return nil, false
+		}
+		if i < len(code) && code[i] == '/' {
+			sign = -1
+		} else {
+			sign = 1
+		}
+		start = i + 1
+	}
+	return factors, true
+}
+
+// parseFactor splits a symbol from its trailing exponent, if it has one.
+func parseFactor(symbol string) (factor, bool) {
+	if isDigits(symbol) {
+		return factor{symbol, 1}, symbol == "1"
+	}
+	end := len(symbol)
+	for end > 0 && symbol[end-1] >= '0' && symbol[end-1] <= '9' {
+		end--
+	}
+	if end > 0 && end < len(symbol) && (symbol[end-1] == '+' || symbol[end-1] == '-') {
+		end--
+	}
+	if end == len(symbol) {
+		return factor{symbol, 1}, true
+	}
+	exponent, err := strconv.Atoi(symbol[end:])
+	if err != nil || end == 0 {
+		return factor{}, false
+	}
+	return factor{symbol[:end], exponent}, true
+}
+
+// addFactor adds the factor to the list of factors, combining it with any
+// existing factor of the same symbol.
+func addFactor(factors []factor, f factor) []factor {
+	for i, existing := range factors {
+		if existing.symbol != f.symbol {
+			continue
+		}
+		result := append([]factor{}, factors...)
+		if exponent := existing.exponent + f.exponent; exponent != 0 {
+			result[i].exponent = exponent
+			return result
+		}
+		return append(result[:i], result[i+1:]...)
+	}
+	if f.symbol == "1" || f.exponent == 0 {
+		return factors
+	}
+	return append(factors, f)
+}
+
+// formatFactors formats the factors as a unit expression, with all the
+// factors with negative exponents written after a '/'.
+func formatFactors(factors []factor) string {
+	var sb strings.Builder
+	for _, f := range factors {
+		if f.exponent > 0 {
+			if sb.Len() > 0 {
+				sb.WriteByte('.')
+			}
+			writeFactor(&sb, f.symbol, f.exponent)
+		}
+	}
+	for _, f := range factors {
+		if f.exponent < 0 {
+			sb.WriteByte('/')
+			writeFactor(&sb, f.symbol, -f.exponent)
+		}
+	}
+	if sb.Len() == 0 {
+		return "1"
+	}
+	return sb.String()
+}
+
+func writeFactor(sb *strings.Builder, symbol string, exponent int) {
+	sb.WriteString(symbol)
+	if exponent != 1 {
+		sb.WriteString(strconv.Itoa(exponent))
+	}
+}
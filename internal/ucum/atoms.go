@@ -0,0 +1,228 @@
+package ucum
+
+import (
+	"math/big"
+	"sort"
+)
+
+// definition defines a UCUM unit atom as a multiple of another unit
+// expression. Base units have an empty unit expression.
+type definition struct {
+	// metric is true if the atom can be combined with a metric prefix.
+	metric bool
+
+	// value is the number of units that make up one of the atom.
+	value string
+
+	// unit is the unit expression that the atom is defined in terms of.
+	unit string
+
+	// base is true for the base units and arbitrary units, which are only
+	// commensurable with themselves.
+	base bool
+
+	// special holds the conversion functions for non-ratio units, such as
+	// degrees Celsius.
+	special *special
+}
+
+// special defines the conversion of a non-ratio unit to and from Kelvin.
+type special struct {
+	toBase   func(*big.Rat) *big.Rat
+	fromBase func(*big.Rat) *big.Rat
+}
+
+// prefixes holds the supported metric prefixes and their factors.
+var prefixes = map[string]string{
+	"Y":  "1e24",
+	"Z":  "1e21",
+	"E":  "1e18",
+	"P":  "1e15",
+	"T":  "1e12",
+	"G":  "1e9",
+	"M":  "1e6",
+	"k":  "1e3",
+	"h":  "1e2",
+	"da": "1e1",
+	"d":  "1e-1",
+	"c":  "1e-2",
+	"m":  "1e-3",
+	"u":  "1e-6",
+	"n":  "1e-9",
+	"p":  "1e-12",
+	"f":  "1e-15",
+	"a":  "1e-18",
+	"z":  "1e-21",
+	"y":  "1e-24",
+	"Ki": "1024",
+	"Mi": "1048576",
+	"Gi": "1073741824",
+	"Ti": "1099511627776",
+}
+
+// prefixOrder lists the prefixes with the longest ones first, so that eg.
+// 'dam' resolves to decameters rather than deci-'am'.
+var prefixOrder = func() []string {
+	order := make([]string, 0, len(prefixes))
+	for prefix := range prefixes {
+		order = append(order, prefix)
+	}
+	sort.Slice(order, func(i, j int) bool {
+		if len(order[i]) != len(order[j]) {
+			return len(order[i]) > len(order[j])
+		}
+		return order[i] < order[j]
+	})
+	return order
+}()
+
+// atoms holds the supported UCUM unit atoms, keyed by their case-sensitive
+// code.
+var atoms = map[string]definition{
+	// Base units.
+	"m":   {metric: true, base: true},
+	"s":   {metric: true, base: true},
+	"g":   {metric: true, base: true},
+	"rad": {metric: true, base: true},
+	"K":   {metric: true, base: true},
+	"C":   {metric: true, base: true},
+	"cd":  {metric: true, base: true},
+
+	// Dimensionless units.
+	"10*":    {value: "10", unit: "1"},
+	"10^":    {value: "10", unit: "1"},
+	"[pi]":   {value: "3.1415926535897932384626433832795028841971693993751", unit: "1"},
+	"%":      {value: "1", unit: "10*-2"},
+	"[ppth]": {value: "1", unit: "10*-3"},
+	"[ppm]":  {value: "1", unit: "10*-6"},
+	"[ppb]":  {value: "1", unit: "10*-9"},
+	"[pptr]": {value: "1", unit: "10*-12"},
+	"mol":    {metric: true, value: "6.02214076", unit: "10*23"},
+	"sr":     {metric: true, value: "1", unit: "rad2"},
+	"deg":    {value: "2", unit: "[pi].rad/360"},
+	"bit":    {metric: true, value: "1", unit: "1"},
+	"By":     {metric: true, value: "8", unit: "bit"},
+
+	// SI derived units.
+	"Hz":  {metric: true, value: "1", unit: "s-1"},
+	"N":   {metric: true, value: "1", unit: "kg.m/s2"},
+	"Pa":  {metric: true, value: "1", unit: "N/m2"},
+	"J":   {metric: true, value: "1", unit: "N.m"},
+	"W":   {metric: true, value: "1", unit: "J/s"},
+	"A":   {metric: true, value: "1", unit: "C/s"},
+	"V":   {metric: true, value: "1", unit: "J/C"},
+	"F":   {metric: true, value: "1", unit: "C/V"},
+	"Ohm": {metric: true, value: "1", unit: "V/A"},
+	"S":   {metric: true, value: "1", unit: "Ohm-1"},
+	"Wb":  {metric: true, value: "1", unit: "V.s"},
+	"T":   {metric: true, value: "1", unit: "Wb/m2"},
+	"H":   {metric: true, value: "1", unit: "Wb/A"},
+	"lm":  {metric: true, value: "1", unit: "cd.sr"},
+	"lx":  {metric: true, value: "1", unit: "lm/m2"},
+	"Bq":  {metric: true, value: "1", unit: "s-1"},
+	"Gy":  {metric: true, value: "1", unit: "J/kg"},
+	"Sv":  {metric: true, value: "1", unit: "J/kg"},
+	"kat": {metric: true, value: "1", unit: "mol/s"},
+	"U":   {metric: true, value: "1", unit: "umol/min"},
+	"eq":  {metric: true, value: "1", unit: "mol"},
+	"osm": {metric: true, value: "1", unit: "mol"},
+
+	// Other metric units.
+	"l":     {metric: true, value: "1", unit: "dm3"},
+	"L":     {metric: true, value: "1", unit: "l"},
+	"ar":    {metric: true, value: "100", unit: "m2"},
+	"t":     {metric: true, value: "1000", unit: "kg"},
+	"u":     {metric: true, value: "1.6605402e-24", unit: "g"},
+	"bar":   {metric: true, value: "1e5", unit: "Pa"},
+	"Ao":    {value: "0.1", unit: "nm"},
+	"Ci":    {metric: true, value: "3.7e10", unit: "Bq"},
+	"erg":   {metric: true, value: "1", unit: "dyn.cm"},
+	"dyn":   {metric: true, value: "1", unit: "g.cm/s2"},
+	"cal":   {metric: true, value: "4.184", unit: "J"},
+	"[Cal]": {value: "1", unit: "kcal"},
+	"g%":    {metric: true, value: "1", unit: "g/dl"},
+	"[g]":   {value: "9.80665", unit: "m/s2"},
+	"gf":    {metric: true, value: "1", unit: "g.[g]"},
+
+	// Time units.
+	"min":  {value: "60", unit: "s"},
+	"h":    {value: "60", unit: "min"},
+	"d":    {value: "24", unit: "h"},
+	"wk":   {value: "7", unit: "d"},
+	"a_t":  {value: "365.24219", unit: "d"},
+	"a_j":  {value: "365.25", unit: "d"},
+	"a_g":  {value: "365.2425", unit: "d"},
+	"a":    {value: "1", unit: "a_j"},
+	"mo_s": {value: "29.53059", unit: "d"},
+	"mo_j": {value: "1", unit: "a_j/12"},
+	"mo_g": {value: "1", unit: "a_g/12"},
+	"mo":   {value: "1", unit: "mo_j"},
+
+	// Pressure units.
+	"m[Hg]":     {metric: true, value: "133.3220", unit: "kPa"},
+	"m[H2O]":    {metric: true, value: "9.80665", unit: "kPa"},
+	"[in_i'Hg]": {value: "1", unit: "m[Hg].[in_i]/m"},
+	"atm":       {value: "101325", unit: "Pa"},
+
+	// Temperature units.
+	"Cel":    {metric: true, special: celsius},
+	"[degF]": {special: fahrenheit},
+	"[degR]": {value: "5", unit: "K/9"},
+
+	// International customary units.
+	"[in_i]":  {value: "2.54", unit: "cm"},
+	"[ft_i]":  {value: "12", unit: "[in_i]"},
+	"[yd_i]":  {value: "3", unit: "[ft_i]"},
+	"[mi_i]":  {value: "5280", unit: "[ft_i]"},
+	"[nmi_i]": {value: "1852", unit: "m"},
+
+	// Avoirdupois weights.
+	"[gr]":       {value: "64.79891", unit: "mg"},
+	"[lb_av]":    {value: "7000", unit: "[gr]"},
+	"[oz_av]":    {value: "1", unit: "[lb_av]/16"},
+	"[dr_av]":    {value: "1", unit: "[oz_av]/16"},
+	"[stone_av]": {value: "14", unit: "[lb_av]"},
+
+	// US volumes.
+	"[gal_us]": {value: "231", unit: "[in_i]3"},
+	"[qt_us]":  {value: "1", unit: "[gal_us]/4"},
+	"[pt_us]":  {value: "1", unit: "[qt_us]/2"},
+	"[cup_us]": {value: "1", unit: "[pt_us]/2"},
+	"[foz_us]": {value: "1", unit: "[gil_us]/4"},
+	"[gil_us]": {value: "1", unit: "[pt_us]/4"},
+	"[tbs_us]": {value: "1", unit: "[foz_us]/2"},
+	"[tsp_us]": {value: "1", unit: "[tbs_us]/3"},
+	"[drp]":    {value: "1", unit: "ml/20"},
+
+	// Arbitrary units, which are only commensurable with themselves.
+	"[iU]":    {metric: true, base: true},
+	"[IU]":    {metric: true, value: "1", unit: "[iU]"},
+	"[arb'U]": {base: true},
+}
+
+// Temperature offsets, relative to Kelvin.
+var (
+	celsiusOffset    = big.NewRat(27315, 100)
+	fahrenheitOffset = big.NewRat(45967, 100)
+	fahrenheitScale  = big.NewRat(5, 9)
+)
+
+var celsius = &special{
+	toBase: func(v *big.Rat) *big.Rat {
+		return new(big.Rat).Add(v, celsiusOffset)
+	},
+	fromBase: func(v *big.Rat) *big.Rat {
+		return new(big.Rat).Sub(v, celsiusOffset)
+	},
+}
+
+var fahrenheit = &special{
+	toBase: func(v *big.Rat) *big.Rat {
+		sum := new(big.Rat).Add(v, fahrenheitOffset)
+		return sum.Mul(sum, fahrenheitScale)
+	},
+	fromBase: func(v *big.Rat) *big.Rat {
+		scaled := new(big.Rat).Quo(v, fahrenheitScale)
+		return scaled.Sub(scaled, fahrenheitOffset)
+	},
+}
@@ -0,0 +1,61 @@
+package ucum
+
+import (
+	"container/list"
+	"sync"
+)
+
+// cacheSize is the number of parsed units that are kept. Units that were
+// evicted are parsed again, so the size only affects performance.
+const cacheSize = 1024
+
+// units caches the most recently parsed units, so that units written in
+// expressions and data aren't parsed on every comparison.
+var units = newCache(cacheSize)
+
+// cache is a least-recently-used cache of parsed units, by unit expression.
+type cache struct {
+	mu    sync.Mutex
+	size  int
+	items map[string]*list.Element
+	// order holds the cached entries, from most to least recently used.
+	order *list.List
+}
+
+type cacheEntry struct {
+	code string
+	unit Unit
+}
+
+func newCache(size int) *cache {
+	return &cache{size: size, items: map[string]*list.Element{}, order: list.New()}
+}
+
+// get returns the cached unit of the expression, if any.
+func (c *cache) get(code string) (Unit, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.items[code]
+	if !ok {
+		return Unit{}, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(cacheEntry).unit, true
+}
+
+// add caches the unit of the expression, evicting the least recently used
+// unit if the cache is full.
+func (c *cache) add(code string, unit Unit) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.items[code]; ok {
+		c.order.MoveToFront(element)
+		return
+	}
+	c.items[code] = c.order.PushFront(cacheEntry{code, unit})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(cacheEntry).code)
+	}
+}
@@ -0,0 +1,35 @@
+package ucum
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := newCache(2)
+	c.add("m", one())
+	c.add("g", one())
+	c.get("m")
+	c.add("s", one())
+
+	if _, ok := c.get("g"); ok {
+		t.Errorf("cache kept 'g', want it evicted as the least recently used unit")
+	}
+	for _, code := range []string{"m", "s"} {
+		if _, ok := c.get(code); !ok {
+			t.Errorf("cache evicted %q, want it kept", code)
+		}
+	}
+}
+
+func TestParse_BoundsCache(t *testing.T) {
+	for i := 0; i < cacheSize+10; i++ {
+		if _, err := Parse(fmt.Sprintf("m{%d}", i)); err != nil {
+			t.Fatalf("Parse returned unexpected error: %v", err)
+		}
+	}
+
+	if got := len(units.items); got > cacheSize {
+		t.Errorf("cache holds %d units, want at most %d", got, cacheSize)
+	}
+}
@@ -0,0 +1,14 @@
+/*
+Package ucum provides an embedded subset of the Unified Code for Units of
+Measure (UCUM), for validating unit expressions and converting values between
+commensurable units.
+
+Unit expressions are parsed according to the UCUM case-sensitive grammar,
+supporting metric prefixes, exponents, multiplication ('.'), division ('/'),
+parentheses, numeric factors and annotations. The supported atoms cover the
+base and derived SI units, along with the customary, clinical and time units
+commonly found in healthcare data.
+
+See https://ucum.org/ucum for the full specification.
+*/
+package ucum
@@ -0,0 +1,400 @@
+package ucum
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidUnit     = errors.New("invalid UCUM unit")
+	ErrIncommensurable = errors.New("incommensurable units")
+	ErrSpecialUnit     = errors.New("special units cannot be combined with other units")
+)
+
+// Unit is a parsed UCUM unit expression, represented as a multiple of a
+// product of powers of base units.
+type Unit struct {
+	factor  *big.Rat
+	dims    map[string]int
+	special *special
+}
+
+// Parse parses the given UCUM unit expression. Returns ErrInvalidUnit if the
+// expression is not valid, or uses atoms that aren't supported.
+func Parse(code string) (Unit, error) {
+	if unit, ok := units.get(code); ok {
+		return unit, nil
+	}
+
+	p := &parser{code: code}
+	unit, err := p.parse()
+	if err != nil {
+		return Unit{}, fmt.Errorf("%w: '%s': %v", ErrInvalidUnit, code, err)
+	}
+
+	units.add(code, unit)
+	return unit, nil
+}
+
+// Validate returns an error if the given code is not a valid UCUM unit
+// expression.
+func Validate(code string) error {
+	_, err := Parse(code)
+	return err
+}
+
+// Commensurable returns true if values in this unit can be converted to the
+// other unit.
+func (u Unit) Commensurable(other Unit) bool {
+	if len(u.dims) != len(other.dims) {
+		return false
+	}
+	for name, exponent := range u.dims {
+		if other.dims[name] != exponent {
+			return false
+		}
+	}
+	return true
+}
+
+// Canonical returns the unit expression of the base units that this unit is
+// a multiple of, eg. 'g.m-3' for 'mg/dL'. Commensurable units have the same
+// canonical expression.
+func (u Unit) Canonical() string {
+	if len(u.dims) == 0 {
+		return "1"
+	}
+	names := make([]string, 0, len(u.dims))
+	for name := range u.dims {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for i, name := range names {
+		if i > 0 {
+			sb.WriteByte('.')
+		}
+		sb.WriteString(name)
+		if exponent := u.dims[name]; exponent != 1 {
+			sb.WriteString(strconv.Itoa(exponent))
+		}
+	}
+	return sb.String()
+}
+
+// Special returns true if this is a special unit, such as 'Cel', which is not
+// a ratio of its canonical unit and so can't be multiplied or divided.
+func (u Unit) Special() bool {
+	return u.special != nil
+}
+
+// ToCanonical converts the given value in this unit to the canonical unit.
+func (u Unit) ToCanonical(value *big.Rat) *big.Rat {
+	if u.special != nil {
+		return u.special.toBase(value)
+	}
+	return new(big.Rat).Mul(value, u.factor)
+}
+
+// FromCanonical converts the given value in the canonical unit to this unit.
+func (u Unit) FromCanonical(value *big.Rat) *big.Rat {
+	if u.special != nil {
+		return u.special.fromBase(value)
+	}
+	return new(big.Rat).Quo(value, u.factor)
+}
+
+// Convert converts the value from one unit to another. Returns ErrInvalidUnit
+// if either unit is invalid, and ErrIncommensurable if the units don't measure
+// the same kind of quantity.
+func Convert(value *big.Rat, from, to string) (*big.Rat, error) {
+	fromUnit, err := Parse(from)
+	if err != nil {
+		return nil, err
+	}
+	toUnit, err := Parse(to)
+	if err != nil {
+		return nil, err
+	}
+	if !fromUnit.Commensurable(toUnit) {
+		return nil, fmt.Errorf("%w: '%s' and '%s'", ErrIncommensurable, from, to)
+	}
+	return toUnit.FromCanonical(fromUnit.ToCanonical(value)), nil
+}
+
+// one is the dimensionless unit '1'.
+func one() Unit {
+	return Unit{factor: big.NewRat(1, 1), dims: map[string]int{}}
+}
+
+// Mul returns the product of the two units.
+func (u Unit) Mul(other Unit) (Unit, error) {
+	if u.special != nil || other.special != nil {
+		return Unit{}, ErrSpecialUnit
+	}
+	result := Unit{factor: new(big.Rat).Mul(u.factor, other.factor), dims: map[string]int{}}
+	for name, exponent := range u.dims {
+		result.dims[name] = exponent
+	}
+	for name, exponent := range other.dims {
+		result.addDim(name, exponent)
+	}
+	return result, nil
+}
+
+// Div returns the quotient of the two units.
+func (u Unit) Div(other Unit) (Unit, error) {
+	inverse, err := other.pow(-1)
+	if err != nil {
+		return Unit{}, err
+	}
+	return u.Mul(inverse)
+}
+
+func (u Unit) pow(exponent int) (Unit, error) {
+	if exponent == 1 {
+		return u, nil
+	}
+	if u.special != nil {
+		return Unit{}, errors.New("special units cannot have an exponent")
+	}
+	result := one()
+	for name, e := range u.dims {
+		result.dims[name] = e * exponent
+	}
+	base := u.factor
+	if exponent < 0 {
+		base = new(big.Rat).Inv(base)
+		exponent = -exponent
+	}
+	for i := 0; i < exponent; i++ {
+		result.factor.Mul(result.factor, base)
+	}
+	return result, nil
+}
+
+func (u Unit) addDim(name string, exponent int) {
+	if total := u.dims[name] + exponent; total != 0 {
+		u.dims[name] = total
+	} else {
+		delete(u.dims, name)
+	}
+}
+
+// parser is a recursive descent parser for UCUM unit expressions.
+type parser struct {
+	code string
+	pos  int
+}
+
+func (p *parser) parse() (Unit, error) {
+	if p.code == "" {
+		return one(), nil
+	}
+	unit, err := p.parseTerm()
+	if err != nil {
+		return Unit{}, err
+	}
+	if p.pos != len(p.code) {
+		return Unit{}, fmt.Errorf("unexpected '%c' at position %d", p.code[p.pos], p.pos)
+	}
+	return unit, nil
+}
+
+// parseTerm parses a sequence of components separated by '.' or '/', with an
+// optional leading '/'.
+func (p *parser) parseTerm() (Unit, error) {
+	divide := p.consume('/')
+	result, err := p.parseComponent()
+	if err != nil {
+		return Unit{}, err
+	}
+	if divide {
+		if result, err = one().Div(result); err != nil {
+			return Unit{}, err
+		}
+	}
+	for p.pos < len(p.code) && p.code[p.pos] != ')' {
+		operator := p.code[p.pos]
+		if operator != '.' && operator != '/' {
+			return Unit{}, fmt.Errorf("unexpected '%c' at position %d", operator, p.pos)
+		}
+		p.pos++
+		component, err := p.parseComponent()
+		if err != nil {
+			return Unit{}, err
+		}
+		if operator == '.' {
+			result, err = result.Mul(component)
+		} else {
+			result, err = result.Div(component)
+		}
+		if err != nil {
+			return Unit{}, err
+		}
+	}
+	return result, nil
+}
+
+// parseComponent parses a parenthesized term, an annotation, a numeric
+// factor, or a unit symbol with an optional exponent and annotation.
+func (p *parser) parseComponent() (Unit, error) {
+	if p.consume('(') {
+		unit, err := p.parseTerm()
+		if err != nil {
+			return Unit{}, err
+		}
+		if !p.consume(')') {
+			return Unit{}, errors.New("missing closing parenthesis")
+		}
+		return unit, p.skipAnnotation()
+	}
+	if p.pos < len(p.code) && p.code[p.pos] == '{' {
+		return one(), p.skipAnnotation()
+	}
+
+	start, depth := p.pos, 0
+	for ; p.pos < len(p.code); p.pos++ {
+		c := p.code[p.pos]
+		if c == '[' {
+			depth++
+		} else if c == ']' {
+			depth--
+		} else if depth == 0 && strings.IndexByte("./(){", c) >= 0 {
+			break
+		}
+	}
+	if depth != 0 {
+		return Unit{}, errors.New("unbalanced square brackets")
+	}
+	symbol := p.code[start:p.pos]
+	if symbol == "" {
+		return Unit{}, fmt.Errorf("missing unit at position %d", start)
+	}
+	unit, err := resolveSymbol(symbol)
+	if err != nil {
+		return Unit{}, err
+	}
+	return unit, p.skipAnnotation()
+}
+
+// skipAnnotation skips an optional curly brace annotation, which has no
+// effect on the meaning of the unit.
+func (p *parser) skipAnnotation() error {
+	if !p.consume('{') {
+		return nil
+	}
+	end := strings.IndexByte(p.code[p.pos:], '}')
+	if end < 0 {
+		return errors.New("unterminated annotation")
+	}
+	p.pos += end + 1
+	return nil
+}
+
+func (p *parser) consume(c byte) bool {
+	if p.pos < len(p.code) && p.code[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// resolveSymbol resolves a unit symbol with an optional exponent, such as
+// 'm2', 'mg', '10*3' or '[in_i]'. A symbol made of only digits is a numeric
+// factor.
+func resolveSymbol(symbol string) (Unit, error) {
+	if isDigits(symbol) {
+		factor, ok := new(big.Rat).SetString(symbol)
+		if !ok {
+			return Unit{}, fmt.Errorf("invalid factor '%s'", symbol)
+		}
+		return Unit{factor: factor, dims: map[string]int{}}, nil
+	}
+
+	name, exponent := symbol, 1
+	end := len(symbol)
+	for end > 0 && symbol[end-1] >= '0' && symbol[end-1] <= '9' {
+		end--
+	}
+	if end < len(symbol) {
+		digits := symbol[end:]
+		if end > 0 && (symbol[end-1] == '+' || symbol[end-1] == '-') {
+			end--
+		}
+		if end > 0 {
+			value, err := strconv.Atoi(symbol[end:])
+			if err != nil {
+				return Unit{}, fmt.Errorf("invalid exponent '%s'", digits)
+			}
+			name, exponent = symbol[:end], value
+		}
+	}
+
+	unit, err := resolveAtom(name)
+	if err != nil {
+		return Unit{}, err
+	}
+	return unit.pow(exponent)
+}
+
+// resolveAtom resolves a unit atom with an optional metric prefix.
+func resolveAtom(name string) (Unit, error) {
+	if def, ok := atoms[name]; ok {
+		return def.resolve(name)
+	}
+	for _, prefix := range prefixOrder {
+		atom, ok := strings.CutPrefix(name, prefix)
+		if !ok {
+			continue
+		}
+		def, ok := atoms[atom]
+		if !ok || !def.metric {
+			continue
+		}
+		if def.special != nil {
+			return Unit{}, fmt.Errorf("special unit '%s' cannot be prefixed", atom)
+		}
+		unit, err := def.resolve(atom)
+		if err != nil {
+			return Unit{}, err
+		}
+		multiplier, _ := new(big.Rat).SetString(prefixes[prefix])
+		unit.factor = new(big.Rat).Mul(unit.factor, multiplier)
+		return unit, nil
+	}
+	return Unit{}, fmt.Errorf("unknown unit '%s'", name)
+}
+
+// resolve returns the Unit defined by the definition of the given atom.
+func (d definition) resolve(name string) (Unit, error) {
+	switch {
+	case d.base:
+		return Unit{factor: big.NewRat(1, 1), dims: map[string]int{name: 1}}, nil
+	case d.special != nil:
+		return Unit{factor: big.NewRat(1, 1), dims: map[string]int{"K": 1}, special: d.special}, nil
+	}
+	unit, err := Parse(d.unit)
+	if err != nil {
+		return Unit{}, err
+	}
+	value, ok := new(big.Rat).SetString(d.value)
+	if !ok {
+		return Unit{}, fmt.Errorf("invalid definition of '%s'", name)
+	}
+	return Unit{factor: new(big.Rat).Mul(unit.factor, value), dims: unit.dims}, nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
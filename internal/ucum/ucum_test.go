@@ -0,0 +1,164 @@
+package ucum_test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/verily-src/fhirpath-go/internal/ucum"
+)
+
+func TestParse_ValidUnits(t *testing.T) {
+	testCases := []struct {
+		code          string
+		wantCanonical string
+	}{
+		{"1", "1"},
+		{"", "1"},
+		{"%", "1"},
+		{"kg", "g"},
+		{"mg/dL", "g.m-3"},
+		{"m2", "m2"},
+		{"/min", "s-1"},
+		{"10*3/uL", "m-3"},
+		{"10*-3", "1"},
+		{"mm[Hg]", "g.m-1.s-2"},
+		{"[in_i]", "m"},
+		{"kg/m2", "g.m-2"},
+		{"mmol/L", "m-3"},
+		{"{score}", "1"},
+		{"mg{creat}/g", "1"},
+		{"(kg.m)/s2", "g.m.s-2"},
+		{"Cel", "K"},
+		{"[degF]", "K"},
+		{"[IU]/L", "[iU].m-3"},
+		{"dam", "m"},
+		{"cm[H2O]", "g.m-1.s-2"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.code, func(t *testing.T) {
+			unit, err := ucum.Parse(tc.code)
+			if err != nil {
+				t.Fatalf("Parse(%q) returned unexpected error: %v", tc.code, err)
+			}
+			if got := unit.Canonical(); got != tc.wantCanonical {
+				t.Errorf("Parse(%q).Canonical() = %q, want %q", tc.code, got, tc.wantCanonical)
+			}
+		})
+	}
+}
+
+func TestParse_InvalidUnits(t *testing.T) {
+	codes := []string{
+		"lbs",
+		"km per hour",
+		"kg/",
+		"(kg",
+		"[in_i",
+		"mg{creat",
+		"kCel",
+		"Cel2",
+		"Cel/s",
+		"xyz",
+	}
+
+	for _, code := range codes {
+		t.Run(code, func(t *testing.T) {
+			if err := ucum.Validate(code); !errors.Is(err, ucum.ErrInvalidUnit) {
+				t.Errorf("Validate(%q) = %v, want %v", code, err, ucum.ErrInvalidUnit)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	testCases := []struct {
+		name  string
+		value string
+		from  string
+		to    string
+		want  string
+	}{
+		{"kilograms to grams", "1", "kg", "g", "1000"},
+		{"grams to kilograms", "500", "g", "kg", "1/2"},
+		{"pounds to kilograms", "1", "[lb_av]", "kg", "0.45359237"},
+		{"inches to centimeters", "1", "[in_i]", "cm", "2.54"},
+		{"mass concentration", "1", "g/dL", "mg/L", "10000"},
+		{"molar concentration", "1", "mmol/L", "umol/mL", "1"},
+		{"hours to minutes", "2", "h", "min", "120"},
+		{"percent to unity", "50", "%", "1", "1/2"},
+		{"celsius to kelvin", "37", "Cel", "K", "310.15"},
+		{"fahrenheit to celsius", "212", "[degF]", "Cel", "100"},
+		{"millimeters of mercury to kilopascals", "760", "mm[Hg]", "kPa", "101.32472"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			value, _ := new(big.Rat).SetString(tc.value)
+			want, _ := new(big.Rat).SetString(tc.want)
+
+			got, err := ucum.Convert(value, tc.from, tc.to)
+			if err != nil {
+				t.Fatalf("Convert(%v, %q, %q) returned unexpected error: %v", tc.value, tc.from, tc.to, err)
+			}
+			if got.Cmp(want) != 0 {
+				t.Errorf("Convert(%v, %q, %q) = %v, want %v", tc.value, tc.from, tc.to, got.FloatString(6), tc.want)
+			}
+		})
+	}
+}
+
+func TestConvert_ReturnsError(t *testing.T) {
+	testCases := []struct {
+		name    string
+		from    string
+		to      string
+		wantErr error
+	}{
+		{"incommensurable units", "kg", "m", ucum.ErrIncommensurable},
+		{"arbitrary and molar units", "[IU]/L", "mol/L", ucum.ErrIncommensurable},
+		{"invalid source unit", "lbs", "kg", ucum.ErrInvalidUnit},
+		{"invalid target unit", "kg", "lbs", ucum.ErrInvalidUnit},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ucum.Convert(big.NewRat(1, 1), tc.from, tc.to); !errors.Is(err, tc.wantErr) {
+				t.Errorf("Convert(1, %q, %q) returned error %v, want %v", tc.from, tc.to, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestMultiplyAndDivide(t *testing.T) {
+	testCases := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"multiplies by dimensionless unit", ucum.Multiply("mg", "1"), "mg"},
+		{"multiplies distinct units", ucum.Multiply("kg", "m"), "kg.m"},
+		{"combines exponents", ucum.Multiply("m", "m"), "m2"},
+		{"cancels units", ucum.Multiply("km/h", "h"), "km"},
+		{"multiplies compound units", ucum.Multiply("mg/dL", "L/mmol"), "mg.L/dL/mmol"},
+		{"divides distinct units", ucum.Divide("kg", "m2"), "kg/m2"},
+		{"divides dimensionless unit", ucum.Divide("1", "s"), "/s"},
+		{"divides by a quotient", ucum.Divide("m", "s-1"), "m.s"},
+		{"divides identical units", ucum.Divide("mg/dL", "mg/dL"), "1"},
+		{"divides bracketed units", ucum.Divide("[lb_av]", "[in_i]2"), "[lb_av]/[in_i]2"},
+		{"composes annotated units", ucum.Multiply("mg{creat}", "m"), "(mg{creat}).(m)"},
+		{"composes numeric factors", ucum.Divide("m", "10*3.10"), "(m)/(10*3.10)"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.got != tc.want {
+				t.Errorf("got %q, want %q", tc.got, tc.want)
+			}
+			if err := ucum.Validate(tc.got); err != nil {
+				t.Errorf("result %q is not a valid unit: %v", tc.got, err)
+			}
+		})
+	}
+}